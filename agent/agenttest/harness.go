@@ -0,0 +1,169 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package agenttest provides helpers for testing agents: a scripted model,
+// a harness running agents through a runner with in-memory services, and
+// assertions on the resulting event trajectories.
+//
+// Typical usage:
+//
+//	m := agenttest.NewModel(
+//		agenttest.CallTool("calculator", map[string]any{"a": 1, "b": 2}),
+//		agenttest.Reply("the answer is 3"),
+//	)
+//	ag := llmagent.New("assistant", llmagent.WithModel(m), llmagent.WithTools(tools))
+//	tr := agenttest.NewHarness(ag).MustRun(t, "what is 1+2?")
+//	agenttest.AssertToolCalls(t, tr, agenttest.Call{Name: "calculator", Args: map[string]any{"a": 1, "b": 2}})
+//	agenttest.AssertFinalResponse(t, tr, "the answer is 3")
+package agenttest
+
+import (
+	"context"
+	"testing"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/artifact"
+	artifactinmemory "trpc.group/trpc-go/trpc-agent-go/artifact/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/memory"
+	memoryinmemory "trpc.group/trpc-go/trpc-agent-go/memory/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	sessioninmemory "trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+)
+
+// Default identifiers used by the harness.
+const (
+	defaultAppName   = "agenttest"
+	defaultUserID    = "user"
+	defaultSessionID = "session"
+)
+
+// HarnessOption configures a Harness.
+type HarnessOption func(*Harness)
+
+// WithAppName sets the runner app name.
+func WithAppName(name string) HarnessOption {
+	return func(h *Harness) {
+		h.AppName = name
+	}
+}
+
+// WithUserID sets the user ID of the runs.
+func WithUserID(id string) HarnessOption {
+	return func(h *Harness) {
+		h.UserID = id
+	}
+}
+
+// WithSessionID sets the session ID of the runs.
+func WithSessionID(id string) HarnessOption {
+	return func(h *Harness) {
+		h.SessionID = id
+	}
+}
+
+// WithSessionService replaces the in-memory session service.
+func WithSessionService(s session.Service) HarnessOption {
+	return func(h *Harness) {
+		h.SessionService = s
+	}
+}
+
+// WithMemoryService replaces the in-memory memory service.
+func WithMemoryService(s memory.Service) HarnessOption {
+	return func(h *Harness) {
+		h.MemoryService = s
+	}
+}
+
+// WithArtifactService replaces the in-memory artifact service.
+func WithArtifactService(s artifact.Service) HarnessOption {
+	return func(h *Harness) {
+		h.ArtifactService = s
+	}
+}
+
+// Harness runs an agent through runner.NewRunner with in-memory services.
+// Consecutive runs share the same session.
+type Harness struct {
+	AppName   string
+	UserID    string
+	SessionID string
+
+	SessionService  session.Service
+	MemoryService   memory.Service
+	ArtifactService artifact.Service
+
+	Runner runner.Runner
+}
+
+// NewHarness creates a harness for ag.
+func NewHarness(ag agent.Agent, opts ...HarnessOption) *Harness {
+	h := &Harness{
+		AppName:   defaultAppName,
+		UserID:    defaultUserID,
+		SessionID: defaultSessionID,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.SessionService == nil {
+		h.SessionService = sessioninmemory.NewSessionService()
+	}
+	if h.MemoryService == nil {
+		h.MemoryService = memoryinmemory.NewMemoryService()
+	}
+	if h.ArtifactService == nil {
+		h.ArtifactService = artifactinmemory.NewService()
+	}
+	h.Runner = runner.NewRunner(h.AppName, ag,
+		runner.WithSessionService(h.SessionService),
+		runner.WithMemoryService(h.MemoryService),
+		runner.WithArtifactService(h.ArtifactService),
+	)
+	return h
+}
+
+// Run sends a user message and collects all events of the run.
+func (h *Harness) Run(ctx context.Context, message string, runOpts ...agent.RunOption) (*Trajectory, error) {
+	return h.RunMessage(ctx, model.NewUserMessage(message), runOpts...)
+}
+
+// RunMessage sends a message and collects all events of the run.
+func (h *Harness) RunMessage(ctx context.Context, message model.Message, runOpts ...agent.RunOption) (*Trajectory, error) {
+	ch, err := h.Runner.Run(ctx, h.UserID, h.SessionID, message, runOpts...)
+	if err != nil {
+		return nil, err
+	}
+	tr := &Trajectory{}
+	for evt := range ch {
+		tr.Events = append(tr.Events, evt)
+	}
+	return tr, nil
+}
+
+// MustRun is Run with a background context that fails the test on error.
+func (h *Harness) MustRun(t testing.TB, message string, runOpts ...agent.RunOption) *Trajectory {
+	t.Helper()
+	tr, err := h.Run(context.Background(), message, runOpts...)
+	if err != nil {
+		t.Fatalf("agenttest: run failed: %v", err)
+	}
+	return tr
+}
+
+// Session returns the session shared by the runs.
+func (h *Harness) Session(ctx context.Context) (*session.Session, error) {
+	return h.SessionService.GetSession(ctx, session.Key{
+		AppName:   h.AppName,
+		UserID:    h.UserID,
+		SessionID: h.SessionID,
+	})
+}
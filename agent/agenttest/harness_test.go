@@ -0,0 +1,82 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package agenttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+type addArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func newAddTool() tool.Tool {
+	return function.NewFunctionTool(func(_ context.Context, in addArgs) (int, error) {
+		return in.A + in.B, nil
+	}, function.WithName("add"), function.WithDescription("adds two numbers"))
+}
+
+func TestHarness_ToolCallAndOutputKey(t *testing.T) {
+	m := NewModel(
+		CallTool("add", map[string]any{"a": 1, "b": 2}),
+		Reply("the answer is 3"),
+	)
+	ag := llmagent.New("assistant",
+		llmagent.WithModel(m),
+		llmagent.WithTools([]tool.Tool{newAddTool()}),
+		llmagent.WithOutputKey("answer"),
+	)
+	h := NewHarness(ag)
+	tr := h.MustRun(t, "what is 1+2?")
+
+	AssertNoErrors(t, tr)
+	AssertToolCalls(t, tr, Call{Name: "add", Args: map[string]any{"a": 1, "b": 2}})
+	AssertToolCalled(t, tr, "add", `{"b":2,"a":1}`)
+	AssertFinalResponse(t, tr, "the answer is 3")
+	AssertStateDelta(t, tr, map[string]any{"answer": []byte("the answer is 3")})
+	AssertBranch(t, tr, "assistant", "assistant")
+
+	results := tr.ToolResults()
+	require.Len(t, results, 1)
+	assert.Equal(t, "3", results["call_1"])
+	assert.Equal(t, 0, m.Remaining())
+
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, sess)
+	assert.Equal(t, []byte("the answer is 3"), sess.State["answer"])
+}
+
+func TestHarness_Transfer(t *testing.T) {
+	worker := llmagent.New("worker",
+		llmagent.WithModel(NewModel(Reply("work done"))),
+		llmagent.WithDescription("does the work"),
+	)
+	coordinator := llmagent.New("coordinator",
+		llmagent.WithModel(NewModel(CallTool("transfer_to_agent", map[string]any{"agent_name": "worker"}))),
+		llmagent.WithSubAgents([]agent.Agent{worker}),
+	)
+	tr := NewHarness(coordinator, WithSessionID("transfer")).MustRun(t, "do it")
+
+	AssertNoErrors(t, tr)
+	AssertTransfers(t, tr, "worker")
+	AssertFinalResponse(t, tr, "work done")
+	assert.Equal(t, []string{"coordinator", "worker"}, tr.Authors()[:2])
+}
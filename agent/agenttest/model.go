@@ -0,0 +1,242 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package agenttest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Turn is the scripted outcome of one GenerateContent call.
+type Turn struct {
+	// Text is the assistant content.
+	Text string
+	// Reasoning is the reasoning content.
+	Reasoning string
+	// ToolCalls are the tool calls made by the model.
+	ToolCalls []model.ToolCall
+	// Error is delivered as Response.Error.
+	Error *model.ResponseError
+	// Err is returned by GenerateContent itself.
+	Err error
+	// Usage is attached to the final response.
+	Usage *model.Usage
+}
+
+// Reply returns a turn answering with text.
+func Reply(text string) Turn {
+	return Turn{Text: text}
+}
+
+// Call describes a tool call made by the scripted model.
+type Call struct {
+	// Name is the tool name.
+	Name string
+	// Args is marshaled to JSON unless it is already a []byte, json.RawMessage
+	// or string.
+	Args any
+}
+
+// CallTools returns a turn calling tools. Call IDs are assigned by the model
+// as "call_1", "call_2", ... in script order.
+func CallTools(calls ...Call) Turn {
+	t := Turn{}
+	for _, c := range calls {
+		t.ToolCalls = append(t.ToolCalls, model.ToolCall{
+			Type: "function",
+			Function: model.FunctionDefinitionParam{
+				Name:      c.Name,
+				Arguments: marshalArgs(c.Args),
+			},
+		})
+	}
+	return t
+}
+
+// CallTool returns a turn calling a single tool.
+func CallTool(name string, args any) Turn {
+	return CallTools(Call{Name: name, Args: args})
+}
+
+// Fail returns a turn delivering an API error response.
+func Fail(message string) Turn {
+	return Turn{Error: &model.ResponseError{Message: message, Type: model.ErrorTypeAPIError}}
+}
+
+func marshalArgs(args any) []byte {
+	switch a := args.(type) {
+	case nil:
+		return []byte("{}")
+	case []byte:
+		return a
+	case json.RawMessage:
+		return a
+	case string:
+		return []byte(a)
+	default:
+		data, err := json.Marshal(a)
+		if err != nil {
+			panic(fmt.Sprintf("agenttest: marshal tool args: %v", err))
+		}
+		return data
+	}
+}
+
+// Model is a model.Model that plays a script of turns, one per call, and
+// records the requests it receives.
+type Model struct {
+	name string
+
+	mu       sync.Mutex
+	turns    []Turn
+	next     int
+	callSeq  int
+	requests []*model.Request
+}
+
+// NewModel creates a scripted model.
+func NewModel(turns ...Turn) *Model {
+	return &Model{name: "agenttest-model", turns: turns}
+}
+
+// WithName sets the name reported by Info and returns the model.
+func (m *Model) WithName(name string) *Model {
+	m.name = name
+	return m
+}
+
+// Append adds turns to the end of the script.
+func (m *Model) Append(turns ...Turn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns = append(m.turns, turns...)
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return model.Info{Name: m.name}
+}
+
+// Requests returns the requests received so far.
+func (m *Model) Requests() []*model.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*model.Request(nil), m.requests...)
+}
+
+// Remaining returns the number of turns not played yet.
+func (m *Model) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.turns) - m.next
+}
+
+// GenerateContent implements the model.Model interface. Once the script is
+// exhausted every call gets an error response.
+func (m *Model) GenerateContent(ctx context.Context, request *model.Request) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	m.mu.Lock()
+	m.requests = append(m.requests, request)
+	if m.next >= len(m.turns) {
+		m.mu.Unlock()
+		return respond(&model.Response{
+			Object:    model.ObjectTypeError,
+			Error:     &model.ResponseError{Message: fmt.Sprintf("agenttest: script exhausted after %d turns", len(m.turns)), Type: model.ErrorTypeAPIError},
+			Timestamp: time.Now(),
+			Done:      true,
+		}), nil
+	}
+	turn := m.turns[m.next]
+	m.next++
+	calls := make([]model.ToolCall, len(turn.ToolCalls))
+	for i, tc := range turn.ToolCalls {
+		m.callSeq++
+		idx := i
+		if tc.ID == "" {
+			tc.ID = fmt.Sprintf("call_%d", m.callSeq)
+		}
+		tc.Index = &idx
+		calls[i] = tc
+	}
+	m.mu.Unlock()
+
+	if turn.Err != nil {
+		return nil, turn.Err
+	}
+	id := fmt.Sprintf("agenttest-%d", m.next)
+	now := time.Now()
+	if turn.Error != nil {
+		return respond(&model.Response{
+			ID:        id,
+			Object:    model.ObjectTypeError,
+			Error:     turn.Error,
+			Timestamp: now,
+			Done:      true,
+		}), nil
+	}
+
+	var rsps []*model.Response
+	if request.Stream && (turn.Text != "" || turn.Reasoning != "") {
+		rsps = append(rsps, &model.Response{
+			ID:        id,
+			Object:    model.ObjectTypeChatCompletionChunk,
+			Created:   now.Unix(),
+			Model:     m.name,
+			Timestamp: now,
+			IsPartial: true,
+			Choices: []model.Choice{{Delta: model.Message{
+				Role:             model.RoleAssistant,
+				Content:          turn.Text,
+				ReasoningContent: turn.Reasoning,
+			}}},
+		})
+	}
+	finish := "stop"
+	if len(calls) > 0 {
+		finish = "tool_calls"
+	}
+	final := &model.Response{
+		ID:        id,
+		Object:    model.ObjectTypeChatCompletion,
+		Created:   now.Unix(),
+		Model:     m.name,
+		Timestamp: now,
+		Usage:     turn.Usage,
+		Choices: []model.Choice{{
+			Message: model.Message{
+				Role:             model.RoleAssistant,
+				Content:          turn.Text,
+				ReasoningContent: turn.Reasoning,
+				ToolCalls:        calls,
+			},
+			FinishReason: &finish,
+		}},
+	}
+	final.Done = !final.IsToolCallResponse()
+	rsps = append(rsps, final)
+	return respond(rsps...), nil
+}
+
+// respond delivers responses on a closed, buffered channel.
+func respond(rsps ...*model.Response) <-chan *model.Response {
+	ch := make(chan *model.Response, len(rsps))
+	for _, r := range rsps {
+		ch <- r
+	}
+	close(ch)
+	return ch
+}
@@ -0,0 +1,108 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package agenttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func collect(t *testing.T, ch <-chan *model.Response) []*model.Response {
+	t.Helper()
+	var rsps []*model.Response
+	for r := range ch {
+		rsps = append(rsps, r)
+	}
+	return rsps
+}
+
+func TestModel_PlaysScript(t *testing.T) {
+	m := NewModel(
+		CallTools(Call{Name: "a", Args: map[string]any{"x": 1}}, Call{Name: "b", Args: `{"y":2}`}),
+		Reply("done"),
+	)
+	ctx := context.Background()
+
+	ch, err := m.GenerateContent(ctx, &model.Request{})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	calls := rsps[0].Choices[0].Message.ToolCalls
+	require.Len(t, calls, 2)
+	assert.Equal(t, "call_1", calls[0].ID)
+	assert.Equal(t, "call_2", calls[1].ID)
+	assert.JSONEq(t, `{"x":1}`, string(calls[0].Function.Arguments))
+	assert.JSONEq(t, `{"y":2}`, string(calls[1].Function.Arguments))
+	assert.True(t, rsps[0].IsToolCallResponse())
+	assert.False(t, rsps[0].Done)
+	assert.Equal(t, "tool_calls", *rsps[0].Choices[0].FinishReason)
+
+	ch, err = m.GenerateContent(ctx, &model.Request{})
+	require.NoError(t, err)
+	rsps = collect(t, ch)
+	require.Len(t, rsps, 1)
+	assert.Equal(t, "done", rsps[0].Choices[0].Message.Content)
+	assert.True(t, rsps[0].Done)
+	assert.Equal(t, 0, m.Remaining())
+	assert.Len(t, m.Requests(), 2)
+}
+
+func TestModel_Streaming(t *testing.T) {
+	m := NewModel(Reply("hello"))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		GenerationConfig: model.GenerationConfig{Stream: true},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 2)
+	assert.True(t, rsps[0].IsPartial)
+	assert.Equal(t, "hello", rsps[0].Choices[0].Delta.Content)
+	assert.False(t, rsps[1].IsPartial)
+	assert.Equal(t, "hello", rsps[1].Choices[0].Message.Content)
+}
+
+func TestModel_Errors(t *testing.T) {
+	boom := errors.New("boom")
+	m := NewModel(Fail("rate limited"), Turn{Err: boom})
+	ctx := context.Background()
+
+	ch, err := m.GenerateContent(ctx, &model.Request{})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Equal(t, "rate limited", rsps[0].Error.Message)
+
+	_, err = m.GenerateContent(ctx, &model.Request{})
+	assert.ErrorIs(t, err, boom)
+
+	ch, err = m.GenerateContent(ctx, &model.Request{})
+	require.NoError(t, err)
+	rsps = collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Contains(t, rsps[0].Error.Message, "script exhausted")
+
+	_, err = m.GenerateContent(ctx, nil)
+	assert.Error(t, err)
+}
+
+func TestModel_AppendAndName(t *testing.T) {
+	m := NewModel().WithName("scripted")
+	assert.Equal(t, "scripted", m.Info().Name)
+	m.Append(Reply("a"), Reply("b"))
+	assert.Equal(t, 2, m.Remaining())
+}
@@ -0,0 +1,316 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package agenttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Trajectory is the ordered list of events produced by a run.
+type Trajectory struct {
+	Events []*event.Event
+}
+
+// ToolCall is a tool call observed in a trajectory.
+type ToolCall struct {
+	// Author is the agent that made the call.
+	Author string
+	// ID is the tool call ID.
+	ID string
+	// Name is the tool name.
+	Name string
+	// Args is the raw JSON arguments.
+	Args []byte
+}
+
+// Transfer is a hand-off between agents observed in a trajectory.
+type Transfer struct {
+	From string
+	To   string
+}
+
+// ToolCalls returns the tool calls in the order they were made.
+func (tr *Trajectory) ToolCalls() []ToolCall {
+	var calls []ToolCall
+	for _, evt := range tr.Events {
+		if evt.Response == nil || evt.IsPartial || !evt.IsToolCallResponse() {
+			continue
+		}
+		for _, tc := range evt.Choices[0].Message.ToolCalls {
+			calls = append(calls, ToolCall{
+				Author: evt.Author,
+				ID:     tc.ID,
+				Name:   tc.Function.Name,
+				Args:   tc.Function.Arguments,
+			})
+		}
+	}
+	return calls
+}
+
+// ToolResults returns the content of tool result messages keyed by call ID.
+func (tr *Trajectory) ToolResults() map[string]string {
+	results := make(map[string]string)
+	for _, evt := range tr.Events {
+		if evt.Response == nil || evt.IsPartial {
+			continue
+		}
+		for _, c := range evt.Choices {
+			if c.Message.Role == model.RoleTool {
+				results[c.Message.ToolID] = c.Message.Content
+			}
+		}
+	}
+	return results
+}
+
+// Authors returns the authors of the events, with consecutive duplicates
+// collapsed.
+func (tr *Trajectory) Authors() []string {
+	var authors []string
+	for _, evt := range tr.Events {
+		if evt.Author == "" || (len(authors) > 0 && authors[len(authors)-1] == evt.Author) {
+			continue
+		}
+		authors = append(authors, evt.Author)
+	}
+	return authors
+}
+
+// Transfers returns the agent transfers in order. The target of a transfer
+// is the next author that differs from the transferring agent.
+func (tr *Trajectory) Transfers() []Transfer {
+	var transfers []Transfer
+	for i, evt := range tr.Events {
+		if evt.Object != model.ObjectTypeTransfer {
+			continue
+		}
+		t := Transfer{From: evt.Author}
+		for _, next := range tr.Events[i+1:] {
+			if next.Author != "" && next.Author != evt.Author {
+				t.To = next.Author
+				break
+			}
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers
+}
+
+// StateDelta returns the state deltas of all events merged in order.
+func (tr *Trajectory) StateDelta() map[string][]byte {
+	state := make(map[string][]byte)
+	for _, evt := range tr.Events {
+		for k, v := range evt.StateDelta {
+			state[k] = v
+		}
+	}
+	return state
+}
+
+// FinalResponse returns the content of the last complete assistant message.
+func (tr *Trajectory) FinalResponse() string {
+	for i := len(tr.Events) - 1; i >= 0; i-- {
+		evt := tr.Events[i]
+		if evt.Response == nil || evt.IsPartial || len(evt.Choices) == 0 {
+			continue
+		}
+		msg := evt.Choices[0].Message
+		if msg.Role == model.RoleAssistant && msg.Content != "" && evt.Object != model.ObjectTypeTransfer {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+// Errors returns the error messages carried by the events.
+func (tr *Trajectory) Errors() []string {
+	var errs []string
+	for _, evt := range tr.Events {
+		if evt.Response != nil && evt.Error != nil {
+			errs = append(errs, evt.Error.Message)
+		}
+	}
+	return errs
+}
+
+// Filter returns the events matching fn.
+func (tr *Trajectory) Filter(fn func(*event.Event) bool) []*event.Event {
+	var out []*event.Event
+	for _, evt := range tr.Events {
+		if fn(evt) {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
+
+// AssertToolCalls checks that exactly the expected tools were called, in
+// order, with JSON-equal arguments. A nil Args skips the argument check.
+func AssertToolCalls(t testing.TB, tr *Trajectory, want ...Call) {
+	t.Helper()
+	got := tr.ToolCalls()
+	if len(got) != len(want) {
+		t.Errorf("agenttest: got %d tool calls %v, want %d", len(got), toolNames(got), len(want))
+		return
+	}
+	for i, w := range want {
+		if got[i].Name != w.Name {
+			t.Errorf("agenttest: tool call %d is %q, want %q", i, got[i].Name, w.Name)
+			continue
+		}
+		if w.Args == nil {
+			continue
+		}
+		if !jsonEqual(got[i].Args, marshalArgs(w.Args)) {
+			t.Errorf("agenttest: tool call %d (%s) args are %s, want %s", i, w.Name, got[i].Args, marshalArgs(w.Args))
+		}
+	}
+}
+
+// AssertToolCalled checks that a tool was called at least once, with
+// JSON-equal arguments when args is not nil.
+func AssertToolCalled(t testing.TB, tr *Trajectory, name string, args any) {
+	t.Helper()
+	var seen [][]byte
+	for _, c := range tr.ToolCalls() {
+		if c.Name != name {
+			continue
+		}
+		if args == nil || jsonEqual(c.Args, marshalArgs(args)) {
+			return
+		}
+		seen = append(seen, c.Args)
+	}
+	if len(seen) == 0 {
+		t.Errorf("agenttest: tool %q was not called, calls: %v", name, toolNames(tr.ToolCalls()))
+		return
+	}
+	t.Errorf("agenttest: tool %q was not called with %s, got %q", name, marshalArgs(args), seen)
+}
+
+// AssertTransfers checks the agents control was transferred to, in order.
+func AssertTransfers(t testing.TB, tr *Trajectory, agents ...string) {
+	t.Helper()
+	var got []string
+	for _, tf := range tr.Transfers() {
+		got = append(got, tf.To)
+	}
+	if !reflect.DeepEqual(got, agents) && !(len(got) == 0 && len(agents) == 0) {
+		t.Errorf("agenttest: transfers are %v, want %v", got, agents)
+	}
+}
+
+// AssertStateDelta checks the merged state delta. Each expected value is
+// compared as JSON with the recorded bytes; []byte values are compared as is.
+func AssertStateDelta(t testing.TB, tr *Trajectory, want map[string]any) {
+	t.Helper()
+	state := tr.StateDelta()
+	for k, w := range want {
+		got, ok := state[k]
+		if !ok {
+			t.Errorf("agenttest: state delta has no key %q", k)
+			continue
+		}
+		if b, isBytes := w.([]byte); isBytes {
+			if !bytes.Equal(got, b) {
+				t.Errorf("agenttest: state delta %q is %q, want %q", k, got, b)
+			}
+			continue
+		}
+		wantJSON, err := json.Marshal(w)
+		if err != nil {
+			t.Errorf("agenttest: marshal expected state %q: %v", k, err)
+			continue
+		}
+		if !jsonEqual(got, wantJSON) {
+			t.Errorf("agenttest: state delta %q is %s, want %s", k, got, wantJSON)
+		}
+	}
+}
+
+// AssertBranch checks that every non-empty branch of the events of author
+// equals branch.
+func AssertBranch(t testing.TB, tr *Trajectory, author, branch string) {
+	t.Helper()
+	assertEventField(t, tr, author, "branch", branch, func(e *event.Event) string { return e.Branch })
+}
+
+// AssertFilterKey checks that every non-empty filter key of the events of
+// author equals key.
+func AssertFilterKey(t testing.TB, tr *Trajectory, author, key string) {
+	t.Helper()
+	assertEventField(t, tr, author, "filter key", key, func(e *event.Event) string { return e.FilterKey })
+}
+
+// AssertFinalResponse checks the content of the last assistant message.
+func AssertFinalResponse(t testing.TB, tr *Trajectory, want string) {
+	t.Helper()
+	if got := tr.FinalResponse(); got != want {
+		t.Errorf("agenttest: final response is %q, want %q", got, want)
+	}
+}
+
+// AssertNoErrors checks that no event carries an error.
+func AssertNoErrors(t testing.TB, tr *Trajectory) {
+	t.Helper()
+	if errs := tr.Errors(); len(errs) > 0 {
+		t.Errorf("agenttest: unexpected errors: %q", errs)
+	}
+}
+
+func assertEventField(t testing.TB, tr *Trajectory, author, field, want string, get func(*event.Event) string) {
+	t.Helper()
+	found := false
+	for _, evt := range tr.Events {
+		if evt.Author != author {
+			continue
+		}
+		found = true
+		if got := get(evt); got != "" && got != want {
+			t.Errorf("agenttest: event %s of %q has %s %q, want %q", evt.ID, author, field, got, want)
+			return
+		}
+	}
+	if !found {
+		t.Errorf("agenttest: no event authored by %q", author)
+	}
+}
+
+func toolNames(calls []ToolCall) []string {
+	names := make([]string, 0, len(calls))
+	for _, c := range calls {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func jsonEqual(a, b []byte) bool {
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		return bytes.Equal(a, b)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// String implements fmt.Stringer for readable failure output.
+func (c ToolCall) String() string {
+	return fmt.Sprintf("%s(%s)", c.Name, c.Args)
+}
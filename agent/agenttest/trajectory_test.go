@@ -0,0 +1,133 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package agenttest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// recorder is a testing.TB that records failures instead of failing.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func assistantEvent(author, content string) *event.Event {
+	evt := event.New("inv", author)
+	evt.Response = &model.Response{
+		Object: model.ObjectTypeChatCompletion,
+		Done:   true,
+		Choices: []model.Choice{{Message: model.Message{
+			Role:    model.RoleAssistant,
+			Content: content,
+		}}},
+	}
+	return evt
+}
+
+func sampleTrajectory() *Trajectory {
+	call := event.New("inv", "root", event.WithBranch("root"))
+	call.Response = &model.Response{
+		Object: model.ObjectTypeChatCompletion,
+		Choices: []model.Choice{{Message: model.Message{
+			Role: model.RoleAssistant,
+			ToolCalls: []model.ToolCall{{
+				ID:       "call_1",
+				Function: model.FunctionDefinitionParam{Name: "lookup", Arguments: []byte(`{"q":"go"}`)},
+			}},
+		}}},
+	}
+	result := event.New("inv", "root", event.WithBranch("root"))
+	result.Response = &model.Response{
+		Object:  model.ObjectTypeToolResponse,
+		Choices: []model.Choice{{Message: model.Message{Role: model.RoleTool, ToolID: "call_1", Content: "found"}}},
+	}
+	transfer := assistantEvent("root", "Transferring control to agent: child")
+	transfer.Object = model.ObjectTypeTransfer
+	child := assistantEvent("child", "answer")
+	child.Branch = "root/child"
+	child.FilterKey = "root/child"
+	child.StateDelta = map[string][]byte{"k": []byte(`{"n":1}`)}
+	last := event.New("inv", "child")
+	last.StateDelta = map[string][]byte{"k": []byte(`{"n":2}`), "s": []byte(`"v"`)}
+	return &Trajectory{Events: []*event.Event{call, result, transfer, child, last}}
+}
+
+func TestTrajectory_Accessors(t *testing.T) {
+	tr := sampleTrajectory()
+
+	calls := tr.ToolCalls()
+	assert.Len(t, calls, 1)
+	assert.Equal(t, "root", calls[0].Author)
+	assert.Equal(t, `lookup({"q":"go"})`, calls[0].String())
+	assert.Equal(t, map[string]string{"call_1": "found"}, tr.ToolResults())
+	assert.Equal(t, []string{"root", "child"}, tr.Authors())
+	assert.Equal(t, []Transfer{{From: "root", To: "child"}}, tr.Transfers())
+	assert.Equal(t, "answer", tr.FinalResponse())
+	assert.Equal(t, `{"n":2}`, string(tr.StateDelta()["k"]))
+	assert.Empty(t, tr.Errors())
+	assert.Len(t, tr.Filter(func(e *event.Event) bool { return e.Author == "child" }), 2)
+}
+
+func TestTrajectory_AssertionsPass(t *testing.T) {
+	tr := sampleTrajectory()
+	AssertToolCalls(t, tr, Call{Name: "lookup", Args: map[string]any{"q": "go"}})
+	AssertToolCalled(t, tr, "lookup", nil)
+	AssertTransfers(t, tr, "child")
+	AssertStateDelta(t, tr, map[string]any{"k": map[string]any{"n": 2}, "s": "v"})
+	AssertBranch(t, tr, "child", "root/child")
+	AssertFilterKey(t, tr, "child", "root/child")
+	AssertFinalResponse(t, tr, "answer")
+	AssertNoErrors(t, tr)
+}
+
+func TestTrajectory_AssertionsFail(t *testing.T) {
+	tr := sampleTrajectory()
+	tr.Events = append(tr.Events, &event.Event{Response: &model.Response{
+		Error: &model.ResponseError{Message: "boom"},
+	}})
+
+	tests := []struct {
+		name string
+		run  func(tb testing.TB)
+	}{
+		{"tool count", func(tb testing.TB) { AssertToolCalls(tb, tr) }},
+		{"tool name", func(tb testing.TB) { AssertToolCalls(tb, tr, Call{Name: "search"}) }},
+		{"tool args", func(tb testing.TB) { AssertToolCalls(tb, tr, Call{Name: "lookup", Args: `{"q":"rust"}`}) }},
+		{"tool not called", func(tb testing.TB) { AssertToolCalled(tb, tr, "search", nil) }},
+		{"tool wrong args", func(tb testing.TB) { AssertToolCalled(tb, tr, "lookup", map[string]any{"q": 1}) }},
+		{"transfers", func(tb testing.TB) { AssertTransfers(tb, tr, "other") }},
+		{"state missing", func(tb testing.TB) { AssertStateDelta(tb, tr, map[string]any{"x": 1}) }},
+		{"state value", func(tb testing.TB) { AssertStateDelta(tb, tr, map[string]any{"s": "w"}) }},
+		{"branch", func(tb testing.TB) { AssertBranch(tb, tr, "child", "other") }},
+		{"filter key unknown author", func(tb testing.TB) { AssertFilterKey(tb, tr, "nobody", "x") }},
+		{"final response", func(tb testing.TB) { AssertFinalResponse(tb, tr, "other") }},
+		{"errors", func(tb testing.TB) { AssertNoErrors(tb, tr) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tt.run(r)
+			assert.Len(t, r.errors, 1, r.errors)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package a2a adds the remote agents reached over the A2A protocol to the
+// declarative loader:
+//
+//	agents:
+//	  - name: billing
+//	    type: a2a
+//	    description: Answers billing questions.
+//	    config:
+//	      url: http://billing.internal:8080
+//	      streaming: true
+//
+// The agent card of a remote agent is fetched on its first run, so that a
+// spec loads while its remote agents are unreachable. As the description of
+// the card is only known from then on, give the description in the spec, or
+// set fetch_card_on_load to fetch the card when the spec is loaded.
+//
+// It is a package of its own so that the loader does not depend on the A2A
+// client unless A2A agents are used.
+package a2a
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/a2aagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/declarative"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Type is the agent type of A2A agents.
+const Type = "a2a"
+
+// Config is the config of the agents of type a2a.
+type Config struct {
+	// URL is the URL of the agent card of the remote agent.
+	URL string `yaml:"url"`
+	// Streaming chooses the streaming protocol, as told by the agent card
+	// when not set.
+	Streaming *bool `yaml:"streaming"`
+	// TransferStateKeys lists the session state keys sent to the remote
+	// agent.
+	TransferStateKeys []string `yaml:"transfer_state_keys"`
+	// UserIDHeader is the HTTP header carrying the user ID, "X-User-ID" by
+	// default.
+	UserIDHeader string `yaml:"user_id_header"`
+	// FetchCardOnLoad fetches the agent card when the spec is loaded, which
+	// then fails if the remote agent is unreachable, instead of on the
+	// first run.
+	FetchCardOnLoad bool `yaml:"fetch_card_on_load"`
+}
+
+// Enable returns the loader option adding the a2a agent type.
+func Enable() declarative.Option {
+	return declarative.WithAgentType(Type, Build)
+}
+
+// Build builds an agent of type a2a. A2A agents have no sub-agents.
+func Build(spec *declarative.AgentSpec, subAgents []agent.Agent) (agent.Agent, error) {
+	if len(subAgents) > 0 {
+		return nil, spec.Errorf("an a2a agent has no sub-agents")
+	}
+	var cfg Config
+	if err := spec.DecodeConfig(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.URL == "" {
+		return nil, spec.Errorf("a2a agent %q has no url", spec.Name)
+	}
+	opts := []a2aagent.Option{
+		a2aagent.WithName(spec.Name),
+		a2aagent.WithDescription(spec.Description),
+		a2aagent.WithAgentCardURL(cfg.URL),
+		a2aagent.WithUserIDHeader(cfg.UserIDHeader),
+	}
+	if cfg.Streaming != nil {
+		opts = append(opts, a2aagent.WithEnableStreaming(*cfg.Streaming))
+	}
+	if len(cfg.TransferStateKeys) > 0 {
+		opts = append(opts, a2aagent.WithTransferStateKey(cfg.TransferStateKeys...))
+	}
+	remote := &remoteAgent{name: spec.Name, description: spec.Description, opts: opts}
+	if cfg.FetchCardOnLoad {
+		if _, err := remote.resolve(); err != nil {
+			return nil, err
+		}
+	}
+	return remote, nil
+}
+
+// remoteAgent is an A2A agent created on its first run, since creating it
+// fetches its agent card. A failed creation is tried again on the next run.
+type remoteAgent struct {
+	name        string
+	description string
+	opts        []a2aagent.Option
+
+	mu    sync.Mutex
+	agent *a2aagent.A2AAgent
+}
+
+// resolve returns the A2A agent, creating it if needed.
+func (r *remoteAgent) resolve() (*a2aagent.A2AAgent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.agent == nil {
+		a, err := a2aagent.New(r.opts...)
+		if err != nil {
+			return nil, fmt.Errorf("a2a agent %s: %w", r.name, err)
+		}
+		r.agent = a
+	}
+	return r.agent, nil
+}
+
+// Run implements the agent.Agent interface.
+func (r *remoteAgent) Run(ctx context.Context, invocation *agent.Invocation) (<-chan *event.Event, error) {
+	a, err := r.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return a.Run(ctx, invocation)
+}
+
+// Tools implements the agent.Agent interface. Remote agents expose no tools.
+func (r *remoteAgent) Tools() []tool.Tool {
+	return []tool.Tool{}
+}
+
+// Info implements the agent.Agent interface. Without a description in the
+// spec, the description is the one of the agent card once fetched.
+func (r *remoteAgent) Info() agent.Info {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.agent != nil {
+		return r.agent.Info()
+	}
+	return agent.Info{Name: r.name, Description: r.description}
+}
+
+// SubAgents implements the agent.Agent interface.
+func (r *remoteAgent) SubAgents() []agent.Agent {
+	return []agent.Agent{}
+}
+
+// FindSubAgent implements the agent.Agent interface.
+func (r *remoteAgent) FindSubAgent(string) agent.Agent {
+	return nil
+}
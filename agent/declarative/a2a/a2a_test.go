@@ -0,0 +1,50 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package a2a
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/declarative"
+)
+
+func TestBuild_FetchesCardOnFirstRun(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	spec := "agents:\n  - name: billing\n    type: a2a\n    description: Answers billing questions.\n    config:\n      url: " + srv.URL + "\n"
+	l := declarative.NewLoader(Enable())
+
+	defs, err := l.Load("agents.yaml", []byte(spec))
+	require.NoError(t, err, "the spec loads while the remote agent is unreachable")
+	defer defs.Close()
+	billing := defs.Roots["billing"]
+	require.NotNil(t, billing)
+	assert.Equal(t, agent.Info{Name: "billing", Description: "Answers billing questions."}, billing.Info())
+	assert.Zero(t, fetches.Load())
+
+	_, err = billing.Run(context.Background(), agent.NewInvocation())
+	assert.ErrorContains(t, err, "a2a agent billing")
+	assert.NotZero(t, fetches.Load())
+
+	_, err = l.Load("agents.yaml", []byte(spec+"      fetch_card_on_load: true\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,435 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/chainagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/cycleagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/parallelagent"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Aggregators of parallel agents.
+const (
+	aggregatorFirstSuccess = "first_success"
+	aggregatorMajorityVote = "majority_vote"
+)
+
+// commonFields are the fields of the agents of every type.
+var commonFields = []string{"name", "type", "sub_agents"}
+
+// customFields are the other fields of the agents of custom types.
+var customFields = []string{"description", "config"}
+
+// typeFields are the other fields of the agents of the built-in types.
+var typeFields = map[string][]string{
+	TypeLLM: {
+		"description", "model", "instruction", "global_instruction", "tools", "toolsets",
+		"generation", "output_key", "output_schema", "input_schema",
+	},
+	TypeChain:    nil,
+	TypeParallel: {"aggregator", "branch_timeout", "output_key"},
+	TypeCycle:    {"max_iterations"},
+	TypeGraph:    {"description", "graph"},
+}
+
+// build holds the state of a Load.
+type build struct {
+	loader *Loader
+	spec   *Spec
+	p      *problems
+
+	agentSpecs   map[string]*AgentSpec
+	toolSetSpecs map[string]*ToolSetSpec
+	models       map[string]model.Model
+
+	agents   map[string]agent.Agent
+	toolSets map[string]tool.ToolSet
+	built    []tool.ToolSet
+}
+
+func newBuild(l *Loader, spec *Spec, p *problems) *build {
+	return &build{
+		loader:       l,
+		spec:         spec,
+		p:            p,
+		agentSpecs:   make(map[string]*AgentSpec),
+		toolSetSpecs: make(map[string]*ToolSetSpec),
+		models:       make(map[string]model.Model),
+		agents:       make(map[string]agent.Agent),
+		toolSets:     make(map[string]tool.ToolSet),
+	}
+}
+
+// validate reports the problems of the spec.
+func (b *build) validate() {
+	for _, ts := range b.spec.ToolSets {
+		b.validateToolSet(ts)
+	}
+	for _, a := range b.spec.Agents {
+		switch {
+		case a.Name == "":
+			b.p.at(a.node, "agent has no name")
+		case b.agentSpecs[a.Name] != nil:
+			b.p.at(keyNode(a.node, "name"), "agent %q is defined twice", a.Name)
+		default:
+			b.agentSpecs[a.Name] = a
+		}
+	}
+	for _, a := range b.spec.Agents {
+		b.validateAgent(a)
+	}
+	b.checkCycles()
+}
+
+func (b *build) validateToolSet(ts *ToolSetSpec) {
+	switch {
+	case ts.Name == "":
+		b.p.at(ts.node, "tool set has no name")
+		return
+	case b.toolSetSpecs[ts.Name] != nil || b.loader.toolSets[ts.Name] != nil:
+		b.p.at(keyNode(ts.node, "name"), "tool set %q is defined twice", ts.Name)
+		return
+	}
+	b.toolSetSpecs[ts.Name] = ts
+	if _, ok := b.loader.toolSetTypes[ts.Type]; !ok {
+		b.p.at(orNode(keyNode(ts.node, "type"), ts.node), "unknown tool set type %q", ts.Type)
+	}
+}
+
+func (b *build) validateAgent(a *AgentSpec) {
+	if a.Type == "" {
+		a.Type = TypeLLM
+	}
+	fields, builtin := typeFields[a.Type]
+	if !builtin {
+		if _, ok := b.loader.agentTypes[a.Type]; !ok {
+			b.p.at(keyNode(a.node, "type"), "unknown agent type %q", a.Type)
+			return
+		}
+		fields = customFields
+	}
+	b.checkFields(a, fields)
+	for i, name := range a.SubAgents {
+		at := keyNode(a.node, "sub_agents").Content[i]
+		switch {
+		case name == a.Name:
+			b.p.at(at, "agent %q cannot be its own sub-agent", name)
+		case b.agentSpecs[name] == nil:
+			b.p.at(at, "unknown agent %q", name)
+		}
+	}
+	switch a.Type {
+	case TypeLLM:
+		b.validateLLM(a)
+	case TypeParallel:
+		switch a.Aggregator {
+		case "", aggregatorFirstSuccess, aggregatorMajorityVote:
+		default:
+			b.p.at(keyNode(a.node, "aggregator"), "unknown aggregator %q, want %s or %s",
+				a.Aggregator, aggregatorFirstSuccess, aggregatorMajorityVote)
+		}
+		if a.OutputKey != "" && a.Aggregator == "" {
+			b.p.at(keyNode(a.node, "output_key"), "output_key requires an aggregator")
+		}
+	case TypeCycle:
+		if a.MaxIterations < 0 {
+			b.p.at(keyNode(a.node, "max_iterations"), "max_iterations must not be negative")
+		}
+	case TypeGraph:
+		b.validateGraph(a)
+	}
+}
+
+// checkFields reports the fields of a that do not apply to its type.
+func (b *build) checkFields(a *AgentSpec, fields []string) {
+	allowed := make(map[string]bool)
+	for _, f := range append(fields, commonFields...) {
+		allowed[f] = true
+	}
+	for i := 0; i+1 < len(a.node.Content); i += 2 {
+		key := a.node.Content[i]
+		if !allowed[key.Value] {
+			b.p.at(key, "field %q does not apply to %s agents", key.Value, a.Type)
+		}
+	}
+}
+
+func (b *build) validateLLM(a *AgentSpec) {
+	if a.Model == "" {
+		b.p.at(a.node, "agent %q has no model", a.Name)
+	} else {
+		b.resolveModel(keyNode(a.node, "model"), a.Model)
+	}
+	b.validateTools(keyNode(a.node, "tools"), a.Tools)
+	for i, name := range a.ToolSets {
+		if b.toolSetSpecs[name] == nil && b.loader.toolSets[name] == nil {
+			b.p.at(keyNode(a.node, "toolsets").Content[i], "unknown tool set %q", name)
+		}
+	}
+	if a.OutputSchema != nil && (len(a.Tools) > 0 || len(a.ToolSets) > 0 || len(a.SubAgents) > 0) {
+		b.p.at(keyNode(a.node, "output_schema"), "an agent with an output_schema cannot use tools or sub-agents")
+	}
+}
+
+// validateTools reports the unknown tools of the list n.
+func (b *build) validateTools(n *yaml.Node, names []string) {
+	for i, name := range names {
+		if b.loader.tools[name] == nil {
+			b.p.at(n.Content[i], "unknown tool %q", name)
+		}
+	}
+}
+
+// resolveModel finds the model called name, creating it with the model
+// factory of the loader on first use.
+func (b *build) resolveModel(at *yaml.Node, name string) {
+	if _, ok := b.models[name]; ok {
+		return
+	}
+	if m, ok := b.loader.models[name]; ok {
+		b.models[name] = m
+		return
+	}
+	if b.loader.modelFactory == nil {
+		b.p.at(at, "unknown model %q", name)
+		return
+	}
+	m, err := b.loader.modelFactory(name)
+	if err != nil {
+		b.p.at(at, "create model %q: %v", name, err)
+		return
+	}
+	b.models[name] = m
+}
+
+// children returns the names of the sub-agents of a, which for a graph
+// agent include the agents of its nodes.
+func (b *build) children(a *AgentSpec) []string {
+	if a.Type != TypeGraph || a.Graph == nil {
+		return a.SubAgents
+	}
+	names := append([]string(nil), a.SubAgents...)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, n := range a.Graph.Nodes {
+		if n.Agent != "" && !seen[n.Agent] {
+			seen[n.Agent] = true
+			names = append(names, n.Agent)
+		}
+	}
+	return names
+}
+
+// checkCycles reports the agents that are their own sub-agent through
+// other agents.
+func (b *build) checkCycles() {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(a *AgentSpec, path []string)
+	visit = func(a *AgentSpec, path []string) {
+		state[a.Name] = visiting
+		path = append(path, a.Name)
+		for _, name := range b.children(a) {
+			child := b.agentSpecs[name]
+			if child == nil || name == a.Name {
+				continue
+			}
+			switch state[name] {
+			case visiting:
+				cycle := path
+				for i, n := range path {
+					if n == name {
+						cycle = path[i:]
+						break
+					}
+				}
+				b.p.at(orNode(keyNode(a.node, "sub_agents"), a.node), "agent %q is its own sub-agent through %s",
+					name, strings.Join(append(cycle[:len(cycle):len(cycle)], name), " -> "))
+			case 0:
+				visit(child, path)
+			}
+		}
+		state[a.Name] = done
+	}
+	for _, a := range b.spec.Agents {
+		if a.Name != "" && b.agentSpecs[a.Name] == a && state[a.Name] == 0 {
+			visit(a, nil)
+		}
+	}
+}
+
+// run builds the tool sets and agents of the validated spec.
+func (b *build) run() *Definitions {
+	defs := &Definitions{
+		Spec:   b.spec,
+		Agents: make(map[string]agent.Agent),
+		Roots:  make(map[string]agent.Agent),
+	}
+	for _, ts := range b.spec.ToolSets {
+		built, err := b.loader.toolSetTypes[ts.Type](ts)
+		if err != nil {
+			b.p.add(ts.node, fmt.Errorf("tool set %q: %w", ts.Name, err))
+			continue
+		}
+		b.toolSets[ts.Name] = built
+		b.built = append(b.built, built)
+	}
+	defs.toolSets = b.built
+	if len(b.p.errs) > 0 {
+		return defs
+	}
+	isChild := make(map[string]bool)
+	for _, a := range b.spec.Agents {
+		ag := b.agent(a)
+		if ag == nil {
+			continue
+		}
+		defs.Agents[a.Name] = ag
+		for _, name := range b.children(a) {
+			isChild[name] = true
+		}
+	}
+	for name, ag := range defs.Agents {
+		if !isChild[name] {
+			defs.Roots[name] = ag
+		}
+	}
+	return defs
+}
+
+// agent builds the agent of a after its sub-agents, or returns nil when it
+// cannot be built.
+func (b *build) agent(a *AgentSpec) agent.Agent {
+	if ag, ok := b.agents[a.Name]; ok {
+		return ag
+	}
+	b.agents[a.Name] = nil
+	var subAgents []agent.Agent
+	for _, name := range b.children(a) {
+		sub := b.agent(b.agentSpecs[name])
+		if sub == nil {
+			return nil
+		}
+		subAgents = append(subAgents, sub)
+	}
+	var (
+		ag  agent.Agent
+		err error
+	)
+	switch a.Type {
+	case TypeLLM:
+		ag = b.llmAgent(a, subAgents)
+	case TypeChain:
+		ag = chainagent.New(a.Name, chainagent.WithSubAgents(subAgents))
+	case TypeParallel:
+		ag = b.parallelAgent(a, subAgents)
+	case TypeCycle:
+		opts := []cycleagent.Option{cycleagent.WithSubAgents(subAgents)}
+		if a.MaxIterations > 0 {
+			opts = append(opts, cycleagent.WithMaxIterations(a.MaxIterations))
+		}
+		ag = cycleagent.New(a.Name, opts...)
+	case TypeGraph:
+		ag, err = b.graphAgent(a, subAgents)
+	default:
+		ag, err = b.loader.agentTypes[a.Type](a, subAgents)
+	}
+	if err != nil {
+		b.p.add(a.node, fmt.Errorf("agent %q: %w", a.Name, err))
+		return nil
+	}
+	b.agents[a.Name] = ag
+	return ag
+}
+
+func (b *build) llmAgent(a *AgentSpec, subAgents []agent.Agent) agent.Agent {
+	opts := []llmagent.Option{
+		llmagent.WithModel(b.models[a.Model]),
+		llmagent.WithDescription(a.Description),
+		llmagent.WithInstruction(a.Instruction),
+	}
+	if a.GlobalInstruction != "" {
+		opts = append(opts, llmagent.WithGlobalInstruction(a.GlobalInstruction))
+	}
+	if len(a.Tools) > 0 {
+		opts = append(opts, llmagent.WithTools(b.tools(a.Tools)))
+	}
+	if len(a.ToolSets) > 0 {
+		toolSets := make([]tool.ToolSet, 0, len(a.ToolSets))
+		for _, name := range a.ToolSets {
+			if ts, ok := b.toolSets[name]; ok {
+				toolSets = append(toolSets, ts)
+			} else {
+				toolSets = append(toolSets, b.loader.toolSets[name])
+			}
+		}
+		opts = append(opts, llmagent.WithToolSets(toolSets))
+	}
+	if len(subAgents) > 0 {
+		opts = append(opts, llmagent.WithSubAgents(subAgents))
+	}
+	if g := a.Generation; g != nil {
+		opts = append(opts, llmagent.WithGenerationConfig(model.GenerationConfig{
+			MaxTokens:   g.MaxTokens,
+			Temperature: g.Temperature,
+			TopP:        g.TopP,
+			Stream:      g.Stream,
+			Stop:        g.Stop,
+		}))
+	}
+	if a.OutputKey != "" {
+		opts = append(opts, llmagent.WithOutputKey(a.OutputKey))
+	}
+	if a.OutputSchema != nil {
+		opts = append(opts, llmagent.WithOutputSchema(a.OutputSchema))
+	}
+	if a.InputSchema != nil {
+		opts = append(opts, llmagent.WithInputSchema(a.InputSchema))
+	}
+	return llmagent.New(a.Name, opts...)
+}
+
+func (b *build) parallelAgent(a *AgentSpec, subAgents []agent.Agent) agent.Agent {
+	opts := []parallelagent.Option{
+		parallelagent.WithSubAgents(subAgents),
+		parallelagent.WithBranchTimeout(a.BranchTimeout),
+		parallelagent.WithOutputKey(a.OutputKey),
+	}
+	switch a.Aggregator {
+	case aggregatorFirstSuccess:
+		opts = append(opts, parallelagent.WithAggregator(parallelagent.FirstSuccess()))
+	case aggregatorMajorityVote:
+		opts = append(opts, parallelagent.WithAggregator(parallelagent.MajorityVote()))
+	}
+	return parallelagent.New(a.Name, opts...)
+}
+
+// tools returns the tools of the loader called names.
+func (b *build) tools(names []string) []tool.Tool {
+	tools := make([]tool.Tool, 0, len(names))
+	for _, name := range names {
+		tools = append(tools, b.loader.tools[name])
+	}
+	return tools
+}
@@ -0,0 +1,207 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Error is a problem found at a position of a spec file.
+type Error struct {
+	// File is the path of the spec file, empty when the spec was not read
+	// from a file.
+	File string
+	// Line is the 1-based line of the problem, 0 when unknown.
+	Line int
+	// Column is the 1-based column of the problem, 0 when unknown.
+	Column int
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface with the file:line:column: message
+// layout of compilers, so that editors can jump to the problem.
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, "%d:", e.Column)
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// Errors lists the problems found in a spec file, ordered by position.
+type Errors []*Error
+
+// Error implements the error interface with one problem per line.
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// problems collects the errors found while loading a spec.
+type problems struct {
+	file string
+	errs Errors
+}
+
+// at records a problem at the position of n, which may be nil.
+func (p *problems) at(n *yaml.Node, format string, args ...any) {
+	e := &Error{File: p.file, Message: fmt.Sprintf(format, args...)}
+	if n != nil {
+		e.Line, e.Column = n.Line, n.Column
+	}
+	p.errs = append(p.errs, e)
+}
+
+// add records err, keeping the positions of the errors it holds. Errors
+// without a position are reported at the position of n.
+func (p *problems) add(n *yaml.Node, err error) {
+	var errs Errors
+	var single *Error
+	switch {
+	case errors.As(err, &errs):
+		for _, e := range errs {
+			p.errs = append(p.errs, &Error{File: p.file, Line: e.Line, Column: e.Column, Message: e.Message})
+		}
+	case errors.As(err, &single):
+		p.errs = append(p.errs, &Error{File: p.file, Line: single.Line, Column: single.Column, Message: single.Message})
+	default:
+		p.at(n, "%s", err.Error())
+	}
+}
+
+// err returns the problems sorted by position, nil when there are none.
+func (p *problems) err() error {
+	if len(p.errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(p.errs, func(i, j int) bool {
+		if p.errs[i].Line != p.errs[j].Line {
+			return p.errs[i].Line < p.errs[j].Line
+		}
+		return p.errs[i].Column < p.errs[j].Column
+	})
+	return p.errs
+}
+
+// yamlErrors converts the errors of the yaml package, which carry the line
+// in their text, into positioned errors.
+func yamlErrors(err error) Errors {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		errs := make(Errors, 0, len(typeErr.Errors))
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, yamlError(msg))
+		}
+		return errs
+	}
+	return Errors{yamlError(strings.TrimPrefix(err.Error(), "yaml: "))}
+}
+
+// yamlError parses a "line N: message" error of the yaml package.
+func yamlError(msg string) *Error {
+	var line int
+	if _, err := fmt.Sscanf(msg, "line %d:", &line); err == nil {
+		msg = strings.TrimSpace(msg[strings.Index(msg, ":")+1:])
+	}
+	return &Error{Line: line, Message: msg}
+}
+
+// checkKeys reports the keys of the mappings in n that match no field of
+// the struct type t, so that a misspelled field is not silently ignored.
+func checkKeys(p *problems, n *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	switch {
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		if t == reflect.TypeOf(yaml.Node{}) {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				p.at(key, "unknown field %q", key.Value)
+				continue
+			}
+			checkKeys(p, value, field.Type)
+		}
+	case n.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, item := range n.Content {
+			checkKeys(p, item, t.Elem())
+		}
+	}
+}
+
+// yamlFields returns the fields of the struct type t by their yaml key.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// keyNode returns the value node of key in the mapping n, or nil.
+func keyNode(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// orNode returns a when it is not nil and b otherwise.
+func orNode(a, b *yaml.Node) *yaml.Node {
+	if a != nil {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,153 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/graphagent"
+	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// nodeEnd is the target of the edges that end a graph.
+const nodeEnd = "end"
+
+// id returns the id of the node, which defaults to the name of its agent.
+func (n *NodeSpec) id() string {
+	if n.ID != "" {
+		return n.ID
+	}
+	return n.Agent
+}
+
+// isTools reports whether the node runs the tool calls of a model node.
+func (n *NodeSpec) isTools() bool {
+	return n.Agent == "" && n.Model == "" && len(n.Tools) > 0
+}
+
+func (b *build) validateGraph(a *AgentSpec) {
+	g := a.Graph
+	if g == nil || len(g.Nodes) == 0 {
+		b.p.at(orNode(keyNode(a.node, "graph"), a.node), "graph agent %q has no nodes", a.Name)
+		return
+	}
+	nodes := make(map[string]*NodeSpec)
+	for _, n := range g.Nodes {
+		id := n.id()
+		switch {
+		case id == "":
+			b.p.at(n.node, "node has no id")
+			continue
+		case id == nodeEnd:
+			b.p.at(orNode(keyNode(n.node, "id"), n.node), "node id %q is reserved", nodeEnd)
+			continue
+		case nodes[id] != nil:
+			b.p.at(orNode(keyNode(n.node, "id"), n.node), "node %q is defined twice", id)
+			continue
+		}
+		nodes[id] = n
+		b.validateNode(n)
+	}
+	if g.Entry != "" && nodes[g.Entry] == nil {
+		b.p.at(keyNode(keyNode(a.node, "graph"), "entry"), "unknown entry node %q", g.Entry)
+	}
+	for _, e := range g.Edges {
+		if nodes[e.From] == nil {
+			b.p.at(orNode(keyNode(e.node, "from"), e.node), "unknown node %q", e.From)
+		}
+		if e.To == "" {
+			b.p.at(e.node, "edge from %q has no target", e.From)
+		} else if e.To != nodeEnd && nodes[e.To] == nil {
+			b.p.at(keyNode(e.node, "to"), "unknown node %q", e.To)
+		}
+		if e.Tools == "" {
+			continue
+		}
+		if n := nodes[e.Tools]; n == nil || !n.isTools() {
+			b.p.at(keyNode(e.node, "tools"), "%q is not a tools node", e.Tools)
+		}
+		if n := nodes[e.From]; n != nil && n.Model == "" {
+			b.p.at(keyNode(e.node, "tools"), "only the edges from model nodes can go to tools nodes")
+		}
+	}
+}
+
+func (b *build) validateNode(n *NodeSpec) {
+	switch {
+	case n.Agent != "":
+		if n.ID != "" && n.ID != n.Agent {
+			b.p.at(keyNode(n.node, "id"), "the id of an agent node is the name of its agent %q", n.Agent)
+		}
+		if n.Model != "" || n.Instruction != "" || len(n.Tools) > 0 {
+			b.p.at(n.node, "an agent node cannot have a model, instruction or tools")
+		}
+		if b.agentSpecs[n.Agent] == nil {
+			b.p.at(keyNode(n.node, "agent"), "unknown agent %q", n.Agent)
+		}
+	case n.Model != "":
+		b.resolveModel(keyNode(n.node, "model"), n.Model)
+		b.validateTools(keyNode(n.node, "tools"), n.Tools)
+	case len(n.Tools) > 0:
+		if n.Instruction != "" {
+			b.p.at(keyNode(n.node, "instruction"), "a tools node has no instruction")
+		}
+		b.validateTools(keyNode(n.node, "tools"), n.Tools)
+	default:
+		b.p.at(n.node, "node %q needs an agent, a model or tools", n.id())
+	}
+}
+
+func (b *build) graphAgent(a *AgentSpec, subAgents []agent.Agent) (agent.Agent, error) {
+	g := a.Graph
+	sg := graph.NewStateGraph(graph.MessagesStateSchema())
+	for _, n := range g.Nodes {
+		switch {
+		case n.Agent != "":
+			sg.AddAgentNode(n.Agent)
+		case n.Model != "":
+			sg.AddLLMNode(n.id(), b.models[n.Model], n.Instruction, b.toolMap(n.Tools))
+		default:
+			sg.AddToolsNode(n.id(), b.toolMap(n.Tools))
+		}
+	}
+	entry := g.Entry
+	if entry == "" {
+		entry = g.Nodes[0].id()
+	}
+	sg.SetEntryPoint(entry)
+	for _, e := range g.Edges {
+		to := e.To
+		if to == nodeEnd {
+			to = graph.End
+		}
+		if e.Tools != "" {
+			sg.AddToolsConditionalEdges(e.From, e.Tools, to)
+		} else {
+			sg.AddEdge(e.From, to)
+		}
+	}
+	compiled, err := sg.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return graphagent.New(a.Name, compiled,
+		graphagent.WithDescription(a.Description),
+		graphagent.WithSubAgents(subAgents),
+	)
+}
+
+// toolMap returns the tools of the loader called names by name.
+func (b *build) toolMap(names []string) map[string]tool.Tool {
+	tools := make(map[string]tool.Tool, len(names))
+	for _, name := range names {
+		tools[name] = b.loader.tools[name]
+	}
+	return tools
+}
@@ -0,0 +1,220 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package declarative builds agents and workflows from YAML or JSON specs,
+// so that agents can be defined without writing Go.
+//
+// A spec lists agents and tool sets by name:
+//
+//	toolsets:
+//	  - name: docs
+//	    type: file
+//	    config:
+//	      base_dir: ./docs
+//	      save_file: false
+//	agents:
+//	  - name: researcher
+//	    model: gpt-4o
+//	    instruction: Find the facts the user asks for.
+//	    toolsets: [docs]
+//	  - name: writer
+//	    model: gpt-4o
+//	    instruction: Write a short report from the facts.
+//	    output_key: report
+//	  - name: pipeline
+//	    type: chain
+//	    sub_agents: [researcher, writer]
+//
+// Models, tools and tool sets are given to the Loader by name, and other
+// agent and tool set types are added with WithAgentType and WithToolSetType.
+// Every problem of a spec is reported with its line.
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// AgentBuilder builds an agent of a custom type from its spec, usually
+// reading the config field with spec.DecodeConfig. subAgents holds the
+// agents listed in the sub_agents field.
+type AgentBuilder func(spec *AgentSpec, subAgents []agent.Agent) (agent.Agent, error)
+
+// ToolSetBuilder builds a tool set from its spec, usually reading the
+// config field with spec.DecodeConfig.
+type ToolSetBuilder func(spec *ToolSetSpec) (tool.ToolSet, error)
+
+// ModelFactory creates the model of a name not given with WithModel.
+type ModelFactory func(name string) (model.Model, error)
+
+// Loader builds agents from specs.
+type Loader struct {
+	models       map[string]model.Model
+	modelFactory ModelFactory
+	tools        map[string]tool.Tool
+	toolSets     map[string]tool.ToolSet
+	agentTypes   map[string]AgentBuilder
+	toolSetTypes map[string]ToolSetBuilder
+}
+
+// Option configures a Loader.
+type Option func(*Loader)
+
+// WithModel makes model m available to the specs as name.
+func WithModel(name string, m model.Model) Option {
+	return func(l *Loader) { l.models[name] = m }
+}
+
+// WithModelFactory sets how the models of the names not given with
+// WithModel are created, for instance with openai.New.
+func WithModelFactory(f ModelFactory) Option {
+	return func(l *Loader) { l.modelFactory = f }
+}
+
+// WithTools makes the tools available to the specs by their declared name.
+func WithTools(tools ...tool.Tool) Option {
+	return func(l *Loader) {
+		for _, t := range tools {
+			l.tools[t.Declaration().Name] = t
+		}
+	}
+}
+
+// WithToolSets makes the tool sets available to the specs by their name.
+// They are shared by the loaded definitions and are not closed with them.
+func WithToolSets(toolSets ...tool.ToolSet) Option {
+	return func(l *Loader) {
+		for _, ts := range toolSets {
+			l.toolSets[ts.Name()] = ts
+		}
+	}
+}
+
+// WithAgentType adds an agent type built by b. The fields of the agents of
+// this type are name, type, description, sub_agents and config.
+func WithAgentType(typ string, b AgentBuilder) Option {
+	return func(l *Loader) { l.agentTypes[typ] = b }
+}
+
+// WithToolSetType adds a tool set type built by b.
+func WithToolSetType(typ string, b ToolSetBuilder) Option {
+	return func(l *Loader) { l.toolSetTypes[typ] = b }
+}
+
+// NewLoader creates a Loader. The file tool set type is always available.
+func NewLoader(opts ...Option) *Loader {
+	l := &Loader{
+		models:       make(map[string]model.Model),
+		tools:        make(map[string]tool.Tool),
+		toolSets:     make(map[string]tool.ToolSet),
+		agentTypes:   make(map[string]AgentBuilder),
+		toolSetTypes: map[string]ToolSetBuilder{ToolSetTypeFile: buildFileToolSet},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Definitions holds the agents built from a spec.
+type Definitions struct {
+	// Spec is the spec the agents are built from.
+	Spec *Spec
+	// Agents holds every agent of the spec by name.
+	Agents map[string]agent.Agent
+	// Roots holds the agents that are not a sub-agent of another agent by
+	// name. They are the apps to serve, for instance with the debug server.
+	Roots map[string]agent.Agent
+
+	toolSets []tool.ToolSet
+}
+
+// Close closes the tool sets built from the spec.
+func (d *Definitions) Close() error {
+	var errs []error
+	for _, ts := range d.toolSets {
+		if err := ts.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close tool set %s: %w", ts.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoadFile builds the agents of the spec file at path. Relative paths of
+// the spec, such as the base_dir of file tool sets, are relative to the
+// directory of the file.
+func (l *Loader) LoadFile(path string) (*Definitions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read spec: %w", err)
+	}
+	return l.Load(path, data)
+}
+
+// Load builds the agents of the YAML or JSON spec data read from file,
+// which names the file in errors and may be empty. The problems of the spec
+// are returned as Errors.
+func (l *Loader) Load(file string, data []byte) (*Definitions, error) {
+	p := &problems{file: file}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		for _, e := range yamlErrors(err) {
+			p.add(nil, e)
+		}
+		return nil, p.err()
+	}
+	if len(doc.Content) == 0 {
+		p.at(nil, "empty spec")
+		return nil, p.err()
+	}
+	checkKeys(p, doc.Content[0], reflect.TypeOf(Spec{}))
+	spec := &Spec{}
+	if err := doc.Decode(spec); err != nil {
+		for _, e := range yamlErrors(err) {
+			p.add(nil, e)
+		}
+	}
+	if err := p.err(); err != nil {
+		return nil, err
+	}
+	for i, a := range spec.Agents {
+		if a == nil {
+			p.at(keyNode(doc.Content[0], "agents").Content[i], "empty agent")
+			return nil, p.err()
+		}
+		a.file = file
+	}
+	for i, ts := range spec.ToolSets {
+		if ts == nil {
+			p.at(keyNode(doc.Content[0], "toolsets").Content[i], "empty tool set")
+			return nil, p.err()
+		}
+		ts.file = file
+	}
+
+	b := newBuild(l, spec, p)
+	b.validate()
+	if err := p.err(); err != nil {
+		return nil, err
+	}
+	defs := b.run()
+	if err := p.err(); err != nil {
+		defs.Close()
+		return nil, err
+	}
+	return defs, nil
+}
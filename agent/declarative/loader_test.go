@@ -0,0 +1,403 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/chainagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/cycleagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/graphagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/parallelagent"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+type addArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func addTool() tool.Tool {
+	return function.NewFunctionTool(func(_ context.Context, in addArgs) (int, error) {
+		return in.A + in.B, nil
+	}, function.WithName("add"), function.WithDescription("Adds a and b."))
+}
+
+// messages returns the text of the errors in err.
+func messages(t *testing.T, err error) []string {
+	t.Helper()
+	var errs Errors
+	require.ErrorAs(t, err, &errs)
+	var out []string
+	for _, e := range errs {
+		out = append(out, e.Error())
+	}
+	return out
+}
+
+const workflowSpec = `
+agents:
+  - name: researcher
+    description: Finds facts.
+    model: fast
+    instruction: Find the facts.
+    tools: [add]
+  - name: writer
+    model: fast
+    instruction: Write the report.
+    output_key: report
+    generation:
+      temperature: 0.2
+  - name: pipeline
+    type: chain
+    sub_agents: [researcher, writer]
+  - name: votes
+    type: parallel
+    sub_agents: [researcher, writer]
+    aggregator: majority_vote
+    branch_timeout: 30s
+  - name: loop
+    type: cycle
+    sub_agents: [writer]
+    max_iterations: 2
+  - name: flow
+    type: graph
+    description: Researches then writes.
+    graph:
+      nodes:
+        - agent: researcher
+        - id: summarize
+          model: fast
+          instruction: Summarize.
+      edges:
+        - from: researcher
+          to: summarize
+        - from: summarize
+          to: end
+`
+
+func TestLoader_BuildsAgents(t *testing.T) {
+	l := NewLoader(WithModel("fast", agenttest.NewModel()), WithTools(addTool()))
+
+	defs, err := l.Load("agents.yaml", []byte(workflowSpec))
+	require.NoError(t, err)
+	defer defs.Close()
+
+	assert.Len(t, defs.Agents, 6)
+	assert.IsType(t, &llmagent.LLMAgent{}, defs.Agents["researcher"])
+	assert.IsType(t, &chainagent.ChainAgent{}, defs.Agents["pipeline"])
+	assert.IsType(t, &parallelagent.ParallelAgent{}, defs.Agents["votes"])
+	assert.IsType(t, &cycleagent.CycleAgent{}, defs.Agents["loop"])
+	assert.IsType(t, &graphagent.GraphAgent{}, defs.Agents["flow"])
+
+	var roots []string
+	for name := range defs.Roots {
+		roots = append(roots, name)
+	}
+	assert.ElementsMatch(t, []string{"pipeline", "votes", "loop", "flow"}, roots)
+
+	researcher := defs.Agents["researcher"]
+	assert.Equal(t, "Finds facts.", researcher.Info().Description)
+	require.Len(t, researcher.Tools(), 1)
+	assert.Equal(t, "add", researcher.Tools()[0].Declaration().Name)
+	assert.Same(t, researcher, defs.Agents["pipeline"].FindSubAgent("researcher"))
+	assert.Same(t, researcher, defs.Agents["flow"].FindSubAgent("researcher"),
+		"the agents of graph nodes are sub-agents of the graph agent")
+}
+
+func TestLoader_RunsChain(t *testing.T) {
+	researcherModel := agenttest.NewModel(agenttest.Reply("the facts"))
+	writerModel := agenttest.NewModel(agenttest.Reply("the report"))
+	l := NewLoader(WithModel("research", researcherModel), WithModel("write", writerModel))
+
+	defs, err := l.Load("", []byte(`{
+	"agents": [
+		{"name": "researcher", "model": "research", "instruction": "Find the facts."},
+		{"name": "writer", "model": "write", "instruction": "Write the report.", "output_key": "report"},
+		{"name": "pipeline", "type": "chain", "sub_agents": ["researcher", "writer"]}
+	]
+}`))
+	require.NoError(t, err)
+	require.Len(t, defs.Roots, 1)
+
+	h := agenttest.NewHarness(defs.Roots["pipeline"])
+	tr := h.MustRun(t, "write about the bug")
+
+	agenttest.AssertFinalResponse(t, tr, "the report")
+	assert.Contains(t, writerModel.Requests()[0].Messages[0].Content, "Write the report.")
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "the report", string(sess.State["report"]))
+}
+
+func TestLoader_RunsGraph(t *testing.T) {
+	researcherModel := agenttest.NewModel(agenttest.Reply("the facts"))
+	summaryModel := agenttest.NewModel(agenttest.Reply("the summary"))
+	l := NewLoader(WithModel("research", researcherModel), WithModel("summarize", summaryModel))
+
+	defs, err := l.Load("", []byte(`
+agents:
+  - name: researcher
+    model: research
+  - name: flow
+    type: graph
+    graph:
+      entry: researcher
+      nodes:
+        - agent: researcher
+        - id: summarize
+          model: summarize
+          instruction: Summarize.
+      edges:
+        - from: researcher
+          to: summarize
+        - from: summarize
+          to: end
+`))
+	require.NoError(t, err)
+
+	tr := agenttest.NewHarness(defs.Roots["flow"]).MustRun(t, "what happened?")
+
+	agenttest.AssertNoErrors(t, tr)
+	assert.Equal(t, 0, researcherModel.Remaining())
+	assert.Equal(t, 0, summaryModel.Remaining())
+	assert.Equal(t, "Summarize.", summaryModel.Requests()[0].Messages[0].Content)
+}
+
+func TestLoader_ValidatesGraphs(t *testing.T) {
+	l := NewLoader(WithModel("fast", agenttest.NewModel()), WithTools(addTool()))
+
+	_, err := l.Load("flow.yaml", []byte(`agents:
+  - name: flow
+    type: graph
+    graph:
+      entry: start
+      nodes:
+        - id: ask
+          model: fast
+          tools: [add]
+        - id: run_tools
+          tools: [add]
+          instruction: Run.
+        - id: empty
+      edges:
+        - from: run_tools
+          tools: ask
+          to: end
+        - from: ask
+  - name: bare
+    type: graph
+`))
+
+	assert.Equal(t, []string{
+		`flow.yaml:5:14: unknown entry node "start"`,
+		`flow.yaml:12:24: a tools node has no instruction`,
+		`flow.yaml:13:11: node "empty" needs an agent, a model or tools`,
+		`flow.yaml:16:18: "ask" is not a tools node`,
+		`flow.yaml:16:18: only the edges from model nodes can go to tools nodes`,
+		`flow.yaml:18:11: edge from "ask" has no target`,
+		`flow.yaml:19:5: graph agent "bare" has no nodes`,
+	}, messages(t, err))
+}
+
+func TestLoader_ReportsProblemsWithLines(t *testing.T) {
+	l := NewLoader(WithModel("fast", agenttest.NewModel()), WithTools(addTool()))
+
+	_, err := l.Load("agents.yaml", []byte(`agents:
+  - name: a
+    model: fast
+    instructions: typo
+`))
+	assert.Equal(t, []string{`agents.yaml:4:5: unknown field "instructions"`}, messages(t, err),
+		"a misspelled field is reported before the spec is checked")
+
+	_, err = l.Load("agents.yaml", []byte(`agents:
+  - name: a
+    model: slow
+    tools: [add, search]
+    max_iterations: 3
+  - name: b
+    type: chain
+    sub_agents: [a, c, b]
+  - name: a
+    model: fast
+  - name: c
+    type: cycle
+    sub_agents: [d]
+  - name: d
+    type: parallel
+    sub_agents: [c]
+    output_key: out
+  - name: e
+    type: graph
+    graph:
+      nodes:
+        - id: end
+          model: fast
+        - agent: a
+          model: fast
+      edges:
+        - from: a
+          to: nowhere
+`))
+	assert.Equal(t, []string{
+		`agents.yaml:3:12: unknown model "slow"`,
+		`agents.yaml:4:18: unknown tool "search"`,
+		`agents.yaml:5:5: field "max_iterations" does not apply to llm agents`,
+		`agents.yaml:8:24: agent "b" cannot be its own sub-agent`,
+		`agents.yaml:9:11: agent "a" is defined twice`,
+		`agents.yaml:16:17: agent "c" is its own sub-agent through c -> d -> c`,
+		`agents.yaml:17:17: output_key requires an aggregator`,
+		`agents.yaml:22:15: node id "end" is reserved`,
+		`agents.yaml:24:11: an agent node cannot have a model, instruction or tools`,
+		`agents.yaml:28:15: unknown node "nowhere"`,
+	}, messages(t, err))
+}
+
+func TestLoader_SyntaxAndTypeErrors(t *testing.T) {
+	l := NewLoader()
+
+	_, err := l.Load("agents.yaml", []byte("agents:\n  - name: a\n    model: x: y\n"))
+	assert.Equal(t, []string{"agents.yaml:3: mapping values are not allowed in this context"}, messages(t, err))
+
+	_, err = l.Load("agents.json", []byte(`{"agents": [{"name": "a", "max_iterations": "many"}]}`))
+	msgs := messages(t, err)
+	require.Len(t, msgs, 1)
+	assert.True(t, strings.HasPrefix(msgs[0], "agents.json:1: cannot unmarshal !!str `many` into int"), msgs[0])
+
+	_, err = l.Load("", nil)
+	assert.EqualError(t, err, "empty spec")
+}
+
+func TestLoader_CustomTypes(t *testing.T) {
+	type remoteConfig struct {
+		URL string `yaml:"url"`
+	}
+	var gotURL string
+	var closed atomic.Int32
+	l := NewLoader(
+		WithModelFactory(func(name string) (model.Model, error) {
+			if name != "gpt" {
+				return nil, errors.New("no such model")
+			}
+			return agenttest.NewModel(), nil
+		}),
+		WithAgentType("remote", func(spec *AgentSpec, subAgents []agent.Agent) (agent.Agent, error) {
+			var cfg remoteConfig
+			if err := spec.DecodeConfig(&cfg); err != nil {
+				return nil, err
+			}
+			if cfg.URL == "" {
+				return nil, spec.Errorf("no url")
+			}
+			gotURL = cfg.URL
+			return chainagent.New(spec.Name, chainagent.WithSubAgents(subAgents)), nil
+		}),
+		WithToolSetType("tracked", func(spec *ToolSetSpec) (tool.ToolSet, error) {
+			return &countingToolSet{name: spec.Name, closed: &closed}, nil
+		}),
+	)
+
+	defs, err := l.Load("agents.yaml", []byte(`toolsets:
+  - name: extra
+    type: tracked
+agents:
+  - name: remote
+    type: remote
+    description: Remote agent.
+    config:
+      url: http://remote
+  - name: local
+    model: gpt
+    toolsets: [extra]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "http://remote", gotURL)
+	require.NoError(t, defs.Close())
+	assert.Equal(t, int32(1), closed.Load())
+
+	_, err = l.Load("agents.yaml", []byte(`agents:
+  - name: remote
+    type: remote
+    config:
+      uri: http://remote
+  - name: other
+    type: remote
+`))
+	assert.Equal(t, []string{
+		`agents.yaml:5:7: unknown field "uri"`,
+		`agents.yaml:6:5: no url`,
+	}, messages(t, err), "the configs are checked by the builders")
+
+	_, err = l.Load("agents.yaml", []byte(`agents:
+  - name: local
+    model: claude
+`))
+	assert.Equal(t, []string{`agents.yaml:3:12: create model "claude": no such model`}, messages(t, err))
+}
+
+func TestLoader_FileToolSet(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "docs"), 0o755))
+	path := filepath.Join(dir, "agents.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`toolsets:
+  - name: docs
+    type: file
+    config:
+      base_dir: docs
+      save_file: false
+      replace_content: false
+agents:
+  - name: reader
+    model: fast
+    toolsets: [docs]
+`), 0o644))
+	l := NewLoader(WithModel("fast", agenttest.NewModel()))
+
+	defs, err := l.LoadFile(path)
+	require.NoError(t, err)
+	defer defs.Close()
+
+	var names []string
+	for _, tl := range defs.Agents["reader"].Tools() {
+		names = append(names, tl.Declaration().Name)
+	}
+	assert.NotEmpty(t, names)
+	for _, name := range names {
+		assert.NotContains(t, name, "save_file")
+		assert.NotContains(t, name, "replace_content")
+	}
+
+	_, err = l.Load("agents.yaml", []byte(`toolsets:
+  - name: docs
+    type: file
+    config:
+      base_dir: missing
+agents:
+  - name: reader
+    model: fast
+    toolsets: [docs, web]
+`))
+	assert.Equal(t, []string{`agents.yaml:9:22: unknown tool set "web"`}, messages(t, err))
+}
@@ -0,0 +1,103 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package mcp adds the tool sets of MCP servers to the declarative loader:
+//
+//	toolsets:
+//	  - name: weather
+//	    type: mcp
+//	    config:
+//	      transport: streamable
+//	      server_url: http://localhost:3000/mcp
+//	      timeout: 10s
+//	      include: [get_forecast]
+//
+// It is a package of its own so that the loader does not depend on the MCP
+// client unless MCP tool sets are used.
+package mcp
+
+import (
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/declarative"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	mcptool "trpc.group/trpc-go/trpc-agent-go/tool/mcp"
+)
+
+// Type is the tool set type of MCP servers.
+const Type = "mcp"
+
+// Config is the config of the tool sets of type mcp. It holds the fields of
+// the ConnectionConfig of tool/mcp and the tool filters.
+type Config struct {
+	// Transport is "stdio", "sse" or "streamable".
+	Transport string `yaml:"transport"`
+	// ServerURL is the URL of sse and streamable servers.
+	ServerURL string `yaml:"server_url"`
+	// Headers are the HTTP headers sent to sse and streamable servers.
+	Headers map[string]string `yaml:"headers"`
+	// Command and Args start a stdio server.
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	// Timeout bounds the requests to the server, as in "10s".
+	Timeout time.Duration `yaml:"timeout"`
+	// Include keeps only the listed tools of the server.
+	Include []string `yaml:"include"`
+	// Exclude drops the listed tools of the server.
+	Exclude []string `yaml:"exclude"`
+	// Reconnect is the number of attempts to reconnect an expired session,
+	// 0 to not reconnect.
+	Reconnect int `yaml:"reconnect"`
+}
+
+// Enable returns the loader option adding the mcp tool set type.
+func Enable() declarative.Option {
+	return declarative.WithToolSetType(Type, Build)
+}
+
+// Build builds a tool set of type mcp.
+func Build(spec *declarative.ToolSetSpec) (tool.ToolSet, error) {
+	var cfg Config
+	if err := spec.DecodeConfig(&cfg); err != nil {
+		return nil, err
+	}
+	switch cfg.Transport {
+	case "stdio":
+		if cfg.Command == "" {
+			return nil, spec.Errorf("the stdio transport needs a command")
+		}
+	case "sse", "streamable", "streamable_http":
+		if cfg.ServerURL == "" {
+			return nil, spec.Errorf("the %s transport needs a server_url", cfg.Transport)
+		}
+	default:
+		return nil, spec.Errorf("unknown MCP transport %q, want stdio, sse or streamable", cfg.Transport)
+	}
+	if len(cfg.Include) > 0 && len(cfg.Exclude) > 0 {
+		return nil, spec.Errorf("include and exclude cannot be combined")
+	}
+	opts := []mcptool.ToolSetOption{mcptool.WithName(spec.Name)}
+	switch {
+	case len(cfg.Include) > 0:
+		opts = append(opts, mcptool.WithToolFilter(mcptool.NewIncludeFilter(cfg.Include...)))
+	case len(cfg.Exclude) > 0:
+		opts = append(opts, mcptool.WithToolFilter(mcptool.NewExcludeFilter(cfg.Exclude...)))
+	}
+	if cfg.Reconnect > 0 {
+		opts = append(opts, mcptool.WithSessionReconnect(cfg.Reconnect))
+	}
+	return mcptool.NewMCPToolSet(mcptool.ConnectionConfig{
+		Transport: cfg.Transport,
+		ServerURL: cfg.ServerURL,
+		Headers:   cfg.Headers,
+		Command:   cfg.Command,
+		Args:      cfg.Args,
+		Timeout:   cfg.Timeout,
+	}, opts...), nil
+}
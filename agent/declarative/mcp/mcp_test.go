@@ -0,0 +1,88 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/declarative"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errs   []string
+	}{
+		{
+			name:   "streamable",
+			config: "      transport: streamable\n      server_url: http://localhost:3000/mcp\n      timeout: 10s\n      include: [get_forecast]\n",
+		},
+		{
+			name:   "sse",
+			config: "      transport: sse\n      server_url: http://localhost:3000/sse\n      headers: {Authorization: Bearer token}\n",
+		},
+		{
+			name:   "stdio",
+			config: "      transport: stdio\n      command: weather-server\n      args: [--verbose]\n      exclude: [debug]\n      reconnect: 2\n",
+		},
+		{
+			name:   "stdio without command",
+			config: "      transport: stdio\n",
+			errs:   []string{"agents.yaml:2:5: the stdio transport needs a command"},
+		},
+		{
+			name:   "sse without server url",
+			config: "      transport: sse\n",
+			errs:   []string{"agents.yaml:2:5: the sse transport needs a server_url"},
+		},
+		{
+			name:   "unknown transport",
+			config: "      transport: websocket\n",
+			errs:   []string{`agents.yaml:2:5: unknown MCP transport "websocket", want stdio, sse or streamable`},
+		},
+		{
+			name:   "include and exclude",
+			config: "      transport: stdio\n      command: weather-server\n      include: [a]\n      exclude: [b]\n",
+			errs:   []string{"agents.yaml:2:5: include and exclude cannot be combined"},
+		},
+		{
+			name:   "unknown field",
+			config: "      transport: stdio\n      command: weather-server\n      url: http://localhost\n",
+			errs:   []string{`agents.yaml:7:7: unknown field "url"`},
+		},
+		{
+			name:   "invalid timeout",
+			config: "      transport: stdio\n      command: weather-server\n      timeout: soon\n",
+			errs:   []string{"agents.yaml:7: cannot unmarshal !!str `soon` into time.Duration"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := "toolsets:\n  - name: weather\n    type: mcp\n    config:\n" + tt.config
+			defs, err := declarative.NewLoader(Enable()).Load("agents.yaml", []byte(spec))
+			if tt.errs == nil {
+				require.NoError(t, err)
+				require.NoError(t, defs.Close())
+				return
+			}
+			var errs declarative.Errors
+			require.ErrorAs(t, err, &errs)
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			assert.Equal(t, tt.errs, got)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Agent types built by every loader.
+const (
+	// TypeLLM builds an llmagent. It is the type of agents without a type.
+	TypeLLM = "llm"
+	// TypeChain builds a chainagent running its sub-agents in sequence.
+	TypeChain = "chain"
+	// TypeParallel builds a parallelagent running its sub-agents at once.
+	TypeParallel = "parallel"
+	// TypeCycle builds a cycleagent running its sub-agents in a loop.
+	TypeCycle = "cycle"
+	// TypeGraph builds a graphagent from the graph field.
+	TypeGraph = "graph"
+)
+
+// ToolSetTypeFile is the tool set type of the file tools of tool/file.
+const ToolSetTypeFile = "file"
+
+// Spec is the content of a spec file. JSON files use the same fields, as
+// JSON documents are read as YAML.
+type Spec struct {
+	// Agents defines the agents, which may reference each other by name.
+	Agents []*AgentSpec `yaml:"agents"`
+	// ToolSets defines the tool sets the agents can reference by name in
+	// addition to the tool sets given to the loader.
+	ToolSets []*ToolSetSpec `yaml:"toolsets"`
+}
+
+// AgentSpec defines an agent. Only the fields of its type may be set.
+type AgentSpec struct {
+	// Name is the name of the agent, unique in the spec.
+	Name string `yaml:"name"`
+	// Type is the type of the agent, TypeLLM when empty.
+	Type string `yaml:"type"`
+	// SubAgents lists the names of the sub-agents of the agent.
+	SubAgents []string `yaml:"sub_agents"`
+	// Description describes an llm, graph or custom agent to the agents
+	// that transfer to it.
+	Description string `yaml:"description"`
+
+	// Model is the name of the model of an llm agent.
+	Model string `yaml:"model"`
+	// Instruction is the instruction of an llm agent.
+	Instruction string `yaml:"instruction"`
+	// GlobalInstruction is the system prompt of an llm agent.
+	GlobalInstruction string `yaml:"global_instruction"`
+	// Tools lists the names of the tools of an llm agent.
+	Tools []string `yaml:"tools"`
+	// ToolSets lists the names of the tool sets of an llm agent.
+	ToolSets []string `yaml:"toolsets"`
+	// Generation configures the model calls of an llm agent.
+	Generation *GenerationSpec `yaml:"generation"`
+	// OutputKey is the session state key the answer of an llm or parallel
+	// agent is stored under.
+	OutputKey string `yaml:"output_key"`
+	// OutputSchema is the JSON schema of the answer of an llm agent.
+	OutputSchema map[string]any `yaml:"output_schema"`
+	// InputSchema is the JSON schema of the input of an llm agent.
+	InputSchema map[string]any `yaml:"input_schema"`
+
+	// MaxIterations bounds the loops of a cycle agent.
+	MaxIterations int `yaml:"max_iterations"`
+
+	// Aggregator combines the answers of the branches of a parallel agent:
+	// "first_success" or "majority_vote". The branches answer separately
+	// when empty.
+	Aggregator string `yaml:"aggregator"`
+	// BranchTimeout bounds each branch of a parallel agent, as in "30s".
+	BranchTimeout time.Duration `yaml:"branch_timeout"`
+
+	// Graph is the workflow of a graph agent.
+	Graph *GraphSpec `yaml:"graph"`
+
+	// Config holds the settings of an agent of a custom type, read by its
+	// builder with DecodeConfig.
+	Config yaml.Node `yaml:"config"`
+
+	node *yaml.Node
+	file string
+}
+
+// GenerationSpec configures the model calls of an llm agent.
+type GenerationSpec struct {
+	MaxTokens   *int     `yaml:"max_tokens"`
+	Temperature *float64 `yaml:"temperature"`
+	TopP        *float64 `yaml:"top_p"`
+	Stream      bool     `yaml:"stream"`
+	Stop        []string `yaml:"stop"`
+}
+
+// GraphSpec defines the workflow of a graph agent. The graph starts at the
+// entry node and ends after the nodes with an edge to "end".
+type GraphSpec struct {
+	// Entry is the id of the first node, the first node of Nodes when empty.
+	Entry string `yaml:"entry"`
+	// Nodes lists the nodes of the graph.
+	Nodes []*NodeSpec `yaml:"nodes"`
+	// Edges lists the edges between the nodes.
+	Edges []*EdgeSpec `yaml:"edges"`
+}
+
+// NodeSpec defines a node of a graph. A node runs a sub-agent when Agent is
+// set, calls a model when Model is set, and runs the tool calls of the
+// previous model node when only Tools is set.
+type NodeSpec struct {
+	// ID is the id of the node. The id of an agent node is the name of its
+	// agent, so it may be omitted.
+	ID string `yaml:"id"`
+	// Agent is the name of the agent an agent node runs. It is added to the
+	// sub-agents of the graph agent.
+	Agent string `yaml:"agent"`
+	// Model is the name of the model a model node calls.
+	Model string `yaml:"model"`
+	// Instruction is the instruction of a model node.
+	Instruction string `yaml:"instruction"`
+	// Tools lists the names of the tools of a model or tools node.
+	Tools []string `yaml:"tools"`
+
+	node *yaml.Node
+}
+
+// EdgeSpec defines an edge of a graph. The edge goes to Tools when the last
+// message has tool calls, and to To otherwise.
+type EdgeSpec struct {
+	// From is the id of the node the edge starts from.
+	From string `yaml:"from"`
+	// To is the id of the node the edge goes to, or "end".
+	To string `yaml:"to"`
+	// Tools is the id of the tools node the edge goes to when the model
+	// node From asks for tool calls.
+	Tools string `yaml:"tools"`
+
+	node *yaml.Node
+}
+
+// ToolSetSpec defines a tool set.
+type ToolSetSpec struct {
+	// Name is the name agents reference the tool set by.
+	Name string `yaml:"name"`
+	// Type is the type of the tool set, such as ToolSetTypeFile.
+	Type string `yaml:"type"`
+	// Config holds the settings of the tool set, read by its builder with
+	// DecodeConfig.
+	Config yaml.Node `yaml:"config"`
+
+	node *yaml.Node
+	file string
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler to remember the position of the
+// spec.
+func (s *AgentSpec) UnmarshalYAML(n *yaml.Node) error {
+	type plain AgentSpec
+	s.node = n
+	return n.Decode((*plain)(s))
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler to remember the position of the
+// spec.
+func (s *NodeSpec) UnmarshalYAML(n *yaml.Node) error {
+	type plain NodeSpec
+	s.node = n
+	return n.Decode((*plain)(s))
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler to remember the position of the
+// spec.
+func (s *EdgeSpec) UnmarshalYAML(n *yaml.Node) error {
+	type plain EdgeSpec
+	s.node = n
+	return n.Decode((*plain)(s))
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler to remember the position of the
+// spec.
+func (s *ToolSetSpec) UnmarshalYAML(n *yaml.Node) error {
+	type plain ToolSetSpec
+	s.node = n
+	return n.Decode((*plain)(s))
+}
+
+// DecodeConfig decodes the config field into v. Unknown fields and values
+// of the wrong type are reported as Errors at their line.
+func (s *AgentSpec) DecodeConfig(v any) error {
+	return decodeConfig(s.file, &s.Config, v)
+}
+
+// Errorf returns an error at the position of the spec.
+func (s *AgentSpec) Errorf(format string, args ...any) error {
+	p := &problems{file: s.file}
+	p.at(s.node, format, args...)
+	return p.errs[0]
+}
+
+// DecodeConfig decodes the config field into v. Unknown fields and values
+// of the wrong type are reported as Errors at their line.
+func (s *ToolSetSpec) DecodeConfig(v any) error {
+	return decodeConfig(s.file, &s.Config, v)
+}
+
+// Errorf returns an error at the position of the spec.
+func (s *ToolSetSpec) Errorf(format string, args ...any) error {
+	p := &problems{file: s.file}
+	p.at(s.node, format, args...)
+	return p.errs[0]
+}
+
+// decodeConfig decodes the config node n of a spec into v.
+func decodeConfig(file string, n *yaml.Node, v any) error {
+	if n.Kind == 0 {
+		return nil
+	}
+	p := &problems{file: file}
+	checkKeys(p, n, reflect.TypeOf(v))
+	if err := n.Decode(v); err != nil {
+		for _, e := range yamlErrors(err) {
+			p.add(n, e)
+		}
+	}
+	return p.err()
+}
@@ -0,0 +1,74 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"path/filepath"
+
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/file"
+)
+
+// FileConfig is the config of the tool sets of type file. The tools are
+// all enabled unless disabled.
+type FileConfig struct {
+	// BaseDir is the directory the tools work in. A relative directory is
+	// relative to the directory of the spec file.
+	BaseDir           string `yaml:"base_dir"`
+	SaveFile          *bool  `yaml:"save_file"`
+	ReadFile          *bool  `yaml:"read_file"`
+	ReadMultipleFiles *bool  `yaml:"read_multiple_files"`
+	ListFile          *bool  `yaml:"list_file"`
+	SearchFile        *bool  `yaml:"search_file"`
+	SearchContent     *bool  `yaml:"search_content"`
+	ReplaceContent    *bool  `yaml:"replace_content"`
+	MaxFileSize       int64  `yaml:"max_file_size"`
+}
+
+// buildFileToolSet builds a tool set of type file.
+func buildFileToolSet(spec *ToolSetSpec) (tool.ToolSet, error) {
+	var cfg FileConfig
+	if err := spec.DecodeConfig(&cfg); err != nil {
+		return nil, err
+	}
+	opts := []file.Option{file.WithName(spec.Name), file.WithBaseDir(spec.path(cfg.BaseDir))}
+	enabled := []struct {
+		value *bool
+		opt   func(bool) file.Option
+	}{
+		{cfg.SaveFile, file.WithSaveFileEnabled},
+		{cfg.ReadFile, file.WithReadFileEnabled},
+		{cfg.ReadMultipleFiles, file.WithReadMultipleFilesEnabled},
+		{cfg.ListFile, file.WithListFileEnabled},
+		{cfg.SearchFile, file.WithSearchFileEnabled},
+		{cfg.SearchContent, file.WithSearchContentEnabled},
+		{cfg.ReplaceContent, file.WithReplaceContentEnabled},
+	}
+	for _, e := range enabled {
+		if e.value != nil {
+			opts = append(opts, e.opt(*e.value))
+		}
+	}
+	if cfg.MaxFileSize > 0 {
+		opts = append(opts, file.WithMaxFileSize(cfg.MaxFileSize))
+	}
+	return file.NewToolSet(opts...)
+}
+
+// path resolves p against the directory of the spec file.
+func (s *ToolSetSpec) path(p string) string {
+	if p == "" {
+		p = "."
+	}
+	if filepath.IsAbs(p) || s.file == "" {
+		return p
+	}
+	return filepath.Join(filepath.Dir(s.file), p)
+}
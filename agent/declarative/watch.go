@@ -0,0 +1,200 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+)
+
+// defaultWatchInterval is how often Watch checks the spec file by default.
+const defaultWatchInterval = time.Second
+
+// Watch loads the spec file at path, then loads it again each time its
+// content changes until ctx is done, checking the file every interval.
+//
+// onLoad is called with the definitions of each load, or with the error of
+// a failed load, after which the definitions of the previous load stay in
+// use. Watch counts the runs of the Roots of the definitions it loads: the
+// definitions replaced by a newer load, and the last ones when Watch
+// returns, are closed once their runs in progress are over. With the debug
+// server:
+//
+//	go loader.Watch(ctx, "agents.yaml", 0, func(defs *declarative.Definitions, err error) {
+//		if err != nil {
+//			log.Errorf("reload agents: %v", err)
+//			return
+//		}
+//		server.SetAgents(defs.Roots)
+//	})
+func (l *Loader) Watch(
+	ctx context.Context,
+	path string,
+	interval time.Duration,
+	onLoad func(*Definitions, error),
+) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	w := &watcher{loader: l, path: path, onLoad: onLoad}
+	defer w.close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		w.check()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watcher holds the state of a Watch.
+type watcher struct {
+	loader *Loader
+	path   string
+	onLoad func(*Definitions, error)
+
+	read    bool
+	content []byte
+	readErr string
+	current *loaded
+}
+
+// check loads the spec file when its content changed since the last check.
+func (w *watcher) check() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		// Editors may remove the file for a moment while saving it, so the
+		// same error is only reported once.
+		if err.Error() != w.readErr {
+			w.readErr = err.Error()
+			w.onLoad(nil, fmt.Errorf("read spec: %w", err))
+		}
+		return
+	}
+	w.readErr = ""
+	if w.read && bytes.Equal(data, w.content) {
+		return
+	}
+	w.read, w.content = true, data
+	defs, err := w.loader.Load(w.path, data)
+	var l *loaded
+	if err == nil {
+		l = track(defs)
+	}
+	w.onLoad(defs, err)
+	if err != nil {
+		return
+	}
+	w.close()
+	w.current = l
+}
+
+// close retires the current definitions.
+func (w *watcher) close() {
+	if w.current == nil {
+		return
+	}
+	w.current.retire()
+	w.current = nil
+}
+
+// loaded counts the runs in progress of the roots of definitions loaded by
+// Watch, so that the definitions are closed once replaced and idle.
+type loaded struct {
+	defs *Definitions
+
+	mu      sync.Mutex
+	running int
+	retired bool
+}
+
+// track replaces the roots of defs with agents counting their runs.
+func track(defs *Definitions) *loaded {
+	l := &loaded{defs: defs}
+	for name, a := range defs.Roots {
+		defs.Roots[name] = &trackedAgent{Agent: a, loaded: l}
+	}
+	return l
+}
+
+func (l *loaded) begin() {
+	l.mu.Lock()
+	l.running++
+	l.mu.Unlock()
+}
+
+func (l *loaded) end() {
+	l.mu.Lock()
+	l.running--
+	idle := l.retired && l.running == 0
+	l.mu.Unlock()
+	if idle {
+		l.close()
+	}
+}
+
+// retire closes the definitions now if no run is in progress, or else when
+// the last one is over.
+func (l *loaded) retire() {
+	l.mu.Lock()
+	l.retired = true
+	idle := l.running == 0
+	l.mu.Unlock()
+	if idle {
+		l.close()
+	}
+}
+
+func (l *loaded) close() {
+	if err := l.defs.Close(); err != nil {
+		log.Warnf("declarative: %v", err)
+	}
+}
+
+// trackedAgent is a root agent whose runs are counted by Watch.
+type trackedAgent struct {
+	agent.Agent
+	loaded *loaded
+}
+
+// Run implements the agent.Agent interface.
+func (a *trackedAgent) Run(ctx context.Context, invocation *agent.Invocation) (<-chan *event.Event, error) {
+	a.loaded.begin()
+	events, err := a.Agent.Run(ctx, invocation)
+	if err != nil {
+		a.loaded.end()
+		return nil, err
+	}
+	out := make(chan *event.Event, cap(events))
+	go func() {
+		defer a.loaded.end()
+		defer close(out)
+		for e := range events {
+			if err := event.EmitEvent(ctx, out, e); err != nil {
+				// The run is over only once the agent stops sending.
+				for range events {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,173 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package declarative
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+type countingToolSet struct {
+	name   string
+	closed *atomic.Int32
+}
+
+func (c *countingToolSet) Tools(context.Context) []tool.Tool { return nil }
+func (c *countingToolSet) Close() error                      { c.closed.Add(1); return nil }
+func (c *countingToolSet) Name() string                      { return c.name }
+
+type loadResult struct {
+	defs *Definitions
+	err  error
+}
+
+func TestLoader_Watch(t *testing.T) {
+	var closed atomic.Int32
+	l := NewLoader(
+		WithModel("fast", agenttest.NewModel()),
+		WithToolSetType("counting", func(spec *ToolSetSpec) (tool.ToolSet, error) {
+			return &countingToolSet{name: spec.Name, closed: &closed}, nil
+		}),
+	)
+	path := filepath.Join(t.TempDir(), "agents.yaml")
+	// write replaces the file at once, so that the watcher never reads it
+	// half written.
+	write := func(content string) {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o644))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	spec := func(name string) string {
+		return "toolsets:\n  - name: extra\n    type: counting\nagents:\n  - name: " + name + "\n    model: fast\n    toolsets: [extra]\n"
+	}
+	write(spec("first"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	loads := make(chan loadResult)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Watch(ctx, path, 5*time.Millisecond, func(defs *Definitions, err error) {
+			loads <- loadResult{defs, err}
+		})
+	}()
+	next := func() loadResult {
+		select {
+		case r := <-loads:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reload")
+			return loadResult{}
+		}
+	}
+
+	r := next()
+	require.NoError(t, r.err)
+	assert.Contains(t, r.defs.Roots, "first")
+
+	write(spec("second") + "    model_name: x\n")
+	r = next()
+	assert.Equal(t, []string{path + `:8:5: unknown field "model_name"`}, messages(t, r.err))
+
+	write(spec("second"))
+	r = next()
+	require.NoError(t, r.err)
+	assert.Contains(t, r.defs.Roots, "second")
+
+	require.NoError(t, os.Remove(path))
+	r = next()
+	assert.ErrorIs(t, r.err, os.ErrNotExist)
+
+	cancel()
+	<-done
+	assert.Equal(t, int32(2), closed.Load(), "the replaced and the last definitions are closed")
+}
+
+// gatedModel answers once released.
+type gatedModel struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *gatedModel) Info() model.Info { return model.Info{Name: "gated"} }
+
+func (m *gatedModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	ch := make(chan *model.Response, 1)
+	go func() {
+		defer close(ch)
+		m.started <- struct{}{}
+		<-m.release
+		ch <- &model.Response{Done: true, Choices: []model.Choice{{Message: model.NewAssistantMessage("done")}}}
+	}()
+	return ch, nil
+}
+
+func TestLoader_WatchClosesAfterRuns(t *testing.T) {
+	var closed atomic.Int32
+	gated := &gatedModel{started: make(chan struct{}), release: make(chan struct{})}
+	l := NewLoader(
+		WithModel("gated", gated),
+		WithToolSetType("counting", func(spec *ToolSetSpec) (tool.ToolSet, error) {
+			return &countingToolSet{name: spec.Name, closed: &closed}, nil
+		}),
+	)
+	path := filepath.Join(t.TempDir(), "agents.yaml")
+	write := func(name string) {
+		content := "toolsets:\n  - name: extra\n    type: counting\nagents:\n  - name: " + name + "\n    model: gated\n    toolsets: [extra]\n"
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o644))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	write("first")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	loads := make(chan *Definitions)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Watch(ctx, path, 5*time.Millisecond, func(defs *Definitions, err error) {
+			assert.NoError(t, err)
+			loads <- defs
+		})
+	}()
+
+	defs := <-loads
+	ran := make(chan error)
+	go func() {
+		_, err := agenttest.NewHarness(defs.Roots["first"]).Run(context.Background(), "hi")
+		ran <- err
+	}()
+	<-gated.started
+
+	write("second")
+	<-loads
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), closed.Load(), "the replaced definitions stay open while a run uses them")
+
+	close(gated.release)
+	require.NoError(t, <-ran)
+	assert.Eventually(t, func() bool { return closed.Load() == 1 }, time.Second, time.Millisecond,
+		"the replaced definitions are closed once the run is over")
+
+	cancel()
+	<-done
+	assert.Equal(t, int32(2), closed.Load())
+}
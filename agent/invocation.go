@@ -286,6 +286,12 @@ type RunOptions struct {
 	// The agent will look up the model by name from its registered models.
 	// If both Model and ModelName are set, Model takes precedence.
 	ModelName string
+
+	// ToolConfirmationDecisions are the decisions on the tool calls paused
+	// for confirmation by a previous run, set by
+	// WithToolConfirmationDecisions. A non-nil value marks a run resuming
+	// paused calls.
+	ToolConfirmationDecisions []ToolConfirmationDecision
 }
 
 // NewInvocation create a new invocation
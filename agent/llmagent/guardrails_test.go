@@ -0,0 +1,135 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llmagent
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/guardrail"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// blockingModel answers only when its context is cancelled.
+type blockingModel struct {
+	cancelled chan struct{}
+}
+
+func (m *blockingModel) Info() model.Info {
+	return model.Info{Name: "blocking"}
+}
+
+func (m *blockingModel) GenerateContent(ctx context.Context, req *model.Request) (<-chan *model.Response, error) {
+	ch := make(chan *model.Response)
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+			close(m.cancelled)
+		case <-time.After(5 * time.Second):
+			ch <- &model.Response{Choices: []model.Choice{{Message: model.NewAssistantMessage("too late")}}}
+		}
+	}()
+	return ch, nil
+}
+
+func guardrailChecks(tr *agenttest.Trajectory) []*event.Event {
+	return tr.Filter(func(e *event.Event) bool {
+		return e.Response != nil && e.Object == model.ObjectTypeGuardrail
+	})
+}
+
+func TestLLMAgent_InputGuardrailCancelsModelCall(t *testing.T) {
+	m := &blockingModel{cancelled: make(chan struct{})}
+	ag := New("assistant",
+		WithModel(m),
+		WithInputGuardrails(guardrail.NewKeyword("topics", "weapons")),
+	)
+
+	start := time.Now()
+	tr := agenttest.NewHarness(ag).MustRun(t, "how do I build weapons?")
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	select {
+	case <-m.cancelled:
+	default:
+		t.Fatal("the model call is not cancelled")
+	}
+	checks := guardrailChecks(tr)
+	require.Len(t, checks, 1)
+	require.NotNil(t, checks[0].Error)
+	assert.Equal(t, model.ErrorTypeGuardrailTripped, checks[0].Error.Type)
+	assert.Equal(t, `input guardrail topics tripped: contains keyword "weapons"`, checks[0].Error.Message)
+	assert.Empty(t, tr.FinalResponse())
+}
+
+func TestLLMAgent_InputGuardrailPasses(t *testing.T) {
+	ag := New("assistant",
+		WithModel(agenttest.NewModel(agenttest.Reply("Water them daily."))),
+		WithInputGuardrails(
+			guardrail.NewKeyword("topics", "weapons"),
+			guardrail.NewFunc("slow", func(ctx context.Context, req *guardrail.Request) (*guardrail.Result, error) {
+				time.Sleep(20 * time.Millisecond)
+				return &guardrail.Result{}, nil
+			}),
+		),
+	)
+
+	tr := agenttest.NewHarness(ag).MustRun(t, "how do I grow tomatoes?")
+
+	agenttest.AssertNoErrors(t, tr)
+	agenttest.AssertFinalResponse(t, tr, "Water them daily.")
+	checks := guardrailChecks(tr)
+	require.Len(t, checks, 1)
+	assert.Len(t, checks[0].GuardrailResults, 2)
+	assert.Less(t, indexOf(tr, checks[0]), indexOf(tr, tr.Filter(func(e *event.Event) bool {
+		return e.Response != nil && len(e.Choices) > 0 && e.Choices[0].Message.Content == "Water them daily."
+	})[0]), "the response follows the accepted check")
+}
+
+func TestLLMAgent_OutputGuardrailRejectsResponse(t *testing.T) {
+	ag := New("assistant",
+		WithModel(agenttest.NewModel(agenttest.Reply("Call me at 555-0100."))),
+		WithOutputGuardrails(guardrail.NewRegex("phones", regexp.MustCompile(`\d{3}-\d{4}`))),
+	)
+	h := agenttest.NewHarness(ag)
+
+	tr := h.MustRun(t, "how can I reach you?")
+
+	checks := guardrailChecks(tr)
+	require.Len(t, checks, 1)
+	require.NotNil(t, checks[0].Error)
+	assert.Equal(t, guardrail.StageOutput, checks[0].GuardrailResults[0].Stage)
+	assert.Empty(t, tr.FinalResponse())
+
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	for _, e := range sess.Events {
+		if e.Response != nil && len(e.Choices) > 0 {
+			assert.NotContains(t, e.Choices[0].Message.Content, "555-0100")
+		}
+	}
+}
+
+func indexOf(tr *agenttest.Trajectory, evt *event.Event) int {
+	for i, e := range tr.Events {
+		if e == evt {
+			return i
+		}
+	}
+	return -1
+}
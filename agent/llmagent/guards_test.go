@@ -0,0 +1,148 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llmagent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+type lookupArgs struct {
+	Query string `json:"query"`
+}
+
+func lookupTool(delay time.Duration) tool.Tool {
+	return function.NewFunctionTool(func(ctx context.Context, in lookupArgs) (string, error) {
+		select {
+		case <-time.After(delay):
+			return "no result for " + in.Query, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}, function.WithName("lookup"))
+}
+
+// guardError returns the error of the event ending the run of the agent.
+func guardError(t *testing.T, tr *agenttest.Trajectory) *model.ResponseError {
+	t.Helper()
+	errs := tr.Filter(func(e *event.Event) bool { return e.Response != nil && e.Error != nil })
+	require.Len(t, errs, 1)
+	agentEvents := tr.Filter(func(e *event.Event) bool { return e.Author == "searcher" })
+	assert.Same(t, agentEvents[len(agentEvents)-1], errs[0], "the error event ends the invocation")
+	return errs[0].Error
+}
+
+func TestLLMAgent_MaxRepeatedToolCallsWithWrapUp(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.CallTool("lookup", `{"query": "x"}`),
+		agenttest.CallTool("lookup", `{ "query":"x" }`),
+		agenttest.CallTool("lookup", `{"query": "x"}`),
+		agenttest.Reply("I could not find anything about x."),
+	)
+	ag := New("searcher",
+		WithModel(m),
+		WithTools([]tool.Tool{lookupTool(0)}),
+		WithMaxRepeatedToolCalls(2),
+		WithWrapUpCall(""),
+	)
+
+	tr := agenttest.NewHarness(ag).MustRun(t, "find x")
+
+	assert.Equal(t, model.ErrorTypeToolCallLoop, guardError(t, tr).Type)
+	assert.Len(t, tr.ToolCalls(), 2, "the looping call is dropped")
+	agenttest.AssertFinalResponse(t, tr, "I could not find anything about x.")
+
+	// The wrap up call has no tools and ends with the wrap up prompt, after
+	// the two answered calls.
+	reqs := m.Requests()
+	require.Len(t, reqs, 4)
+	wrapUp := reqs[3]
+	assert.Empty(t, wrapUp.Tools)
+	last := wrapUp.Messages[len(wrapUp.Messages)-1]
+	assert.Equal(t, model.RoleUser, last.Role)
+	assert.Contains(t, last.Content, "best final answer")
+	var toolResults int
+	for _, msg := range wrapUp.Messages {
+		if msg.Role == model.RoleTool {
+			toolResults++
+		}
+	}
+	assert.Equal(t, 2, toolResults)
+}
+
+func TestLLMAgent_MaxLLMCalls(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.CallTool("lookup", `{"query": "a"}`),
+		agenttest.CallTool("lookup", `{"query": "b"}`),
+		agenttest.Reply("done"),
+	)
+	ag := New("searcher", WithModel(m), WithTools([]tool.Tool{lookupTool(0)}), WithMaxLLMCalls(2))
+
+	tr := agenttest.NewHarness(ag).MustRun(t, "find a and b")
+
+	assert.Equal(t, model.ErrorTypeMaxLLMCallsExceeded, guardError(t, tr).Type)
+	assert.Len(t, m.Requests(), 2)
+	assert.Equal(t, 1, m.Remaining())
+}
+
+func TestLLMAgent_MaxToolCalls(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.CallTool("lookup", `{"query": "a"}`),
+		agenttest.CallTools(
+			agenttest.Call{Name: "lookup", Args: `{"query": "b"}`},
+			agenttest.Call{Name: "lookup", Args: `{"query": "c"}`},
+		),
+		agenttest.Reply("done"),
+	)
+	ag := New("searcher",
+		WithModel(m),
+		WithTools([]tool.Tool{lookupTool(0)}),
+		WithMaxToolCalls(2),
+		WithWrapUpCall("Answer now."),
+	)
+
+	tr := agenttest.NewHarness(ag).MustRun(t, "find a, b and c")
+
+	assert.Equal(t, model.ErrorTypeMaxToolCallsExceeded, guardError(t, tr).Type)
+	agenttest.AssertToolCalls(t, tr, agenttest.Call{Name: "lookup", Args: `{"query": "a"}`})
+	agenttest.AssertFinalResponse(t, tr, "done")
+	reqs := m.Requests()
+	require.Len(t, reqs, 3)
+	assert.Equal(t, "Answer now.", reqs[2].Messages[len(reqs[2].Messages)-1].Content)
+}
+
+func TestLLMAgent_InvocationTimeout(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.CallTool("lookup", `{"query": "slow"}`),
+		agenttest.Reply("too late"),
+	)
+	ag := New("searcher",
+		WithModel(m),
+		WithTools([]tool.Tool{lookupTool(time.Minute)}),
+		WithInvocationTimeout(50*time.Millisecond),
+	)
+
+	start := time.Now()
+	tr := agenttest.NewHarness(ag).MustRun(t, "find slowly")
+
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, model.ErrorTypeInvocationTimeout, guardError(t, tr).Type)
+	assert.Equal(t, 1, m.Remaining())
+}
@@ -17,19 +17,21 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	sdktrace "go.opentelemetry.io/otel/trace"
 
 	"trpc.group/trpc-go/trpc-agent-go/agent"
-	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent/internal/jsonschema"
 	"trpc.group/trpc-go/trpc-agent-go/codeexecutor"
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/guardrail"
 	"trpc.group/trpc-go/trpc-agent-go/internal/flow"
 	"trpc.group/trpc-go/trpc-agent-go/internal/flow/llmflow"
 	"trpc.group/trpc-go/trpc-agent-go/internal/flow/processor"
+	"trpc.group/trpc-go/trpc-agent-go/internal/jsonschema"
 	itelemetry "trpc.group/trpc-go/trpc-agent-go/internal/telemetry"
 	itool "trpc.group/trpc-go/trpc-agent-go/internal/tool"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge"
@@ -209,6 +211,17 @@ func WithEnableParallelTools(enable bool) Option {
 	}
 }
 
+// WithToolArgumentValidation validates tool call arguments against the input
+// schema of the tool before calling it. Trailing commas and numbers or
+// booleans sent as strings are repaired; when the arguments are still
+// invalid, the tool is not called and the validation errors are returned to
+// the model as the tool result so that it can correct the call.
+func WithToolArgumentValidation(enabled bool) Option {
+	return func(opts *Options) {
+		opts.ValidateToolArguments = enabled
+	}
+}
+
 // WithDefaultTransferMessage configures the default message used when the model
 // calls a sub-agent without providing a message. If msg is an empty string,
 // the default message injection is disabled; if non-empty, it is enabled and msg is used.
@@ -250,6 +263,86 @@ func WithStructuredOutputJSON(examplePtr any, strict bool, description string) O
 	}
 }
 
+// WithOutputValidation validates the final output against the schema of
+// WithStructuredOutputJSON or WithOutputSchema. When the output is not valid
+// JSON or violates the schema, the model is asked to repair it, with the
+// validation errors, up to maxRetries times. If it is still invalid, an error
+// event of type model.ErrorTypeStructuredOutputError is emitted. The output
+// is only stored under the output key once it is valid.
+func WithOutputValidation(maxRetries int) Option {
+	return func(opts *Options) {
+		opts.ValidateOutput = true
+		opts.OutputValidationRetries = maxRetries
+	}
+}
+
+// WithMaxLLMCalls limits the model calls of each invocation. When the limit
+// is reached, the invocation ends with an error event of type
+// model.ErrorTypeMaxLLMCallsExceeded. 0 means no limit.
+func WithMaxLLMCalls(n int) Option {
+	return func(opts *Options) {
+		opts.MaxLLMCalls = n
+	}
+}
+
+// WithMaxToolCalls limits the tool calls of each invocation. The response
+// requesting the calls over the limit is dropped and the invocation ends
+// with an error event of type model.ErrorTypeMaxToolCallsExceeded. 0 means
+// no limit.
+func WithMaxToolCalls(n int) Option {
+	return func(opts *Options) {
+		opts.MaxToolCalls = n
+	}
+}
+
+// WithInvocationTimeout limits the wall-clock duration of each invocation.
+// When it runs out, the invocation ends with an error event of type
+// model.ErrorTypeInvocationTimeout. 0 means no limit.
+func WithInvocationTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.InvocationTimeout = timeout
+	}
+}
+
+// WithMaxRepeatedToolCalls ends the invocation, with an error event of type
+// model.ErrorTypeToolCallLoop, when the model calls the same tool with the
+// same arguments more than n times. 0 means no limit.
+func WithMaxRepeatedToolCalls(n int) Option {
+	return func(opts *Options) {
+		opts.MaxRepeatedToolCalls = n
+	}
+}
+
+// WithWrapUpCall makes a last model call without tools when one of the
+// limits above ends an invocation, asking the model for a best-effort answer
+// with what it has gathered so far. An empty prompt uses a default one.
+func WithWrapUpCall(prompt string) Option {
+	return func(opts *Options) {
+		opts.WrapUp = true
+		opts.WrapUpPrompt = prompt
+	}
+}
+
+// WithInputGuardrails checks the message the agent is run with. The checks
+// run concurrently with the first model call, which is cancelled and ends
+// the invocation with an error event of type model.ErrorTypeGuardrailTripped
+// when a guardrail trips.
+func WithInputGuardrails(guardrails ...guardrail.Guardrail) Option {
+	return func(opts *Options) {
+		opts.InputGuardrails = append(opts.InputGuardrails, guardrails...)
+	}
+}
+
+// WithOutputGuardrails checks the final responses of the agent. A response
+// rejected by a guardrail is not emitted nor persisted; the invocation ends
+// with an error event of type model.ErrorTypeGuardrailTripped instead. The
+// streamed chunks of the response are not checked.
+func WithOutputGuardrails(guardrails ...guardrail.Guardrail) Option {
+	return func(opts *Options) {
+		opts.OutputGuardrails = append(opts.OutputGuardrails, guardrails...)
+	}
+}
+
 // WithAddCurrentTime adds the current time to the system prompt if true.
 func WithAddCurrentTime(addCurrentTime bool) Option {
 	return func(opts *Options) {
@@ -385,6 +478,8 @@ type Options struct {
 	// EnableParallelTools enables parallel tool execution if true.
 	// If false (default), tools will execute serially for safety.
 	EnableParallelTools bool
+	// ValidateToolArguments validates tool call arguments against the tool input schema if true.
+	ValidateToolArguments bool
 	// AddCurrentTime adds the current time to the system prompt if true.
 	AddCurrentTime bool
 	// Timezone specifies the timezone to use for time display.
@@ -423,6 +518,26 @@ type Options struct {
 	StructuredOutput *model.StructuredOutput
 	// StructuredOutputType is the reflect.Type of the example pointer used to generate the schema.
 	StructuredOutputType reflect.Type
+	// ValidateOutput validates the final output against the structured output or output schema.
+	ValidateOutput bool
+	// OutputValidationRetries is the number of times invalid output is sent back to the model for repair.
+	OutputValidationRetries int
+	// MaxLLMCalls limits the model calls of each invocation (0: no limit).
+	MaxLLMCalls int
+	// MaxToolCalls limits the tool calls of each invocation (0: no limit).
+	MaxToolCalls int
+	// InvocationTimeout limits the duration of each invocation (0: no limit).
+	InvocationTimeout time.Duration
+	// MaxRepeatedToolCalls limits identical tool calls in each invocation (0: no limit).
+	MaxRepeatedToolCalls int
+	// WrapUp makes a last model call without tools when a limit ends an invocation.
+	WrapUp bool
+	// WrapUpPrompt is the prompt of the wrap up call; empty uses a default one.
+	WrapUpPrompt string
+	// InputGuardrails check the message the agent is run with.
+	InputGuardrails []guardrail.Guardrail
+	// OutputGuardrails check the final responses of the agent.
+	OutputGuardrails []guardrail.Guardrail
 	// EndInvocationAfterTransfer controls whether to end the current agent invocation after transfer.
 	// If true, the current agent will end the invocation after transfer, else the current agent will continue to run
 	// when the transfer is complete. Defaults to true.
@@ -515,8 +630,14 @@ func New(name string, opts ...Option) *LLMAgent {
 		structuredOutputType: options.StructuredOutputType,
 	}
 
+	toolcallProcessor := processor.NewFunctionCallResponseProcessor(
+		options.EnableParallelTools,
+		options.ToolCallbacks,
+		processor.WithToolArgumentValidation(options.ValidateToolArguments),
+	)
+
 	// Prepare request processors in the correct order, wiring dynamic getters.
-	requestProcessors := buildRequestProcessorsWithAgent(a, &options)
+	requestProcessors := buildRequestProcessorsWithAgent(a, &options, toolcallProcessor)
 
 	// Prepare response processors.
 	var responseProcessors []flow.ResponseProcessor
@@ -531,11 +652,14 @@ func New(name string, opts ...Option) *LLMAgent {
 
 	// Add output response processor if output_key or output_schema is configured or structured output is requested.
 	if options.OutputKey != "" || options.OutputSchema != nil || options.StructuredOutput != nil {
-		orp := processor.NewOutputResponseProcessor(options.OutputKey, options.OutputSchema)
+		var outputOpts []processor.OutputResponseProcessorOption
+		if options.ValidateOutput {
+			outputOpts = append(outputOpts, processor.WithOutputValidation(options.OutputValidationRetries))
+		}
+		orp := processor.NewOutputResponseProcessor(options.OutputKey, options.OutputSchema, outputOpts...)
 		responseProcessors = append(responseProcessors, orp)
 	}
 
-	toolcallProcessor := processor.NewFunctionCallResponseProcessor(options.EnableParallelTools, options.ToolCallbacks)
 	// Configure default transfer message for direct sub-agent calls.
 	// Default behavior (when not configured): enabled with built-in default message.
 	if options.DefaultTransferMessage != nil {
@@ -554,6 +678,16 @@ func New(name string, opts ...Option) *LLMAgent {
 	flowOpts := llmflow.Options{
 		ChannelBufferSize: options.ChannelBufferSize,
 		ModelCallbacks:    options.ModelCallbacks,
+		Guards: llmflow.Guards{
+			MaxLLMCalls:          options.MaxLLMCalls,
+			MaxToolCalls:         options.MaxToolCalls,
+			Timeout:              options.InvocationTimeout,
+			MaxRepeatedToolCalls: options.MaxRepeatedToolCalls,
+			WrapUp:               options.WrapUp,
+			WrapUpPrompt:         options.WrapUpPrompt,
+		},
+		InputGuardrails:  options.InputGuardrails,
+		OutputGuardrails: options.OutputGuardrails,
 	}
 
 	a.flow = llmflow.New(
@@ -565,7 +699,11 @@ func New(name string, opts ...Option) *LLMAgent {
 }
 
 // buildRequestProcessors constructs the request processors in the required order.
-func buildRequestProcessorsWithAgent(a *LLMAgent, options *Options) []flow.RequestProcessor {
+// Tool calls paused for confirmation are resumed with toolcallProcessor when
+// it is not nil.
+func buildRequestProcessorsWithAgent(
+	a *LLMAgent, options *Options, toolcallProcessor *processor.FunctionCallResponseProcessor,
+) []flow.RequestProcessor {
 	var requestProcessors []flow.RequestProcessor
 
 	// 1. Basic processor - handles generation config.
@@ -626,7 +764,14 @@ func buildRequestProcessorsWithAgent(a *LLMAgent, options *Options) []flow.Reque
 		requestProcessors = append(requestProcessors, timeProcessor)
 	}
 
-	// 6. Content processor - handles messages from invocation.
+	// 6. Tool confirmation processor - resumes tool calls approved by a human,
+	// so that the content processor finds their results in the session.
+	if toolcallProcessor != nil {
+		requestProcessors = append(requestProcessors,
+			processor.NewToolConfirmationRequestProcessor(toolcallProcessor))
+	}
+
+	// 7. Content processor - handles messages from invocation.
 	// Align with GraphAgent: honor runtime include_contents if provided.
 	includeMode := processor.IncludeContentsFiltered
 	if inv, ok := agent.InvocationFromContext(context.Background()); ok && inv != nil {
@@ -665,7 +810,7 @@ func buildRequestProcessors(name string, options *Options) []flow.RequestProcess
 		instruction:  options.Instruction,
 		systemPrompt: options.GlobalInstruction,
 	}
-	return buildRequestProcessorsWithAgent(dummy, options)
+	return buildRequestProcessorsWithAgent(dummy, options, nil)
 }
 
 // initializeModels initializes the models map and determines the initial
@@ -13,10 +13,16 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
 	"trpc.group/trpc-go/trpc-agent-go/knowledge"
 	knowledgetool "trpc.group/trpc-go/trpc-agent-go/knowledge/tool"
+	"trpc.group/trpc-go/trpc-agent-go/model"
 	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
 )
 
 // minimalKnowledge implements knowledge.Knowledge with no-op behaviors for unit tests.
@@ -77,3 +83,34 @@ func TestLLMAgent_Tools_IncludesTransferWhenSubAgents(t *testing.T) {
 		t.Fatalf("expected transfer_to_agent tool when sub agents exist")
 	}
 }
+
+func TestLLMAgent_ToolArgumentValidation(t *testing.T) {
+	type repeatArgs struct {
+		Word  string `json:"word"`
+		Times int    `json:"times"`
+	}
+	repeat := function.NewFunctionTool(func(_ context.Context, in repeatArgs) (int, error) {
+		return in.Times, nil
+	}, function.WithName("repeat"))
+	m := agenttest.NewModel(
+		agenttest.CallTool("repeat", `{"word": "hi", "times": "many"}`),
+		agenttest.CallTool("repeat", `{"word": "hi", "times": "2",}`),
+		agenttest.Reply("hi hi"),
+	)
+	ag := New("echo", WithModel(m), WithTools([]tool.Tool{repeat}), WithToolArgumentValidation(true))
+
+	tr := agenttest.NewHarness(ag).MustRun(t, "say hi twice")
+
+	agenttest.AssertNoErrors(t, tr)
+	agenttest.AssertFinalResponse(t, tr, "hi hi")
+	results := tr.ToolResults()
+	assert.Contains(t, results["call_1"], `"path":"$.times"`)
+	assert.Equal(t, "2", results["call_2"])
+
+	// The validation errors are sent to the model with the tool result.
+	reqs := m.Requests()
+	require.Len(t, reqs, 3)
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	assert.Equal(t, model.RoleTool, last.Role)
+	assert.Contains(t, last.Content, "expected integer, got string")
+}
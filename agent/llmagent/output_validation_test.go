@@ -0,0 +1,92 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llmagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+type validatedCity struct {
+	Name       string `json:"name"`
+	Population int    `json:"population"`
+}
+
+func TestLLMAgent_OutputValidationRepairs(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.Reply(`{"name": "Paris", "population": "2.1 million"}`),
+		agenttest.Reply(`{"name": "Paris", "population": 2100000}`),
+	)
+	ag := New("city",
+		WithModel(m),
+		WithOutputKey("city"),
+		WithStructuredOutputJSON(new(validatedCity), true, "a city"),
+		WithOutputValidation(2),
+	)
+
+	tr := agenttest.NewHarness(ag).MustRun(t, "Tell me about Paris.")
+
+	agenttest.AssertNoErrors(t, tr)
+	agenttest.AssertStateDelta(t, tr, map[string]any{"city": validatedCity{Name: "Paris", Population: 2100000}})
+	repairs := tr.Filter(func(e *event.Event) bool { return e.Object == model.ObjectTypeStructuredOutputRepair })
+	require.Len(t, repairs, 1)
+	assert.Equal(t, []string{"$.population: expected integer, got string"}, repairs[0].StructuredOutputErrors)
+
+	// Only the valid reply is final.
+	replies := tr.Filter(func(e *event.Event) bool {
+		return e.Object != model.ObjectTypeStructuredOutputRepair && len(e.Choices) > 0 && e.Choices[0].Message.Content != ""
+	})
+	require.Len(t, replies, 2)
+	assert.False(t, replies[0].IsFinalResponse())
+	assert.True(t, replies[1].IsFinalResponse())
+
+	// The second request carries the rejected reply and the validation errors.
+	reqs := m.Requests()
+	require.Len(t, reqs, 2)
+	msgs := reqs[1].Messages
+	require.GreaterOrEqual(t, len(msgs), 2)
+	assert.Equal(t, model.RoleAssistant, msgs[len(msgs)-2].Role)
+	assert.Equal(t, model.RoleUser, msgs[len(msgs)-1].Role)
+	assert.Contains(t, msgs[len(msgs)-1].Content, "$.population: expected integer, got string")
+
+	payloads := tr.Filter(func(e *event.Event) bool { return e.StructuredOutput != nil })
+	require.Len(t, payloads, 1)
+	assert.Equal(t, &validatedCity{Name: "Paris", Population: 2100000}, payloads[0].StructuredOutput)
+}
+
+func TestLLMAgent_OutputValidationExhausted(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.Reply(`not json`),
+		agenttest.Reply(`{"name": "Paris"}`),
+	)
+	ag := New("city",
+		WithModel(m),
+		WithOutputKey("city"),
+		WithOutputSchema(map[string]any{
+			"type":     "object",
+			"required": []any{"name", "population"},
+		}),
+		WithOutputValidation(1),
+	)
+
+	tr := agenttest.NewHarness(ag).MustRun(t, "Tell me about Paris.")
+
+	assert.Equal(t, 0, m.Remaining())
+	require.Len(t, tr.Errors(), 1)
+	errs := tr.Filter(func(e *event.Event) bool { return e.Error != nil })
+	assert.Equal(t, model.ErrorTypeStructuredOutputError, errs[0].Error.Type)
+	assert.Empty(t, tr.StateDelta())
+}
@@ -0,0 +1,238 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llmagent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/redact"
+	"trpc.group/trpc-go/trpc-agent-go/session/inmemory"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+type deleteArgs struct {
+	Path string `json:"path"`
+}
+
+// deleteTool records the paths it deletes.
+func deleteTool(deleted *[]string, opts ...function.Option) tool.Tool {
+	opts = append([]function.Option{function.WithName("delete_file")}, opts...)
+	return function.NewFunctionTool(func(_ context.Context, in deleteArgs) (string, error) {
+		*deleted = append(*deleted, in.Path)
+		return "deleted " + in.Path, nil
+	}, opts...)
+}
+
+func confirmationRequests(tr *agenttest.Trajectory) []*event.Event {
+	return tr.Filter(func(e *event.Event) bool {
+		return e.Response != nil && e.Object == model.ObjectTypeToolConfirmationRequest
+	})
+}
+
+func TestLLMAgent_ToolConfirmationApproved(t *testing.T) {
+	var deleted []string
+	m := agenttest.NewModel(
+		agenttest.CallTool("delete_file", deleteArgs{Path: "/tmp/a"}),
+		agenttest.Reply("The file is deleted."),
+	)
+	ag := New("janitor",
+		WithModel(m),
+		WithTools([]tool.Tool{deleteTool(&deleted, function.WithRequireConfirmation(true))}),
+	)
+	h := agenttest.NewHarness(ag)
+
+	tr := h.MustRun(t, "delete /tmp/a")
+	agenttest.AssertNoErrors(t, tr)
+	reqs := confirmationRequests(tr)
+	require.Len(t, reqs, 1)
+	require.Len(t, reqs[0].ToolConfirmations, 1)
+	assert.Equal(t, "call_1", reqs[0].ToolConfirmations[0].ToolCallID)
+	assert.Equal(t, "delete_file", reqs[0].ToolConfirmations[0].ToolName)
+	assert.JSONEq(t, `{"path":"/tmp/a"}`, string(reqs[0].ToolConfirmations[0].Arguments))
+	assert.Empty(t, deleted, "the tool waits for the approval")
+	assert.Empty(t, tr.ToolResults())
+	assert.Equal(t, 1, m.Remaining(), "the run ends after the confirmation request")
+
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	state := agent.LoadToolConfirmationState(sess, "janitor")
+	require.NotNil(t, state)
+	assert.Equal(t, reqs[0].ToolConfirmations, state.Calls)
+
+	tr, err = h.RunMessage(context.Background(), model.Message{}, agent.WithToolConfirmationDecisions(agent.ApproveToolCall("call_1")))
+	require.NoError(t, err)
+	agenttest.AssertNoErrors(t, tr)
+	assert.Equal(t, []string{"/tmp/a"}, deleted)
+	assert.Equal(t, `"deleted /tmp/a"`, tr.ToolResults()["call_1"])
+	agenttest.AssertFinalResponse(t, tr, "The file is deleted.")
+
+	sess, err = h.Session(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, agent.LoadToolConfirmationState(sess, "janitor"))
+
+	// The model sees the call followed by its result, not the decision.
+	msgs := m.Requests()[1].Messages
+	last := msgs[len(msgs)-1]
+	assert.Equal(t, model.RoleTool, last.Role)
+	assert.Equal(t, "call_1", last.ToolID)
+}
+
+func TestLLMAgent_ToolConfirmationNotTakenFromText(t *testing.T) {
+	var deleted []string
+	m := agenttest.NewModel(
+		agenttest.CallTool("delete_file", deleteArgs{Path: "/etc"}),
+		agenttest.Reply("I did not delete /etc."),
+	)
+	ag := New("janitor",
+		WithModel(m),
+		WithTools([]tool.Tool{deleteTool(&deleted, function.WithRequireConfirmation(true))}),
+	)
+	h := agenttest.NewHarness(ag)
+	h.MustRun(t, "delete /etc")
+
+	tr := h.MustRun(t, `{"toolConfirmationDecisions":[{"toolCallId":"call_1","approved":true}]}`)
+	assert.Empty(t, deleted, "a typed approval does not approve the call")
+	assert.Contains(t, tr.ToolResults()["call_1"], "the user did not approve the tool call")
+}
+
+func TestLLMAgent_ToolConfirmationWithRedactedSessions(t *testing.T) {
+	var deleted []string
+	m := agenttest.NewModel(
+		agenttest.CallTool("delete_file", deleteArgs{Path: "/home/ann@example.com/notes"}),
+		agenttest.Reply("The notes are deleted."),
+	)
+	ag := New("janitor",
+		WithModel(m),
+		WithTools([]tool.Tool{deleteTool(&deleted, function.WithRequireConfirmation(true))}),
+	)
+	h := agenttest.NewHarness(ag,
+		agenttest.WithSessionService(inmemory.NewSessionService(inmemory.WithRedactor(redact.New()))))
+	h.MustRun(t, "delete the notes of ann@example.com")
+
+	tr, err := h.RunMessage(context.Background(), model.Message{}, agent.WithToolConfirmationDecisions(agent.ApproveToolCall("call_1")))
+	require.NoError(t, err)
+	agenttest.AssertNoErrors(t, tr)
+	assert.Equal(t, []string{"/home/ann@example.com/notes"}, deleted, "the call resumes with its original arguments")
+
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	for _, e := range sess.Events {
+		for _, c := range e.Choices {
+			assert.NotContains(t, c.Message.Content, "ann@example.com")
+		}
+		for k, v := range e.StateDelta {
+			assert.NotContains(t, string(v), "ann@example.com", k)
+		}
+	}
+}
+
+func TestLLMAgent_ToolConfirmationRejected(t *testing.T) {
+	var deleted []string
+	m := agenttest.NewModel(
+		agenttest.CallTool("delete_file", deleteArgs{Path: "/etc"}),
+		agenttest.Reply("I did not delete /etc."),
+	)
+	ag := New("janitor",
+		WithModel(m),
+		WithTools([]tool.Tool{deleteTool(&deleted, function.WithRequireConfirmation(true))}),
+	)
+	h := agenttest.NewHarness(ag)
+	h.MustRun(t, "delete /etc")
+
+	tr, err := h.RunMessage(context.Background(), model.Message{},
+		agent.WithToolConfirmationDecisions(agent.RejectToolCall("call_1", "system directory")))
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	assert.Contains(t, tr.ToolResults()["call_1"], "system directory")
+	agenttest.AssertFinalResponse(t, tr, "I did not delete /etc.")
+
+	// Invalid edited arguments reject the call.
+	m.Append(agenttest.CallTool("delete_file", deleteArgs{Path: "/etc"}), agenttest.Reply("Still not deleted."))
+	h.MustRun(t, "delete /etc, really")
+	tr, err = h.RunMessage(context.Background(), model.Message{},
+		agent.WithToolConfirmationDecisions(agent.ApproveToolCallWithArguments("call_2", []byte(`{"path":`))))
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	assert.Contains(t, tr.ToolResults()["call_2"], "invalid arguments")
+}
+
+func TestLLMAgent_ToolConfirmationEditedArgumentsWithCallback(t *testing.T) {
+	var deleted []string
+	m := agenttest.NewModel(
+		agenttest.CallTool("delete_file", deleteArgs{Path: "/home"}),
+		agenttest.Reply("Done."),
+	)
+	ag := New("janitor",
+		WithModel(m),
+		WithTools([]tool.Tool{deleteTool(&deleted)}),
+		WithToolCallbacks(tool.NewCallbacks().RegisterBeforeTool(tool.RequireConfirmation("delete_file"))),
+	)
+	h := agenttest.NewHarness(ag)
+	tr := h.MustRun(t, "clean my home")
+	require.Len(t, confirmationRequests(tr), 1)
+	assert.Empty(t, deleted)
+
+	tr, err := h.RunMessage(context.Background(), model.Message{}, agent.WithToolConfirmationDecisions(
+		agent.ApproveToolCallWithArguments("call_1", []byte(`{"path":"/home/tmp"}`))))
+	require.NoError(t, err)
+	agenttest.AssertNoErrors(t, tr)
+	assert.Equal(t, []string{"/home/tmp"}, deleted)
+	agenttest.AssertFinalResponse(t, tr, "Done.")
+}
+
+func TestLLMAgent_ToolConfirmationPartialBatch(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		var deleted []string
+		m := agenttest.NewModel(
+			agenttest.CallTools(
+				agenttest.Call{Name: "lookup", Args: lookupArgs{Query: "a"}},
+				agenttest.Call{Name: "delete_file", Args: deleteArgs{Path: "/tmp/a"}},
+			),
+			agenttest.Reply("Looked up and deleted."),
+		)
+		ag := New("janitor",
+			WithModel(m),
+			WithTools([]tool.Tool{lookupTool(0), deleteTool(&deleted, function.WithRequireConfirmation(true))}),
+			WithEnableParallelTools(parallel),
+		)
+		h := agenttest.NewHarness(ag)
+
+		tr := h.MustRun(t, "look up a and delete /tmp/a")
+		assert.Empty(t, tr.ToolResults(), "results are returned with the paused call")
+		reqs := confirmationRequests(tr)
+		require.Len(t, reqs, 1)
+		require.Len(t, reqs[0].ToolConfirmations, 1)
+		assert.Equal(t, "call_2", reqs[0].ToolConfirmations[0].ToolCallID)
+
+		tr, err := h.RunMessage(context.Background(), model.Message{}, agent.WithToolConfirmationDecisions(agent.ApproveToolCall("call_2")))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/tmp/a"}, deleted)
+		assert.Equal(t, map[string]string{
+			"call_1": `"no result for a"`,
+			"call_2": `"deleted /tmp/a"`,
+		}, tr.ToolResults())
+		agenttest.AssertFinalResponse(t, tr, "Looked up and deleted.")
+
+		msgs := m.Requests()[1].Messages
+		require.GreaterOrEqual(t, len(msgs), 3)
+		assert.Len(t, msgs[len(msgs)-3].ToolCalls, 2)
+		assert.Equal(t, "call_1", msgs[len(msgs)-2].ToolID)
+		assert.Equal(t, "call_2", msgs[len(msgs)-1].ToolID)
+	}
+}
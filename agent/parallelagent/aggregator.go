@@ -0,0 +1,284 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package parallelagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// BranchResult is the outcome of the run of a sub-agent.
+type BranchResult struct {
+	// Agent is the name of the sub-agent.
+	Agent string
+	// Content is the content of the last complete answer of the sub-agent.
+	Content string
+	// StructuredOutput is the structured output of that answer, if any.
+	StructuredOutput any
+	// Err is set when the branch failed, timed out or gave no answer.
+	Err error
+}
+
+// Aggregator combines the results of the branches of a ParallelAgent into a
+// single answer.
+type Aggregator interface {
+	// Done is called each time a branch finishes, with the results so far in
+	// completion order and the number of branches. Returning true decides
+	// the answer early: the branches still running are cancelled.
+	Done(results []BranchResult, total int) bool
+	// Aggregate returns the answer from the results of the finished
+	// branches. The Agent of the answer names the chosen branch, if any.
+	Aggregate(ctx context.Context, results []BranchResult) (BranchResult, error)
+}
+
+// errNoSuccess is returned when no branch succeeded.
+var errNoSuccess = errors.New("no branch succeeded")
+
+// succeeded returns the results without error.
+func succeeded(results []BranchResult) []BranchResult {
+	var ok []BranchResult
+	for _, r := range results {
+		if r.Err == nil {
+			ok = append(ok, r)
+		}
+	}
+	return ok
+}
+
+// firstSuccess is the Aggregator returned by FirstSuccess.
+type firstSuccess struct{}
+
+// FirstSuccess returns an Aggregator answering with the first branch that
+// succeeds, cancelling the others.
+func FirstSuccess() Aggregator {
+	return firstSuccess{}
+}
+
+func (firstSuccess) Done(results []BranchResult, _ int) bool {
+	return len(succeeded(results)) > 0
+}
+
+func (firstSuccess) Aggregate(_ context.Context, results []BranchResult) (BranchResult, error) {
+	ok := succeeded(results)
+	if len(ok) == 0 {
+		return BranchResult{}, errNoSuccess
+	}
+	return ok[0], nil
+}
+
+// majorityVote is the Aggregator returned by MajorityVote.
+type majorityVote struct{}
+
+// MajorityVote returns an Aggregator answering with the answer given by the
+// most branches. Structured outputs and JSON answers are compared by value,
+// other answers by their text with spaces normalized. Ties go to the answer
+// reached first. The remaining branches are cancelled once an answer has a
+// strict majority.
+func MajorityVote() Aggregator {
+	return majorityVote{}
+}
+
+func (majorityVote) Done(results []BranchResult, total int) bool {
+	votes, _ := countVotes(results)
+	for _, n := range votes {
+		if 2*n > total {
+			return true
+		}
+	}
+	return false
+}
+
+func (majorityVote) Aggregate(_ context.Context, results []BranchResult) (BranchResult, error) {
+	votes, order := countVotes(results)
+	if len(order) == 0 {
+		return BranchResult{}, errNoSuccess
+	}
+	best := order[0]
+	for _, candidate := range order[1:] {
+		if votes[candidate.key] > votes[best.key] {
+			best = candidate
+		}
+	}
+	return best.result, nil
+}
+
+// ballot is an answer and the first result giving it.
+type ballot struct {
+	key    string
+	result BranchResult
+}
+
+// countVotes counts the successful results by answer. The answers are
+// returned in the order they were first given.
+func countVotes(results []BranchResult) (map[string]int, []ballot) {
+	votes := make(map[string]int)
+	var order []ballot
+	for _, r := range succeeded(results) {
+		key := voteKey(r)
+		if votes[key] == 0 {
+			order = append(order, ballot{key: key, result: r})
+		}
+		votes[key]++
+	}
+	return votes, order
+}
+
+// voteKey returns the value results are compared by.
+func voteKey(r BranchResult) string {
+	if r.StructuredOutput != nil {
+		if b, err := json.Marshal(r.StructuredOutput); err == nil {
+			return canonicalJSON(b)
+		}
+	}
+	content := strings.TrimSpace(r.Content)
+	if json.Valid([]byte(content)) {
+		return canonicalJSON([]byte(content))
+	}
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// canonicalJSON re-encodes b with sorted object keys and no spaces.
+func canonicalJSON(b []byte) string {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return string(b)
+	}
+	return string(out)
+}
+
+// defaultJudgeInstruction is the instruction of Judge when none is given.
+const defaultJudgeInstruction = "Combine the answers below into the single best answer to the request. " +
+	"Keep what they agree on, resolve their contradictions and drop what is wrong."
+
+// judge is the Aggregator returned by Judge.
+type judge struct {
+	model          model.Model
+	instruction    string
+	modelCallbacks *model.Callbacks
+}
+
+// JudgeOption configures the Aggregator returned by Judge.
+type JudgeOption func(*judge)
+
+// WithJudgeModelCallbacks sets the callbacks run around the model call of
+// the judge.
+func WithJudgeModelCallbacks(cb *model.Callbacks) JudgeOption {
+	return func(j *judge) {
+		j.modelCallbacks = cb
+	}
+}
+
+// Judge returns an Aggregator waiting for every branch and asking m to
+// write the answer from the successful ones. instruction tells the model
+// how to combine them; a default one is used when it is empty. The model
+// call counts against the budget of the run.
+func Judge(m model.Model, instruction string, opts ...JudgeOption) Aggregator {
+	if instruction == "" {
+		instruction = defaultJudgeInstruction
+	}
+	j := &judge{model: m, instruction: instruction}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+func (j *judge) Done([]BranchResult, int) bool {
+	return false
+}
+
+func (j *judge) Aggregate(ctx context.Context, results []BranchResult) (BranchResult, error) {
+	ok := succeeded(results)
+	if len(ok) == 0 {
+		return BranchResult{}, errNoSuccess
+	}
+	var b strings.Builder
+	if inv, found := agent.InvocationFromContext(ctx); found && inv.Message.Content != "" {
+		fmt.Fprintf(&b, "Request:\n%s\n\n", inv.Message.Content)
+	}
+	for _, r := range ok {
+		fmt.Fprintf(&b, "Answer from %s:\n%s\n\n", r.Agent, answerText(r))
+	}
+	responses, err := budget.Generate(ctx, j.model, j.modelCallbacks, &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage(j.instruction),
+			model.NewUserMessage(strings.TrimSpace(b.String())),
+		},
+		GenerationConfig: model.GenerationConfig{Stream: false},
+	})
+	if err != nil {
+		return BranchResult{}, fmt.Errorf("judge model: %w", err)
+	}
+	var content string
+	for _, rsp := range responses {
+		if rsp.Error != nil {
+			return BranchResult{}, fmt.Errorf("judge model: %s", rsp.Error.Message)
+		}
+		if len(rsp.Choices) > 0 {
+			content += rsp.Choices[0].Message.Content
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		return BranchResult{}, errors.New("judge model gave no answer")
+	}
+	return BranchResult{Content: content}, nil
+}
+
+// answerText returns the text of the answer of a branch: its content, or its
+// structured output as JSON when it has no content.
+func answerText(r BranchResult) string {
+	if content := strings.TrimSpace(r.Content); content != "" || r.StructuredOutput == nil {
+		return content
+	}
+	b, err := json.Marshal(r.StructuredOutput)
+	if err != nil {
+		return fmt.Sprint(r.StructuredOutput)
+	}
+	return string(b)
+}
+
+// quorum is the Aggregator returned by Quorum.
+type quorum struct {
+	n     int
+	inner Aggregator
+}
+
+// Quorum returns an Aggregator requiring n successful branches. Once n
+// branches succeeded, the remaining branches are cancelled and inner
+// aggregates the results. Combined with WithBranchTimeout, it answers
+// without waiting for slow branches.
+func Quorum(n int, inner Aggregator) Aggregator {
+	return &quorum{n: n, inner: inner}
+}
+
+func (q *quorum) Done(results []BranchResult, total int) bool {
+	ok := len(succeeded(results))
+	failed := len(results) - ok
+	// Stop when the quorum is reached or can no longer be.
+	return ok >= q.n || total-failed < q.n
+}
+
+func (q *quorum) Aggregate(ctx context.Context, results []BranchResult) (BranchResult, error) {
+	if ok := len(succeeded(results)); ok < q.n {
+		return BranchResult{}, fmt.Errorf("quorum not reached: %d of %d needed branches succeeded", ok, q.n)
+	}
+	return q.inner.Aggregate(ctx, results)
+}
@@ -0,0 +1,220 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package parallelagent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+func replyAgent(name, reply string) agent.Agent {
+	return llmagent.New(name, llmagent.WithModel(agenttest.NewModel(agenttest.Reply(reply))))
+}
+
+// hangingAgent answers nothing until its context is done.
+type hangingAgent struct {
+	name string
+
+	mu  sync.Mutex
+	err error
+}
+
+func (h *hangingAgent) Info() agent.Info                { return agent.Info{Name: h.name} }
+func (h *hangingAgent) SubAgents() []agent.Agent        { return nil }
+func (h *hangingAgent) FindSubAgent(string) agent.Agent { return nil }
+func (h *hangingAgent) Tools() []tool.Tool              { return nil }
+func (h *hangingAgent) Run(ctx context.Context, inv *agent.Invocation) (<-chan *event.Event, error) {
+	ch := make(chan *event.Event)
+	go func() {
+		defer close(ch)
+		<-ctx.Done()
+		h.mu.Lock()
+		h.err = ctx.Err()
+		h.mu.Unlock()
+	}()
+	return ch, nil
+}
+
+func (h *hangingAgent) stoppedBy() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func aggregated(t *testing.T, tr *agenttest.Trajectory) *event.Event {
+	t.Helper()
+	events := tr.Filter(func(e *event.Event) bool { return e.Author == "parallel" && e.Response != nil })
+	require.Len(t, events, 1)
+	return events[0]
+}
+
+func TestAggregator_FirstSuccessCancelsOthers(t *testing.T) {
+	slow := &hangingAgent{name: "slow"}
+	parallel := New("parallel",
+		WithSubAgents([]agent.Agent{slow, &failAgent{name: "broken"}, replyAgent("fast", "fast answer")}),
+		WithAggregator(FirstSuccess()),
+	)
+
+	tr := agenttest.NewHarness(parallel).MustRun(t, "question")
+
+	agenttest.AssertFinalResponse(t, tr, "fast answer")
+	errs := tr.Filter(func(e *event.Event) bool { return e.Error != nil })
+	require.Len(t, errs, 1, "only the failed start is reported")
+	assert.Equal(t, "boom", errs[0].Error.Message)
+	assert.ErrorIs(t, slow.stoppedBy(), context.Canceled)
+}
+
+func TestAggregator_MajorityVote(t *testing.T) {
+	parallel := New("parallel",
+		WithSubAgents([]agent.Agent{
+			replyAgent("a", `{"city": "Paris", "country": "FR"}`),
+			replyAgent("b", "Lyon"),
+			replyAgent("c", `{"country":"FR","city":"Paris"}`),
+		}),
+		WithAggregator(MajorityVote()),
+	)
+
+	tr := agenttest.NewHarness(parallel).MustRun(t, "question")
+
+	final := aggregated(t, tr)
+	assert.JSONEq(t, `{"city": "Paris", "country": "FR"}`, final.Choices[0].Message.Content)
+	assert.True(t, final.Done)
+}
+
+func TestAggregator_MajorityVoteStopsAtMajority(t *testing.T) {
+	slow := &hangingAgent{name: "slow"}
+	parallel := New("parallel",
+		WithSubAgents([]agent.Agent{slow, replyAgent("a", "42"), replyAgent("b", " 42 ")}),
+		WithAggregator(MajorityVote()),
+	)
+
+	tr := agenttest.NewHarness(parallel).MustRun(t, "question")
+
+	assert.Equal(t, "42", strings.TrimSpace(tr.FinalResponse()))
+	assert.ErrorIs(t, slow.stoppedBy(), context.Canceled)
+}
+
+func TestAggregator_JudgeWithOutputKey(t *testing.T) {
+	judgeModel := agenttest.NewModel(agenttest.Reply("combined answer"))
+	slow := &hangingAgent{name: "slow"}
+	parallel := New("parallel",
+		WithSubAgents([]agent.Agent{replyAgent("a", "answer a"), replyAgent("b", "answer b"), slow}),
+		WithAggregator(Judge(judgeModel, "")),
+		WithBranchTimeout(50*time.Millisecond),
+		WithOutputKey("summary"),
+	)
+	h := agenttest.NewHarness(parallel)
+
+	tr := h.MustRun(t, "what happened?")
+
+	agenttest.AssertFinalResponse(t, tr, "combined answer")
+	assert.ErrorIs(t, slow.stoppedBy(), context.DeadlineExceeded)
+	reqs := judgeModel.Requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, defaultJudgeInstruction, reqs[0].Messages[0].Content)
+	prompt := reqs[0].Messages[1].Content
+	assert.Contains(t, prompt, "Request:\nwhat happened?")
+	assert.Contains(t, prompt, "Answer from a:\nanswer a")
+	assert.Contains(t, prompt, "Answer from b:\nanswer b")
+	assert.NotContains(t, prompt, "slow")
+
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "combined answer", string(sess.State["summary"]))
+}
+
+func TestAggregator_JudgeStructuredOutputAndBudget(t *testing.T) {
+	reply := agenttest.Reply("Paris")
+	reply.Usage = &model.Usage{PromptTokens: 50, CompletionTokens: 10, TotalTokens: 60}
+	judgeModel := agenttest.NewModel(reply, agenttest.Reply("unreachable"))
+	var calls int
+	j := Judge(judgeModel, "", WithJudgeModelCallbacks(model.NewCallbacks().RegisterBeforeModel(
+		func(context.Context, *model.Request) (*model.Response, error) {
+			calls++
+			return nil, nil
+		})))
+	tracker := budget.New(budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 50})).Start(nil)
+	ctx := budget.NewContext(context.Background(), tracker)
+	results := []BranchResult{
+		{Agent: "a", StructuredOutput: map[string]any{"city": "Paris"}},
+		{Agent: "b", Content: "Paris"},
+	}
+
+	_, err := j.Aggregate(ctx, results)
+	_, stopped := agent.AsStopError(err)
+	assert.True(t, stopped, "the call exceeding the budget fails: %v", err)
+	assert.Equal(t, 1, calls)
+	reqs := judgeModel.Requests()
+	require.Len(t, reqs, 1)
+	assert.Contains(t, reqs[0].Messages[1].Content, "Answer from a:\n{\"city\":\"Paris\"}")
+
+	_, err = j.Aggregate(ctx, results)
+	require.Error(t, err)
+	assert.Equal(t, 1, judgeModel.Remaining(), "no call is made once the budget is exceeded")
+}
+
+func TestAggregator_QuorumNotReached(t *testing.T) {
+	parallel := New("parallel",
+		WithSubAgents([]agent.Agent{
+			replyAgent("a", "yes"),
+			&hangingAgent{name: "slow"},
+			llmagent.New("failing", llmagent.WithModel(agenttest.NewModel(agenttest.Fail("overloaded")))),
+		}),
+		WithAggregator(Quorum(2, MajorityVote())),
+		WithBranchTimeout(50*time.Millisecond),
+	)
+
+	tr := agenttest.NewHarness(parallel).MustRun(t, "question")
+
+	final := aggregated(t, tr)
+	require.NotNil(t, final.Error)
+	assert.Equal(t, model.ErrorTypeAggregationFailed, final.Error.Type)
+	assert.Contains(t, final.Error.Message, "quorum not reached: 1 of 2")
+}
+
+func TestQuorum_Done(t *testing.T) {
+	q := Quorum(2, FirstSuccess())
+	ok := BranchResult{Agent: "a", Content: "x"}
+	failed := BranchResult{Agent: "b", Err: errors.New("failed")}
+
+	assert.False(t, q.Done([]BranchResult{ok}, 3))
+	assert.True(t, q.Done([]BranchResult{ok, ok}, 3))
+	assert.False(t, q.Done([]BranchResult{failed}, 3))
+	assert.True(t, q.Done([]BranchResult{failed, failed}, 3), "the quorum can no longer be reached")
+
+	answer, err := q.Aggregate(context.Background(), []BranchResult{failed, ok, ok})
+	require.NoError(t, err)
+	assert.Equal(t, "a", answer.Agent)
+}
+
+func TestVoteKey(t *testing.T) {
+	assert.Equal(t, voteKey(BranchResult{Content: "the  answer\n"}), voteKey(BranchResult{Content: "the answer"}))
+	assert.Equal(t, voteKey(BranchResult{Content: `[1, 2]`}), voteKey(BranchResult{Content: `[1,2]`}))
+	assert.Equal(t,
+		voteKey(BranchResult{StructuredOutput: map[string]int{"b": 2, "a": 1}}),
+		voteKey(BranchResult{Content: `{"a": 1, "b": 2}`}),
+	)
+	assert.NotEqual(t, voteKey(BranchResult{Content: "Paris"}), voteKey(BranchResult{Content: "paris"}))
+}
@@ -12,9 +12,12 @@ package parallelagent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"sync"
+	"time"
 
 	"trpc.group/trpc-go/trpc-agent-go/agent"
 	"trpc.group/trpc-go/trpc-agent-go/event"
@@ -30,11 +33,17 @@ const defaultChannelBufferSize = 256
 // attempts on a single task, such as:
 // - Running different algorithms simultaneously.
 // - Generating multiple responses for review by a subsequent evaluation agent.
+//
+// With an Aggregator, the results of the sub-agents are also combined into a
+// single final event, such as the first successful answer or a majority vote.
 type ParallelAgent struct {
 	name              string
 	subAgents         []agent.Agent
 	channelBufferSize int
 	agentCallbacks    *agent.Callbacks
+	aggregator        Aggregator
+	branchTimeout     time.Duration
+	outputKey         string
 }
 
 // Option configures ParallelAgent settings using the functional options pattern.
@@ -47,6 +56,9 @@ type Options struct {
 	subAgents         []agent.Agent
 	channelBufferSize int
 	agentCallbacks    *agent.Callbacks
+	aggregator        Aggregator
+	branchTimeout     time.Duration
+	outputKey         string
 }
 
 // WithSubAgents sets the sub-agents that will be executed in parallel.
@@ -70,6 +82,26 @@ func WithAgentCallbacks(cb *agent.Callbacks) Option {
 	return func(o *Options) { o.agentCallbacks = cb }
 }
 
+// WithAggregator sets how the results of the sub-agents are combined. The
+// events of the sub-agents are still forwarded, followed by a final event
+// authored by the parallel agent holding the aggregated answer, or an error
+// event when the results cannot be aggregated.
+func WithAggregator(aggregator Aggregator) Option {
+	return func(o *Options) { o.aggregator = aggregator }
+}
+
+// WithBranchTimeout bounds the run of each sub-agent. A sub-agent still
+// running after the timeout is cancelled and its branch counts as failed.
+func WithBranchTimeout(timeout time.Duration) Option {
+	return func(o *Options) { o.branchTimeout = timeout }
+}
+
+// WithOutputKey sets the key in session state to store the aggregated
+// answer. It requires WithAggregator.
+func WithOutputKey(outputKey string) Option {
+	return func(o *Options) { o.outputKey = outputKey }
+}
+
 // New creates a new ParallelAgent with the given name and options.
 // ParallelAgent executes all its sub-agents simultaneously and merges
 // their event streams into a single output channel.
@@ -88,6 +120,9 @@ func New(name string, opts ...Option) *ParallelAgent {
 		subAgents:         cfg.subAgents,
 		channelBufferSize: cfg.channelBufferSize,
 		agentCallbacks:    cfg.agentCallbacks,
+		aggregator:        cfg.aggregator,
+		branchTimeout:     cfg.branchTimeout,
+		outputKey:         cfg.outputKey,
 	}
 }
 
@@ -154,20 +189,39 @@ func (a *ParallelAgent) handleBeforeAgentCallbacks(
 	return true
 }
 
-// startSubAgents starts all sub-agents in parallel and returns their event channels.
+// branch is the run of a sub-agent.
+type branch struct {
+	agent  agent.Agent
+	ctx    context.Context
+	cancel context.CancelFunc
+	events <-chan *event.Event
+	// err is set when the sub-agent failed to start.
+	err error
+}
+
+// startSubAgents starts all sub-agents in parallel and returns their branches.
 func (a *ParallelAgent) startSubAgents(
 	ctx context.Context,
 	invocation *agent.Invocation,
 	eventChan chan<- *event.Event,
-) []<-chan *event.Event {
+) []*branch {
 	// Start all sub-agents in parallel.
 	var wg sync.WaitGroup
-	eventChans := make([]<-chan *event.Event, len(a.subAgents))
+	branches := make([]*branch, len(a.subAgents))
 
 	for i, subAgent := range a.subAgents {
+		b := &branch{agent: subAgent}
+		if a.branchTimeout > 0 {
+			b.ctx, b.cancel = context.WithTimeout(ctx, a.branchTimeout)
+		} else {
+			b.ctx, b.cancel = context.WithCancel(ctx)
+		}
+		branches[i] = b
+
 		wg.Add(1)
-		go func(idx int, sa agent.Agent) {
+		go func(idx int, b *branch) {
 			defer wg.Done()
+			sa := b.agent
 			// Recover from panics in sub-agent execution to prevent
 			// the whole service from crashing.
 			defer func() {
@@ -175,12 +229,13 @@ func (a *ParallelAgent) startSubAgents(
 					stack := debug.Stack()
 					log.Errorf("Sub-agent execution panic for %s (index: %d, parent: %s): %v\n%s",
 						sa.Info().Name, idx, invocation.AgentName, r, string(stack))
+					b.err = fmt.Errorf("sub-agent %s panic: %v", sa.Info().Name, r)
 					// Send error event for the panic.
 					errorEvent := event.NewErrorEvent(
 						invocation.InvocationID,
 						invocation.AgentName,
 						model.ErrorTypeFlowError,
-						fmt.Sprintf("sub-agent %s panic: %v", sa.Info().Name, r),
+						b.err.Error(),
 					)
 					agent.EmitEvent(ctx, invocation, eventChan, errorEvent)
 				}
@@ -190,11 +245,12 @@ func (a *ParallelAgent) startSubAgents(
 			branchInvocation := a.createBranchInvocation(sa, invocation)
 
 			// Reset invocation information in context
-			branchAgentCtx := agent.NewInvocationContext(ctx, branchInvocation)
+			branchAgentCtx := agent.NewInvocationContext(b.ctx, branchInvocation)
 
 			// Run the sub-agent.
 			subEventChan, err := sa.Run(branchAgentCtx, branchInvocation)
 			if err != nil {
+				b.err = err
 				// Send error event.
 				agent.EmitEvent(ctx, invocation, eventChan, event.NewErrorEvent(
 					invocation.InvocationID,
@@ -205,13 +261,13 @@ func (a *ParallelAgent) startSubAgents(
 				return
 			}
 
-			eventChans[idx] = subEventChan
-		}(i, subAgent)
+			b.events = subEventChan
+		}(i, b)
 	}
 
 	// Wait for all sub-agents to start.
 	wg.Wait()
-	return eventChans
+	return branches
 }
 
 // handleAfterAgentCallbacks handles post-execution callbacks.
@@ -273,10 +329,15 @@ func (a *ParallelAgent) executeParallelRun(
 	}
 
 	// Start sub-agents.
-	eventChans := a.startSubAgents(ctx, invocation, eventChan)
+	branches := a.startSubAgents(ctx, invocation, eventChan)
 
-	// Merge events from all sub-agents.
-	a.mergeEventStreams(ctx, eventChans, eventChan)
+	if a.aggregator != nil {
+		// Merge events from the sub-agents and aggregate their results.
+		a.aggregate(ctx, invocation, branches, eventChan)
+	} else {
+		// Merge events from all sub-agents.
+		a.mergeEventStreams(ctx, branches, eventChan)
+	}
 
 	// Handle after agent callbacks.
 	a.handleAfterAgentCallbacks(ctx, invocation, eventChan)
@@ -286,40 +347,196 @@ func (a *ParallelAgent) executeParallelRun(
 // This implementation processes events as they arrive from different sub-agents.
 func (a *ParallelAgent) mergeEventStreams(
 	ctx context.Context,
-	eventChans []<-chan *event.Event,
+	branches []*branch,
 	outputChan chan<- *event.Event,
 ) {
 	var wg sync.WaitGroup
 
-	// Start a goroutine for each input channel.
-	for _, ch := range eventChans {
-		if ch == nil {
+	// Start a goroutine for each branch.
+	for _, b := range branches {
+		if b.events == nil {
+			b.cancel()
 			continue
 		}
 
 		wg.Add(1)
-		go func(inputChan <-chan *event.Event) {
+		go func(b *branch) {
 			defer wg.Done()
-			// Recover from potential panics during event merging.
-			defer func() {
-				if r := recover(); r != nil {
-					// Log the panic but don't propagate error events here since
-					// we're already in the event merging phase.
-					log.Errorf("Event merging panic in parallel agent %s: %v", a.name, r)
-				}
-			}()
-			for evt := range inputChan {
-				if err := event.EmitEvent(ctx, outputChan, evt); err != nil {
-					return
-				}
-			}
-		}(ch)
+			a.forward(ctx, b, outputChan)
+		}(b)
 	}
 
 	// Wait for all goroutines to finish.
 	wg.Wait()
 }
 
+// aggregate merges the events of the branches like mergeEventStreams, and
+// then emits the answer aggregated from their results.
+func (a *ParallelAgent) aggregate(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	branches []*branch,
+	outputChan chan<- *event.Event,
+) {
+	var wg sync.WaitGroup
+	resultChan := make(chan BranchResult, len(branches))
+	for _, b := range branches {
+		if b.events == nil {
+			b.cancel()
+			resultChan <- BranchResult{Agent: b.agent.Info().Name, Err: b.err}
+			continue
+		}
+		wg.Add(1)
+		go func(b *branch) {
+			defer wg.Done()
+			resultChan <- a.forward(ctx, b, outputChan)
+		}(b)
+	}
+
+	results := make([]BranchResult, 0, len(branches))
+	for range branches {
+		results = append(results, <-resultChan)
+		if a.aggregator.Done(results, len(branches)) {
+			break
+		}
+	}
+	// Cancel the branches still running and wait until they stop
+	// forwarding events.
+	for _, b := range branches {
+		b.cancel()
+	}
+	wg.Wait()
+	if err := agent.CheckContextCancelled(ctx); err != nil {
+		return
+	}
+
+	answer, err := a.aggregator.Aggregate(agent.NewInvocationContext(ctx, invocation), results)
+	if err != nil {
+		log.Warnf("Parallel agent %s failed to aggregate %d results: %v", a.name, len(results), err)
+		agent.EmitEvent(ctx, invocation, outputChan, event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			model.ErrorTypeAggregationFailed,
+			err.Error(),
+		))
+		return
+	}
+	a.emitAnswer(ctx, invocation, answer, outputChan)
+}
+
+// emitAnswer emits the aggregated answer as the final event, storing it
+// under the output key if any.
+func (a *ParallelAgent) emitAnswer(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	answer BranchResult,
+	outputChan chan<- *event.Event,
+) {
+	content := answer.Content
+	if content == "" && answer.StructuredOutput != nil {
+		if b, err := json.Marshal(answer.StructuredOutput); err == nil {
+			content = string(b)
+		}
+	}
+	rsp := &model.Response{
+		Object: model.ObjectTypeChatCompletion,
+		Done:   true,
+		Choices: []model.Choice{{
+			Index:   0,
+			Message: model.NewAssistantMessage(content),
+		}},
+	}
+	var opts []event.Option
+	if answer.StructuredOutput != nil {
+		opts = append(opts, event.WithStructuredOutputPayload(answer.StructuredOutput))
+	}
+	if a.outputKey != "" {
+		opts = append(opts, event.WithStateDelta(map[string][]byte{a.outputKey: []byte(content)}))
+	}
+	evt := event.NewResponseEvent(invocation.InvocationID, invocation.AgentName, rsp, opts...)
+	evt.RequiresCompletion = a.outputKey != ""
+	if err := agent.EmitEvent(ctx, invocation, outputChan, evt); err != nil || a.outputKey == "" {
+		return
+	}
+	// Let the next agent read the output key from the session.
+	completionID := agent.GetAppendEventNoticeKey(evt.ID)
+	if err := invocation.AddNoticeChannelAndWait(ctx, completionID,
+		agent.WaitNoticeWithoutTimeout); err != nil {
+		log.Warnf("Failed to add notice channel for completion ID %s: %v", completionID, err)
+	}
+}
+
+// forward forwards the events of branch b until it ends or is cancelled,
+// and returns its result.
+func (a *ParallelAgent) forward(
+	ctx context.Context,
+	b *branch,
+	outputChan chan<- *event.Event,
+) (result BranchResult) {
+	defer b.cancel()
+	result.Agent = b.agent.Info().Name
+	// Recover from potential panics during event merging.
+	defer func() {
+		if r := recover(); r != nil {
+			// Log the panic but don't propagate error events here since
+			// we're already in the event merging phase.
+			log.Errorf("Event merging panic in parallel agent %s: %v", a.name, r)
+			result.Err = fmt.Errorf("sub-agent %s panic: %v", result.Agent, r)
+		}
+	}()
+	for {
+		select {
+		case evt, ok := <-b.events:
+			if !ok {
+				if result.Err == nil && result.Content == "" && result.StructuredOutput == nil {
+					result.Err = fmt.Errorf("sub-agent %s gave no answer", result.Agent)
+				}
+				return result
+			}
+			recordResult(&result, evt)
+			if err := event.EmitEvent(ctx, outputChan, evt); err != nil {
+				go drain(b.events)
+				result.Err = err
+				return result
+			}
+		case <-b.ctx.Done():
+			// Let the sub-agent stop without blocking on its channel.
+			go drain(b.events)
+			if errors.Is(b.ctx.Err(), context.DeadlineExceeded) {
+				result.Err = fmt.Errorf("sub-agent %s timed out after %s", result.Agent, a.branchTimeout)
+			} else {
+				result.Err = fmt.Errorf("sub-agent %s cancelled: %w", result.Agent, b.ctx.Err())
+			}
+			return result
+		}
+	}
+}
+
+// recordResult updates the result of a branch with one of its events.
+func recordResult(result *BranchResult, evt *event.Event) {
+	if evt == nil || evt.Response == nil {
+		return
+	}
+	if evt.Error != nil {
+		result.Err = errors.New(evt.Error.Message)
+		return
+	}
+	if evt.IsPartial || len(evt.Choices) == 0 || evt.IsToolCallResponse() {
+		return
+	}
+	msg := evt.Choices[0].Message
+	if msg.Role != model.RoleAssistant || (msg.Content == "" && evt.StructuredOutput == nil) {
+		return
+	}
+	result.Content, result.StructuredOutput, result.Err = msg.Content, evt.StructuredOutput, nil
+}
+
+// drain consumes the remaining events of ch.
+func drain(ch <-chan *event.Event) {
+	for range ch {
+	}
+}
+
 // Tools implements the agent.Agent interface.
 // It returns the tools available to this agent.
 func (a *ParallelAgent) Tools() []tool.Tool {
@@ -0,0 +1,71 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package reflectionagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+)
+
+// Review is the score of a draft against the rubric and the feedback to
+// improve it. A critic agent answers with a Review as JSON, for instance by
+// using llmagent.WithStructuredOutputJSON(new(Review), true, "").
+type Review struct {
+	// Score is the score of the draft, from 0 to 1.
+	Score float64 `json:"score" jsonschema:"description=Score of the draft against the rubric from 0 to 1"`
+	// Feedback explains the score and how to improve the draft.
+	Feedback string `json:"feedback" jsonschema:"description=What to change to improve the draft"`
+}
+
+// CriticFunc reviews draft, the answer to request, against rubric.
+type CriticFunc func(ctx context.Context, rubric, request, draft string) (Review, error)
+
+// reviewFromEvent returns the review given by a final event of a critic
+// agent.
+func reviewFromEvent(e *event.Event) (Review, bool, error) {
+	switch out := e.StructuredOutput.(type) {
+	case *Review:
+		if out != nil {
+			return *out, true, nil
+		}
+	case Review:
+		return out, true, nil
+	}
+	content, ok := finalMessage(e)
+	if !ok {
+		return Review{}, false, nil
+	}
+	review, err := parseReview(content)
+	return review, true, err
+}
+
+// parseReview parses the JSON review in the answer of a critic agent.
+func parseReview(answer string) (Review, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end <= start {
+		return Review{}, errors.New("the critic answer holds no JSON object")
+	}
+	var review struct {
+		Score    *float64 `json:"score"`
+		Feedback string   `json:"feedback"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &review); err != nil {
+		return Review{}, fmt.Errorf("invalid critic JSON: %w", err)
+	}
+	if review.Score == nil {
+		return Review{}, errors.New("the critic answer has no score")
+	}
+	return Review{Score: *review.Score, Feedback: review.Feedback}, nil
+}
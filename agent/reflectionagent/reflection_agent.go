@@ -0,0 +1,537 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package reflectionagent provides a generator-critic agent.
+//
+// A ReflectionAgent runs rounds: a producer agent drafts an answer, then a
+// critic agent or function scores the draft against a rubric. The critique
+// is fed back to the producer for the next round, until a draft reaches the
+// score threshold, the maximum number of rounds is reached, or a round does
+// not improve on the best draft. The best draft, not the last one, is the
+// answer.
+package reflectionagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	itelemetry "trpc.group/trpc-go/trpc-agent-go/internal/telemetry"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/trace"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+const (
+	defaultChannelBufferSize = 256
+	defaultMaxRounds         = 3
+	defaultScoreThreshold    = 0.8
+)
+
+// Reasons for stopping the rounds, reported in event.Critique.Stop.
+const (
+	// StopThreshold reports a draft reaching the score threshold.
+	StopThreshold = "threshold"
+	// StopMaxRounds reports the maximum number of rounds being reached.
+	StopMaxRounds = "max_rounds"
+	// StopNoImprovement reports a draft not improving on the best one.
+	StopNoImprovement = "no_improvement"
+)
+
+// ReflectionAgent is an agent improving the drafts of a producer agent with
+// the reviews of a critic until they are good enough.
+type ReflectionAgent struct {
+	name              string
+	description       string
+	producer          agent.Agent
+	critic            agent.Agent
+	criticFunc        CriticFunc
+	rubric            string
+	scoreThreshold    float64
+	maxRounds         int
+	minImprovement    float64
+	channelBufferSize int
+	agentCallbacks    *agent.Callbacks
+}
+
+// Option configures ReflectionAgent settings using the functional options
+// pattern.
+type Option func(*Options)
+
+// Options contains all configuration options for ReflectionAgent.
+type Options struct {
+	description       string
+	producer          agent.Agent
+	critic            agent.Agent
+	criticFunc        CriticFunc
+	rubric            string
+	scoreThreshold    float64
+	maxRounds         int
+	minImprovement    float64
+	channelBufferSize int
+	agentCallbacks    *agent.Callbacks
+}
+
+// WithDescription sets the description of the agent.
+func WithDescription(description string) Option {
+	return func(o *Options) { o.description = description }
+}
+
+// WithProducer sets the agent writing the drafts. The first round sends it
+// the user message; the next ones the request, the draft to revise and its
+// critique.
+func WithProducer(producer agent.Agent) Option {
+	return func(o *Options) { o.producer = producer }
+}
+
+// WithCritic sets the agent reviewing the drafts. It receives the rubric,
+// the request and the draft, and answers with a Review as JSON or as the
+// structured output of its final event.
+func WithCritic(critic agent.Agent) Option {
+	return func(o *Options) { o.critic = critic }
+}
+
+// WithCriticFunc sets a function reviewing the drafts instead of a critic
+// agent.
+func WithCriticFunc(f CriticFunc) Option {
+	return func(o *Options) { o.criticFunc = f }
+}
+
+// WithRubric sets the criteria the drafts are scored against.
+func WithRubric(rubric string) Option {
+	return func(o *Options) { o.rubric = rubric }
+}
+
+// WithScoreThreshold sets the score a draft needs to stop the rounds.
+// Default is 0.8.
+func WithScoreThreshold(threshold float64) Option {
+	return func(o *Options) { o.scoreThreshold = threshold }
+}
+
+// WithMaxRounds sets the maximum number of drafts. Default is 3.
+func WithMaxRounds(n int) Option {
+	return func(o *Options) { o.maxRounds = n }
+}
+
+// WithMinImprovement sets how much a draft must improve on the best score
+// so far for the rounds to go on. Default is 0: any improvement will do.
+func WithMinImprovement(delta float64) Option {
+	return func(o *Options) { o.minImprovement = delta }
+}
+
+// WithChannelBufferSize sets the buffer size for the event channel.
+// Default is 256 if not specified.
+func WithChannelBufferSize(size int) Option {
+	return func(o *Options) { o.channelBufferSize = size }
+}
+
+// WithAgentCallbacks attaches lifecycle callbacks to the reflection agent.
+func WithAgentCallbacks(cb *agent.Callbacks) Option {
+	return func(o *Options) { o.agentCallbacks = cb }
+}
+
+// New creates a new ReflectionAgent with the given name and options.
+func New(name string, opts ...Option) *ReflectionAgent {
+	cfg := Options{
+		scoreThreshold:    defaultScoreThreshold,
+		maxRounds:         defaultMaxRounds,
+		channelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.channelBufferSize <= 0 {
+		cfg.channelBufferSize = defaultChannelBufferSize
+	}
+	if cfg.maxRounds <= 0 {
+		cfg.maxRounds = defaultMaxRounds
+	}
+	return &ReflectionAgent{
+		name:              name,
+		description:       cfg.description,
+		producer:          cfg.producer,
+		critic:            cfg.critic,
+		criticFunc:        cfg.criticFunc,
+		rubric:            cfg.rubric,
+		scoreThreshold:    cfg.scoreThreshold,
+		maxRounds:         cfg.maxRounds,
+		minImprovement:    cfg.minImprovement,
+		channelBufferSize: cfg.channelBufferSize,
+		agentCallbacks:    cfg.agentCallbacks,
+	}
+}
+
+// Run implements the agent.Agent interface.
+func (a *ReflectionAgent) Run(ctx context.Context, invocation *agent.Invocation) (<-chan *event.Event, error) {
+	if a.producer == nil {
+		return nil, errors.New("reflection agent requires a producer agent")
+	}
+	if a.critic == nil && a.criticFunc == nil {
+		return nil, errors.New("reflection agent requires a critic agent or function")
+	}
+	eventChan := make(chan *event.Event, a.channelBufferSize)
+	go func() {
+		defer close(eventChan)
+		a.executeReflectionRun(ctx, invocation, eventChan)
+	}()
+	return eventChan, nil
+}
+
+// executeReflectionRun handles the main execution logic for reflection agent.
+func (a *ReflectionAgent) executeReflectionRun(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) {
+	ctx, span := trace.Tracer.Start(ctx, fmt.Sprintf("%s %s", itelemetry.OperationInvokeAgent, a.name))
+	itelemetry.TraceBeforeInvokeAgent(span, invocation, a.description, "", nil)
+	defer span.End()
+
+	invocation.Agent = a
+	invocation.AgentName = a.name
+
+	if a.handleBeforeAgentCallbacks(ctx, invocation, eventChan) {
+		return
+	}
+
+	e := a.reflect(ctx, invocation, eventChan)
+	if a.agentCallbacks != nil {
+		e = a.handleAfterAgentCallbacks(ctx, invocation, eventChan)
+	}
+	itelemetry.TraceAfterInvokeAgent(span, e)
+}
+
+// draft is a draft of the producer and its review.
+type draft struct {
+	round   int
+	content string
+	review  Review
+}
+
+// reflect runs the rounds and emits the best draft. It returns the last
+// event.
+func (a *ReflectionAgent) reflect(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) *event.Event {
+	request := invocation.Message.Content
+	var best, last *draft
+	for round := 1; round <= a.maxRounds; round++ {
+		input := request
+		if last != nil {
+			input = revisionPrompt(request, last)
+		}
+		content, err := a.runSub(ctx, invocation, eventChan, a.producer, input)
+		if err != nil {
+			return a.stop(ctx, invocation, eventChan, best, fmt.Errorf("producer failed in round %d: %w", round, err))
+		}
+		review, err := a.review(ctx, invocation, eventChan, request, content)
+		if err != nil {
+			return a.stop(ctx, invocation, eventChan, best, fmt.Errorf("critic failed in round %d: %w", round, err))
+		}
+
+		current := &draft{round: round, content: content, review: review}
+		critique := &event.Critique{Round: round, Score: review.Score, Feedback: review.Feedback}
+		switch {
+		case review.Score >= a.scoreThreshold:
+			critique.Stop = StopThreshold
+		case best != nil && review.Score <= best.review.Score+a.minImprovement:
+			critique.Stop = StopNoImprovement
+		case round == a.maxRounds:
+			critique.Stop = StopMaxRounds
+		}
+		if best == nil || review.Score > best.review.Score {
+			best = current
+		}
+		last = current
+		critique.BestRound = best.round
+		e := event.New(invocation.InvocationID, a.name, event.WithObject(model.ObjectTypeCritique))
+		e.Critique = critique
+		if err := agent.EmitEvent(ctx, invocation, eventChan, e); err != nil {
+			return nil
+		}
+		if critique.Stop != "" {
+			break
+		}
+	}
+	return a.answer(ctx, invocation, eventChan, best)
+}
+
+// review scores content, the draft answering request.
+func (a *ReflectionAgent) review(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	request, content string,
+) (Review, error) {
+	if a.criticFunc != nil {
+		return a.criticFunc(ctx, a.rubric, request, content)
+	}
+	var review Review
+	var reviewErr error
+	found := false
+	err := a.forward(ctx, invocation, eventChan, a.critic, critiquePrompt(a.rubric, request, content),
+		func(e *event.Event) {
+			if r, ok, err := reviewFromEvent(e); ok {
+				review, reviewErr, found = r, err, true
+			}
+		})
+	if err != nil {
+		return Review{}, err
+	}
+	if !found {
+		return Review{}, errors.New("the critic gave no review")
+	}
+	return review, reviewErr
+}
+
+// runSub runs sub with input and returns its last final answer.
+func (a *ReflectionAgent) runSub(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	sub agent.Agent,
+	input string,
+) (string, error) {
+	var content string
+	err := a.forward(ctx, invocation, eventChan, sub, input, func(e *event.Event) {
+		if msg, ok := finalMessage(e); ok {
+			content = msg
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if content == "" {
+		return "", fmt.Errorf("agent %s gave no answer", sub.Info().Name)
+	}
+	return content, nil
+}
+
+// forward runs sub with input, forwarding its events and passing them to
+// observe. It fails on error events.
+func (a *ReflectionAgent) forward(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	sub agent.Agent,
+	input string,
+	observe func(*event.Event),
+) error {
+	// Each run of a sub-agent has its own history, outside the branch of the
+	// parent: the input holds what it needs from the request and the
+	// previous rounds.
+	filterKey := sub.Info().Name + "-" + uuid.NewString()
+	subInvocation := invocation.Clone(
+		agent.WithInvocationAgent(sub),
+		agent.WithInvocationMessage(model.NewUserMessage(input)),
+		agent.WithInvocationEventFilterKey(filterKey),
+	)
+	subEventChan, err := sub.Run(agent.NewInvocationContext(ctx, subInvocation), subInvocation)
+	if err != nil {
+		return err
+	}
+	var subErr error
+	for e := range subEventChan {
+		if e != nil && e.Response != nil {
+			if e.Error != nil {
+				subErr = errors.New(e.Error.Message)
+			} else {
+				observe(e)
+			}
+		}
+		if err := event.EmitEvent(ctx, eventChan, e); err != nil {
+			return err
+		}
+	}
+	if subErr != nil {
+		return subErr
+	}
+	return agent.CheckContextCancelled(ctx)
+}
+
+// answer emits the best draft as the answer of the agent.
+func (a *ReflectionAgent) answer(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	best *draft,
+) *event.Event {
+	if best == nil {
+		return nil
+	}
+	rsp := &model.Response{
+		Object: model.ObjectTypeChatCompletion,
+		Done:   true,
+		Choices: []model.Choice{{
+			Index:   0,
+			Message: model.NewAssistantMessage(best.content),
+		}},
+	}
+	e := event.NewResponseEvent(invocation.InvocationID, a.name, rsp)
+	e.Critique = &event.Critique{
+		Round:     best.round,
+		Score:     best.review.Score,
+		Feedback:  best.review.Feedback,
+		BestRound: best.round,
+	}
+	agent.EmitEvent(ctx, invocation, eventChan, e)
+	return e
+}
+
+// stop reports err and answers with the best draft so far, if any.
+func (a *ReflectionAgent) stop(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	best *draft,
+	err error,
+) *event.Event {
+	if ctx.Err() != nil {
+		return nil
+	}
+	e := event.NewErrorEvent(invocation.InvocationID, a.name, model.ErrorTypeFlowError, err.Error())
+	if agent.EmitEvent(ctx, invocation, eventChan, e) != nil || best == nil {
+		return e
+	}
+	return a.answer(ctx, invocation, eventChan, best)
+}
+
+// finalMessage returns the content of a final assistant answer.
+func finalMessage(e *event.Event) (string, bool) {
+	if e.Response == nil || e.IsPartial || len(e.Choices) == 0 || e.IsToolCallResponse() {
+		return "", false
+	}
+	msg := e.Choices[0].Message
+	if msg.Role != model.RoleAssistant || msg.Content == "" {
+		return "", false
+	}
+	return msg.Content, true
+}
+
+// revisionPrompt asks the producer to revise the last draft.
+func revisionPrompt(request string, last *draft) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Request:\n%s\n\n", request)
+	fmt.Fprintf(&b, "Your previous answer:\n%s\n\n", last.content)
+	fmt.Fprintf(&b, "Review (score %.2g):\n%s\n\n", last.review.Score, last.review.Feedback)
+	b.WriteString("Write an improved answer to the request that addresses the review. Reply with the answer only.")
+	return b.String()
+}
+
+// critiquePrompt asks the critic to review draft.
+func critiquePrompt(rubric, request, draft string) string {
+	var b strings.Builder
+	if rubric != "" {
+		fmt.Fprintf(&b, "Rubric:\n%s\n\n", rubric)
+	}
+	fmt.Fprintf(&b, "Request:\n%s\n\nAnswer to review:\n%s\n\n", request, draft)
+	b.WriteString(`Score the answer from 0 to 1 against the rubric and say what to change to improve it. ` +
+		`Reply with a JSON object only: {"score": 0.5, "feedback": "..."}`)
+	return b.String()
+}
+
+// handleBeforeAgentCallbacks handles pre-execution callbacks.
+func (a *ReflectionAgent) handleBeforeAgentCallbacks(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) bool {
+	if a.agentCallbacks == nil {
+		return false
+	}
+	customResponse, err := a.agentCallbacks.RunBeforeAgent(ctx, invocation)
+	if err != nil {
+		agent.EmitEvent(ctx, invocation, eventChan, event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			agent.ErrorTypeAgentCallbackError,
+			err.Error(),
+		))
+		return true
+	}
+	if customResponse != nil {
+		agent.EmitEvent(ctx, invocation, eventChan, event.NewResponseEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			customResponse,
+		))
+		return true
+	}
+	return false
+}
+
+// handleAfterAgentCallbacks handles post-execution callbacks.
+func (a *ReflectionAgent) handleAfterAgentCallbacks(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) *event.Event {
+	customResponse, err := a.agentCallbacks.RunAfterAgent(ctx, invocation, nil)
+	var evt *event.Event
+	if err != nil {
+		evt = event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			agent.ErrorTypeAgentCallbackError,
+			err.Error(),
+		)
+	} else if customResponse != nil {
+		evt = event.NewResponseEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			customResponse,
+		)
+	}
+	agent.EmitEvent(ctx, invocation, eventChan, evt)
+	return evt
+}
+
+// Tools implements the agent.Agent interface.
+func (a *ReflectionAgent) Tools() []tool.Tool {
+	return []tool.Tool{}
+}
+
+// Info implements the agent.Agent interface.
+func (a *ReflectionAgent) Info() agent.Info {
+	return agent.Info{
+		Name:        a.name,
+		Description: a.description,
+	}
+}
+
+// SubAgents implements the agent.Agent interface.
+// It returns the producer and the critic agents.
+func (a *ReflectionAgent) SubAgents() []agent.Agent {
+	var subs []agent.Agent
+	for _, sub := range []agent.Agent{a.producer, a.critic} {
+		if sub != nil {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// FindSubAgent implements the agent.Agent interface.
+func (a *ReflectionAgent) FindSubAgent(name string) agent.Agent {
+	for _, sub := range a.SubAgents() {
+		if sub.Info().Name == name {
+			return sub
+		}
+	}
+	return nil
+}
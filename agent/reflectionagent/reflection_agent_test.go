@@ -0,0 +1,213 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package reflectionagent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func producer(drafts ...string) (agent.Agent, *agenttest.Model) {
+	var turns []agenttest.Turn
+	for _, d := range drafts {
+		turns = append(turns, agenttest.Reply(d))
+	}
+	m := agenttest.NewModel(turns...)
+	return llmagent.New("writer", llmagent.WithModel(m)), m
+}
+
+// scores returns a critic function scoring the drafts from a table.
+func scores(table map[string]float64) CriticFunc {
+	return func(_ context.Context, _, _, draft string) (Review, error) {
+		score, ok := table[draft]
+		if !ok {
+			return Review{}, errors.New("unexpected draft " + draft)
+		}
+		return Review{Score: score, Feedback: "feedback on " + draft}, nil
+	}
+}
+
+func critiques(tr *agenttest.Trajectory) []*event.Critique {
+	var out []*event.Critique
+	for _, e := range tr.Filter(func(e *event.Event) bool { return e.Object == model.ObjectTypeCritique }) {
+		out = append(out, e.Critique)
+	}
+	return out
+}
+
+func TestReflectionAgent_StopsAtThreshold(t *testing.T) {
+	writer, writerModel := producer("draft 1", "draft 2")
+	criticModel := agenttest.NewModel(
+		agenttest.Reply(`{"score": 0.5, "feedback": "add detail"}`),
+		agenttest.Reply("Review: {\"score\": 0.9, \"feedback\": \"good\"}"),
+	)
+	reflection := New("reflection",
+		WithProducer(writer),
+		WithCritic(llmagent.New("critic", llmagent.WithModel(criticModel))),
+		WithRubric("Be precise."),
+	)
+
+	tr := agenttest.NewHarness(reflection).MustRun(t, "Describe the bug")
+
+	agenttest.AssertFinalResponse(t, tr, "draft 2")
+	cs := critiques(tr)
+	require.Len(t, cs, 2)
+	assert.Equal(t, event.Critique{Round: 1, Score: 0.5, Feedback: "add detail", BestRound: 1}, *cs[0])
+	assert.Equal(t, event.Critique{Round: 2, Score: 0.9, Feedback: "good", BestRound: 2, Stop: StopThreshold}, *cs[1])
+
+	revision := writerModel.Requests()[1].Messages
+	last := revision[len(revision)-1].Content
+	assert.Contains(t, last, "Request:\nDescribe the bug")
+	assert.Contains(t, last, "Your previous answer:\ndraft 1")
+	assert.Contains(t, last, "add detail")
+	review := criticModel.Requests()[0].Messages
+	prompt := review[len(review)-1].Content
+	assert.Contains(t, prompt, "Rubric:\nBe precise.")
+	assert.Contains(t, prompt, "Answer to review:\ndraft 1")
+}
+
+func TestReflectionAgent_KeepsBestDraft(t *testing.T) {
+	writer, _ := producer("draft 1", "draft 2")
+	reflection := New("reflection",
+		WithProducer(writer),
+		WithCriticFunc(scores(map[string]float64{"draft 1": 0.6, "draft 2": 0.4})),
+	)
+
+	tr := agenttest.NewHarness(reflection).MustRun(t, "Describe the bug")
+
+	agenttest.AssertFinalResponse(t, tr, "draft 1")
+	cs := critiques(tr)
+	require.Len(t, cs, 2)
+	assert.Equal(t, StopNoImprovement, cs[1].Stop)
+	assert.Equal(t, 1, cs[1].BestRound)
+	final := tr.Events[len(tr.Events)-2]
+	require.NotNil(t, final.Critique, "the answer carries the score of the best draft")
+	assert.Equal(t, 0.6, final.Critique.Score)
+}
+
+func TestReflectionAgent_MinImprovementAndMaxRounds(t *testing.T) {
+	writer, _ := producer("draft 1", "draft 2", "draft 3")
+	reflection := New("reflection",
+		WithProducer(writer),
+		WithCriticFunc(scores(map[string]float64{"draft 1": 0.3, "draft 2": 0.5, "draft 3": 0.7})),
+		WithMinImprovement(0.1),
+		WithMaxRounds(3),
+	)
+
+	tr := agenttest.NewHarness(reflection).MustRun(t, "Describe the bug")
+
+	agenttest.AssertFinalResponse(t, tr, "draft 3")
+	cs := critiques(tr)
+	require.Len(t, cs, 3)
+	assert.Equal(t, StopMaxRounds, cs[2].Stop)
+
+	writer, _ = producer("draft 1", "draft 2")
+	reflection = New("reflection",
+		WithProducer(writer),
+		WithCriticFunc(scores(map[string]float64{"draft 1": 0.3, "draft 2": 0.35})),
+		WithMinImprovement(0.1),
+	)
+	tr = agenttest.NewHarness(reflection).MustRun(t, "Describe the bug")
+	agenttest.AssertFinalResponse(t, tr, "draft 2")
+	cs = critiques(tr)
+	require.Len(t, cs, 2)
+	assert.Equal(t, StopNoImprovement, cs[1].Stop, "a small improvement stops the rounds but is kept")
+	assert.Equal(t, 2, cs[1].BestRound)
+
+	writer, _ = producer("draft 1", "draft 2")
+	reflection = New("reflection",
+		WithProducer(writer),
+		WithCriticFunc(scores(map[string]float64{"draft 1": 0.75, "draft 2": 0.8})),
+		WithMinImprovement(0.1),
+	)
+	tr = agenttest.NewHarness(reflection).MustRun(t, "Describe the bug")
+	agenttest.AssertFinalResponse(t, tr, "draft 2")
+	cs = critiques(tr)
+	require.Len(t, cs, 2)
+	assert.Equal(t, StopThreshold, cs[1].Stop, "reaching the threshold wins over a small improvement")
+}
+
+func TestReflectionAgent_Info(t *testing.T) {
+	reflection := New("reflection", WithDescription("Writes and reviews bug reports."))
+	assert.Equal(t, agent.Info{Name: "reflection", Description: "Writes and reviews bug reports."}, reflection.Info())
+}
+
+func TestReflectionAgent_StructuredCritic(t *testing.T) {
+	writer, _ := producer("draft 1")
+	critic := llmagent.New("critic",
+		llmagent.WithModel(agenttest.NewModel(agenttest.Reply(`{"score": 0.95, "feedback": "fine"}`))),
+		llmagent.WithStructuredOutputJSON(new(Review), true, "Review of the draft."),
+	)
+	reflection := New("reflection", WithProducer(writer), WithCritic(critic))
+
+	tr := agenttest.NewHarness(reflection).MustRun(t, "Describe the bug")
+
+	agenttest.AssertFinalResponse(t, tr, "draft 1")
+	cs := critiques(tr)
+	require.Len(t, cs, 1)
+	assert.Equal(t, 0.95, cs[0].Score)
+	assert.Equal(t, StopThreshold, cs[0].Stop)
+}
+
+func TestReflectionAgent_CriticFailureKeepsBestDraft(t *testing.T) {
+	writer, _ := producer("draft 1", "draft 2")
+	reflection := New("reflection",
+		WithProducer(writer),
+		WithCriticFunc(scores(map[string]float64{"draft 1": 0.5})),
+	)
+
+	tr := agenttest.NewHarness(reflection).MustRun(t, "Describe the bug")
+
+	errs := tr.Errors()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0], "critic failed in round 2: unexpected draft draft 2")
+	agenttest.AssertFinalResponse(t, tr, "draft 1")
+
+	critic := llmagent.New("critic", llmagent.WithModel(agenttest.NewModel(agenttest.Reply("looks good"))))
+	writer, _ = producer("draft 1")
+	tr = agenttest.NewHarness(New("reflection", WithProducer(writer), WithCritic(critic))).MustRun(t, "Describe the bug")
+	errs = tr.Errors()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0], "no JSON object")
+}
+
+func TestReflectionAgent_RequiresProducerAndCritic(t *testing.T) {
+	writer, _ := producer()
+	_, err := New("reflection", WithCriticFunc(scores(nil))).Run(context.Background(), &agent.Invocation{})
+	assert.Error(t, err)
+	_, err = New("reflection", WithProducer(writer)).Run(context.Background(), &agent.Invocation{})
+	assert.Error(t, err)
+
+	r := New("reflection", WithProducer(writer))
+	assert.Len(t, r.SubAgents(), 1)
+	assert.Equal(t, writer, r.FindSubAgent("writer"))
+	assert.Nil(t, r.FindSubAgent("critic"))
+}
+
+func TestParseReview(t *testing.T) {
+	review, err := parseReview("```json\n{\"score\": 0, \"feedback\": \"wrong\"}\n```")
+	require.NoError(t, err)
+	assert.Equal(t, Review{Score: 0, Feedback: "wrong"}, review)
+
+	_, err = parseReview(`{"feedback": "no score"}`)
+	assert.ErrorContains(t, err, "no score")
+	_, err = parseReview(`{"score": "high"}`)
+	assert.ErrorContains(t, err, "invalid critic JSON")
+}
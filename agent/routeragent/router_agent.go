@@ -0,0 +1,498 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package routeragent provides an agent dispatching each message to the
+// most similar of its sub-agents, by embedding, without a model call.
+//
+// Each sub-agent is described by its Info().Description and by example
+// utterances. A message is embedded and run by the sub-agent whose
+// description or examples are the most similar to it. When no sub-agent
+// matches well enough, the message is run by a fallback agent, such as an
+// LLM agent routing through transfers, or a default agent. Every decision is
+// reported by an event with object model.ObjectTypeRouting.
+package routeragent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	itelemetry "trpc.group/trpc-go/trpc-agent-go/internal/telemetry"
+	"trpc.group/trpc-go/trpc-agent-go/knowledge/embedder"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/trace"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+const defaultChannelBufferSize = 256
+
+// RouterAgent is an agent that runs the sub-agent most similar to the
+// invocation message.
+type RouterAgent struct {
+	name              string
+	subAgents         []agent.Agent
+	embedder          embedder.Embedder
+	examples          map[string][]string
+	threshold         float64
+	margin            float64
+	fallback          agent.Agent
+	channelBufferSize int
+	agentCallbacks    *agent.Callbacks
+
+	mu     sync.Mutex
+	routes []route
+}
+
+// route is a sub-agent with the embeddings of its description and examples.
+type route struct {
+	agent      agent.Agent
+	embeddings [][]float64
+}
+
+// Option configures RouterAgent settings using the functional options
+// pattern.
+type Option func(*Options)
+
+// Options contains all configuration options for RouterAgent.
+type Options struct {
+	subAgents         []agent.Agent
+	embedder          embedder.Embedder
+	examples          map[string][]string
+	threshold         float64
+	margin            float64
+	fallback          agent.Agent
+	channelBufferSize int
+	agentCallbacks    *agent.Callbacks
+}
+
+// WithSubAgents sets the sub-agents messages are dispatched to.
+func WithSubAgents(subAgents []agent.Agent) Option {
+	return func(o *Options) { o.subAgents = subAgents }
+}
+
+// WithEmbedder sets the embedder of the descriptions, examples and messages.
+func WithEmbedder(e embedder.Embedder) Option {
+	return func(o *Options) { o.embedder = e }
+}
+
+// WithExamples adds example utterances for the sub-agent named agentName,
+// on top of its description. A few typical requests usually route much
+// better than the description alone.
+func WithExamples(agentName string, utterances ...string) Option {
+	return func(o *Options) {
+		if o.examples == nil {
+			o.examples = make(map[string][]string)
+		}
+		o.examples[agentName] = append(o.examples[agentName], utterances...)
+	}
+}
+
+// WithThreshold sets the minimum cosine similarity for a sub-agent to be
+// chosen. Below it, the message is run by the fallback agent. Defaults to 0.
+func WithThreshold(threshold float64) Option {
+	return func(o *Options) { o.threshold = threshold }
+}
+
+// WithMargin sets the minimum lead of the best sub-agent over the second
+// one. A closer match is ambiguous and the message is run by the fallback
+// agent. Defaults to 0.
+func WithMargin(margin float64) Option {
+	return func(o *Options) { o.margin = margin }
+}
+
+// WithFallbackAgent sets the agent running the messages no sub-agent
+// matches well enough, or when the embedding fails. It can be an LLM agent
+// having the same sub-agents, which routes through transfers, or a default
+// agent. Without a fallback agent, the best sub-agent is always chosen.
+func WithFallbackAgent(fallback agent.Agent) Option {
+	return func(o *Options) { o.fallback = fallback }
+}
+
+// WithChannelBufferSize sets the buffer size for the event channel.
+// Default is 256 if not specified.
+func WithChannelBufferSize(size int) Option {
+	return func(o *Options) { o.channelBufferSize = size }
+}
+
+// WithAgentCallbacks attaches lifecycle callbacks to the router agent.
+func WithAgentCallbacks(cb *agent.Callbacks) Option {
+	return func(o *Options) { o.agentCallbacks = cb }
+}
+
+// New creates a new RouterAgent with the given name and options. The
+// descriptions and examples are embedded on the first run.
+func New(name string, opts ...Option) *RouterAgent {
+	cfg := Options{
+		channelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.channelBufferSize <= 0 {
+		cfg.channelBufferSize = defaultChannelBufferSize
+	}
+	return &RouterAgent{
+		name:              name,
+		subAgents:         cfg.subAgents,
+		embedder:          cfg.embedder,
+		examples:          cfg.examples,
+		threshold:         cfg.threshold,
+		margin:            cfg.margin,
+		fallback:          cfg.fallback,
+		channelBufferSize: cfg.channelBufferSize,
+		agentCallbacks:    cfg.agentCallbacks,
+	}
+}
+
+// Run implements the agent.Agent interface.
+// It emits the routing decision and then the events of the chosen agent.
+func (a *RouterAgent) Run(ctx context.Context, invocation *agent.Invocation) (<-chan *event.Event, error) {
+	eventChan := make(chan *event.Event, a.channelBufferSize)
+	go func() {
+		defer close(eventChan)
+		a.executeRouterRun(ctx, invocation, eventChan)
+	}()
+	return eventChan, nil
+}
+
+// executeRouterRun handles the main execution logic for router agent.
+func (a *RouterAgent) executeRouterRun(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) {
+	ctx, span := trace.Tracer.Start(ctx, fmt.Sprintf("%s %s", itelemetry.OperationInvokeAgent, a.name))
+	itelemetry.TraceBeforeInvokeAgent(span, invocation, "router-agent", "", nil)
+	defer span.End()
+
+	invocation.Agent = a
+	invocation.AgentName = a.name
+
+	if a.handleBeforeAgentCallbacks(ctx, invocation, eventChan) {
+		return
+	}
+
+	e := a.routeAndRun(ctx, invocation, eventChan)
+	if a.agentCallbacks != nil {
+		e = a.handleAfterAgentCallbacks(ctx, invocation, eventChan)
+	}
+	itelemetry.TraceAfterInvokeAgent(span, e)
+}
+
+// routeAndRun chooses the agent of the message, reports the decision and
+// runs the agent. It returns the last complete event.
+func (a *RouterAgent) routeAndRun(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) *event.Event {
+	decision, target, err := a.route(ctx, invocation)
+	if err != nil {
+		log.Warnf("Router agent %q failed to route: %v", a.name, err)
+		e := event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			model.ErrorTypeFlowError,
+			fmt.Sprintf("router agent %q failed to route: %v", a.name, err),
+		)
+		agent.EmitEvent(ctx, invocation, eventChan, e)
+		return e
+	}
+	routing := event.New(invocation.InvocationID, a.name, event.WithObject(model.ObjectTypeRouting))
+	routing.Done = true
+	routing.Routing = decision
+	if err := agent.EmitEvent(ctx, invocation, eventChan, routing); err != nil {
+		return nil
+	}
+
+	subInvocation := invocation.Clone(agent.WithInvocationAgent(target))
+	subEventChan, err := target.Run(agent.NewInvocationContext(ctx, subInvocation), subInvocation)
+	if err != nil {
+		log.Warnf("Router agent %q failed to run agent %q: %v", a.name, decision.Agent, err)
+		e := event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			model.ErrorTypeFlowError,
+			err.Error(),
+		)
+		agent.EmitEvent(ctx, invocation, eventChan, e)
+		return e
+	}
+	var last *event.Event
+	for subEvent := range subEventChan {
+		if subEvent != nil && subEvent.Response != nil && !subEvent.Response.IsPartial {
+			last = subEvent
+		}
+		if err := event.EmitEvent(ctx, eventChan, subEvent); err != nil {
+			return nil
+		}
+	}
+	return last
+}
+
+// route returns the decision for the invocation message and the chosen
+// agent.
+func (a *RouterAgent) route(
+	ctx context.Context,
+	invocation *agent.Invocation,
+) (*event.RoutingDecision, agent.Agent, error) {
+	scores, err := a.score(ctx, messageText(invocation.Message))
+	if err != nil {
+		if a.fallback == nil {
+			return nil, nil, err
+		}
+		return a.fallbackDecision(nil, fmt.Sprintf("embedding failed: %v", err)), a.fallback, nil
+	}
+	if len(scores) == 0 {
+		if a.fallback == nil {
+			return nil, nil, errors.New("no sub-agents to route to")
+		}
+		return a.fallbackDecision(nil, "no sub-agents to route to"), a.fallback, nil
+	}
+
+	best := scores[0]
+	if a.fallback != nil {
+		if best.Score < a.threshold {
+			return a.fallbackDecision(scores, fmt.Sprintf(
+				"best score %.3f of agent %s is below the threshold %.3f", best.Score, best.Agent, a.threshold,
+			)), a.fallback, nil
+		}
+		if len(scores) > 1 && best.Score-scores[1].Score < a.margin {
+			return a.fallbackDecision(scores, fmt.Sprintf(
+				"agents %s and %s are within the margin %.3f", best.Agent, scores[1].Agent, a.margin,
+			)), a.fallback, nil
+		}
+	}
+	return &event.RoutingDecision{Agent: best.Agent, Score: best.Score, Scores: scores}, a.findRouted(best.Agent), nil
+}
+
+func (a *RouterAgent) fallbackDecision(scores []event.RouteScore, reason string) *event.RoutingDecision {
+	d := &event.RoutingDecision{
+		Agent:    a.fallback.Info().Name,
+		Fallback: true,
+		Reason:   reason,
+		Scores:   scores,
+	}
+	if len(scores) > 0 {
+		d.Score = scores[0].Score
+	}
+	return d
+}
+
+// score returns the similarity of text with each sub-agent, best first. The
+// similarity with a sub-agent is the best similarity with its description
+// and examples.
+func (a *RouterAgent) score(ctx context.Context, text string) ([]event.RouteScore, error) {
+	routes, err := a.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("the message has no text")
+	}
+	query, err := embed(ctx, a.embedder, text)
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]event.RouteScore, 0, len(routes))
+	for _, r := range routes {
+		best := math.Inf(-1)
+		for _, e := range r.embeddings {
+			best = math.Max(best, cosineSimilarity(query, e))
+		}
+		if len(r.embeddings) == 0 {
+			best = 0
+		}
+		scores = append(scores, event.RouteScore{Agent: r.agent.Info().Name, Score: best})
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	return scores, nil
+}
+
+// index embeds the descriptions and examples of the sub-agents, once. A
+// failed attempt is retried on the next run.
+func (a *RouterAgent) index(ctx context.Context) ([]route, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.routes != nil || len(a.subAgents) == 0 {
+		return a.routes, nil
+	}
+	if a.embedder == nil {
+		return nil, errors.New("no embedder configured")
+	}
+	routes := make([]route, 0, len(a.subAgents))
+	for _, sub := range a.subAgents {
+		info := sub.Info()
+		texts := append([]string{info.Description}, a.examples[info.Name]...)
+		r := route{agent: sub}
+		for _, text := range texts {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			e, err := embed(ctx, a.embedder, text)
+			if err != nil {
+				return nil, fmt.Errorf("embed agent %s: %w", info.Name, err)
+			}
+			r.embeddings = append(r.embeddings, e)
+		}
+		if len(r.embeddings) == 0 {
+			log.Warnf("Router agent %q: agent %q has neither description nor examples", a.name, info.Name)
+		}
+		routes = append(routes, r)
+	}
+	a.routes = routes
+	return routes, nil
+}
+
+func embed(ctx context.Context, e embedder.Embedder, text string) ([]float64, error) {
+	embedding, err := e.GetEmbedding(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedding) == 0 {
+		return nil, errors.New("received empty embedding")
+	}
+	return embedding, nil
+}
+
+// messageText returns the text of msg, content parts included.
+func messageText(msg model.Message) string {
+	parts := []string{msg.Content}
+	for _, p := range msg.ContentParts {
+		if p.Text != nil {
+			parts = append(parts, *p.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// cosineSimilarity calculates the cosine similarity between two vectors.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0.0
+	}
+	var dotProduct, normA, normB float64
+	for i := 0; i < len(a); i++ {
+		dotProduct += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0.0
+	}
+	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func (a *RouterAgent) findRouted(name string) agent.Agent {
+	for _, sub := range a.subAgents {
+		if sub.Info().Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// handleBeforeAgentCallbacks handles pre-execution callbacks.
+func (a *RouterAgent) handleBeforeAgentCallbacks(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) bool {
+	if a.agentCallbacks == nil {
+		return false
+	}
+	customResponse, err := a.agentCallbacks.RunBeforeAgent(ctx, invocation)
+	if err != nil {
+		agent.EmitEvent(ctx, invocation, eventChan, event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			agent.ErrorTypeAgentCallbackError,
+			err.Error(),
+		))
+		return true
+	}
+	if customResponse != nil {
+		agent.EmitEvent(ctx, invocation, eventChan, event.NewResponseEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			customResponse,
+		))
+		return true
+	}
+	return false
+}
+
+// handleAfterAgentCallbacks handles post-execution callbacks.
+func (a *RouterAgent) handleAfterAgentCallbacks(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) *event.Event {
+	customResponse, err := a.agentCallbacks.RunAfterAgent(ctx, invocation, nil)
+	var evt *event.Event
+	if err != nil {
+		evt = event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			agent.ErrorTypeAgentCallbackError,
+			err.Error(),
+		)
+	} else if customResponse != nil {
+		evt = event.NewResponseEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			customResponse,
+		)
+	}
+	agent.EmitEvent(ctx, invocation, eventChan, evt)
+	return evt
+}
+
+// Tools implements the agent.Agent interface.
+func (a *RouterAgent) Tools() []tool.Tool {
+	return []tool.Tool{}
+}
+
+// Info implements the agent.Agent interface.
+func (a *RouterAgent) Info() agent.Info {
+	return agent.Info{
+		Name:        a.name,
+		Description: fmt.Sprintf("Router agent that dispatches messages to %d sub-agents", len(a.subAgents)),
+	}
+}
+
+// SubAgents implements the agent.Agent interface.
+// It returns the sub-agents messages are routed to.
+func (a *RouterAgent) SubAgents() []agent.Agent {
+	return a.subAgents
+}
+
+// FindSubAgent implements the agent.Agent interface.
+// It finds a sub-agent, or the fallback agent, by name.
+func (a *RouterAgent) FindSubAgent(name string) agent.Agent {
+	if sub := a.findRouted(name); sub != nil {
+		return sub
+	}
+	if a.fallback != nil && a.fallback.Info().Name == name {
+		return a.fallback
+	}
+	return nil
+}
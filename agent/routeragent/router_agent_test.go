@@ -0,0 +1,194 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package routeragent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// wordEmbedder embeds a text as the counts of a fixed vocabulary.
+type wordEmbedder struct {
+	vocabulary []string
+	err        error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *wordEmbedder) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	words := strings.Fields(strings.ToLower(text))
+	v := make([]float64, len(e.vocabulary))
+	for i, w := range e.vocabulary {
+		for _, word := range words {
+			if strings.Trim(word, ".,?!") == w {
+				v[i]++
+			}
+		}
+	}
+	return v, nil
+}
+
+func (e *wordEmbedder) GetEmbeddingWithUsage(ctx context.Context, text string) ([]float64, map[string]any, error) {
+	v, err := e.GetEmbedding(ctx, text)
+	return v, nil, err
+}
+
+func (e *wordEmbedder) GetDimensions() int {
+	return len(e.vocabulary)
+}
+
+func newEmbedder() *wordEmbedder {
+	return &wordEmbedder{vocabulary: []string{"invoice", "refund", "payment", "password", "login", "error", "weather"}}
+}
+
+func replyAgent(name, description, reply string) agent.Agent {
+	return llmagent.New(name,
+		llmagent.WithDescription(description),
+		llmagent.WithModel(agenttest.NewModel(agenttest.Reply(reply))),
+	)
+}
+
+func subAgents() []agent.Agent {
+	return []agent.Agent{
+		replyAgent("billing", "Handles invoice and payment questions.", "billing here"),
+		replyAgent("support", "Fixes login and password problems.", "support here"),
+	}
+}
+
+func routing(t *testing.T, tr *agenttest.Trajectory) *event.RoutingDecision {
+	t.Helper()
+	events := tr.Filter(func(e *event.Event) bool { return e.Response != nil && e.Object == model.ObjectTypeRouting })
+	require.Len(t, events, 1)
+	assert.Equal(t, "router", events[0].Author)
+	return events[0].Routing
+}
+
+func TestRouterAgent_RoutesToMostSimilarAgent(t *testing.T) {
+	emb := newEmbedder()
+	router := New("router",
+		WithSubAgents(subAgents()),
+		WithEmbedder(emb),
+		WithExamples("billing", "I want a refund"),
+	)
+	h := agenttest.NewHarness(router)
+
+	tr := h.MustRun(t, "Where is my refund?")
+
+	d := routing(t, tr)
+	assert.Equal(t, "billing", d.Agent)
+	assert.False(t, d.Fallback)
+	require.Len(t, d.Scores, 2)
+	assert.Equal(t, "billing", d.Scores[0].Agent)
+	assert.InDelta(t, 1.0, d.Score, 1e-9, "the example matches exactly")
+	agenttest.AssertFinalResponse(t, tr, "billing here")
+	agenttest.AssertBranch(t, tr, "billing", "router/billing")
+
+	tr = h.MustRun(t, "I forgot my password")
+	assert.Equal(t, "support", routing(t, tr).Agent)
+	agenttest.AssertFinalResponse(t, tr, "support here")
+	// The descriptions and examples are embedded once: 3 texts and 2 messages.
+	assert.Equal(t, 5, emb.calls)
+}
+
+func TestRouterAgent_FallbackBelowThreshold(t *testing.T) {
+	router := New("router",
+		WithSubAgents(subAgents()),
+		WithEmbedder(newEmbedder()),
+		WithThreshold(0.3),
+		WithFallbackAgent(replyAgent("default", "", "default here")),
+	)
+
+	tr := agenttest.NewHarness(router).MustRun(t, "What is the weather like?")
+
+	d := routing(t, tr)
+	assert.Equal(t, "default", d.Agent)
+	assert.True(t, d.Fallback)
+	assert.Contains(t, d.Reason, "below the threshold")
+	agenttest.AssertFinalResponse(t, tr, "default here")
+	assert.NotNil(t, router.FindSubAgent("default"))
+	assert.Len(t, router.SubAgents(), 2)
+}
+
+func TestRouterAgent_FallbackWhenAmbiguous(t *testing.T) {
+	router := New("router",
+		WithSubAgents(subAgents()),
+		WithEmbedder(newEmbedder()),
+		WithMargin(0.1),
+		WithFallbackAgent(replyAgent("triage", "", "triage here")),
+	)
+
+	tr := agenttest.NewHarness(router).MustRun(t, "payment error after login")
+
+	d := routing(t, tr)
+	assert.True(t, d.Fallback)
+	assert.Contains(t, d.Reason, "within the margin")
+	agenttest.AssertFinalResponse(t, tr, "triage here")
+}
+
+func TestRouterAgent_EmbeddingFailure(t *testing.T) {
+	emb := newEmbedder()
+	emb.err = errors.New("quota exceeded")
+
+	withFallback := New("router",
+		WithSubAgents(subAgents()),
+		WithEmbedder(emb),
+		WithFallbackAgent(replyAgent("default", "", "default here")),
+	)
+	tr := agenttest.NewHarness(withFallback).MustRun(t, "refund please")
+	d := routing(t, tr)
+	assert.True(t, d.Fallback)
+	assert.Contains(t, d.Reason, "quota exceeded")
+	agenttest.AssertFinalResponse(t, tr, "default here")
+
+	withoutFallback := New("router", WithSubAgents(subAgents()), WithEmbedder(emb))
+	tr = agenttest.NewHarness(withoutFallback).MustRun(t, "refund please")
+	errs := tr.Errors()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0], "quota exceeded")
+	assert.Empty(t, tr.FinalResponse())
+
+	// The index is built again once the embedder recovers.
+	emb.err = nil
+	tr = agenttest.NewHarness(withoutFallback).MustRun(t, "refund please")
+	assert.Equal(t, "billing", routing(t, tr).Agent)
+}
+
+func TestRouterAgent_WithoutFallbackChoosesBest(t *testing.T) {
+	router := New("router",
+		WithSubAgents(subAgents()),
+		WithEmbedder(newEmbedder()),
+		WithThreshold(0.99),
+	)
+
+	tr := agenttest.NewHarness(router).MustRun(t, "invoice for my login")
+
+	d := routing(t, tr)
+	assert.False(t, d.Fallback)
+	assert.Contains(t, []string{"billing", "support"}, d.Agent)
+}
@@ -0,0 +1,281 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package supervisoragent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// planStateKeyPrefix prefixes the session state key of the plan.
+const planStateKeyPrefix = "plan:"
+
+// PlanStateKey returns the session state key holding the plan of the
+// supervisor agent named agentName.
+func PlanStateKey(agentName string) string {
+	return planStateKeyPrefix + agentName
+}
+
+// PlanStatus is the status of a plan.
+type PlanStatus string
+
+// Plan statuses.
+const (
+	PlanStatusRunning   PlanStatus = "running"
+	PlanStatusCompleted PlanStatus = "completed"
+	PlanStatusFailed    PlanStatus = "failed"
+)
+
+// StepStatus is the status of a step.
+type StepStatus string
+
+// Step statuses.
+const (
+	StepStatusPending   StepStatus = "pending"
+	StepStatusRunning   StepStatus = "running"
+	StepStatusCompleted StepStatus = "completed"
+	StepStatusFailed    StepStatus = "failed"
+)
+
+// Plan is the plan of a supervisor agent and its progress.
+type Plan struct {
+	// Goal is the request the plan answers.
+	Goal string `json:"goal"`
+	// Status is the status of the plan.
+	Status PlanStatus `json:"status"`
+	// Revision counts the replans.
+	Revision int `json:"revision"`
+	// Steps are the steps of the plan.
+	Steps []*Step `json:"steps"`
+	// Answer is the final answer, once the plan is completed.
+	Answer string `json:"answer,omitempty"`
+	// Error explains why the plan failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Step is a step of a plan, run by a sub-agent or a tool.
+type Step struct {
+	// ID identifies the step in the plan.
+	ID string `json:"id"`
+	// Description is the task of the step.
+	Description string `json:"description"`
+	// Agent is the name of the sub-agent running the step.
+	Agent string `json:"agent,omitempty"`
+	// Tool is the name of the tool running the step.
+	Tool string `json:"tool,omitempty"`
+	// Arguments are the JSON arguments of the tool.
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	// DependsOn lists the steps whose results the step needs.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Status is the status of the step.
+	Status StepStatus `json:"status"`
+	// Result is the result of a completed step.
+	Result string `json:"result,omitempty"`
+	// Error explains why the step failed.
+	Error string `json:"error,omitempty"`
+}
+
+// LoadPlan returns the plan of the supervisor agent named agentName stored
+// in the state of sess, or nil if there is none.
+func LoadPlan(sess *session.Session, agentName string) (*Plan, error) {
+	if sess == nil {
+		return nil, nil
+	}
+	raw := sess.State[PlanStateKey(agentName)]
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var plan Plan
+	if err := json.Unmarshal(raw, &plan); err != nil {
+		return nil, fmt.Errorf("unmarshal plan: %w", err)
+	}
+	return &plan, nil
+}
+
+// step returns the step with id, or nil.
+func (p *Plan) step(id string) *Step {
+	for _, s := range p.Steps {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// ready returns the pending steps whose dependencies are completed.
+func (p *Plan) ready() []*Step {
+	var steps []*Step
+	for _, s := range p.Steps {
+		if s.Status != StepStatusPending {
+			continue
+		}
+		ok := true
+		for _, dep := range s.DependsOn {
+			if d := p.step(dep); d == nil || d.Status != StepStatusCompleted {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// completed reports whether every step is completed.
+func (p *Plan) completed() bool {
+	for _, s := range p.Steps {
+		if s.Status != StepStatusCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// failed returns the first failed step, or nil.
+func (p *Plan) failed() *Step {
+	for _, s := range p.Steps {
+		if s.Status == StepStatusFailed {
+			return s
+		}
+	}
+	return nil
+}
+
+// resume prepares an interrupted plan to run again.
+func (p *Plan) resume() {
+	for _, s := range p.Steps {
+		if s.Status == StepStatusRunning {
+			s.Status = StepStatusPending
+		}
+	}
+}
+
+// replace replaces the steps that are not completed with steps.
+func (p *Plan) replace(steps []*Step) {
+	kept := make([]*Step, 0, len(p.Steps)+len(steps))
+	for _, s := range p.Steps {
+		if s.Status == StepStatusCompleted {
+			kept = append(kept, s)
+		}
+	}
+	p.Steps = append(kept, steps...)
+	p.Revision++
+}
+
+// plannedSteps is the answer of the planner model.
+type plannedSteps struct {
+	Steps []*Step `json:"steps"`
+}
+
+// parseSteps parses the steps planned by the model and checks them against
+// the known agents and tools and the completed steps of plan, if any. The
+// tools mapped to false require confirmation and cannot be used by steps.
+func parseSteps(answer string, agents, tools map[string]bool, plan *Plan) ([]*Step, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end <= start {
+		return nil, errors.New("the answer holds no JSON object")
+	}
+	var planned plannedSteps
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &planned); err != nil {
+		return nil, fmt.Errorf("invalid plan JSON: %w", err)
+	}
+	if len(planned.Steps) == 0 {
+		return nil, errors.New("the plan has no steps")
+	}
+
+	known := make(map[string]bool)
+	if plan != nil {
+		for _, s := range plan.Steps {
+			if s.Status == StepStatusCompleted {
+				known[s.ID] = true
+			}
+		}
+	}
+	for _, s := range planned.Steps {
+		if s == nil || s.ID == "" {
+			return nil, errors.New("every step needs an id")
+		}
+		if known[s.ID] {
+			return nil, fmt.Errorf("step id %q is used twice", s.ID)
+		}
+		known[s.ID] = true
+	}
+	for _, s := range planned.Steps {
+		usable, declared := tools[s.Tool]
+		switch {
+		case (s.Agent == "") == (s.Tool == ""):
+			return nil, fmt.Errorf("step %q needs exactly one of agent and tool", s.ID)
+		case s.Agent != "" && !agents[s.Agent]:
+			return nil, fmt.Errorf("step %q uses unknown agent %q", s.ID, s.Agent)
+		case s.Tool != "" && !declared:
+			return nil, fmt.Errorf("step %q uses unknown tool %q", s.ID, s.Tool)
+		case s.Tool != "" && !usable:
+			return nil, fmt.Errorf("step %q uses tool %q, which requires confirmation: assign the step to an agent", s.ID, s.Tool)
+		}
+		for _, dep := range s.DependsOn {
+			if !known[dep] {
+				return nil, fmt.Errorf("step %q depends on unknown step %q", s.ID, dep)
+			}
+		}
+		if s.Tool != "" && len(s.Arguments) > 0 && !json.Valid(s.Arguments) {
+			return nil, fmt.Errorf("step %q has invalid tool arguments", s.ID)
+		}
+		s.Status = StepStatusPending
+		s.Result, s.Error = "", ""
+	}
+	if err := checkCycles(planned.Steps); err != nil {
+		return nil, err
+	}
+	return planned.Steps, nil
+}
+
+// checkCycles fails when the dependencies of steps form a cycle.
+func checkCycles(steps []*Step) error {
+	byID := make(map[string]*Step, len(steps))
+	for _, s := range steps {
+		byID[s.ID] = s
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int, len(steps))
+	var visit func(s *Step) error
+	visit = func(s *Step) error {
+		switch marks[s.ID] {
+		case visiting:
+			return fmt.Errorf("step %q depends on itself", s.ID)
+		case visited:
+			return nil
+		}
+		marks[s.ID] = visiting
+		for _, dep := range s.DependsOn {
+			if d, ok := byID[dep]; ok {
+				if err := visit(d); err != nil {
+					return err
+				}
+			}
+		}
+		marks[s.ID] = visited
+		return nil
+	}
+	for _, s := range steps {
+		if err := visit(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
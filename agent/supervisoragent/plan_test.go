@@ -0,0 +1,108 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package supervisoragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+func TestParseSteps(t *testing.T) {
+	agents := map[string]bool{"search": true}
+	tools := map[string]bool{"sum": true, "rm": false}
+	done := &Plan{Steps: []*Step{{ID: "s1", Status: StepStatusCompleted}, {ID: "s2", Status: StepStatusFailed}}}
+
+	tests := []struct {
+		name    string
+		answer  string
+		plan    *Plan
+		wantErr string
+	}{
+		{name: "no json", answer: "I cannot plan this", wantErr: "no JSON object"},
+		{name: "no steps", answer: `{"steps": []}`, wantErr: "no steps"},
+		{name: "missing id", answer: `{"steps": [{"agent": "search"}]}`, wantErr: "needs an id"},
+		{name: "duplicate id", answer: `{"steps": [{"id": "a", "agent": "search"}, {"id": "a", "agent": "search"}]}`, wantErr: "used twice"},
+		{name: "agent and tool", answer: `{"steps": [{"id": "a", "agent": "search", "tool": "sum"}]}`, wantErr: "exactly one"},
+		{name: "unknown tool", answer: `{"steps": [{"id": "a", "tool": "mul"}]}`, wantErr: `unknown tool "mul"`},
+		{name: "tool requiring confirmation", answer: `{"steps": [{"id": "a", "tool": "rm"}]}`, wantErr: `tool "rm", which requires confirmation`},
+		{name: "unknown dependency", answer: `{"steps": [{"id": "a", "agent": "search", "dependsOn": ["b"]}]}`, wantErr: `unknown step "b"`},
+		{name: "cycle", answer: `{"steps": [{"id": "a", "agent": "search", "dependsOn": ["b"]}, {"id": "b", "agent": "search", "dependsOn": ["a"]}]}`, wantErr: "depends on itself"},
+		{name: "completed id reused", answer: `{"steps": [{"id": "s1", "agent": "search"}]}`, plan: done, wantErr: "used twice"},
+		{name: "failed step dependency", answer: `{"steps": [{"id": "a", "agent": "search", "dependsOn": ["s2"]}]}`, plan: done, wantErr: `unknown step "s2"`},
+		{name: "completed step dependency", answer: "```json\n" + `{"steps": [{"id": "a", "tool": "sum", "arguments": {"a": 1}, "dependsOn": ["s1"], "status": "completed", "result": "x"}]}` + "\n```", plan: done},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := parseSteps(tt.answer, agents, tools, tt.plan)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, steps, 1)
+			assert.Equal(t, StepStatusPending, steps[0].Status)
+			assert.Empty(t, steps[0].Result)
+			assert.JSONEq(t, `{"a": 1}`, string(steps[0].Arguments))
+		})
+	}
+}
+
+func TestPlan_ReadyResumeReplace(t *testing.T) {
+	plan := &Plan{Steps: []*Step{
+		{ID: "a", Status: StepStatusCompleted},
+		{ID: "b", Status: StepStatusRunning},
+		{ID: "c", Status: StepStatusPending, DependsOn: []string{"a"}},
+		{ID: "d", Status: StepStatusPending, DependsOn: []string{"b"}},
+	}}
+	ready := plan.ready()
+	require.Len(t, ready, 1)
+	assert.Equal(t, "c", ready[0].ID)
+
+	plan.resume()
+	assert.Equal(t, StepStatusPending, plan.step("b").Status)
+	assert.Len(t, plan.ready(), 2)
+	assert.False(t, plan.completed())
+
+	plan.step("c").Status = StepStatusFailed
+	assert.Equal(t, "c", plan.failed().ID)
+	plan.replace([]*Step{{ID: "e", Status: StepStatusPending}})
+	assert.Equal(t, 1, plan.Revision)
+	require.Len(t, plan.Steps, 2)
+	assert.Equal(t, "a", plan.Steps[0].ID)
+	assert.Equal(t, "e", plan.Steps[1].ID)
+}
+
+func TestLoadPlan(t *testing.T) {
+	plan, err := LoadPlan(nil, "supervisor")
+	assert.NoError(t, err)
+	assert.Nil(t, plan)
+
+	sess := &session.Session{State: session.StateMap{}}
+	plan, err = LoadPlan(sess, "supervisor")
+	assert.NoError(t, err)
+	assert.Nil(t, plan)
+
+	sess.State[PlanStateKey("supervisor")] = []byte(`{"goal": "g", "status": "running", "steps": [{"id": "s1", "status": "pending"}]}`)
+	plan, err = LoadPlan(sess, "supervisor")
+	require.NoError(t, err)
+	assert.Equal(t, "g", plan.Goal)
+	assert.Equal(t, PlanStatusRunning, plan.Status)
+	require.Len(t, plan.Steps, 1)
+
+	sess.State[PlanStateKey("supervisor")] = []byte("{")
+	_, err = LoadPlan(sess, "supervisor")
+	assert.Error(t, err)
+	assert.Equal(t, "plan:supervisor", PlanStateKey("supervisor"))
+}
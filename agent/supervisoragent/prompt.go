@@ -0,0 +1,111 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package supervisoragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const planFormat = `Reply with a JSON object only, of the form:
+{"steps": [{"id": "s1", "description": "what to do", "agent": "agent name", "dependsOn": []},
+           {"id": "s2", "description": "what to do", "tool": "tool name", "arguments": {}, "dependsOn": ["s1"]}]}
+Each step is run either by one agent, receiving the description and the results of the steps it depends on,
+or by one tool, called with the given arguments. Steps without dependencies between them run in parallel,
+so only list the dependencies a step really needs.`
+
+// plannerPrompt returns the system prompt of the planner model.
+func (a *SupervisorAgent) plannerPrompt() string {
+	var b strings.Builder
+	b.WriteString("You are a supervisor breaking down a request into steps run by other agents and tools.\n\n")
+	if len(a.subAgents) > 0 {
+		b.WriteString("Agents:\n")
+		for _, sub := range a.subAgents {
+			info := sub.Info()
+			fmt.Fprintf(&b, "- %s: %s\n", info.Name, info.Description)
+		}
+		b.WriteString("\n")
+	}
+	if len(a.tools) > 0 {
+		b.WriteString("Tools:\n")
+		for _, t := range a.tools {
+			decl := t.Declaration()
+			fmt.Fprintf(&b, "- %s: %s", decl.Name, decl.Description)
+			if decl.InputSchema != nil {
+				if schema, err := json.Marshal(decl.InputSchema); err == nil {
+					fmt.Fprintf(&b, " Arguments schema: %s", schema)
+				}
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if a.instruction != "" {
+		b.WriteString(a.instruction)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(planFormat)
+	return b.String()
+}
+
+// replanPrompt returns the request asking the planner model to replace the
+// steps of plan that are not completed.
+func replanPrompt(plan *Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Request:\n%s\n\n", plan.Goal)
+	b.WriteString("The plan below did not go as expected.\n\n")
+	for _, s := range plan.Steps {
+		fmt.Fprintf(&b, "- %s (%s) [%s]: %s\n", s.ID, runner(s), s.Status, s.Description)
+		switch s.Status {
+		case StepStatusCompleted:
+			fmt.Fprintf(&b, "  result: %s\n", s.Result)
+		case StepStatusFailed:
+			fmt.Fprintf(&b, "  error: %s\n", s.Error)
+		}
+	}
+	b.WriteString("\nPlan the remaining work. The completed steps are kept and can be listed in dependsOn; " +
+		"list only new steps, with new ids.")
+	return b.String()
+}
+
+// stepInput returns the message sent to the agent running step.
+func stepInput(plan *Plan, step *Step) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Overall request:\n%s\n\nYour task:\n%s\n", plan.Goal, step.Description)
+	if len(step.DependsOn) > 0 {
+		b.WriteString("\nResults of previous steps:\n")
+		for _, dep := range step.DependsOn {
+			if d := plan.step(dep); d != nil {
+				fmt.Fprintf(&b, "- %s: %s\n", d.Description, d.Result)
+			}
+		}
+	}
+	return b.String()
+}
+
+// synthesisPrompt returns the request asking the model for the final
+// answer.
+func synthesisPrompt(plan *Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Request:\n%s\n\nResults of the steps run to answer it:\n", plan.Goal)
+	for _, s := range plan.Steps {
+		fmt.Fprintf(&b, "- %s: %s\n", s.Description, s.Result)
+	}
+	b.WriteString("\nWrite the final answer to the request from these results.")
+	return b.String()
+}
+
+func runner(s *Step) string {
+	if s.Tool != "" {
+		return "tool " + s.Tool
+	}
+	return "agent " + s.Agent
+}
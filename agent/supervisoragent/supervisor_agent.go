@@ -0,0 +1,653 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package supervisoragent provides a plan-and-execute agent.
+//
+// A SupervisorAgent works in stages. A model first turns the request into
+// an explicit plan of steps, each run by a sub-agent or a tool. The steps
+// then run as soon as the steps they depend on are completed, in parallel
+// when possible. When a step fails, or its result is rejected by the step
+// validator, the model replans the remaining work. Once every step is
+// completed, the model writes the final answer from their results.
+//
+// The plan and its progress are kept in the session state under
+// PlanStateKey, updated by events with object model.ObjectTypePlanUpdate,
+// so that a UI can render them. A run interrupted before the plan is
+// completed resumes it when the agent is run again with the same request,
+// or with an empty message.
+package supervisoragent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	itelemetry "trpc.group/trpc-go/trpc-agent-go/internal/telemetry"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/trace"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+const (
+	defaultChannelBufferSize = 256
+	defaultMaxReplans        = 2
+	defaultMaxParallelSteps  = 4
+	// maxPlanAttempts bounds the model calls producing a valid plan.
+	maxPlanAttempts = 3
+)
+
+// StepValidator checks the result of a step. An error marks the step as
+// failed, which triggers a replan, for instance when the result diverges
+// from what the plan expects.
+type StepValidator func(ctx context.Context, step Step) error
+
+// SupervisorAgent is an agent that plans a request, runs the steps of the
+// plan with its sub-agents and tools, and answers from their results.
+type SupervisorAgent struct {
+	name        string
+	description string
+	model       model.Model
+	instruction string
+	subAgents   []agent.Agent
+	tools       []tool.Tool
+	// confirmedTools are the names of the tools requiring confirmation,
+	// which steps cannot use.
+	confirmedTools    []string
+	maxReplans        int
+	maxParallelSteps  int
+	validator         StepValidator
+	channelBufferSize int
+	agentCallbacks    *agent.Callbacks
+	modelCallbacks    *model.Callbacks
+}
+
+// Option configures SupervisorAgent settings using the functional options
+// pattern.
+type Option func(*Options)
+
+// Options contains all configuration options for SupervisorAgent.
+type Options struct {
+	description       string
+	model             model.Model
+	instruction       string
+	subAgents         []agent.Agent
+	tools             []tool.Tool
+	maxReplans        int
+	maxParallelSteps  int
+	validator         StepValidator
+	channelBufferSize int
+	agentCallbacks    *agent.Callbacks
+	modelCallbacks    *model.Callbacks
+}
+
+// WithDescription sets the description of the agent.
+func WithDescription(description string) Option {
+	return func(o *Options) { o.description = description }
+}
+
+// WithModel sets the model planning, replanning and answering.
+func WithModel(m model.Model) Option {
+	return func(o *Options) { o.model = m }
+}
+
+// WithInstruction adds guidance to the planning prompt, such as the
+// preferred granularity of the steps.
+func WithInstruction(instruction string) Option {
+	return func(o *Options) { o.instruction = instruction }
+}
+
+// WithSubAgents sets the agents steps can be assigned to. They are
+// described to the planner by their Info().Description.
+func WithSubAgents(subAgents []agent.Agent) Option {
+	return func(o *Options) { o.subAgents = subAgents }
+}
+
+// WithTools sets the tools steps can be assigned to. Only callable tools
+// are used. Tools whose calls require confirmation are not offered to the
+// planner and steps using them are rejected, since steps are not paused for
+// approval; give them to a sub-agent instead.
+func WithTools(tools []tool.Tool) Option {
+	return func(o *Options) { o.tools = tools }
+}
+
+// WithMaxReplans sets how many times the remaining work can be replanned
+// after a failed step. Defaults to 2; 0 fails the plan at the first failed
+// step.
+func WithMaxReplans(n int) Option {
+	return func(o *Options) { o.maxReplans = n }
+}
+
+// WithMaxParallelSteps bounds the steps running at the same time. Defaults
+// to 4; 0 or less removes the bound.
+func WithMaxParallelSteps(n int) Option {
+	return func(o *Options) { o.maxParallelSteps = n }
+}
+
+// WithStepValidator sets the check of the step results.
+func WithStepValidator(v StepValidator) Option {
+	return func(o *Options) { o.validator = v }
+}
+
+// WithChannelBufferSize sets the buffer size for the event channel.
+// Default is 256 if not specified.
+func WithChannelBufferSize(size int) Option {
+	return func(o *Options) { o.channelBufferSize = size }
+}
+
+// WithAgentCallbacks attaches lifecycle callbacks to the supervisor agent.
+func WithAgentCallbacks(cb *agent.Callbacks) Option {
+	return func(o *Options) { o.agentCallbacks = cb }
+}
+
+// WithModelCallbacks sets the callbacks run around the planning, replanning
+// and answering model calls.
+func WithModelCallbacks(cb *model.Callbacks) Option {
+	return func(o *Options) { o.modelCallbacks = cb }
+}
+
+// New creates a new SupervisorAgent with the given name and options.
+func New(name string, opts ...Option) *SupervisorAgent {
+	cfg := Options{
+		maxReplans:        defaultMaxReplans,
+		maxParallelSteps:  defaultMaxParallelSteps,
+		channelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.channelBufferSize <= 0 {
+		cfg.channelBufferSize = defaultChannelBufferSize
+	}
+	var tools []tool.Tool
+	var confirmedTools []string
+	for _, t := range cfg.tools {
+		if _, ok := t.(tool.CallableTool); !ok {
+			continue
+		}
+		if r, ok := t.(tool.ConfirmationRequirer); ok && r.RequiresConfirmation() {
+			confirmedTools = append(confirmedTools, t.Declaration().Name)
+			continue
+		}
+		tools = append(tools, t)
+	}
+	return &SupervisorAgent{
+		name:              name,
+		description:       cfg.description,
+		model:             cfg.model,
+		instruction:       cfg.instruction,
+		subAgents:         cfg.subAgents,
+		tools:             tools,
+		confirmedTools:    confirmedTools,
+		maxReplans:        cfg.maxReplans,
+		maxParallelSteps:  cfg.maxParallelSteps,
+		validator:         cfg.validator,
+		channelBufferSize: cfg.channelBufferSize,
+		agentCallbacks:    cfg.agentCallbacks,
+		modelCallbacks:    cfg.modelCallbacks,
+	}
+}
+
+// Run implements the agent.Agent interface.
+func (a *SupervisorAgent) Run(ctx context.Context, invocation *agent.Invocation) (<-chan *event.Event, error) {
+	if a.model == nil {
+		return nil, errors.New("supervisor agent requires a model")
+	}
+	eventChan := make(chan *event.Event, a.channelBufferSize)
+	go func() {
+		defer close(eventChan)
+		a.executeSupervisorRun(ctx, invocation, eventChan)
+	}()
+	return eventChan, nil
+}
+
+// executeSupervisorRun handles the main execution logic for supervisor agent.
+func (a *SupervisorAgent) executeSupervisorRun(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) {
+	ctx, span := trace.Tracer.Start(ctx, fmt.Sprintf("%s %s", itelemetry.OperationInvokeAgent, a.name))
+	itelemetry.TraceBeforeInvokeAgent(span, invocation, a.description, "", nil)
+	defer span.End()
+
+	invocation.Agent = a
+	invocation.AgentName = a.name
+
+	if a.handleBeforeAgentCallbacks(ctx, invocation, eventChan) {
+		return
+	}
+
+	e := a.run(ctx, invocation, eventChan)
+	if a.agentCallbacks != nil {
+		e = a.handleAfterAgentCallbacks(ctx, invocation, eventChan)
+	}
+	itelemetry.TraceAfterInvokeAgent(span, e)
+}
+
+// run plans or resumes the plan of the invocation, runs it and answers. It
+// returns the last event.
+func (a *SupervisorAgent) run(ctx context.Context, invocation *agent.Invocation, eventChan chan<- *event.Event) *event.Event {
+	plan, err := LoadPlan(invocation.Session, a.name)
+	if err != nil {
+		log.Warnf("Supervisor agent %q ignores its stored plan: %v", a.name, err)
+	}
+	goal := strings.TrimSpace(invocation.Message.Content)
+	if plan != nil && plan.Status == PlanStatusRunning && (goal == "" || goal == plan.Goal) {
+		plan.resume()
+	} else {
+		if goal == "" {
+			return a.fail(ctx, invocation, eventChan, nil, "no request to plan")
+		}
+		plan = &Plan{Goal: goal, Status: PlanStatusRunning}
+		steps, err := a.plan(ctx, plan, model.NewUserMessage(goal))
+		if err != nil {
+			return a.fail(ctx, invocation, eventChan, plan, fmt.Sprintf("planning failed: %v", err))
+		}
+		plan.Steps = steps
+	}
+	if !a.update(ctx, invocation, eventChan, plan) {
+		return nil
+	}
+
+	for {
+		if err := a.execute(ctx, invocation, eventChan, plan); err != nil {
+			return nil
+		}
+		failed := plan.failed()
+		if failed == nil && plan.completed() {
+			break
+		}
+		if failed == nil {
+			// Only a stored plan could leave steps that can never run.
+			return a.fail(ctx, invocation, eventChan, plan, "the plan has steps that cannot run")
+		}
+		if plan.Revision >= a.maxReplans {
+			return a.fail(ctx, invocation, eventChan, plan,
+				fmt.Sprintf("step %s failed: %s", failed.ID, failed.Error))
+		}
+		steps, err := a.plan(ctx, plan, model.NewUserMessage(replanPrompt(plan)))
+		if err != nil {
+			return a.fail(ctx, invocation, eventChan, plan, fmt.Sprintf("replanning failed: %v", err))
+		}
+		plan.replace(steps)
+		if !a.update(ctx, invocation, eventChan, plan) {
+			return nil
+		}
+	}
+	return a.answer(ctx, invocation, eventChan, plan)
+}
+
+// plan asks the model for the steps answering request, retrying on invalid
+// plans.
+func (a *SupervisorAgent) plan(ctx context.Context, plan *Plan, request model.Message) ([]*Step, error) {
+	agents := make(map[string]bool, len(a.subAgents))
+	for _, sub := range a.subAgents {
+		agents[sub.Info().Name] = true
+	}
+	tools := make(map[string]bool, len(a.tools)+len(a.confirmedTools))
+	for _, t := range a.tools {
+		tools[t.Declaration().Name] = true
+	}
+	for _, name := range a.confirmedTools {
+		tools[name] = false
+	}
+	messages := []model.Message{model.NewSystemMessage(a.plannerPrompt()), request}
+	var err error
+	for attempt := 0; attempt < maxPlanAttempts; attempt++ {
+		var answer string
+		answer, err = a.generate(ctx, messages)
+		if err != nil {
+			return nil, err
+		}
+		var steps []*Step
+		if steps, err = parseSteps(answer, agents, tools, plan); err == nil {
+			return steps, nil
+		}
+		log.Debugf("Supervisor agent %q got an invalid plan: %v", a.name, err)
+		messages = append(messages,
+			model.NewAssistantMessage(answer),
+			model.NewUserMessage(fmt.Sprintf("The plan is invalid: %v. Reply with a corrected plan.", err)),
+		)
+	}
+	return nil, err
+}
+
+// stepOutcome is the outcome of a step run.
+type stepOutcome struct {
+	step   *Step
+	result string
+	err    error
+}
+
+// execute runs the steps of plan as soon as their dependencies are
+// completed, until every step is completed or one fails. It only returns an
+// error when the context is cancelled.
+func (a *SupervisorAgent) execute(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	plan *Plan,
+) error {
+	outcomes := make(chan stepOutcome)
+	running := 0
+	stop := false
+	for {
+		started := false
+		for _, step := range plan.ready() {
+			if stop || (a.maxParallelSteps > 0 && running >= a.maxParallelSteps) {
+				break
+			}
+			step.Status = StepStatusRunning
+			running++
+			started = true
+			// The goroutine gets a copy, as the plan is only changed here.
+			go func(step *Step, copied Step, input string) {
+				result, err := a.runStep(ctx, invocation, eventChan, copied, input)
+				outcomes <- stepOutcome{step: step, result: result, err: err}
+			}(step, *step, stepInput(plan, step))
+		}
+		if started && !a.update(ctx, invocation, eventChan, plan) {
+			stop = true
+		}
+		if running == 0 {
+			return agent.CheckContextCancelled(ctx)
+		}
+
+		o := <-outcomes
+		running--
+		if o.err == nil && a.validator != nil {
+			checked := *o.step
+			checked.Result = o.result
+			o.err = a.validator(ctx, checked)
+		}
+		if o.err != nil {
+			o.step.Status, o.step.Error = StepStatusFailed, o.err.Error()
+			// Let the running steps finish, but start no new step.
+			stop = true
+		} else {
+			o.step.Status, o.step.Result = StepStatusCompleted, o.result
+		}
+		if !a.update(ctx, invocation, eventChan, plan) {
+			stop = true
+		}
+	}
+}
+
+// runStep runs step with its agent or tool and returns its result.
+func (a *SupervisorAgent) runStep(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	step Step,
+	input string,
+) (string, error) {
+	if step.Tool != "" {
+		return a.callTool(ctx, step)
+	}
+	sub := a.FindSubAgent(step.Agent)
+	if sub == nil {
+		return "", fmt.Errorf("unknown agent %q", step.Agent)
+	}
+	// Each step has its own history, outside the branch of the supervisor,
+	// so that the agent answers its input rather than the user message.
+	filterKey := step.Agent + "-" + uuid.NewString()
+	subInvocation := invocation.Clone(
+		agent.WithInvocationAgent(sub),
+		agent.WithInvocationMessage(model.NewUserMessage(input)),
+		agent.WithInvocationEventFilterKey(filterKey),
+	)
+	subEventChan, err := sub.Run(agent.NewInvocationContext(ctx, subInvocation), subInvocation)
+	if err != nil {
+		return "", err
+	}
+	var result string
+	var stepErr error
+	for e := range subEventChan {
+		if e != nil && e.Response != nil {
+			if e.Error != nil {
+				stepErr = errors.New(e.Error.Message)
+			} else if msg, ok := finalMessage(e.Response); ok {
+				result = msg
+			}
+		}
+		if err := event.EmitEvent(ctx, eventChan, e); err != nil {
+			return "", err
+		}
+	}
+	if stepErr != nil {
+		return "", stepErr
+	}
+	if result == "" {
+		return "", fmt.Errorf("agent %s gave no answer", step.Agent)
+	}
+	return result, nil
+}
+
+// callTool calls the tool of step with its arguments.
+func (a *SupervisorAgent) callTool(ctx context.Context, step Step) (string, error) {
+	for _, t := range a.tools {
+		if t.Declaration().Name != step.Tool {
+			continue
+		}
+		args := []byte(step.Arguments)
+		if len(args) == 0 {
+			args = []byte("{}")
+		}
+		out, err := t.(tool.CallableTool).Call(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := out.(string); ok {
+			return s, nil
+		}
+		b, err := json.Marshal(out)
+		if err != nil {
+			return "", fmt.Errorf("marshal result of tool %s: %w", step.Tool, err)
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("unknown tool %q", step.Tool)
+}
+
+// answer asks the model for the final answer and completes the plan.
+func (a *SupervisorAgent) answer(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	plan *Plan,
+) *event.Event {
+	answer, err := a.generate(ctx, []model.Message{model.NewUserMessage(synthesisPrompt(plan))})
+	if err != nil {
+		return a.fail(ctx, invocation, eventChan, plan, fmt.Sprintf("answering failed: %v", err))
+	}
+	plan.Status, plan.Answer = PlanStatusCompleted, answer
+	if !a.update(ctx, invocation, eventChan, plan) {
+		return nil
+	}
+	rsp := &model.Response{
+		Object: model.ObjectTypeChatCompletion,
+		Model:  a.model.Info().Name,
+		Done:   true,
+		Choices: []model.Choice{{
+			Index:   0,
+			Message: model.NewAssistantMessage(answer),
+		}},
+	}
+	e := event.NewResponseEvent(invocation.InvocationID, a.name, rsp)
+	agent.EmitEvent(ctx, invocation, eventChan, e)
+	return e
+}
+
+// fail marks plan as failed, if any, and emits the error.
+func (a *SupervisorAgent) fail(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	plan *Plan,
+	reason string,
+) *event.Event {
+	log.Warnf("Supervisor agent %q: %s", a.name, reason)
+	if plan != nil {
+		plan.Status, plan.Error = PlanStatusFailed, reason
+		a.update(ctx, invocation, eventChan, plan)
+	}
+	e := event.NewErrorEvent(invocation.InvocationID, a.name, model.ErrorTypePlanFailed, reason)
+	agent.EmitEvent(ctx, invocation, eventChan, e)
+	return e
+}
+
+// update stores plan in the session state. It returns false when the event
+// cannot be emitted.
+func (a *SupervisorAgent) update(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+	plan *Plan,
+) bool {
+	state, err := json.Marshal(plan)
+	if err != nil {
+		log.Errorf("Supervisor agent %q failed to marshal its plan: %v", a.name, err)
+		return true
+	}
+	e := event.New(
+		invocation.InvocationID,
+		a.name,
+		event.WithObject(model.ObjectTypePlanUpdate),
+		event.WithStateDelta(map[string][]byte{PlanStateKey(a.name): state}),
+	)
+	return agent.EmitEvent(ctx, invocation, eventChan, e) == nil
+}
+
+// generate calls the model and returns the content of its answer. The call
+// counts against the budget of the run.
+func (a *SupervisorAgent) generate(ctx context.Context, messages []model.Message) (string, error) {
+	responses, err := budget.Generate(ctx, a.model, a.modelCallbacks, &model.Request{
+		Messages:         messages,
+		GenerationConfig: model.GenerationConfig{Stream: false},
+	})
+	if err != nil {
+		return "", err
+	}
+	var answer string
+	for _, rsp := range responses {
+		if rsp.Error != nil {
+			return "", errors.New(rsp.Error.Message)
+		}
+		if len(rsp.Choices) > 0 {
+			answer += rsp.Choices[0].Message.Content
+		}
+	}
+	if strings.TrimSpace(answer) == "" {
+		return "", errors.New("empty answer from the model")
+	}
+	return answer, nil
+}
+
+// finalMessage returns the content of a final assistant answer.
+func finalMessage(rsp *model.Response) (string, bool) {
+	if rsp.IsPartial || len(rsp.Choices) == 0 || rsp.IsToolCallResponse() {
+		return "", false
+	}
+	msg := rsp.Choices[0].Message
+	if msg.Role != model.RoleAssistant || msg.Content == "" {
+		return "", false
+	}
+	return msg.Content, true
+}
+
+// handleBeforeAgentCallbacks handles pre-execution callbacks.
+func (a *SupervisorAgent) handleBeforeAgentCallbacks(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) bool {
+	if a.agentCallbacks == nil {
+		return false
+	}
+	customResponse, err := a.agentCallbacks.RunBeforeAgent(ctx, invocation)
+	if err != nil {
+		agent.EmitEvent(ctx, invocation, eventChan, event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			agent.ErrorTypeAgentCallbackError,
+			err.Error(),
+		))
+		return true
+	}
+	if customResponse != nil {
+		agent.EmitEvent(ctx, invocation, eventChan, event.NewResponseEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			customResponse,
+		))
+		return true
+	}
+	return false
+}
+
+// handleAfterAgentCallbacks handles post-execution callbacks.
+func (a *SupervisorAgent) handleAfterAgentCallbacks(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) *event.Event {
+	customResponse, err := a.agentCallbacks.RunAfterAgent(ctx, invocation, nil)
+	var evt *event.Event
+	if err != nil {
+		evt = event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			agent.ErrorTypeAgentCallbackError,
+			err.Error(),
+		)
+	} else if customResponse != nil {
+		evt = event.NewResponseEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			customResponse,
+		)
+	}
+	agent.EmitEvent(ctx, invocation, eventChan, evt)
+	return evt
+}
+
+// Tools implements the agent.Agent interface.
+func (a *SupervisorAgent) Tools() []tool.Tool {
+	return a.tools
+}
+
+// Info implements the agent.Agent interface.
+func (a *SupervisorAgent) Info() agent.Info {
+	return agent.Info{Name: a.name, Description: a.description}
+}
+
+// SubAgents implements the agent.Agent interface.
+func (a *SupervisorAgent) SubAgents() []agent.Agent {
+	return a.subAgents
+}
+
+// FindSubAgent implements the agent.Agent interface.
+func (a *SupervisorAgent) FindSubAgent(name string) agent.Agent {
+	for _, sub := range a.subAgents {
+		if sub.Info().Name == name {
+			return sub
+		}
+	}
+	return nil
+}
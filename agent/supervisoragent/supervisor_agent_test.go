@@ -0,0 +1,363 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package supervisoragent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	agentrunner "trpc.group/trpc-go/trpc-agent-go/runner"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+// stepAgent answers its input with a fixed reply. When barrier is set, it
+// waits for the other agents sharing it before answering.
+type stepAgent struct {
+	name    string
+	reply   string
+	barrier *sync.WaitGroup
+
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (a *stepAgent) Run(ctx context.Context, inv *agent.Invocation) (<-chan *event.Event, error) {
+	a.mu.Lock()
+	a.inputs = append(a.inputs, inv.Message.Content)
+	a.mu.Unlock()
+	ch := make(chan *event.Event, 1)
+	go func() {
+		defer close(ch)
+		reply := a.reply
+		if a.barrier != nil {
+			a.barrier.Done()
+			done := make(chan struct{})
+			go func() { a.barrier.Wait(); close(done) }()
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				reply = "ran alone"
+			}
+		}
+		ch <- event.NewResponseEvent(inv.InvocationID, a.name, &model.Response{
+			Done:    true,
+			Choices: []model.Choice{{Message: model.NewAssistantMessage(reply)}},
+		})
+	}()
+	return ch, nil
+}
+
+func (a *stepAgent) Tools() []tool.Tool { return nil }
+func (a *stepAgent) Info() agent.Info {
+	return agent.Info{Name: a.name, Description: "Agent " + a.name}
+}
+func (a *stepAgent) SubAgents() []agent.Agent        { return nil }
+func (a *stepAgent) FindSubAgent(string) agent.Agent { return nil }
+
+type sumInput struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func sumTool() tool.Tool {
+	return function.NewFunctionTool(func(_ context.Context, in sumInput) (int, error) {
+		return in.A + in.B, nil
+	}, function.WithName("sum"), function.WithDescription("Adds a and b."))
+}
+
+func planJSON(steps ...map[string]any) string {
+	b, _ := json.Marshal(map[string]any{"steps": steps})
+	return string(b)
+}
+
+func storedPlan(t *testing.T, h *agenttest.Harness, name string) *Plan {
+	t.Helper()
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	plan, err := LoadPlan(sess, name)
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+	return plan
+}
+
+func TestSupervisorAgent_RunsIndependentStepsInParallel(t *testing.T) {
+	barrier := &sync.WaitGroup{}
+	barrier.Add(2)
+	flights := &stepAgent{name: "flights", reply: "flight at 9am", barrier: barrier}
+	hotels := &stepAgent{name: "hotels", reply: "hotel by the sea", barrier: barrier}
+	writer := &stepAgent{name: "writer", reply: "itinerary written"}
+	m := agenttest.NewModel(
+		agenttest.Reply("Here is the plan:\n"+planJSON(
+			map[string]any{"id": "s1", "description": "find a flight", "agent": "flights"},
+			map[string]any{"id": "s2", "description": "find a hotel", "agent": "hotels"},
+			map[string]any{"id": "s3", "description": "count nights", "tool": "sum",
+				"arguments": map[string]int{"a": 2, "b": 1}},
+			map[string]any{"id": "s4", "description": "write the itinerary", "agent": "writer",
+				"dependsOn": []string{"s1", "s2", "s3"}},
+		)),
+		agenttest.Reply("Your trip is ready."),
+	)
+	supervisor := New("supervisor",
+		WithModel(m),
+		WithSubAgents([]agent.Agent{flights, hotels, writer}),
+		WithTools([]tool.Tool{sumTool()}),
+		WithInstruction("Prefer few steps."),
+	)
+	h := agenttest.NewHarness(supervisor)
+
+	tr := h.MustRun(t, "Plan a trip to Nice")
+
+	agenttest.AssertNoErrors(t, tr)
+	agenttest.AssertFinalResponse(t, tr, "Your trip is ready.")
+	plan := storedPlan(t, h, "supervisor")
+	assert.Equal(t, PlanStatusCompleted, plan.Status)
+	assert.Equal(t, "Plan a trip to Nice", plan.Goal)
+	assert.Equal(t, "Your trip is ready.", plan.Answer)
+	require.Len(t, plan.Steps, 4)
+	assert.Equal(t, "flight at 9am", plan.Steps[0].Result, "the independent steps ran together")
+	assert.Equal(t, "hotel by the sea", plan.Steps[1].Result)
+	assert.Equal(t, "3", plan.Steps[2].Result)
+	for _, s := range plan.Steps {
+		assert.Equal(t, StepStatusCompleted, s.Status)
+	}
+
+	require.Len(t, writer.inputs, 1)
+	assert.Contains(t, writer.inputs[0], "write the itinerary")
+	assert.Contains(t, writer.inputs[0], "find a flight: flight at 9am")
+	assert.Contains(t, writer.inputs[0], "count nights: 3")
+
+	reqs := m.Requests()
+	require.Len(t, reqs, 2)
+	system := reqs[0].Messages[0].Content
+	assert.Contains(t, system, "- flights: Agent flights")
+	assert.Contains(t, system, "- sum: Adds a and b.")
+	assert.Contains(t, system, "Prefer few steps.")
+	assert.Contains(t, reqs[1].Messages[0].Content, "write the itinerary: itinerary written")
+
+	updates := tr.Filter(func(e *event.Event) bool { return e.Object == model.ObjectTypePlanUpdate })
+	assert.Greater(t, len(updates), 4, "every change of the plan is reported")
+}
+
+func TestSupervisorAgent_RetriesInvalidPlan(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "search", "agent": "unknown"})),
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "search", "agent": "search"})),
+		agenttest.Reply("done"),
+	)
+	supervisor := New("supervisor",
+		WithModel(m),
+		WithSubAgents([]agent.Agent{&stepAgent{name: "search", reply: "found"}}),
+	)
+
+	tr := agenttest.NewHarness(supervisor).MustRun(t, "find it")
+
+	agenttest.AssertFinalResponse(t, tr, "done")
+	retry := m.Requests()[1].Messages
+	require.Len(t, retry, 4)
+	assert.Contains(t, retry[3].Content, `unknown agent "unknown"`)
+}
+
+func TestSupervisorAgent_RejectsToolsRequiringConfirmation(t *testing.T) {
+	var deleted []string
+	rm := function.NewFunctionTool(func(_ context.Context, in struct {
+		Path string `json:"path"`
+	}) (string, error) {
+		deleted = append(deleted, in.Path)
+		return "deleted", nil
+	}, function.WithName("rm"), function.WithDescription("Deletes a file."), function.WithRequireConfirmation(true))
+	m := agenttest.NewModel(
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "delete", "tool": "rm", "arguments": map[string]any{"path": "/etc"}})),
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "search", "agent": "search"})),
+		agenttest.Reply("done"),
+	)
+	supervisor := New("supervisor",
+		WithModel(m),
+		WithSubAgents([]agent.Agent{&stepAgent{name: "search", reply: "found"}}),
+		WithTools([]tool.Tool{sumTool(), rm}),
+	)
+
+	tr := agenttest.NewHarness(supervisor).MustRun(t, "delete /etc")
+
+	agenttest.AssertFinalResponse(t, tr, "done")
+	assert.Empty(t, deleted, "a plan step never runs a tool requiring confirmation")
+	prompt := m.Requests()[0].Messages[0].Content
+	assert.Contains(t, prompt, "sum")
+	assert.NotContains(t, prompt, "- rm:")
+	retry := m.Requests()[1].Messages
+	assert.Contains(t, retry[len(retry)-1].Content, `tool "rm", which requires confirmation`)
+}
+
+func TestSupervisorAgent_Budget(t *testing.T) {
+	plan := agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "search", "agent": "search"}))
+	plan.Usage = &model.Usage{PromptTokens: 50, CompletionTokens: 10, TotalTokens: 60}
+	m := agenttest.NewModel(plan, agenttest.Reply("unreachable"))
+	var calls int
+	search := &stepAgent{name: "search", reply: "found"}
+	supervisor := New("supervisor",
+		WithModel(m),
+		WithSubAgents([]agent.Agent{search}),
+		WithModelCallbacks(model.NewCallbacks().RegisterBeforeModel(
+			func(context.Context, *model.Request) (*model.Response, error) {
+				calls++
+				return nil, nil
+			})),
+	)
+	h := agenttest.NewHarness(supervisor)
+	b := budget.New(budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 50}))
+	h.Runner = agentrunner.NewRunner(h.AppName, supervisor,
+		agentrunner.WithSessionService(h.SessionService), agentrunner.WithBudget(b))
+
+	tr := h.MustRun(t, "find it")
+
+	errs := tr.Errors()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0], "budget exceeded for invocation")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, m.Remaining(), "the plan is not run once the budget is exceeded")
+	assert.Equal(t, PlanStatusFailed, storedPlan(t, h, "supervisor").Status)
+}
+
+func TestSupervisorAgent_ReplansFailedStep(t *testing.T) {
+	flaky := llmagent.New("flaky",
+		llmagent.WithDescription("Sometimes fails."),
+		llmagent.WithModel(agenttest.NewModel(agenttest.Fail("service unavailable"))),
+	)
+	backupModel := agenttest.NewModel(agenttest.Reply("backup result"))
+	backup := llmagent.New("backup", llmagent.WithDescription("Always works."), llmagent.WithModel(backupModel))
+	m := agenttest.NewModel(
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "fetch data", "agent": "flaky"})),
+		agenttest.Reply(planJSON(map[string]any{"id": "s2", "description": "fetch data again", "agent": "backup"})),
+		agenttest.Reply("answer from backup"),
+	)
+	supervisor := New("supervisor", WithModel(m), WithSubAgents([]agent.Agent{flaky, backup}))
+	h := agenttest.NewHarness(supervisor)
+
+	tr := h.MustRun(t, "get the data")
+
+	agenttest.AssertFinalResponse(t, tr, "answer from backup")
+	replan := m.Requests()[1].Messages[1].Content
+	assert.Contains(t, replan, "s1 (agent flaky) [failed]")
+	assert.Contains(t, replan, "service unavailable")
+	plan := storedPlan(t, h, "supervisor")
+	assert.Equal(t, PlanStatusCompleted, plan.Status)
+	assert.Equal(t, 1, plan.Revision)
+	require.Len(t, plan.Steps, 1)
+	assert.Equal(t, "s2", plan.Steps[0].ID)
+	assert.Equal(t, "backup result", plan.Steps[0].Result)
+	require.Len(t, backupModel.Requests(), 1)
+	msgs := backupModel.Requests()[0].Messages
+	assert.Contains(t, msgs[len(msgs)-1].Content, "Your task:\nfetch data again",
+		"the step agent answers its input, not the user message")
+}
+
+func TestSupervisorAgent_ValidatorFailsPlanWithoutReplans(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "estimate", "agent": "estimator"})),
+	)
+	var checked []string
+	supervisor := New("supervisor",
+		WithModel(m),
+		WithSubAgents([]agent.Agent{&stepAgent{name: "estimator", reply: "-5"}}),
+		WithMaxReplans(0),
+		WithStepValidator(func(_ context.Context, step Step) error {
+			checked = append(checked, step.Result)
+			if strings.HasPrefix(step.Result, "-") {
+				return errors.New("negative estimate")
+			}
+			return nil
+		}),
+	)
+	h := agenttest.NewHarness(supervisor)
+
+	tr := h.MustRun(t, "estimate the cost")
+
+	assert.Equal(t, []string{"-5"}, checked)
+	errs := tr.Filter(func(e *event.Event) bool { return e.Error != nil })
+	require.Len(t, errs, 1)
+	assert.Equal(t, model.ErrorTypePlanFailed, errs[0].Error.Type)
+	assert.Contains(t, errs[0].Error.Message, "negative estimate")
+	plan := storedPlan(t, h, "supervisor")
+	assert.Equal(t, PlanStatusFailed, plan.Status)
+	assert.Equal(t, StepStatusFailed, plan.Steps[0].Status)
+	assert.Empty(t, plan.Steps[0].Result)
+	assert.Equal(t, 0, m.Remaining())
+}
+
+func TestSupervisorAgent_ResumesStoredPlan(t *testing.T) {
+	interrupted := &Plan{
+		Goal:   "summarize the report",
+		Status: PlanStatusRunning,
+		Steps: []*Step{
+			{ID: "s1", Description: "read the report", Agent: "reader", Status: StepStatusCompleted, Result: "42 pages"},
+			{ID: "s2", Description: "summarize", Agent: "summarizer", DependsOn: []string{"s1"}, Status: StepStatusRunning},
+		},
+	}
+	state, err := json.Marshal(interrupted)
+	require.NoError(t, err)
+	reader := &stepAgent{name: "reader", reply: "read again"}
+	summarizer := &stepAgent{name: "summarizer", reply: "short summary"}
+	m := agenttest.NewModel(agenttest.Reply("The report is 42 pages long."))
+	h := agenttest.NewHarness(New("supervisor", WithModel(m), WithSubAgents([]agent.Agent{reader, summarizer})))
+	_, err = h.SessionService.CreateSession(context.Background(),
+		session.Key{AppName: h.AppName, UserID: h.UserID, SessionID: h.SessionID},
+		session.StateMap{PlanStateKey("supervisor"): state},
+	)
+	require.NoError(t, err)
+
+	tr := h.MustRun(t, "")
+
+	agenttest.AssertFinalResponse(t, tr, "The report is 42 pages long.")
+	assert.Empty(t, reader.inputs, "completed steps are not run again")
+	require.Len(t, summarizer.inputs, 1)
+	assert.Contains(t, summarizer.inputs[0], "read the report: 42 pages")
+	require.Len(t, m.Requests(), 1, "the stored plan is not planned again")
+	assert.Equal(t, PlanStatusCompleted, storedPlan(t, h, "supervisor").Status)
+}
+
+func TestSupervisorAgent_NewRequestReplacesPlan(t *testing.T) {
+	m := agenttest.NewModel(
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "look up", "agent": "search"})),
+		agenttest.Reply("first"),
+		agenttest.Reply(planJSON(map[string]any{"id": "s1", "description": "look up again", "agent": "search"})),
+		agenttest.Reply("second"),
+	)
+	h := agenttest.NewHarness(New("supervisor",
+		WithModel(m),
+		WithSubAgents([]agent.Agent{&stepAgent{name: "search", reply: "found"}}),
+	))
+
+	agenttest.AssertFinalResponse(t, h.MustRun(t, "first question"), "first")
+	agenttest.AssertFinalResponse(t, h.MustRun(t, "second question"), "second")
+
+	plan := storedPlan(t, h, "supervisor")
+	assert.Equal(t, "second question", plan.Goal)
+	assert.Equal(t, "look up again", plan.Steps[0].Description)
+}
+
+func TestNew_RequiresModel(t *testing.T) {
+	_, err := New("supervisor").Run(context.Background(), &agent.Invocation{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package agent
+
+import (
+	"encoding/json"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// toolConfirmationStateKeyPrefix prefixes the session state key holding the
+// tool calls of an agent waiting for a human decision.
+const toolConfirmationStateKeyPrefix = "tool_confirmation:"
+
+// ToolConfirmationStateKey returns the session state key holding the tool
+// calls of the named agent waiting for a human decision.
+func ToolConfirmationStateKey(agentName string) string {
+	return toolConfirmationStateKeyPrefix + agentName
+}
+
+// ToolConfirmationState is the session state of the tool calls of an agent
+// paused until a human decides on them.
+type ToolConfirmationState struct {
+	// Calls are the paused tool calls.
+	Calls []event.ToolConfirmation `json:"calls"`
+	// Results are the results of the other calls of the same response. They
+	// are returned to the model along with the results of the paused calls.
+	Results []model.Choice `json:"results,omitempty"`
+}
+
+// LoadToolConfirmationState returns the tool calls of the named agent paused
+// in sess, or nil when none is.
+func LoadToolConfirmationState(sess *session.Session, agentName string) *ToolConfirmationState {
+	if sess == nil {
+		return nil
+	}
+	raw := sess.State[ToolConfirmationStateKey(agentName)]
+	if len(raw) == 0 {
+		return nil
+	}
+	var state ToolConfirmationState
+	if err := json.Unmarshal(raw, &state); err != nil || len(state.Calls) == 0 {
+		return nil
+	}
+	return &state
+}
+
+// ToolConfirmationDecision is a human decision on a tool call paused for
+// confirmation.
+type ToolConfirmationDecision struct {
+	// ToolCallID is the ID of the paused tool call.
+	ToolCallID string `json:"toolCallId"`
+	// Approved reports whether the tool may run.
+	Approved bool `json:"approved"`
+	// Arguments, when set, replace the arguments of an approved call.
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	// Reason explains a rejection to the model.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ApproveToolCall approves the paused tool call with the given ID.
+func ApproveToolCall(toolCallID string) ToolConfirmationDecision {
+	return ToolConfirmationDecision{ToolCallID: toolCallID, Approved: true}
+}
+
+// ApproveToolCallWithArguments approves the paused tool call with the given
+// ID, running it with args instead of the arguments chosen by the model.
+func ApproveToolCallWithArguments(toolCallID string, args []byte) ToolConfirmationDecision {
+	return ToolConfirmationDecision{ToolCallID: toolCallID, Approved: true, Arguments: args}
+}
+
+// RejectToolCall rejects the paused tool call with the given ID. The reason
+// is returned to the model as the tool result.
+func RejectToolCall(toolCallID, reason string) ToolConfirmationDecision {
+	return ToolConfirmationDecision{ToolCallID: toolCallID, Reason: reason}
+}
+
+// WithToolConfirmationDecisions resumes the tool calls paused for
+// confirmation by a previous run with decisions. Paused calls without a
+// decision are rejected. The decisions are only taken from this option,
+// never from the text of a message, so that they cannot be typed in by the
+// users of a chat; the message of the run is not added to the conversation.
+func WithToolConfirmationDecisions(decisions ...ToolConfirmationDecision) RunOption {
+	return func(opts *RunOptions) {
+		if decisions == nil {
+			decisions = []ToolConfirmationDecision{}
+		}
+		opts.ToolConfirmationDecisions = decisions
+	}
+}
@@ -0,0 +1,55 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+func TestWithToolConfirmationDecisions(t *testing.T) {
+	var opts RunOptions
+	assert.Nil(t, opts.ToolConfirmationDecisions)
+
+	WithToolConfirmationDecisions(
+		ApproveToolCall("call_1"),
+		ApproveToolCallWithArguments("call_2", []byte(`{"path":"/tmp"}`)),
+		RejectToolCall("call_3", "too risky"),
+	)(&opts)
+	assert.Equal(t, []ToolConfirmationDecision{
+		{ToolCallID: "call_1", Approved: true},
+		{ToolCallID: "call_2", Approved: true, Arguments: []byte(`{"path":"/tmp"}`)},
+		{ToolCallID: "call_3", Reason: "too risky"},
+	}, opts.ToolConfirmationDecisions)
+
+	WithToolConfirmationDecisions()(&opts)
+	assert.NotNil(t, opts.ToolConfirmationDecisions, "no decision still resumes the paused calls")
+	assert.Empty(t, opts.ToolConfirmationDecisions)
+}
+
+func TestLoadToolConfirmationState(t *testing.T) {
+	sess := &session.Session{State: session.StateMap{}}
+	assert.Nil(t, LoadToolConfirmationState(sess, "a"))
+	assert.Nil(t, LoadToolConfirmationState(nil, "a"))
+
+	sess.State[ToolConfirmationStateKey("a")] = []byte(`{"calls":[{"toolCallId":"call_1","toolName":"rm"}]}`)
+	state := LoadToolConfirmationState(sess, "a")
+	require.NotNil(t, state)
+	assert.Equal(t, []event.ToolConfirmation{{ToolCallID: "call_1", ToolName: "rm"}}, state.Calls)
+	assert.Nil(t, LoadToolConfirmationState(sess, "b"))
+
+	sess.State[ToolConfirmationStateKey("a")] = nil
+	assert.Nil(t, LoadToolConfirmationState(sess, "a"))
+}
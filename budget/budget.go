@@ -0,0 +1,368 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package budget accumulates token usage and cost of model calls and stops
+// agent execution when configured limits are exceeded.
+//
+// A Budget is attached to a runner with runner.WithBudget. Each run gets a
+// Tracker carried by the context, so sub-agents, AgentTool calls and graph
+// nodes all record into the same totals. Session totals are persisted in
+// session state under the budget state key.
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+)
+
+// DefaultStateKey is the session state key holding the session totals.
+const DefaultStateKey = "budget:totals"
+
+// Price is the cost of a model in currency units per million tokens.
+type Price struct {
+	// Prompt is the cost per million prompt tokens.
+	Prompt float64 `json:"prompt"`
+	// Completion is the cost per million completion tokens.
+	Completion float64 `json:"completion"`
+}
+
+// Cost returns the cost of usage.
+func (p Price) Cost(usage model.Usage) float64 {
+	return (float64(usage.PromptTokens)*p.Prompt + float64(usage.CompletionTokens)*p.Completion) / 1e6
+}
+
+// PriceTable maps model names to prices. Lookups are case-insensitive and
+// fall back to the longest entry that prefixes the model name, so an entry
+// "gpt-4o" also prices "gpt-4o-2024-08-06".
+type PriceTable map[string]Price
+
+// Lookup returns the price of a model.
+func (t PriceTable) Lookup(modelName string) (Price, bool) {
+	name := strings.ToLower(modelName)
+	best, found := "", false
+	var price Price
+	for k, p := range t {
+		key := strings.ToLower(k)
+		if key == name {
+			return p, true
+		}
+		if strings.HasPrefix(name, key) && len(key) > len(best) {
+			best, price, found = key, p, true
+		}
+	}
+	return price, found
+}
+
+// Limits bounds the usage of an invocation or a session. Zero values mean no
+// limit.
+type Limits struct {
+	MaxPromptTokens     int
+	MaxCompletionTokens int
+	MaxTotalTokens      int
+	MaxCost             float64
+}
+
+// exceeded returns a description of the first limit exceeded by totals.
+func (l Limits) exceeded(t Totals) string {
+	switch {
+	case l.MaxPromptTokens > 0 && t.PromptTokens > l.MaxPromptTokens:
+		return fmt.Sprintf("prompt tokens %d exceed limit %d", t.PromptTokens, l.MaxPromptTokens)
+	case l.MaxCompletionTokens > 0 && t.CompletionTokens > l.MaxCompletionTokens:
+		return fmt.Sprintf("completion tokens %d exceed limit %d", t.CompletionTokens, l.MaxCompletionTokens)
+	case l.MaxTotalTokens > 0 && t.TotalTokens > l.MaxTotalTokens:
+		return fmt.Sprintf("total tokens %d exceed limit %d", t.TotalTokens, l.MaxTotalTokens)
+	case l.MaxCost > 0 && t.Cost > l.MaxCost:
+		return fmt.Sprintf("cost %.6f exceeds limit %.6f", t.Cost, l.MaxCost)
+	}
+	return ""
+}
+
+// Totals is accumulated usage.
+type Totals struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+	// Calls is the number of model calls recorded.
+	Calls int `json:"calls"`
+}
+
+func (t *Totals) add(usage model.Usage, cost float64) {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+	t.PromptTokens += usage.PromptTokens
+	t.CompletionTokens += usage.CompletionTokens
+	t.TotalTokens += total
+	t.Cost += cost
+	t.Calls++
+}
+
+// Usage returns the token totals as a model.Usage.
+func (t Totals) Usage() *model.Usage {
+	return &model.Usage{
+		PromptTokens:     t.PromptTokens,
+		CompletionTokens: t.CompletionTokens,
+		TotalTokens:      t.TotalTokens,
+	}
+}
+
+// Option configures a Budget.
+type Option func(*Budget)
+
+// WithPrices sets the price table. Models missing from the table are
+// counted with zero cost.
+func WithPrices(prices PriceTable) Option {
+	return func(b *Budget) {
+		b.prices = prices
+	}
+}
+
+// WithInvocationLimits sets the limits of a single run.
+func WithInvocationLimits(limits Limits) Option {
+	return func(b *Budget) {
+		b.invocationLimits = limits
+	}
+}
+
+// WithSessionLimits sets the limits accumulated across the runs of a session.
+func WithSessionLimits(limits Limits) Option {
+	return func(b *Budget) {
+		b.sessionLimits = limits
+	}
+}
+
+// WithStateKey sets the session state key of the session totals.
+func WithStateKey(key string) Option {
+	return func(b *Budget) {
+		b.stateKey = key
+	}
+}
+
+// Budget holds prices and limits. It is safe for concurrent use.
+type Budget struct {
+	prices           PriceTable
+	invocationLimits Limits
+	sessionLimits    Limits
+	stateKey         string
+
+	warnOnce sync.Map
+}
+
+// New creates a Budget.
+func New(opts ...Option) *Budget {
+	b := &Budget{stateKey: DefaultStateKey}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// StateKey returns the session state key of the session totals.
+func (b *Budget) StateKey() string {
+	return b.stateKey
+}
+
+// Start creates the tracker of a run, seeded with the session totals stored
+// in sess.
+func (b *Budget) Start(sess *session.Session) *Tracker {
+	t := &Tracker{budget: b}
+	if sess == nil {
+		return t
+	}
+	raw := sess.State[b.stateKey]
+	if len(raw) == 0 {
+		return t
+	}
+	if err := json.Unmarshal(raw, &t.session); err != nil {
+		log.Warnf("budget: ignoring invalid session totals in %q: %v", b.stateKey, err)
+		t.session = Totals{}
+	}
+	return t
+}
+
+func (b *Budget) cost(modelName string, usage model.Usage) float64 {
+	if len(b.prices) == 0 {
+		return 0
+	}
+	price, ok := b.prices.Lookup(modelName)
+	if !ok {
+		if _, warned := b.warnOnce.LoadOrStore(modelName, struct{}{}); !warned {
+			log.Warnf("budget: no price for model %q, counting zero cost", modelName)
+		}
+		return 0
+	}
+	return price.Cost(usage)
+}
+
+// Tracker accumulates the usage of one run. It is safe for concurrent use.
+type Tracker struct {
+	budget *Budget
+
+	mu         sync.Mutex
+	invocation Totals
+	session    Totals
+}
+
+// Record adds the usage of a model call. It returns an *agent.StopError
+// once a limit is exceeded.
+func (t *Tracker) Record(modelName string, usage *model.Usage) error {
+	if usage == nil {
+		return t.Check()
+	}
+	cost := t.budget.cost(modelName, *usage)
+	t.mu.Lock()
+	t.invocation.add(*usage, cost)
+	t.session.add(*usage, cost)
+	t.mu.Unlock()
+	return t.Check()
+}
+
+// Check returns an *agent.StopError if a limit is already exceeded.
+func (t *Tracker) Check() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if reason := t.budget.invocationLimits.exceeded(t.invocation); reason != "" {
+		return agent.NewStopError("budget exceeded for invocation: " + reason)
+	}
+	if reason := t.budget.sessionLimits.exceeded(t.session); reason != "" {
+		return agent.NewStopError("budget exceeded for session: " + reason)
+	}
+	return nil
+}
+
+// Invocation returns the totals of the run.
+func (t *Tracker) Invocation() Totals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.invocation
+}
+
+// Session returns the totals of the session, including the run.
+func (t *Tracker) Session() Totals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.session
+}
+
+// StateDelta returns the state delta persisting the session totals.
+func (t *Tracker) StateDelta() map[string][]byte {
+	data, err := json.Marshal(t.Session())
+	if err != nil {
+		return nil
+	}
+	return map[string][]byte{t.budget.stateKey: data}
+}
+
+type trackerKey struct{}
+
+// NewContext returns a context carrying t. The tracker is also installed as
+// the model.UsageRecorder of the context, so model calls made inside models
+// are recorded too.
+func NewContext(ctx context.Context, t *Tracker) context.Context {
+	ctx = context.WithValue(ctx, trackerKey{}, t)
+	return model.NewUsageRecorderContext(ctx, t)
+}
+
+// FromContext returns the tracker carried by ctx.
+func FromContext(ctx context.Context) (*Tracker, bool) {
+	t, ok := ctx.Value(trackerKey{}).(*Tracker)
+	return t, ok && t != nil
+}
+
+// Record records usage on the tracker carried by ctx, if any.
+func Record(ctx context.Context, modelName string, usage *model.Usage) error {
+	t, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return t.Record(modelName, usage)
+}
+
+// Check checks the tracker carried by ctx, if any.
+func Check(ctx context.Context) error {
+	t, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return t.Check()
+}
+
+// Generate makes a model call on behalf of the run carried by ctx, for the
+// calls made outside the LLM flow such as those of planners, judges and
+// classifiers. Like the flow, it checks the budget before the call, runs the
+// model callbacks cb, if any, around it and records the usage of the
+// complete responses, except those replayed from a cache. It returns the
+// complete responses; an exceeded budget is returned as an *agent.StopError.
+func Generate(ctx context.Context, m model.Model, cb *model.Callbacks, req *model.Request) ([]*model.Response, error) {
+	if err := Check(ctx); err != nil {
+		return nil, err
+	}
+	var responseChan <-chan *model.Response
+	if cb != nil {
+		custom, err := cb.RunBeforeModel(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("callback before model error: %w", err)
+		}
+		if custom != nil {
+			ch := make(chan *model.Response, 1)
+			ch <- custom
+			close(ch)
+			responseChan = ch
+		}
+	}
+	if responseChan == nil {
+		var err error
+		if responseChan, err = m.GenerateContent(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	var responses []*model.Response
+	for rsp := range responseChan {
+		if cb != nil {
+			custom, err := cb.RunAfterModel(ctx, req, rsp, nil)
+			if err != nil {
+				drain(responseChan)
+				return nil, fmt.Errorf("callback after model error: %w", err)
+			}
+			if custom != nil {
+				rsp = custom
+			}
+		}
+		if rsp.IsPartial {
+			continue
+		}
+		responses = append(responses, rsp)
+		if rsp.Usage != nil && !rsp.CacheHit {
+			if err := Record(ctx, m.Info().Name, rsp.Usage); err != nil {
+				drain(responseChan)
+				return nil, err
+			}
+		}
+	}
+	return responses, nil
+}
+
+// drain discards the remaining responses of a model call, so that the model
+// does not block on sending them.
+func drain(responseChan <-chan *model.Response) {
+	go func() {
+		for range responseChan {
+		}
+	}()
+}
@@ -0,0 +1,258 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package budget_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+	"trpc.group/trpc-go/trpc-agent-go/session"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+func TestPriceTable_Lookup(t *testing.T) {
+	prices := budget.PriceTable{
+		"gpt-4o":      {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini": {Prompt: 0.15, Completion: 0.6},
+	}
+	p, ok := prices.Lookup("GPT-4o")
+	require.True(t, ok)
+	assert.Equal(t, 2.5, p.Prompt)
+
+	p, ok = prices.Lookup("gpt-4o-mini-2024-07-18")
+	require.True(t, ok)
+	assert.Equal(t, 0.15, p.Prompt)
+
+	_, ok = prices.Lookup("claude")
+	assert.False(t, ok)
+
+	assert.InDelta(t, 0.0125, budget.Price{Prompt: 2.5, Completion: 10}.Cost(model.Usage{
+		PromptTokens: 1000, CompletionTokens: 1000,
+	}), 1e-9)
+}
+
+func TestTracker_Limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []budget.Option
+		usage  model.Usage
+		errMsg string
+	}{
+		{"within", []budget.Option{budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 100})},
+			model.Usage{PromptTokens: 50, CompletionTokens: 50}, ""},
+		{"prompt", []budget.Option{budget.WithInvocationLimits(budget.Limits{MaxPromptTokens: 10})},
+			model.Usage{PromptTokens: 11}, "prompt tokens 11 exceed limit 10"},
+		{"completion", []budget.Option{budget.WithInvocationLimits(budget.Limits{MaxCompletionTokens: 10})},
+			model.Usage{CompletionTokens: 11}, "completion tokens"},
+		{"total", []budget.Option{budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 10})},
+			model.Usage{PromptTokens: 6, CompletionTokens: 6}, "total tokens 12"},
+		{"cost", []budget.Option{
+			budget.WithPrices(budget.PriceTable{"m": {Prompt: 1e6}}),
+			budget.WithInvocationLimits(budget.Limits{MaxCost: 1}),
+		}, model.Usage{PromptTokens: 2}, "cost"},
+		{"session", []budget.Option{budget.WithSessionLimits(budget.Limits{MaxTotalTokens: 10})},
+			model.Usage{TotalTokens: 11}, "budget exceeded for session"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := budget.New(tt.opts...).Start(nil)
+			err := tr.Record("m", &tt.usage)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			_, ok := agent.AsStopError(err)
+			assert.True(t, ok)
+			assert.Contains(t, err.Error(), tt.errMsg)
+			assert.Error(t, tr.Check())
+		})
+	}
+}
+
+func TestTracker_SessionTotals(t *testing.T) {
+	b := budget.New(
+		budget.WithPrices(budget.PriceTable{"m": {Prompt: 1, Completion: 2}}),
+		budget.WithSessionLimits(budget.Limits{MaxTotalTokens: 25}),
+	)
+	prev, err := json.Marshal(budget.Totals{PromptTokens: 10, TotalTokens: 20, Calls: 1})
+	require.NoError(t, err)
+	sess := &session.Session{State: session.StateMap{budget.DefaultStateKey: prev}}
+
+	tr := b.Start(sess)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = tr.Record("m", &model.Usage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, budget.Totals{PromptTokens: 2, CompletionTokens: 2, TotalTokens: 4, Cost: 6e-6, Calls: 2}, tr.Invocation())
+	assert.Equal(t, 24, tr.Session().TotalTokens)
+	assert.Equal(t, 3, tr.Session().Calls)
+	assert.NoError(t, tr.Check())
+
+	var stored budget.Totals
+	require.NoError(t, json.Unmarshal(tr.StateDelta()[b.StateKey()], &stored))
+	assert.Equal(t, tr.Session(), stored)
+
+	assert.Error(t, tr.Record("m", &model.Usage{TotalTokens: 2}))
+}
+
+func TestTracker_InvalidState(t *testing.T) {
+	sess := &session.Session{State: session.StateMap{"k": []byte("not json")}}
+	tr := budget.New(budget.WithStateKey("k")).Start(sess)
+	assert.Equal(t, budget.Totals{}, tr.Session())
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, budget.Record(ctx, "m", &model.Usage{TotalTokens: 1}))
+	assert.NoError(t, budget.Check(ctx))
+
+	tr := budget.New(budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 1})).Start(nil)
+	ctx = budget.NewContext(ctx, tr)
+	got, ok := budget.FromContext(ctx)
+	require.True(t, ok)
+	assert.Same(t, tr, got)
+	recorder, ok := model.UsageRecorderFromContext(ctx)
+	require.True(t, ok)
+	assert.Same(t, tr, recorder)
+	assert.Error(t, budget.Record(ctx, "m", &model.Usage{TotalTokens: 2}))
+	assert.Error(t, budget.Check(ctx))
+}
+
+func TestRunner_StopsWhenExceeded(t *testing.T) {
+	echo := function.NewFunctionTool(func(_ context.Context, in map[string]any) (map[string]any, error) {
+		return in, nil
+	}, function.WithName("echo"))
+	first := agenttest.CallTool("echo", map[string]any{"x": 1})
+	first.Usage = &model.Usage{PromptTokens: 40, CompletionTokens: 20, TotalTokens: 60}
+	second := agenttest.CallTool("echo", map[string]any{"x": 2})
+	second.Usage = &model.Usage{PromptTokens: 50, CompletionTokens: 10, TotalTokens: 60}
+	m := agenttest.NewModel(first, second, agenttest.Reply("unreachable"))
+	ag := llmagent.New("assistant", llmagent.WithModel(m), llmagent.WithTools([]tool.Tool{echo}))
+
+	b := budget.New(budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 100}))
+	h := agenttest.NewHarness(ag)
+	h.Runner = runner.NewRunner(h.AppName, ag, runner.WithSessionService(h.SessionService), runner.WithBudget(b))
+
+	tr := h.MustRun(t, "go")
+	errs := tr.Errors()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0], "budget exceeded for invocation: total tokens 120 exceed limit 100")
+	agenttest.AssertToolCalls(t, tr, agenttest.Call{Name: "echo"}, agenttest.Call{Name: "echo"})
+	assert.Len(t, tr.ToolResults(), 1, "tools of the exceeding response must not run")
+	assert.Equal(t, 1, m.Remaining())
+
+	last := tr.Events[len(tr.Events)-1]
+	require.Equal(t, model.ObjectTypeRunnerCompletion, last.Object)
+	assert.Equal(t, &model.Usage{PromptTokens: 90, CompletionTokens: 30, TotalTokens: 120}, last.Usage)
+
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	var stored budget.Totals
+	require.NoError(t, json.Unmarshal(sess.State[budget.DefaultStateKey], &stored))
+	assert.Equal(t, 120, stored.TotalTokens)
+	assert.Equal(t, 2, stored.Calls)
+}
+
+func TestGenerate(t *testing.T) {
+	reply := agenttest.Reply("fine")
+	reply.Usage = &model.Usage{PromptTokens: 40, CompletionTokens: 20, TotalTokens: 60}
+	m := agenttest.NewModel(reply, agenttest.Reply("unreachable"))
+	tr := budget.New(budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 50})).Start(nil)
+	ctx := budget.NewContext(context.Background(), tr)
+	var seen []string
+	cb := model.NewCallbacks().
+		RegisterBeforeModel(func(context.Context, *model.Request) (*model.Response, error) {
+			seen = append(seen, "before")
+			return nil, nil
+		}).
+		RegisterAfterModel(func(context.Context, *model.Request, *model.Response, error) (*model.Response, error) {
+			seen = append(seen, "after")
+			return nil, nil
+		})
+	req := &model.Request{Messages: []model.Message{model.NewUserMessage("hi")}}
+
+	_, err := budget.Generate(ctx, m, cb, req)
+	_, stopped := agent.AsStopError(err)
+	assert.True(t, stopped, "the call exceeding the budget is reported: %v", err)
+	assert.Equal(t, 60, tr.Invocation().TotalTokens)
+	assert.Equal(t, []string{"before", "after"}, seen)
+
+	_, err = budget.Generate(ctx, m, nil, req)
+	_, stopped = agent.AsStopError(err)
+	assert.True(t, stopped)
+	assert.Equal(t, 1, m.Remaining(), "no call is made once the budget is exceeded")
+
+	rsps, err := budget.Generate(context.Background(), m, nil, req)
+	require.NoError(t, err)
+	require.Len(t, rsps, 1)
+	assert.Equal(t, "unreachable", rsps[0].Choices[0].Message.Content)
+}
+
+// streamingModel sends its responses on an unbuffered channel and closes
+// sent once it could send them all.
+type streamingModel struct {
+	responses []*model.Response
+	sent      chan struct{}
+}
+
+func (m *streamingModel) Info() model.Info { return model.Info{Name: "streaming"} }
+
+func (m *streamingModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	ch := make(chan *model.Response)
+	go func() {
+		defer close(m.sent)
+		defer close(ch)
+		for _, rsp := range m.responses {
+			ch <- rsp
+		}
+	}()
+	return ch, nil
+}
+
+func TestGenerate_DrainsOnStop(t *testing.T) {
+	m := &streamingModel{
+		responses: []*model.Response{
+			{Done: true, Usage: &model.Usage{PromptTokens: 40, CompletionTokens: 20, TotalTokens: 60}},
+			{Done: true},
+		},
+		sent: make(chan struct{}),
+	}
+	tr := budget.New(budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 50})).Start(nil)
+	ctx := budget.NewContext(context.Background(), tr)
+
+	_, err := budget.Generate(ctx, m, nil, &model.Request{})
+	_, stopped := agent.AsStopError(err)
+	assert.True(t, stopped)
+	select {
+	case <-m.sent:
+	case <-time.After(time.Second):
+		t.Fatal("the model is blocked on sending")
+	}
+}
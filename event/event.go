@@ -12,6 +12,7 @@ package event
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 	"time"
@@ -85,6 +86,31 @@ type Event struct {
 	// This is not serialized and is meant for immediate consumer access.
 	StructuredOutput any `json:"-"`
 
+	// PartialStructuredOutput carries a snapshot of a structured output still
+	// being generated, on events with object
+	// model.ObjectTypeStructuredOutputPartial.
+	PartialStructuredOutput *PartialStructuredOutput `json:"partialStructuredOutput,omitempty"`
+
+	// StructuredOutputErrors lists the schema violations of the final
+	// structured output. It is empty when the output is valid.
+	StructuredOutputErrors []string `json:"structuredOutputErrors,omitempty"`
+
+	// ToolConfirmations lists the tool calls waiting for a human decision, on
+	// events with object model.ObjectTypeToolConfirmationRequest.
+	ToolConfirmations []ToolConfirmation `json:"toolConfirmations,omitempty"`
+
+	// GuardrailResults lists the outcome of each guardrail, on events with
+	// object model.ObjectTypeGuardrail.
+	GuardrailResults []GuardrailResult `json:"guardrailResults,omitempty"`
+
+	// Routing is the decision of a router agent, on events with object
+	// model.ObjectTypeRouting.
+	Routing *RoutingDecision `json:"routing,omitempty"`
+
+	// Critique is the review of a draft by the critic of a reflection
+	// agent, on events with object model.ObjectTypeCritique.
+	Critique *Critique `json:"critique,omitempty"`
+
 	// Actions carry flow-level hints that influence how this event is treated
 	// by the runner/flow (e.g., skip summarization after a tool response).
 	Actions *EventActions `json:"actions,omitempty"`
@@ -96,6 +122,86 @@ type Event struct {
 	Version int `json:"version,omitempty"`
 }
 
+// PartialStructuredOutput is a snapshot of a structured output streamed by
+// the model.
+type PartialStructuredOutput struct {
+	// JSON is the output received so far, completed into valid JSON.
+	// Properties not received yet are absent and the last string may be
+	// truncated.
+	JSON json.RawMessage `json:"json"`
+	// Value is JSON decoded into the structured output type, or nil when the
+	// snapshot does not decode into it yet.
+	Value any `json:"-"`
+}
+
+// ToolConfirmation is a tool call paused until a human approves it.
+type ToolConfirmation struct {
+	// ToolCallID is the ID of the tool call the decision refers to.
+	ToolCallID string `json:"toolCallId"`
+	// ToolName is the name of the called tool.
+	ToolName string `json:"toolName"`
+	// Arguments are the arguments the model called the tool with.
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// GuardrailStage tells which side of an agent a guardrail checks.
+type GuardrailStage string
+
+const (
+	// GuardrailStageInput is the check of the message an agent is run with.
+	GuardrailStageInput GuardrailStage = "input"
+	// GuardrailStageOutput is the check of a final response of an agent.
+	GuardrailStageOutput GuardrailStage = "output"
+)
+
+// GuardrailResult is the outcome of a guardrail check.
+type GuardrailResult struct {
+	// Name is the name of the guardrail.
+	Name string `json:"name"`
+	// Stage is the checked side of the agent.
+	Stage GuardrailStage `json:"stage"`
+	// Tripped reports a policy violation.
+	Tripped bool `json:"tripped"`
+	// Reason explains the violation.
+	Reason string `json:"reason,omitempty"`
+}
+
+// RoutingDecision is the sub-agent chosen by a router agent.
+type RoutingDecision struct {
+	// Agent is the name of the chosen agent.
+	Agent string `json:"agent"`
+	// Score is the similarity of the message with the chosen agent.
+	Score float64 `json:"score"`
+	// Fallback reports that no sub-agent matched well enough and the
+	// fallback agent was chosen.
+	Fallback bool `json:"fallback,omitempty"`
+	// Reason explains a fallback.
+	Reason string `json:"reason,omitempty"`
+	// Scores lists the similarity of the message with each sub-agent, best
+	// first.
+	Scores []RouteScore `json:"scores,omitempty"`
+}
+
+// RouteScore is the similarity of a message with a sub-agent.
+type RouteScore struct {
+	Agent string  `json:"agent"`
+	Score float64 `json:"score"`
+}
+
+// Critique is the review of a draft by the critic of a reflection agent.
+type Critique struct {
+	// Round is the round of the draft, starting at 1.
+	Round int `json:"round"`
+	// Score is the score given to the draft.
+	Score float64 `json:"score"`
+	// Feedback explains the score and how to improve the draft.
+	Feedback string `json:"feedback,omitempty"`
+	// BestRound is the round of the best draft so far.
+	BestRound int `json:"bestRound"`
+	// Stop is why no other round follows, if none does.
+	Stop string `json:"stop,omitempty"`
+}
+
 // EventActions represents optional actions/hints attached to an event.
 // These are used by the flow to adjust control behavior without
 // overloading Response fields.
@@ -129,6 +235,33 @@ func (e *Event) Clone() *Event {
 			copy(clone.StateDelta[k], v)
 		}
 	}
+	if e.PartialStructuredOutput != nil {
+		partial := *e.PartialStructuredOutput
+		partial.JSON = append(json.RawMessage(nil), partial.JSON...)
+		clone.PartialStructuredOutput = &partial
+	}
+	if e.StructuredOutputErrors != nil {
+		clone.StructuredOutputErrors = append([]string(nil), e.StructuredOutputErrors...)
+	}
+	if e.ToolConfirmations != nil {
+		clone.ToolConfirmations = make([]ToolConfirmation, len(e.ToolConfirmations))
+		for i, c := range e.ToolConfirmations {
+			c.Arguments = append(json.RawMessage(nil), c.Arguments...)
+			clone.ToolConfirmations[i] = c
+		}
+	}
+	if e.GuardrailResults != nil {
+		clone.GuardrailResults = append([]GuardrailResult(nil), e.GuardrailResults...)
+	}
+	if e.Routing != nil {
+		routing := *e.Routing
+		routing.Scores = append([]RouteScore(nil), e.Routing.Scores...)
+		clone.Routing = &routing
+	}
+	if e.Critique != nil {
+		critique := *e.Critique
+		clone.Critique = &critique
+	}
 	if e.Actions != nil {
 		clone.Actions = &EventActions{
 			SkipSummarization: e.Actions.SkipSummarization,
@@ -41,3 +41,47 @@ func TestEvent_Clone_DeepCopy(t *testing.T) {
 		t.Errorf("expected deep copy of StateDelta")
 	}
 }
+
+func TestEvent_Clone_ToolConfirmations(t *testing.T) {
+	e := New("inv-1", "tester")
+	e.ToolConfirmations = []ToolConfirmation{
+		{ToolCallID: "call_1", ToolName: "rm", Arguments: []byte(`{"path":"/"}`)},
+	}
+
+	c := e.Clone()
+	c.ToolConfirmations[0].ToolName = "ls"
+	c.ToolConfirmations[0].Arguments[2] = 'x'
+	if e.ToolConfirmations[0].ToolName != "rm" {
+		t.Errorf("original ToolConfirmations mutated by clone")
+	}
+	if string(e.ToolConfirmations[0].Arguments) != `{"path":"/"}` {
+		t.Errorf("expected deep copy of ToolConfirmations arguments")
+	}
+}
+
+func TestEvent_Clone_Critique(t *testing.T) {
+	e := New("inv-1", "reflection")
+	e.Critique = &Critique{Round: 2, Score: 0.7, Feedback: "too long", BestRound: 2}
+
+	c := e.Clone()
+	c.Critique.Score = 0.1
+	if e.Critique.Score != 0.7 {
+		t.Errorf("original Critique mutated by clone")
+	}
+}
+
+func TestEvent_Clone_Routing(t *testing.T) {
+	e := New("inv-1", "router")
+	e.Routing = &RoutingDecision{
+		Agent:  "billing",
+		Score:  0.9,
+		Scores: []RouteScore{{Agent: "billing", Score: 0.9}},
+	}
+
+	c := e.Clone()
+	c.Routing.Agent = "support"
+	c.Routing.Scores[0].Score = 0.1
+	if e.Routing.Agent != "billing" || e.Routing.Scores[0].Score != 0.9 {
+		t.Errorf("original Routing mutated by clone")
+	}
+}
@@ -53,6 +53,22 @@ func WithStructuredOutputPayload(payload any) Option {
 	}
 }
 
+// WithPartialStructuredOutput sets a partial structured output snapshot on
+// the event.
+func WithPartialStructuredOutput(partial *PartialStructuredOutput) Option {
+	return func(e *Event) {
+		e.PartialStructuredOutput = partial
+	}
+}
+
+// WithStructuredOutputErrors sets the schema violations of the structured
+// output on the event.
+func WithStructuredOutputErrors(errs []string) Option {
+	return func(e *Event) {
+		e.StructuredOutputErrors = errs
+	}
+}
+
 // WithSkipSummarization sets the SkipSummarization action on the event.
 func WithSkipSummarization() Option {
 	return func(e *Event) {
@@ -30,8 +30,8 @@ var (
 	flagModel                = flag.String("model", "deepseek-chat", "Model name, e.g., deepseek-chat or gpt-4o")
 	flagEnableTokenTailoring = flag.Bool("enable-token-tailoring", true, "Enable automatic token tailoring based on model context window")
 	flagMaxInputTokens       = flag.Int("max-input-tokens", 0, "Max input tokens for token tailoring (0 = auto-calculate from context window)")
-	flagCounter              = flag.String("counter", "simple", "Token counter: simple|tiktoken")
-	flagStrategy             = flag.String("strategy", "middle", "Tailoring strategy: middle|head|tail")
+	flagCounter              = flag.String("counter", "simple", "Token counter: simple|tiktoken|accounting")
+	flagStrategy             = flag.String("strategy", "middle", "Tailoring strategy: middle|head|tail|summary")
 	flagStreaming            = flag.Bool("streaming", true, "Stream assistant responses")
 	flagDebug                = flag.Bool("debug", false, "Enable debug logging")
 )
@@ -56,7 +56,7 @@ func main() {
 	opts = append(opts, openai.WithTokenCounter(counter))
 
 	// Always create strategy with the user-provided counter to ensure consistency.
-	strategy := buildStrategy(counter, strings.ToLower(*flagStrategy))
+	strategy := buildStrategy(counter, strings.ToLower(*flagStrategy), *flagModel)
 	opts = append(opts, openai.WithTailoringStrategy(strategy))
 
 	// Add callback to print token statistics before sending request.
@@ -125,8 +125,11 @@ func main() {
 	}
 }
 
-func buildStrategy(counter model.TokenCounter, strategyName string) model.TailoringStrategy {
+func buildStrategy(counter model.TokenCounter, strategyName string, modelName string) model.TailoringStrategy {
 	switch strategyName {
+	case "summary":
+		// The summarizer runs without tailoring so it sees the whole span.
+		return model.NewSummarizingStrategy(counter, openai.New(modelName))
 	case "head":
 		return model.NewHeadOutStrategy(counter)
 	case "tail":
@@ -138,6 +141,9 @@ func buildStrategy(counter model.TokenCounter, strategyName string) model.Tailor
 
 func buildCounter(name string, modelName string) model.TokenCounter {
 	switch name {
+	case "accounting":
+		// Counts tool declarations, tool call arguments and image parts too.
+		return model.NewAccountingTokenCounter(model.AccountingForModel(modelName))
 	case "tiktoken":
 		c, err := tiktoken.New(modelName)
 		if err == nil {
@@ -23,6 +23,7 @@ import (
 	oteltrace "go.opentelemetry.io/otel/trace"
 
 	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	"trpc.group/trpc-go/trpc-agent-go/graph/internal/channel"
 	stateinject "trpc.group/trpc-go/trpc-agent-go/internal/state"
@@ -1419,6 +1420,9 @@ type modelExecutionConfig struct {
 
 // executeModelWithEvents executes the model with event processing.
 func executeModelWithEvents(ctx context.Context, config modelExecutionConfig) (any, error) {
+	if err := budget.Check(ctx); err != nil {
+		return nil, err
+	}
 	responseChan, err := runModel(ctx, config.ModelCallbacks, config.LLMModel, config.Request)
 	if err != nil {
 		config.Span.SetAttributes(attribute.String("trpc.go.agent.error", err.Error()))
@@ -1441,6 +1445,11 @@ func executeModelWithEvents(ctx context.Context, config modelExecutionConfig) (a
 		}); err != nil {
 			return nil, err
 		}
+		if !response.IsPartial && !response.CacheHit && response.Usage != nil {
+			if err := budget.Record(ctx, config.LLMModel.Info().Name, response.Usage); err != nil {
+				return nil, err
+			}
+		}
 
 		if len(response.Choices) > 0 && len(response.Choices[0].Message.ToolCalls) > 0 {
 			toolCalls = append(toolCalls, response.Choices[0].Message.ToolCalls...)
@@ -15,7 +15,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	oteltrace "go.opentelemetry.io/otel/trace"
 
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	"trpc.group/trpc-go/trpc-agent-go/model"
 	"trpc.group/trpc-go/trpc-agent-go/session"
@@ -323,3 +326,42 @@ func TestLLMNode_PlaceholdersOptionalMissing(t *testing.T) {
 	require.NotContains(t, sys.Content, "{user:topics?")
 	require.NotContains(t, sys.Content, "{app:banner?")
 }
+
+// usageModel returns a final response carrying token usage.
+type usageModel struct{ calls int }
+
+func (u *usageModel) GenerateContent(ctx context.Context, req *model.Request) (<-chan *model.Response, error) {
+	u.calls++
+	ch := make(chan *model.Response, 1)
+	ch <- &model.Response{
+		Done:    true,
+		Usage:   &model.Usage{PromptTokens: 8, CompletionTokens: 4, TotalTokens: 12},
+		Choices: []model.Choice{{Index: 0, Message: model.NewAssistantMessage("ok")}},
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (u *usageModel) Info() model.Info { return model.Info{Name: "usage"} }
+
+func TestExecuteModelWithEvents_Budget(t *testing.T) {
+	tracker := budget.New(budget.WithInvocationLimits(budget.Limits{MaxTotalTokens: 10})).Start(nil)
+	ctx := budget.NewContext(context.Background(), tracker)
+	um := &usageModel{}
+	cfg := modelExecutionConfig{
+		LLMModel: um,
+		Request:  &model.Request{Messages: []model.Message{model.NewUserMessage("hi")}},
+		Span:     oteltrace.SpanFromContext(ctx),
+	}
+
+	_, err := executeModelWithEvents(ctx, cfg)
+	require.Error(t, err)
+	_, ok := agent.AsStopError(err)
+	require.True(t, ok)
+	require.Equal(t, 12, tracker.Invocation().TotalTokens)
+
+	// Once exceeded, the model is not called again.
+	_, err = executeModelWithEvents(ctx, cfg)
+	require.Error(t, err)
+	require.Equal(t, 1, um.calls)
+}
@@ -0,0 +1,129 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/budget"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+const (
+	policyPlaceholder = "{policy}"
+	stagePlaceholder  = "{stage}"
+	textPlaceholder   = "{text}"
+)
+
+// defaultClassifierPrompt asks the model for a JSON verdict.
+const defaultClassifierPrompt = "You are a guardrail enforcing the policy below on the {stage} of an AI assistant.\n\n" +
+	"Policy:\n{policy}\n\n" +
+	"Decide whether the text below violates the policy. Reply with a JSON object only, " +
+	`of the form {"violation": true or false, "reason": "short explanation"}.` + "\n\n" +
+	"Text:\n{text}"
+
+// ClassifierOption configures a classifier guardrail.
+type ClassifierOption func(*classifier)
+
+// WithClassifierPrompt replaces the prompt sent to the model. The
+// placeholders {policy}, {stage} and {text} are replaced by the policy, the
+// checked stage and the checked text. The model must reply with a JSON object
+// holding a boolean "violation" and a string "reason".
+func WithClassifierPrompt(prompt string) ClassifierOption {
+	return func(c *classifier) {
+		c.prompt = prompt
+	}
+}
+
+// classifier asks a model whether the text violates a policy.
+type classifier struct {
+	name   string
+	model  model.Model
+	policy string
+	prompt string
+}
+
+// NewClassifier returns a guardrail asking m whether the checked text
+// violates policy, for instance to detect jailbreak attempts or
+// non-compliant answers. A small, fast model keeps the latency low.
+func NewClassifier(name string, m model.Model, policy string, opts ...ClassifierOption) Guardrail {
+	c := &classifier{name: name, model: m, policy: policy, prompt: defaultClassifierPrompt}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *classifier) Name() string {
+	return c.name
+}
+
+// verdict is the reply of the classifier model.
+type verdict struct {
+	Violation *bool  `json:"violation"`
+	Reason    string `json:"reason"`
+}
+
+func (c *classifier) Check(ctx context.Context, req *Request) (*Result, error) {
+	if c.model == nil {
+		return nil, errors.New("no model configured for the classifier")
+	}
+	stage := "user input"
+	if req.Stage == StageOutput {
+		stage = "response"
+	}
+	prompt := strings.NewReplacer(
+		policyPlaceholder, c.policy,
+		stagePlaceholder, stage,
+		textPlaceholder, req.Text(),
+	).Replace(c.prompt)
+
+	// The classifier calls count against the budget of the run.
+	responses, err := budget.Generate(ctx, c.model, nil, &model.Request{
+		Messages:         []model.Message{model.NewUserMessage(prompt)},
+		GenerationConfig: model.GenerationConfig{Stream: false},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("classifier model call failed: %w", err)
+	}
+	var answer string
+	for _, rsp := range responses {
+		if rsp.Error != nil {
+			return nil, fmt.Errorf("classifier model error: %s", rsp.Error.Message)
+		}
+		if len(rsp.Choices) == 0 {
+			continue
+		}
+		answer += rsp.Choices[0].Message.Content
+	}
+
+	v, err := parseVerdict(answer)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Tripped: *v.Violation, Reason: v.Reason}, nil
+}
+
+// parseVerdict extracts the JSON verdict from the answer of the model, which
+// may wrap it in text or a code fence.
+func parseVerdict(answer string) (*verdict, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start >= 0 && end > start {
+		var v verdict
+		if err := json.Unmarshal([]byte(answer[start:end+1]), &v); err == nil && v.Violation != nil {
+			return &v, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected classifier answer: %q", answer)
+}
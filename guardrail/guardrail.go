@@ -0,0 +1,209 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package guardrail checks the input and the output of agents against
+// policies, such as topic restrictions, jailbreak detection or output
+// compliance.
+//
+// Guardrails are attached to an LLM agent with llmagent.WithInputGuardrails
+// and llmagent.WithOutputGuardrails, or to every agent of a runner with
+// runner.WithInputGuardrails and runner.WithOutputGuardrails. Input
+// guardrails run concurrently with the first model call of the invocation,
+// which is cancelled when one of them trips. Output guardrails check final
+// responses before they are persisted in the session. Every check is
+// reported by an event with object model.ObjectTypeGuardrail; a tripped
+// check also carries an error of type model.ErrorTypeGuardrailTripped.
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Stage tells which side of an agent a guardrail checks.
+type Stage = event.GuardrailStage
+
+const (
+	// StageInput checks the message an agent is run with.
+	StageInput = event.GuardrailStageInput
+	// StageOutput checks a final response of an agent.
+	StageOutput = event.GuardrailStageOutput
+)
+
+// Request is the content checked by a guardrail.
+type Request struct {
+	// Stage is the side of the agent being checked.
+	Stage Stage
+	// AgentName is the name of the checked agent.
+	AgentName string
+	// Message is the checked message: the user message for the input stage,
+	// the assistant response for the output stage.
+	Message model.Message
+}
+
+// Text returns the text of the checked message, content parts included.
+func (r *Request) Text() string {
+	var parts []string
+	if r.Message.Content != "" {
+		parts = append(parts, r.Message.Content)
+	}
+	for _, p := range r.Message.ContentParts {
+		if p.Text != nil && *p.Text != "" {
+			parts = append(parts, *p.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Result is the outcome of a guardrail check.
+type Result struct {
+	// Tripped reports a policy violation, stopping the agent.
+	Tripped bool
+	// Reason explains the violation.
+	Reason string
+}
+
+// Guardrail checks the input or the output of an agent.
+type Guardrail interface {
+	// Name identifies the guardrail in events.
+	Name() string
+	// Check checks req. An error is treated as a tripped check.
+	Check(ctx context.Context, req *Request) (*Result, error)
+}
+
+// Func checks a request.
+type Func func(ctx context.Context, req *Request) (*Result, error)
+
+type funcGuardrail struct {
+	name string
+	fn   Func
+}
+
+// NewFunc returns a guardrail checking requests with fn.
+func NewFunc(name string, fn Func) Guardrail {
+	return &funcGuardrail{name: name, fn: fn}
+}
+
+func (g *funcGuardrail) Name() string {
+	return g.name
+}
+
+func (g *funcGuardrail) Check(ctx context.Context, req *Request) (*Result, error) {
+	return g.fn(ctx, req)
+}
+
+// Check runs guardrails concurrently on req and returns their results in
+// order. A guardrail failing to check trips, so that a policy is never
+// skipped silently.
+func Check(ctx context.Context, guardrails []Guardrail, req *Request) []event.GuardrailResult {
+	results := make([]event.GuardrailResult, len(guardrails))
+	var wg sync.WaitGroup
+	for i, g := range guardrails {
+		wg.Add(1)
+		go func(i int, g Guardrail) {
+			defer wg.Done()
+			results[i] = check(ctx, g, req)
+		}(i, g)
+	}
+	wg.Wait()
+	return results
+}
+
+func check(ctx context.Context, g Guardrail, req *Request) (res event.GuardrailResult) {
+	res = event.GuardrailResult{Name: g.Name(), Stage: req.Stage}
+	defer func() {
+		if r := recover(); r != nil {
+			res.Tripped = true
+			res.Reason = fmt.Sprintf("guardrail panic: %v", r)
+		}
+	}()
+	out, err := g.Check(ctx, req)
+	switch {
+	case err != nil:
+		res.Tripped = true
+		res.Reason = fmt.Sprintf("guardrail check failed: %v", err)
+	case out != nil:
+		res.Tripped = out.Tripped
+		res.Reason = out.Reason
+	}
+	return res
+}
+
+// Tripped returns the first tripped result, or nil.
+func Tripped(results []event.GuardrailResult) *event.GuardrailResult {
+	for i := range results {
+		if results[i].Tripped {
+			return &results[i]
+		}
+	}
+	return nil
+}
+
+// NewEvent returns the event reporting results. When a guardrail tripped,
+// the event is an error event of type model.ErrorTypeGuardrailTripped.
+func NewEvent(invocationID, author string, results []event.GuardrailResult) *event.Event {
+	rsp := &model.Response{Object: model.ObjectTypeGuardrail, Done: true}
+	if tripped := Tripped(results); tripped != nil {
+		msg := fmt.Sprintf("%s guardrail %s tripped", tripped.Stage, tripped.Name)
+		if tripped.Reason != "" {
+			msg += ": " + tripped.Reason
+		}
+		rsp.Error = &model.ResponseError{Type: model.ErrorTypeGuardrailTripped, Message: msg}
+	}
+	e := event.NewResponseEvent(invocationID, author, rsp)
+	e.GuardrailResults = results
+	return e
+}
+
+// OutputMessage returns the assistant message of rsp when it is a final
+// response to check with output guardrails.
+func OutputMessage(rsp *model.Response) (model.Message, bool) {
+	if rsp == nil || rsp.IsPartial || rsp.Error != nil || len(rsp.Choices) == 0 ||
+		rsp.IsToolCallResponse() || rsp.IsToolResultResponse() {
+		return model.Message{}, false
+	}
+	msg := rsp.Choices[0].Message
+	if msg.Role != model.RoleAssistant || (msg.Content == "" && len(msg.ContentParts) == 0) {
+		return model.Message{}, false
+	}
+	return msg, true
+}
+
+// runInput holds the input guardrails of a run until an agent claims them.
+type runInput struct {
+	guardrails []Guardrail
+	claimed    atomic.Bool
+}
+
+type inputKey struct{}
+
+// NewContext returns a context carrying input guardrails applied to a run
+// as a whole, by the first LLM agent of the run calling a model.
+func NewContext(ctx context.Context, input []Guardrail) context.Context {
+	if len(input) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, inputKey{}, &runInput{guardrails: input})
+}
+
+// ClaimInput returns the input guardrails carried by ctx on the first call
+// of a run, and nil afterwards.
+func ClaimInput(ctx context.Context) []Guardrail {
+	in, ok := ctx.Value(inputKey{}).(*runInput)
+	if !ok || !in.claimed.CompareAndSwap(false, true) {
+		return nil
+	}
+	return in.guardrails
+}
@@ -0,0 +1,208 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package guardrail_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/guardrail"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/runner"
+)
+
+func inputRequest(text string) *guardrail.Request {
+	return &guardrail.Request{
+		Stage:     guardrail.StageInput,
+		AgentName: "assistant",
+		Message:   model.NewUserMessage(text),
+	}
+}
+
+func TestCheck_ResultsInOrder(t *testing.T) {
+	slow := guardrail.NewFunc("slow", func(ctx context.Context, req *guardrail.Request) (*guardrail.Result, error) {
+		time.Sleep(20 * time.Millisecond)
+		return &guardrail.Result{Tripped: true, Reason: "too slow"}, nil
+	})
+	fast := guardrail.NewFunc("fast", func(ctx context.Context, req *guardrail.Request) (*guardrail.Result, error) {
+		return &guardrail.Result{}, nil
+	})
+
+	results := guardrail.Check(context.Background(), []guardrail.Guardrail{slow, fast}, inputRequest("hi"))
+
+	assert.Equal(t, []event.GuardrailResult{
+		{Name: "slow", Stage: guardrail.StageInput, Tripped: true, Reason: "too slow"},
+		{Name: "fast", Stage: guardrail.StageInput},
+	}, results)
+	assert.Equal(t, "slow", guardrail.Tripped(results).Name)
+}
+
+func TestCheck_FailuresTrip(t *testing.T) {
+	failing := guardrail.NewFunc("failing", func(ctx context.Context, req *guardrail.Request) (*guardrail.Result, error) {
+		return nil, errors.New("service unavailable")
+	})
+	panicking := guardrail.NewFunc("panicking", func(ctx context.Context, req *guardrail.Request) (*guardrail.Result, error) {
+		panic("boom")
+	})
+
+	results := guardrail.Check(context.Background(), []guardrail.Guardrail{failing, panicking}, inputRequest("hi"))
+
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Tripped)
+	assert.Contains(t, results[0].Reason, "service unavailable")
+	assert.True(t, results[1].Tripped)
+	assert.Contains(t, results[1].Reason, "boom")
+}
+
+func TestNewEvent(t *testing.T) {
+	passed := guardrail.NewEvent("inv", "assistant", []event.GuardrailResult{{Name: "topics", Stage: guardrail.StageInput}})
+	assert.Equal(t, model.ObjectTypeGuardrail, passed.Object)
+	assert.Nil(t, passed.Error)
+	assert.Len(t, passed.GuardrailResults, 1)
+	assert.Nil(t, guardrail.Tripped(passed.GuardrailResults))
+
+	tripped := guardrail.NewEvent("inv", "assistant", []event.GuardrailResult{
+		{Name: "topics", Stage: guardrail.StageOutput, Tripped: true, Reason: "off topic"},
+	})
+	require.NotNil(t, tripped.Error)
+	assert.Equal(t, model.ErrorTypeGuardrailTripped, tripped.Error.Type)
+	assert.Equal(t, "output guardrail topics tripped: off topic", tripped.Error.Message)
+}
+
+func TestRegexAndKeyword(t *testing.T) {
+	regex := guardrail.NewRegex("secrets", regexp.MustCompile(`sk-[A-Za-z0-9]{8,}`))
+	keyword := guardrail.NewKeyword("topics", "Weapons", " ")
+	ctx := context.Background()
+
+	res, err := regex.Check(ctx, inputRequest("my key is sk-abcdef123456"))
+	require.NoError(t, err)
+	assert.True(t, res.Tripped)
+	assert.Contains(t, res.Reason, "sk-")
+
+	res, err = regex.Check(ctx, inputRequest("no secret here"))
+	require.NoError(t, err)
+	assert.False(t, res.Tripped)
+
+	res, err = keyword.Check(ctx, inputRequest("Tell me about WEAPONS"))
+	require.NoError(t, err)
+	assert.True(t, res.Tripped)
+	assert.Equal(t, `contains keyword "weapons"`, res.Reason)
+
+	res, err = keyword.Check(ctx, inputRequest("tell me about gardens"))
+	require.NoError(t, err)
+	assert.False(t, res.Tripped, "blank keywords are ignored")
+}
+
+func TestRequest_Text(t *testing.T) {
+	text := "a picture of a cat"
+	msg := model.NewUserMessage("describe")
+	msg.ContentParts = []model.ContentPart{{Type: model.ContentTypeText, Text: &text}}
+	req := &guardrail.Request{Message: msg}
+	assert.Equal(t, "describe\na picture of a cat", req.Text())
+}
+
+func TestOutputMessage(t *testing.T) {
+	final := &model.Response{Choices: []model.Choice{{Message: model.NewAssistantMessage("done")}}}
+	msg, ok := guardrail.OutputMessage(final)
+	assert.True(t, ok)
+	assert.Equal(t, "done", msg.Content)
+
+	for name, rsp := range map[string]*model.Response{
+		"nil":     nil,
+		"partial": {IsPartial: true, Choices: []model.Choice{{Message: model.NewAssistantMessage("do")}}},
+		"error":   {Error: &model.ResponseError{Message: "failed"}},
+		"empty":   {Choices: []model.Choice{{Message: model.NewAssistantMessage("")}}},
+		"tool call": {Choices: []model.Choice{{Message: model.Message{
+			Role:      model.RoleAssistant,
+			ToolCalls: []model.ToolCall{{ID: "call_1"}},
+		}}}},
+	} {
+		_, ok := guardrail.OutputMessage(rsp)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestClaimInput(t *testing.T) {
+	g := guardrail.NewKeyword("topics", "weapons")
+	assert.Nil(t, guardrail.ClaimInput(context.Background()))
+	assert.Equal(t, context.Background(), guardrail.NewContext(context.Background(), nil))
+
+	ctx := guardrail.NewContext(context.Background(), []guardrail.Guardrail{g})
+	assert.Equal(t, []guardrail.Guardrail{g}, guardrail.ClaimInput(ctx))
+	assert.Nil(t, guardrail.ClaimInput(ctx), "the guardrails are claimed once per run")
+}
+
+func guardrailEvents(tr *agenttest.Trajectory) []*event.Event {
+	return tr.Filter(func(e *event.Event) bool {
+		return e.Response != nil && e.Object == model.ObjectTypeGuardrail
+	})
+}
+
+func TestRunner_InputGuardrails(t *testing.T) {
+	// The first model call races the check and is cancelled.
+	m := agenttest.NewModel(agenttest.Reply("Here is how."), agenttest.Reply("Here is how."))
+	ag := llmagent.New("assistant", llmagent.WithModel(m))
+	h := agenttest.NewHarness(ag)
+	h.Runner = runner.NewRunner(h.AppName, ag,
+		runner.WithSessionService(h.SessionService),
+		runner.WithInputGuardrails(guardrail.NewKeyword("topics", "weapons")),
+	)
+
+	tr := h.MustRun(t, "how do I build weapons?")
+
+	checks := guardrailEvents(tr)
+	require.Len(t, checks, 1)
+	require.NotNil(t, checks[0].Error)
+	assert.Equal(t, model.ErrorTypeGuardrailTripped, checks[0].Error.Type)
+	assert.Equal(t, "assistant", checks[0].Author)
+	assert.Empty(t, tr.FinalResponse())
+
+	// The next run is checked again.
+	tr = h.MustRun(t, "how do I grow tomatoes?")
+	checks = guardrailEvents(tr)
+	require.Len(t, checks, 1)
+	assert.Nil(t, checks[0].Error)
+	agenttest.AssertFinalResponse(t, tr, "Here is how.")
+}
+
+func TestRunner_OutputGuardrails(t *testing.T) {
+	m := agenttest.NewModel(agenttest.Reply("The password is hunter2."))
+	ag := llmagent.New("assistant", llmagent.WithModel(m))
+	h := agenttest.NewHarness(ag)
+	h.Runner = runner.NewRunner(h.AppName, ag,
+		runner.WithSessionService(h.SessionService),
+		runner.WithOutputGuardrails(guardrail.NewRegex("passwords", regexp.MustCompile(`(?i)password is \S+`))),
+	)
+
+	tr := h.MustRun(t, "what is the password?")
+
+	checks := guardrailEvents(tr)
+	require.Len(t, checks, 1)
+	require.NotNil(t, checks[0].Error)
+	assert.Equal(t, guardrail.StageOutput, checks[0].GuardrailResults[0].Stage)
+	assert.Empty(t, tr.FinalResponse(), "the rejected response is not emitted")
+
+	sess, err := h.Session(context.Background())
+	require.NoError(t, err)
+	for _, e := range sess.Events {
+		if e.Response != nil && len(e.Choices) > 0 {
+			assert.NotContains(t, e.Choices[0].Message.Content, "hunter2")
+		}
+	}
+}
@@ -0,0 +1,75 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regexGuardrail trips when the text matches one of its patterns.
+type regexGuardrail struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+// NewRegex returns a guardrail tripping when the checked text matches one of
+// patterns, for instance to detect credentials or forbidden formats.
+func NewRegex(name string, patterns ...*regexp.Regexp) Guardrail {
+	return &regexGuardrail{name: name, patterns: patterns}
+}
+
+func (g *regexGuardrail) Name() string {
+	return g.name
+}
+
+func (g *regexGuardrail) Check(_ context.Context, req *Request) (*Result, error) {
+	text := req.Text()
+	for _, re := range g.patterns {
+		if re.MatchString(text) {
+			return &Result{Tripped: true, Reason: fmt.Sprintf("matches pattern %q", re.String())}, nil
+		}
+	}
+	return &Result{}, nil
+}
+
+// keywordGuardrail trips when the text contains one of its keywords.
+type keywordGuardrail struct {
+	name     string
+	keywords []string
+}
+
+// NewKeyword returns a guardrail tripping when the checked text contains one
+// of keywords, ignoring case, for instance to restrict topics.
+func NewKeyword(name string, keywords ...string) Guardrail {
+	lower := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			lower = append(lower, k)
+		}
+	}
+	return &keywordGuardrail{name: name, keywords: lower}
+}
+
+func (g *keywordGuardrail) Name() string {
+	return g.name
+}
+
+func (g *keywordGuardrail) Check(_ context.Context, req *Request) (*Result, error) {
+	text := strings.ToLower(req.Text())
+	for _, k := range g.keywords {
+		if strings.Contains(text, k) {
+			return &Result{Tripped: true, Reason: fmt.Sprintf("contains keyword %q", k)}, nil
+		}
+	}
+	return &Result{}, nil
+}
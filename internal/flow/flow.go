@@ -36,3 +36,24 @@ type ResponseProcessor interface {
 	// ProcessResponse processes the response and sends events directly to the provided channel.
 	ProcessResponse(ctx context.Context, invocation *agent.Invocation, req *model.Request, rsp *model.Response, ch chan<- *event.Event)
 }
+
+// RetryingResponseProcessor is a ResponseProcessor that can reject the final
+// response of a step, in which case the flow calls the model again instead of
+// ending.
+type RetryingResponseProcessor interface {
+	ResponseProcessor
+	// RejectsResponse reports whether ProcessResponse will reject the final
+	// response and ask for another one. The flow asks before emitting the
+	// response, which is then not emitted as final.
+	RejectsResponse(invocation *agent.Invocation, rsp *model.Response) bool
+	// RetryRequested reports whether the processor rejected the final response
+	// of the last step of the invocation, and resets the request.
+	RetryRequested(invocation *agent.Invocation) bool
+}
+
+// InvocationStateProcessor is a processor keeping state per invocation. The
+// flow calls EndInvocation once it stops running an invocation, whatever the
+// reason, so that the processor can drop the state of the invocation.
+type InvocationStateProcessor interface {
+	EndInvocation(invocation *agent.Invocation)
+}
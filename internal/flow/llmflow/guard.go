@@ -0,0 +1,153 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llmflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// defaultWrapUpPrompt asks the model for a final answer when a guard ends
+// the invocation.
+const defaultWrapUpPrompt = "You have reached the limit of steps for this task and cannot call any more tools. " +
+	"Using only the information gathered so far, give your best final answer now, " +
+	"and say briefly what is still missing."
+
+// Guards bounds the work of an invocation, so that a model repeating tool
+// calls cannot run forever. Zero values disable a guard. When a guard trips,
+// the flow emits an error event with one of the model.ErrorType*Exceeded,
+// model.ErrorTypeInvocationTimeout or model.ErrorTypeToolCallLoop types and
+// ends.
+type Guards struct {
+	// MaxLLMCalls limits the model calls of an invocation.
+	MaxLLMCalls int
+	// MaxToolCalls limits the tool calls of an invocation. The response
+	// requesting the calls over the limit is dropped before they run.
+	MaxToolCalls int
+	// Timeout limits the wall-clock duration of an invocation.
+	Timeout time.Duration
+	// MaxRepeatedToolCalls limits how many times the same tool may be called
+	// with the same arguments in an invocation.
+	MaxRepeatedToolCalls int
+	// WrapUp makes a last model call without tools when a guard trips,
+	// asking for a best-effort answer, before the error event.
+	WrapUp bool
+	// WrapUpPrompt replaces the default prompt of the wrap up call.
+	WrapUpPrompt string
+}
+
+// guardError reports a tripped guard.
+type guardError struct {
+	errType string
+	msg     string
+}
+
+func (e *guardError) Error() string {
+	return e.msg
+}
+
+// guard tracks the work of one invocation against Guards.
+type guard struct {
+	Guards
+	llmCalls  int
+	toolCalls int
+	repeated  map[string]int
+}
+
+func newGuard(g Guards) *guard {
+	return &guard{Guards: g, repeated: make(map[string]int)}
+}
+
+// withTimeout returns the context the steps of the invocation run with.
+func (g *guard) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, g.Timeout)
+}
+
+// timedOut reports whether runCtx, derived from ctx by withTimeout, ended
+// because of the timeout guard rather than its parent.
+func (g *guard) timedOut(ctx, runCtx context.Context) *guardError {
+	if g.Timeout <= 0 || ctx.Err() != nil || !errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	return &guardError{
+		errType: model.ErrorTypeInvocationTimeout,
+		msg:     fmt.Sprintf("invocation exceeded the timeout of %s", g.Timeout),
+	}
+}
+
+// beforeLLMCall counts a model call, failing once the limit is reached.
+func (g *guard) beforeLLMCall() *guardError {
+	if g.MaxLLMCalls > 0 && g.llmCalls >= g.MaxLLMCalls {
+		return &guardError{
+			errType: model.ErrorTypeMaxLLMCallsExceeded,
+			msg:     fmt.Sprintf("invocation reached the limit of %d LLM calls", g.MaxLLMCalls),
+		}
+	}
+	g.llmCalls++
+	return nil
+}
+
+// beforeToolCalls counts the tool calls requested by a response, failing
+// when they exceed the limit or repeat a call too many times.
+func (g *guard) beforeToolCalls(calls []model.ToolCall) *guardError {
+	g.toolCalls += len(calls)
+	if g.MaxToolCalls > 0 && g.toolCalls > g.MaxToolCalls {
+		return &guardError{
+			errType: model.ErrorTypeMaxToolCallsExceeded,
+			msg:     fmt.Sprintf("invocation exceeded the limit of %d tool calls", g.MaxToolCalls),
+		}
+	}
+	if g.MaxRepeatedToolCalls <= 0 {
+		return nil
+	}
+	for _, call := range calls {
+		key := call.Function.Name + "\x00" + canonicalArguments(call.Function.Arguments)
+		g.repeated[key]++
+		if g.repeated[key] > g.MaxRepeatedToolCalls {
+			return &guardError{
+				errType: model.ErrorTypeToolCallLoop,
+				msg: fmt.Sprintf("tool %s was called more than %d times with the same arguments",
+					call.Function.Name, g.MaxRepeatedToolCalls),
+			}
+		}
+	}
+	return nil
+}
+
+// wrapUpPrompt returns the prompt of the wrap up call.
+func (g *guard) wrapUpPrompt() string {
+	if g.WrapUpPrompt != "" {
+		return g.WrapUpPrompt
+	}
+	return defaultWrapUpPrompt
+}
+
+// canonicalArguments returns args with sorted keys and no insignificant
+// white space, so that calls differing only in formatting count as
+// identical.
+func canonicalArguments(args []byte) string {
+	var v any
+	if err := json.Unmarshal(args, &v); err != nil {
+		return string(args)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return string(args)
+	}
+	return string(b)
+}
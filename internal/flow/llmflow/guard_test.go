@@ -0,0 +1,74 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llmflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func toolCall(name, args string) model.ToolCall {
+	return model.ToolCall{Function: model.FunctionDefinitionParam{Name: name, Arguments: []byte(args)}}
+}
+
+func TestGuard_LLMCalls(t *testing.T) {
+	g := newGuard(Guards{MaxLLMCalls: 2})
+	require.Nil(t, g.beforeLLMCall())
+	require.Nil(t, g.beforeLLMCall())
+	gerr := g.beforeLLMCall()
+	require.NotNil(t, gerr)
+	assert.Equal(t, model.ErrorTypeMaxLLMCallsExceeded, gerr.errType)
+
+	assert.Nil(t, newGuard(Guards{}).beforeLLMCall(), "zero disables the guard")
+}
+
+func TestGuard_ToolCalls(t *testing.T) {
+	g := newGuard(Guards{MaxToolCalls: 3})
+	require.Nil(t, g.beforeToolCalls([]model.ToolCall{toolCall("a", "{}"), toolCall("b", "{}")}))
+	gerr := g.beforeToolCalls([]model.ToolCall{toolCall("c", "{}"), toolCall("d", "{}")})
+	require.NotNil(t, gerr)
+	assert.Equal(t, model.ErrorTypeMaxToolCallsExceeded, gerr.errType)
+}
+
+func TestGuard_RepeatedToolCalls(t *testing.T) {
+	g := newGuard(Guards{MaxRepeatedToolCalls: 1})
+	require.Nil(t, g.beforeToolCalls([]model.ToolCall{toolCall("search", `{"q":"go","n":1}`)}))
+	require.Nil(t, g.beforeToolCalls([]model.ToolCall{toolCall("search", `{"q":"rust","n":1}`)}))
+	require.Nil(t, g.beforeToolCalls([]model.ToolCall{toolCall("fetch", `{"q":"go","n":1}`)}))
+	// Formatting and key order do not make a call different.
+	gerr := g.beforeToolCalls([]model.ToolCall{toolCall("search", "{\"n\": 1,\n \"q\": \"go\"}")})
+	require.NotNil(t, gerr)
+	assert.Equal(t, model.ErrorTypeToolCallLoop, gerr.errType)
+	assert.Contains(t, gerr.msg, "search")
+}
+
+func TestGuard_TimedOut(t *testing.T) {
+	g := newGuard(Guards{Timeout: time.Millisecond})
+	ctx := context.Background()
+	runCtx, cancel := g.withTimeout(ctx)
+	defer cancel()
+	<-runCtx.Done()
+	gerr := g.timedOut(ctx, runCtx)
+	require.NotNil(t, gerr)
+	assert.Equal(t, model.ErrorTypeInvocationTimeout, gerr.errType)
+
+	// Cancellation by the caller is not reported as a timeout.
+	parent, cancelParent := context.WithCancel(context.Background())
+	runCtx, cancel = g.withTimeout(parent)
+	defer cancel()
+	cancelParent()
+	assert.Nil(t, g.timedOut(parent, runCtx))
+}
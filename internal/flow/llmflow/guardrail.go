@@ -0,0 +1,115 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package llmflow
+
+import (
+	"context"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/guardrail"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// inputCheck is the check of the invocation message by the input
+// guardrails, running concurrently with the first model call.
+type inputCheck struct {
+	done    chan struct{}
+	results []event.GuardrailResult
+	// modelCtx is the context of the model call racing the check. It is
+	// cancelled as soon as a guardrail trips.
+	modelCtx    context.Context
+	cancelModel context.CancelFunc
+	reported    bool
+}
+
+// startInputCheck starts checking the invocation message with the input
+// guardrails of the flow and those of the run, if any.
+func (f *Flow) startInputCheck(ctx context.Context, invocation *agent.Invocation) *inputCheck {
+	guardrails := append(append([]guardrail.Guardrail(nil), f.inputGuardrails...), guardrail.ClaimInput(ctx)...)
+	if len(guardrails) == 0 {
+		return nil
+	}
+	c := &inputCheck{done: make(chan struct{})}
+	c.modelCtx, c.cancelModel = context.WithCancel(ctx)
+	req := &guardrail.Request{
+		Stage:     guardrail.StageInput,
+		AgentName: invocation.AgentName,
+		Message:   invocation.Message,
+	}
+	go func() {
+		defer close(c.done)
+		c.results = guardrail.Check(ctx, guardrails, req)
+		if guardrail.Tripped(c.results) != nil {
+			c.cancelModel()
+		}
+	}()
+	return c
+}
+
+// await waits for the check and reports its results, once. When a guardrail
+// tripped, the reporting event is returned.
+func (c *inputCheck) await(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	eventChan chan<- *event.Event,
+) (*event.Event, error) {
+	if c == nil || c.reported {
+		return nil, nil
+	}
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	c.reported = true
+	evt := guardrail.NewEvent(invocation.InvocationID, invocation.AgentName, c.results)
+	if err := agent.EmitEvent(ctx, invocation, eventChan, evt); err != nil {
+		return nil, err
+	}
+	if guardrail.Tripped(c.results) == nil {
+		return nil, nil
+	}
+	log.Warnf("Input guardrail tripped for agent %s: %s", invocation.AgentName, evt.Error.Message)
+	return evt, nil
+}
+
+// checkOutput runs the output guardrails of the flow on a final response and
+// returns the event reporting the check, or nil when there is nothing to
+// check.
+func (f *Flow) checkOutput(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	response *model.Response,
+) *event.Event {
+	if len(f.outputGuardrails) == 0 {
+		return nil
+	}
+	msg, ok := guardrail.OutputMessage(response)
+	if !ok {
+		return nil
+	}
+	results := guardrail.Check(ctx, f.outputGuardrails, &guardrail.Request{
+		Stage:     guardrail.StageOutput,
+		AgentName: invocation.AgentName,
+		Message:   msg,
+	})
+	return guardrail.NewEvent(invocation.InvocationID, invocation.AgentName, results)
+}
+
+// drain discards the responses of a cancelled model call, so that the model
+// does not block on sending them.
+func drain(responseChan <-chan *model.Response) {
+	go func() {
+		for range responseChan {
+		}
+	}()
+}
@@ -18,7 +18,9 @@ import (
 	oteltrace "go.opentelemetry.io/otel/trace"
 
 	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
 	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/guardrail"
 	"trpc.group/trpc-go/trpc-agent-go/internal/flow"
 	itelemetry "trpc.group/trpc-go/trpc-agent-go/internal/telemetry"
 	"trpc.group/trpc-go/trpc-agent-go/log"
@@ -39,6 +41,12 @@ const (
 type Options struct {
 	ChannelBufferSize int // Buffer size for event channels (default: 256)
 	ModelCallbacks    *model.Callbacks
+	Guards            Guards // Limits of each invocation (default: none)
+	// InputGuardrails check the invocation message concurrently with the
+	// first model call.
+	InputGuardrails []guardrail.Guardrail
+	// OutputGuardrails check final responses before they are emitted.
+	OutputGuardrails []guardrail.Guardrail
 }
 
 // Flow provides the basic flow implementation.
@@ -47,6 +55,9 @@ type Flow struct {
 	responseProcessors []flow.ResponseProcessor
 	channelBufferSize  int
 	modelCallbacks     *model.Callbacks
+	guards             Guards
+	inputGuardrails    []guardrail.Guardrail
+	outputGuardrails   []guardrail.Guardrail
 }
 
 // New creates a new basic flow instance with the provided processors.
@@ -67,6 +78,9 @@ func New(
 		responseProcessors: responseProcessors,
 		channelBufferSize:  channelBufferSize,
 		modelCallbacks:     opts.ModelCallbacks,
+		guards:             opts.Guards,
+		inputGuardrails:    opts.InputGuardrails,
+		outputGuardrails:   opts.OutputGuardrails,
 	}
 }
 
@@ -76,16 +90,46 @@ func (f *Flow) Run(ctx context.Context, invocation *agent.Invocation) (<-chan *e
 
 	go func() {
 		defer close(eventChan)
+		defer f.endInvocation(invocation)
+
+		g := newGuard(f.guards)
+		runCtx, cancel := g.withTimeout(ctx)
+		defer cancel()
+
+		// The input guardrails race the first model call.
+		check := f.startInputCheck(runCtx, invocation)
+		if check != nil {
+			defer check.cancelModel()
+		}
 
 		for {
+			// Stop before the next step when a guard trips.
+			gerr := g.timedOut(ctx, runCtx)
+			if gerr == nil {
+				gerr = g.beforeLLMCall()
+			}
+			if gerr != nil {
+				f.endWithGuardError(ctx, invocation, g, gerr, eventChan)
+				return
+			}
+
 			// emit start event and wait for completion notice.
-			if err := f.emitStartEventAndWait(ctx, invocation, eventChan); err != nil {
+			if err := f.emitStartEventAndWait(runCtx, invocation, eventChan); err != nil {
 				return
 			}
 
 			// Run one step (one LLM call cycle).
-			lastEvent, err := f.runOneStep(ctx, invocation, eventChan)
+			lastEvent, err := f.runOneStep(runCtx, invocation, g, check, eventChan)
+			check = nil
 			if err != nil {
+				// A tripped guard ends the invocation with its own error type.
+				if !errors.As(err, &gerr) {
+					gerr = g.timedOut(ctx, runCtx)
+				}
+				if gerr != nil {
+					f.endWithGuardError(ctx, invocation, g, gerr, eventChan)
+					return
+				}
 				// Treat context cancellation as graceful termination (common in streaming
 				// pipelines where the client closes the stream after final event).
 				if errors.Is(err, context.Canceled) {
@@ -118,8 +162,10 @@ func (f *Flow) Run(ctx context.Context, invocation *agent.Invocation) (<-chan *e
 
 			// Exit conditions.
 			// If no events were produced in this step, treat as terminal to avoid busy loop.
-			// Also break when EndInvocation is set or a final response is observed.
-			if lastEvent == nil || invocation.EndInvocation || lastEvent.IsFinalResponse() {
+			// Also break when EndInvocation is set or a final response is observed,
+			// unless a response processor rejected it.
+			retry := f.retryRequested(invocation)
+			if lastEvent == nil || invocation.EndInvocation || (lastEvent.IsFinalResponse() && !retry) {
 				break
 			}
 		}
@@ -154,6 +200,8 @@ func (f *Flow) emitStartEventAndWait(ctx context.Context, invocation *agent.Invo
 func (f *Flow) runOneStep(
 	ctx context.Context,
 	invocation *agent.Invocation,
+	g *guard,
+	check *inputCheck,
 	eventChan chan<- *event.Event,
 ) (*event.Event, error) {
 	var lastEvent *event.Event
@@ -178,13 +226,22 @@ func (f *Flow) runOneStep(
 	defer span.End()
 
 	// 2. Call LLM (get response channel).
-	responseChan, err := f.callLLM(ctx, invocation, llmRequest)
+	llmCtx := ctx
+	if check != nil {
+		llmCtx = check.modelCtx
+	}
+	responseChan, err := f.callLLM(llmCtx, invocation, llmRequest)
 	if err != nil {
+		// The call fails when a tripped input guardrail cancelled it.
+		if tripped, _ := check.await(ctx, invocation, eventChan); tripped != nil {
+			invocation.EndInvocation = true
+			return tripped, nil
+		}
 		return nil, err
 	}
 
 	// 3. Process streaming responses.
-	return f.processStreamingResponses(ctx, invocation, llmRequest, responseChan, eventChan, span)
+	return f.processStreamingResponses(ctx, invocation, llmRequest, g, check, responseChan, eventChan, span)
 }
 
 // processStreamingResponses handles the streaming response processing logic.
@@ -192,6 +249,8 @@ func (f *Flow) processStreamingResponses(
 	ctx context.Context,
 	invocation *agent.Invocation,
 	llmRequest *model.Request,
+	g *guard,
+	check *inputCheck,
 	responseChan <-chan *model.Response,
 	eventChan chan<- *event.Event,
 	span oteltrace.Span,
@@ -199,6 +258,13 @@ func (f *Flow) processStreamingResponses(
 	var lastEvent *event.Event
 
 	for response := range responseChan {
+		// Nothing from the model is emitted before the input is accepted.
+		if tripped, err := check.await(ctx, invocation, eventChan); err != nil || tripped != nil {
+			drain(responseChan)
+			invocation.EndInvocation = tripped != nil
+			return tripped, err
+		}
+
 		// Handle after model callbacks.
 		customResp, err := f.handleAfterModelCallbacks(ctx, invocation, llmRequest, response, eventChan)
 		if err != nil {
@@ -208,8 +274,33 @@ func (f *Flow) processStreamingResponses(
 			response = customResp
 		}
 
+		// Tool calls over a limit are dropped before they are recorded or run.
+		if !response.IsPartial && response.IsToolCallResponse() {
+			if gerr := g.beforeToolCalls(response.Choices[0].Message.ToolCalls); gerr != nil {
+				return lastEvent, gerr
+			}
+		}
+
+		// A final response rejected by an output guardrail is replaced by
+		// the event reporting the check.
+		if checked := f.checkOutput(ctx, invocation, response); checked != nil {
+			agent.EmitEvent(ctx, invocation, eventChan, checked)
+			if checked.Error != nil {
+				drain(responseChan)
+				invocation.EndInvocation = true
+				return checked, nil
+			}
+		}
+
 		// 4. Create and send LLM response using the clean constructor.
 		llmResponseEvent := f.createLLMResponseEvent(invocation, response, llmRequest)
+		if f.rejectsResponse(invocation, response) {
+			// The rejected reply stays in the history the model repairs it
+			// from, but it is not the answer.
+			rejected := *response
+			rejected.Done = false
+			llmResponseEvent.Response = &rejected
+		}
 		agent.EmitEvent(ctx, invocation, eventChan, llmResponseEvent)
 		lastEvent = llmResponseEvent
 		// 5. Check context cancellation.
@@ -217,6 +308,14 @@ func (f *Flow) processStreamingResponses(
 			return lastEvent, err
 		}
 
+		// Record usage before tool calls run so that an exceeded budget stops
+		// the flow without further work. Cache hits are free.
+		if !response.IsPartial && !response.CacheHit && response.Usage != nil && invocation.Model != nil {
+			if err := budget.Record(ctx, invocation.Model.Info().Name, response.Usage); err != nil {
+				return lastEvent, err
+			}
+		}
+
 		// 6. Postprocess response.
 		f.postprocess(ctx, invocation, llmRequest, response, eventChan)
 		if err := agent.CheckContextCancelled(ctx); err != nil {
@@ -226,6 +325,11 @@ func (f *Flow) processStreamingResponses(
 		itelemetry.TraceChat(span, invocation, llmRequest, response, llmResponseEvent.ID)
 	}
 
+	// A model call without responses still waits for the input check.
+	if tripped, err := check.await(ctx, invocation, eventChan); err != nil || tripped != nil {
+		invocation.EndInvocation = tripped != nil
+		return tripped, err
+	}
 	return lastEvent, nil
 }
 
@@ -323,6 +427,10 @@ func (f *Flow) callLLM(
 		return nil, errors.New("no model available for LLM call")
 	}
 
+	if err := budget.Check(ctx); err != nil {
+		return nil, err
+	}
+
 	log.Debugf("Calling LLM for agent %s", invocation.AgentName)
 
 	// Run before model callbacks if they exist.
@@ -351,6 +459,128 @@ func (f *Flow) callLLM(
 	return responseChan, nil
 }
 
+// endWithGuardError ends an invocation stopped by a guard: it makes the wrap
+// up call if configured, then emits the error event of the guard.
+func (f *Flow) endWithGuardError(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	g *guard,
+	gerr *guardError,
+	eventChan chan<- *event.Event,
+) {
+	log.Warnf("Flow stopped by a guard for agent %s: %s", invocation.AgentName, gerr.msg)
+	if g.WrapUp {
+		if err := f.wrapUp(ctx, invocation, g.wrapUpPrompt(), eventChan); err != nil {
+			log.Errorf("Wrap up call failed for agent %s: %v", invocation.AgentName, err)
+		}
+	}
+	agent.EmitEvent(ctx, invocation, eventChan, event.NewErrorEvent(
+		invocation.InvocationID,
+		invocation.AgentName,
+		gerr.errType,
+		gerr.msg,
+	))
+}
+
+// wrapUp makes a last model call without tools, asking for a best-effort
+// answer. Its responses are emitted without running the response
+// processors, so that nothing else is executed.
+func (f *Flow) wrapUp(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	prompt string,
+	eventChan chan<- *event.Event,
+) error {
+	if err := f.emitStartEventAndWait(ctx, invocation, eventChan); err != nil {
+		return err
+	}
+	llmRequest := &model.Request{
+		Tools: make(map[string]tool.Tool),
+	}
+	f.preprocess(ctx, invocation, llmRequest, eventChan)
+	llmRequest.Tools = nil
+	llmRequest.Messages = append(llmRequest.Messages, model.NewUserMessage(prompt))
+
+	responseChan, err := f.callLLM(ctx, invocation, llmRequest)
+	if err != nil {
+		return err
+	}
+	for response := range responseChan {
+		customResp, err := f.handleAfterModelCallbacks(ctx, invocation, llmRequest, response, eventChan)
+		if err != nil {
+			return err
+		}
+		if customResp != nil {
+			response = customResp
+		}
+		if !response.IsPartial && response.IsToolCallResponse() {
+			// Tools cannot run anymore; keep the text of the answer only.
+			rsp := *response
+			rsp.Choices = make([]model.Choice, len(response.Choices))
+			copy(rsp.Choices, response.Choices)
+			for i := range rsp.Choices {
+				rsp.Choices[i].Message.ToolCalls = nil
+			}
+			response = &rsp
+		}
+		if checked := f.checkOutput(ctx, invocation, response); checked != nil {
+			agent.EmitEvent(ctx, invocation, eventChan, checked)
+			if checked.Error != nil {
+				drain(responseChan)
+				return nil
+			}
+		}
+		agent.EmitEvent(ctx, invocation, eventChan, f.createLLMResponseEvent(invocation, response, llmRequest))
+		if !response.IsPartial && !response.CacheHit && response.Usage != nil && invocation.Model != nil {
+			if err := budget.Record(ctx, invocation.Model.Info().Name, response.Usage); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rejectsResponse reports whether a response processor will reject the
+// final response and ask for another one.
+func (f *Flow) rejectsResponse(invocation *agent.Invocation, response *model.Response) bool {
+	if response.IsPartial {
+		return false
+	}
+	for _, processor := range f.responseProcessors {
+		if rp, ok := processor.(flow.RetryingResponseProcessor); ok && rp.RejectsResponse(invocation, response) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryRequested reports whether a response processor rejected the final
+// response of the last step. Every processor is asked so that all requests
+// are reset.
+func (f *Flow) retryRequested(invocation *agent.Invocation) bool {
+	retry := false
+	for _, processor := range f.responseProcessors {
+		if rp, ok := processor.(flow.RetryingResponseProcessor); ok && rp.RetryRequested(invocation) {
+			retry = true
+		}
+	}
+	return retry
+}
+
+// endInvocation lets the processors drop the state of the invocation.
+func (f *Flow) endInvocation(invocation *agent.Invocation) {
+	for _, processor := range f.requestProcessors {
+		if sp, ok := processor.(flow.InvocationStateProcessor); ok {
+			sp.EndInvocation(invocation)
+		}
+	}
+	for _, processor := range f.responseProcessors {
+		if sp, ok := processor.(flow.InvocationStateProcessor); ok {
+			sp.EndInvocation(invocation)
+		}
+	}
+}
+
 // postprocess handles post-LLM call processing using response processors.
 func (f *Flow) postprocess(
 	ctx context.Context,
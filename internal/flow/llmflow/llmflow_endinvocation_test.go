@@ -124,3 +124,35 @@ func TestStreaming_BreaksWhenEndInvocationSet(t *testing.T) {
 	// Assert: only the first chunk should be observed.
 	require.Equal(t, 2, chunkCount)
 }
+
+// stateProcessor records the invocations it is told have ended.
+type stateProcessor struct{ ended []string }
+
+func (p *stateProcessor) ProcessResponse(context.Context, *agent.Invocation, *model.Request, *model.Response, chan<- *event.Event) {
+}
+
+func (p *stateProcessor) EndInvocation(inv *agent.Invocation) {
+	p.ended = append(p.ended, inv.InvocationID)
+}
+
+func TestRun_EndsInvocationStateOnEveryExit(t *testing.T) {
+	// The flow stops with an error, as there is no model.
+	proc := &stateProcessor{}
+	f := New(nil, []flow.ResponseProcessor{proc}, Options{})
+	inv := &agent.Invocation{InvocationID: "inv-error", AgentName: "agent"}
+
+	ch, err := f.Run(context.Background(), inv)
+	require.NoError(t, err)
+	for range ch {
+	}
+	require.Equal(t, []string{"inv-error"}, proc.ended)
+
+	// The flow stops with a cancelled context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ch, err = f.Run(ctx, &agent.Invocation{InvocationID: "inv-cancel", AgentName: "agent", Model: &twoChunkModel{}})
+	require.NoError(t, err)
+	for range ch {
+	}
+	require.Equal(t, []string{"inv-error", "inv-cancel"}, proc.ended)
+}
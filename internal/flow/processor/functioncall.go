@@ -12,6 +12,7 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -40,6 +41,8 @@ const (
 	ErrorStreamableToolExecution = "Error: streamable tool execution failed"
 	// ErrorMarshalResult is the error message for failed to marshal result.
 	ErrorMarshalResult = "Error: failed to marshal result"
+	// ErrorInvalidArguments is the error message for tool arguments not matching the tool input schema.
+	ErrorInvalidArguments = "Error: invalid tool arguments"
 )
 
 // summarizationSkipper is implemented by tools that can indicate whether
@@ -84,14 +87,36 @@ type subAgentCall struct {
 type FunctionCallResponseProcessor struct {
 	enableParallelTools bool
 	toolCallbacks       *tool.Callbacks
+	validateArguments   bool
+}
+
+// FunctionCallOption configures a FunctionCallResponseProcessor.
+type FunctionCallOption func(*FunctionCallResponseProcessor)
+
+// WithToolArgumentValidation checks the arguments of tool calls against the
+// input schema of the tool before calling it. Common mistakes such as
+// trailing commas or numbers sent as strings are repaired; other violations
+// are returned to the model as the tool result so that it can fix the call.
+func WithToolArgumentValidation(enabled bool) FunctionCallOption {
+	return func(p *FunctionCallResponseProcessor) {
+		p.validateArguments = enabled
+	}
 }
 
 // NewFunctionCallResponseProcessor creates a new transfer response processor.
-func NewFunctionCallResponseProcessor(enableParallelTools bool, toolCallbacks *tool.Callbacks) *FunctionCallResponseProcessor {
-	return &FunctionCallResponseProcessor{
+func NewFunctionCallResponseProcessor(
+	enableParallelTools bool,
+	toolCallbacks *tool.Callbacks,
+	opts ...FunctionCallOption,
+) *FunctionCallResponseProcessor {
+	p := &FunctionCallResponseProcessor{
 		enableParallelTools: enableParallelTools,
 		toolCallbacks:       toolCallbacks,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 // ProcessResponse implements the flow.ResponseProcessor interface.
@@ -138,7 +163,7 @@ func (p *FunctionCallResponseProcessor) handleFunctionCallsAndSendEvent(
 	tools map[string]tool.Tool,
 	eventChan chan<- *event.Event,
 ) (*event.Event, error) {
-	functionResponseEvent, err := p.handleFunctionCalls(
+	functionResponseEvent, pending, err := p.handleFunctionCalls(
 		ctx,
 		invocation,
 		llmResponse,
@@ -155,18 +180,24 @@ func (p *FunctionCallResponseProcessor) handleFunctionCallsAndSendEvent(
 		))
 		return nil, err
 	}
+	if len(pending) > 0 {
+		p.requestConfirmation(ctx, invocation, pending, functionResponseEvent, eventChan)
+		return nil, nil
+	}
 	agent.EmitEvent(ctx, invocation, eventChan, functionResponseEvent)
 	return functionResponseEvent, nil
 }
 
-// handleFunctionCalls executes tool calls and returns a merged response event.
+// handleFunctionCalls executes tool calls and returns a merged response event,
+// along with the calls paused until a human approves them. The event is nil
+// when every call is paused.
 func (p *FunctionCallResponseProcessor) handleFunctionCalls(
 	ctx context.Context,
 	invocation *agent.Invocation,
 	llmResponse *model.Response,
 	tools map[string]tool.Tool,
 	eventChan chan<- *event.Event,
-) (*event.Event, error) {
+) (*event.Event, []model.ToolCall, error) {
 	toolCalls := llmResponse.Choices[0].Message.ToolCalls
 
 	// If parallel tools are enabled AND multiple tool calls, execute concurrently
@@ -175,22 +206,31 @@ func (p *FunctionCallResponseProcessor) handleFunctionCalls(
 	}
 
 	var toolCallResponsesEvents []*event.Event
+	var pending pendingToolCalls
 	for i, tc := range toolCalls {
 		toolEvent, err := p.executeSingleToolCallSequential(
 			ctx, invocation, llmResponse, tools, eventChan, i, tc,
 		)
+		if errors.Is(err, tool.ErrConfirmationRequired) {
+			pending.add(i, tc)
+			continue
+		}
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if toolEvent != nil {
 			toolCallResponsesEvents = append(toolCallResponsesEvents, toolEvent)
 		}
 	}
 
+	paused := pending.list()
+	if len(paused) > 0 && len(toolCallResponsesEvents) == 0 {
+		return nil, paused, nil
+	}
 	mergedEvent := p.buildMergedParallelEvent(
 		ctx, invocation, llmResponse, tools, toolCalls, toolCallResponsesEvents,
 	)
-	return mergedEvent, nil
+	return mergedEvent, paused, nil
 }
 
 // executeSingleToolCallSequential runs one tool call and returns its event.
@@ -245,14 +285,15 @@ func (p *FunctionCallResponseProcessor) executeToolCallsInParallel(
 	toolCalls []model.ToolCall,
 	tools map[string]tool.Tool,
 	eventChan chan<- *event.Event,
-) (*event.Event, error) {
+) (*event.Event, []model.ToolCall, error) {
 	resultChan := make(chan toolResult, len(toolCalls))
 	var wg sync.WaitGroup
+	var pending pendingToolCalls
 
 	for i, tc := range toolCalls {
 		wg.Add(1)
 		go p.runParallelToolCall(
-			ctx, &wg, invocation, llmResponse, tools, eventChan, resultChan, i, tc,
+			ctx, &wg, invocation, llmResponse, tools, eventChan, resultChan, &pending, i, tc,
 		)
 	}
 
@@ -266,10 +307,14 @@ func (p *FunctionCallResponseProcessor) executeToolCallsInParallel(
 	toolCallResponsesEvents, err := p.collectParallelToolResults(
 		ctx, resultChan, len(toolCalls),
 	)
+	paused := pending.list()
+	if len(paused) > 0 && len(toolCallResponsesEvents) == 0 {
+		return nil, paused, err
+	}
 	mergedEvent := p.buildMergedParallelEvent(
 		ctx, invocation, llmResponse, tools, toolCalls, toolCallResponsesEvents,
 	)
-	return mergedEvent, err
+	return mergedEvent, paused, err
 }
 
 // runParallelToolCall executes one tool call and reports the result.
@@ -281,6 +326,7 @@ func (p *FunctionCallResponseProcessor) runParallelToolCall(
 	tools map[string]tool.Tool,
 	eventChan chan<- *event.Event,
 	resultChan chan<- toolResult,
+	pending *pendingToolCalls,
 	index int,
 	tc model.ToolCall,
 ) {
@@ -314,6 +360,12 @@ func (p *FunctionCallResponseProcessor) runParallelToolCall(
 	choice, modifiedArgs, err := p.executeToolCall(
 		ctx, invocation, tc, tools, index, eventChan,
 	)
+	// A call waiting for confirmation has no result yet.
+	if errors.Is(err, tool.ErrConfirmationRequired) {
+		pending.add(index, tc)
+		p.sendToolResult(ctx, resultChan, toolResult{index: index})
+		return
+	}
 	// If error is not nil, it must be a stopError, so we need to return this error.
 	if err != nil {
 		log.Errorf(
@@ -478,13 +530,27 @@ func (p *FunctionCallResponseProcessor) executeToolCall(
 		}
 	}
 
+	if p.validateArguments {
+		args, violations := checkToolArguments(tl.Declaration(), toolCall.Function.Arguments)
+		if len(violations) > 0 {
+			log.Warnf("Invalid arguments for tool %s: %s", toolCall.Function.Name, string(toolCall.Function.Arguments))
+			return p.createErrorChoice(index, toolCall.ID, invalidArgumentsContent(toolCall.Function.Name, violations)),
+				toolCall.Function.Arguments, nil
+		}
+		toolCall.Function.Arguments = args
+	}
+
+	if requiresConfirmation(tl) && !tool.IsConfirmed(ctx) {
+		return nil, toolCall.Function.Arguments, tool.ErrConfirmationRequired
+	}
+
 	log.Debugf("Executing tool %s with args: %s", toolCall.Function.Name, string(toolCall.Function.Arguments))
 
 	// Execute the tool with callbacks.
 	result, modifiedArgs, err := p.executeToolWithCallbacks(ctx, invocation, toolCall, tl, eventChan)
-	// Only return error when it's a stop error
+	// Only return error when it's a stop error or the call waits for confirmation.
 	if err != nil {
-		if _, ok := agent.AsStopError(err); ok {
+		if _, ok := agent.AsStopError(err); ok || errors.Is(err, tool.ErrConfirmationRequired) {
 			return nil, modifiedArgs, err
 		}
 		return p.createErrorChoice(index, toolCall.ID, err.Error()), modifiedArgs, nil
@@ -578,6 +644,9 @@ func (p *FunctionCallResponseProcessor) executeToolWithCallbacks(
 			toolDeclaration,
 			&toolCall.Function.Arguments,
 		)
+		if errors.Is(callbackErr, tool.ErrConfirmationRequired) {
+			return nil, toolCall.Function.Arguments, callbackErr
+		}
 		if callbackErr != nil {
 			log.Errorf("Before tool callback failed for %s: %v", toolCall.Function.Name, callbackErr)
 			return nil, toolCall.Function.Arguments, fmt.Errorf("tool callback error: %w", callbackErr)
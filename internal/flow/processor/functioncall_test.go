@@ -202,7 +202,7 @@ func TestExecuteToolCallsInParallel(t *testing.T) {
 		},
 	}
 	ctx := context.Background()
-	evt, err := NewFunctionCallResponseProcessor(true, nil).executeToolCallsInParallel(ctx, inv, response,
+	evt, _, err := NewFunctionCallResponseProcessor(true, nil).executeToolCallsInParallel(ctx, inv, response,
 		toolCalls, tools, nil)
 	require.NoError(t, err)
 	require.NotNil(t, evt.Choices)
@@ -1016,7 +1016,7 @@ func TestHandleFunctionCalls_SkipSummarization_Parallel_PropagatesFlag(t *testin
 	}
 	rsp := &model.Response{Model: "m", Choices: []model.Choice{{Message: model.Message{ToolCalls: toolCalls}}}}
 
-	evt, err := p.handleFunctionCalls(ctx, inv, rsp, tools, nil)
+	evt, _, err := p.handleFunctionCalls(ctx, inv, rsp, tools, nil)
 	require.NoError(t, err)
 	require.NotNil(t, evt)
 	require.NotNil(t, evt.Actions)
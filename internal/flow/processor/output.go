@@ -10,12 +10,19 @@
 package processor
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	"trpc.group/trpc-go/trpc-agent-go/agent"
 	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/internal/jsonschema"
+	"trpc.group/trpc-go/trpc-agent-go/internal/partialjson"
 	"trpc.group/trpc-go/trpc-agent-go/log"
 	"trpc.group/trpc-go/trpc-agent-go/model"
 )
@@ -24,17 +31,63 @@ import (
 type OutputResponseProcessor struct {
 	outputKey    string
 	outputSchema map[string]any
+
+	// validate rejects final output that does not match the schema, asking
+	// the model to repair it up to maxRetries times.
+	validate   bool
+	maxRetries int
+
+	mu sync.Mutex
+	// partials tracks the structured output being streamed, by invocation ID.
+	partials map[string]*partialOutput
+	// repairs tracks the rejected outputs, by invocation ID. The entries of
+	// both maps are dropped at the latest by EndInvocation.
+	repairs map[string]*repairState
+}
+
+// OutputResponseProcessorOption configures an OutputResponseProcessor.
+type OutputResponseProcessorOption func(*OutputResponseProcessor)
+
+// WithOutputValidation validates the final output against the structured
+// output schema or the output schema. Invalid output is sent back to the
+// model with the validation errors up to maxRetries times; after that an
+// error event of type model.ErrorTypeStructuredOutputError is emitted. The
+// output is stored under the output key only once it is valid.
+func WithOutputValidation(maxRetries int) OutputResponseProcessorOption {
+	return func(p *OutputResponseProcessor) {
+		p.validate = true
+		p.maxRetries = max(maxRetries, 0)
+	}
+}
+
+// repairState counts the outputs of an invocation rejected so far.
+type repairState struct {
+	attempts int
+	// pending is set when the flow should call the model again.
+	pending bool
+}
+
+// partialOutput is the structured output streamed so far in a response.
+type partialOutput struct {
+	responseID string
+	parser     *partialjson.Parser
+	last       []byte
 }
 
 // NewOutputResponseProcessor creates a new instance of OutputResponseProcessor.
 func NewOutputResponseProcessor(
 	outputKey string,
 	outputSchema map[string]any,
+	opts ...OutputResponseProcessorOption,
 ) *OutputResponseProcessor {
-	return &OutputResponseProcessor{
+	p := &OutputResponseProcessor{
 		outputKey:    outputKey,
 		outputSchema: outputSchema,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 // ProcessResponse processes the model response and handles output_key and output_schema functionality.
@@ -46,8 +99,15 @@ func (p *OutputResponseProcessor) ProcessResponse(
 	rsp *model.Response,
 	ch chan<- *event.Event,
 ) {
-	if invocation == nil || rsp == nil || rsp.IsPartial ||
-		(invocation.StructuredOutputType == nil && p.outputKey == "" && p.outputSchema == nil) {
+	if invocation == nil || rsp == nil {
+		return
+	}
+	if rsp.IsPartial {
+		p.emitPartialStructuredOutput(ctx, invocation, rsp, ch)
+		return
+	}
+	p.endPartialStructuredOutput(invocation)
+	if invocation.StructuredOutputType == nil && p.outputKey == "" && p.outputSchema == nil {
 		return
 	}
 	// Only process complete (non-partial) responses.
@@ -58,6 +118,14 @@ func (p *OutputResponseProcessor) ProcessResponse(
 	}
 	jsonObject, ok := extractFirstJSONObject(content)
 
+	if violations, validated := p.validateOutput(invocation, rsp); validated {
+		if len(violations) > 0 {
+			p.rejectOutput(ctx, invocation, violations, ch)
+			return
+		}
+		p.endRepair(invocation)
+	}
+
 	if ok {
 		// 1) Emit typed structured output payload if configured.
 		p.emitTypedStructuredOutput(ctx, invocation, jsonObject, ch)
@@ -95,17 +163,238 @@ func (p *OutputResponseProcessor) emitTypedStructuredOutput(
 		log.Errorf("Structured output unmarshal failed: %v", err)
 		return
 	}
+	var violations []string
+	if so := invocation.StructuredOutput; so != nil && so.JSONSchema != nil && so.JSONSchema.Schema != nil {
+		violations = schemaViolations(jsonschema.ValidateJSON(so.JSONSchema.Schema, []byte(jsonObject)))
+		if len(violations) > 0 {
+			log.Warnf("Structured output does not match the schema: %v", violations)
+		}
+	}
 	typedEvt := event.New(
 		invocation.InvocationID,
 		invocation.AgentName,
 		event.WithObject(model.ObjectTypeStateUpdate),
 		event.WithStructuredOutputPayload(instance),
+		event.WithStructuredOutputErrors(violations),
 	)
 
 	log.Debugf("Emitted typed structured output payload event.")
 	agent.EmitEvent(ctx, invocation, ch, typedEvt)
 }
 
+// emitPartialStructuredOutput feeds a streamed delta to the partial parser of
+// the invocation and emits the snapshot when it changed.
+func (p *OutputResponseProcessor) emitPartialStructuredOutput(
+	ctx context.Context, invocation *agent.Invocation, rsp *model.Response, ch chan<- *event.Event,
+) {
+	if invocation.StructuredOutputType == nil && invocation.StructuredOutput == nil {
+		return
+	}
+	if len(rsp.Choices) == 0 || rsp.Choices[0].Delta.Content == "" {
+		return
+	}
+	p.mu.Lock()
+	if p.partials == nil {
+		p.partials = make(map[string]*partialOutput)
+	}
+	po, ok := p.partials[invocation.InvocationID]
+	if !ok || po.responseID != rsp.ID {
+		po = &partialOutput{responseID: rsp.ID, parser: partialjson.NewParser()}
+		p.partials[invocation.InvocationID] = po
+	}
+	if err := po.parser.Write(rsp.Choices[0].Delta.Content); err != nil {
+		p.mu.Unlock()
+		return
+	}
+	snapshot, ok := po.parser.Snapshot()
+	if !ok || bytes.Equal(snapshot, po.last) {
+		p.mu.Unlock()
+		return
+	}
+	po.last = snapshot
+	p.mu.Unlock()
+
+	partial := &event.PartialStructuredOutput{JSON: snapshot}
+	if t := invocation.StructuredOutputType; t != nil {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		instance := reflect.New(t).Interface()
+		if err := json.Unmarshal(snapshot, instance); err == nil {
+			partial.Value = instance
+		}
+	}
+	evt := event.New(
+		invocation.InvocationID,
+		invocation.AgentName,
+		event.WithObject(model.ObjectTypeStructuredOutputPartial),
+		event.WithPartialStructuredOutput(partial),
+	)
+	evt.Response.ID = rsp.ID
+	evt.IsPartial = true
+	agent.EmitEvent(ctx, invocation, ch, evt)
+}
+
+// endPartialStructuredOutput drops the partial parser of the invocation once
+// the response is complete.
+func (p *OutputResponseProcessor) endPartialStructuredOutput(invocation *agent.Invocation) {
+	p.mu.Lock()
+	delete(p.partials, invocation.InvocationID)
+	p.mu.Unlock()
+}
+
+// validationSchema returns the schema final output is validated against, or
+// nil when validation is disabled.
+func (p *OutputResponseProcessor) validationSchema(invocation *agent.Invocation) map[string]any {
+	if !p.validate {
+		return nil
+	}
+	if so := invocation.StructuredOutput; so != nil && so.JSONSchema != nil && so.JSONSchema.Schema != nil {
+		return so.JSONSchema.Schema
+	}
+	return p.outputSchema
+}
+
+// validateOutput validates the final output of rsp. It reports false when
+// the output is not validated.
+func (p *OutputResponseProcessor) validateOutput(invocation *agent.Invocation, rsp *model.Response) ([]string, bool) {
+	schema := p.validationSchema(invocation)
+	if schema == nil || rsp.IsToolCallResponse() {
+		return nil, false
+	}
+	content, ok := p.extractFinalContent(rsp)
+	if !ok {
+		return nil, false
+	}
+	jsonObject, ok := extractFirstJSONObject(content)
+	if !ok {
+		return []string{"$: the reply does not contain a JSON object"}, true
+	}
+	return schemaViolations(jsonschema.ValidateJSON(schema, []byte(jsonObject))), true
+}
+
+// RejectsResponse implements the flow.RetryingResponseProcessor interface.
+// Invalid output is rejected while retries are left.
+func (p *OutputResponseProcessor) RejectsResponse(invocation *agent.Invocation, rsp *model.Response) bool {
+	if invocation == nil || rsp == nil || rsp.IsPartial {
+		return false
+	}
+	if violations, _ := p.validateOutput(invocation, rsp); len(violations) == 0 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	attempts := 0
+	if rs, ok := p.repairs[invocation.InvocationID]; ok {
+		attempts = rs.attempts
+	}
+	return attempts < p.maxRetries
+}
+
+// rejectOutput asks the model to repair invalid output, or emits an error
+// event once the retries are exhausted.
+func (p *OutputResponseProcessor) rejectOutput(
+	ctx context.Context, invocation *agent.Invocation, violations []string, ch chan<- *event.Event,
+) {
+	p.mu.Lock()
+	if p.repairs == nil {
+		p.repairs = make(map[string]*repairState)
+	}
+	rs, ok := p.repairs[invocation.InvocationID]
+	if !ok {
+		rs = &repairState{}
+		p.repairs[invocation.InvocationID] = rs
+	}
+	retry := rs.attempts < p.maxRetries
+	if retry {
+		rs.attempts++
+		rs.pending = true
+	} else {
+		delete(p.repairs, invocation.InvocationID)
+	}
+	attempts := rs.attempts
+	p.mu.Unlock()
+
+	if !retry {
+		log.Warnf("Structured output is still invalid after %d retries: %v", attempts, violations)
+		agent.EmitEvent(ctx, invocation, ch, event.NewErrorEvent(
+			invocation.InvocationID,
+			invocation.AgentName,
+			model.ErrorTypeStructuredOutputError,
+			fmt.Sprintf("structured output does not match the schema after %d retries: %s",
+				attempts, strings.Join(violations, "; ")),
+			event.WithStructuredOutputErrors(violations),
+		))
+		return
+	}
+
+	log.Debugf("Structured output is invalid, asking for a repair (attempt %d of %d): %v",
+		attempts, p.maxRetries, violations)
+	// The request is a user message, so that the next request sends it to
+	// the model after the rejected reply.
+	var b strings.Builder
+	b.WriteString("Your previous reply does not match the required JSON schema:\n")
+	for _, v := range violations {
+		b.WriteString("- " + v + "\n")
+	}
+	b.WriteString("Reply again with only the corrected JSON.")
+	repairEvt := event.NewResponseEvent(invocation.InvocationID, invocation.AgentName, &model.Response{
+		Object:  model.ObjectTypeStructuredOutputRepair,
+		Choices: []model.Choice{{Message: model.NewUserMessage(b.String())}},
+	}, event.WithStructuredOutputErrors(violations))
+	agent.EmitEvent(ctx, invocation, ch, repairEvt)
+}
+
+// endRepair forgets the rejected outputs of the invocation once the output
+// is valid.
+func (p *OutputResponseProcessor) endRepair(invocation *agent.Invocation) {
+	p.mu.Lock()
+	delete(p.repairs, invocation.InvocationID)
+	p.mu.Unlock()
+}
+
+// EndInvocation implements the flow.InvocationStateProcessor interface.
+func (p *OutputResponseProcessor) EndInvocation(invocation *agent.Invocation) {
+	if invocation == nil {
+		return
+	}
+	p.mu.Lock()
+	delete(p.partials, invocation.InvocationID)
+	delete(p.repairs, invocation.InvocationID)
+	p.mu.Unlock()
+}
+
+// RetryRequested implements the flow.RetryingResponseProcessor interface.
+func (p *OutputResponseProcessor) RetryRequested(invocation *agent.Invocation) bool {
+	if invocation == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rs, ok := p.repairs[invocation.InvocationID]
+	if !ok || !rs.pending {
+		return false
+	}
+	rs.pending = false
+	return true
+}
+
+// schemaViolations returns the violations listed by a validation error.
+func schemaViolations(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []string{err.Error()}
+	}
+	violations := make([]string, len(verr.Violations))
+	for i, v := range verr.Violations {
+		violations[i] = v.String()
+	}
+	return violations
+}
+
 // handleOutputKey validates and emits state delta for output_key/output_schema cases.
 func (p *OutputResponseProcessor) handleOutputKey(ctx context.Context, invocation *agent.Invocation, content string,
 	jsonObject string, ch chan<- *event.Event) {
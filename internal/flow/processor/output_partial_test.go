@@ -0,0 +1,138 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+type articleOut struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+}
+
+func deltaResponse(id, content string) *model.Response {
+	return &model.Response{
+		ID:        id,
+		IsPartial: true,
+		Choices:   []model.Choice{{Delta: model.Message{Content: content}}},
+	}
+}
+
+func TestOutputResponseProcessor_PartialStructuredOutput(t *testing.T) {
+	ctx := context.Background()
+	proc := NewOutputResponseProcessor("", nil)
+	inv := &agent.Invocation{
+		InvocationID:         "inv",
+		AgentName:            "writer",
+		StructuredOutputType: reflect.TypeOf((*articleOut)(nil)),
+	}
+	ch := make(chan *event.Event, 16)
+	for _, delta := range []string{"```json\n", `{"title": "Go`, ` tips", `, `"ta`, `gs": ["a"`, `]}`, "\n```"} {
+		proc.ProcessResponse(ctx, inv, &model.Request{}, deltaResponse("rsp-1", delta), ch)
+	}
+	close(ch)
+
+	var snapshots []string
+	var last *event.Event
+	for evt := range ch {
+		require.Equal(t, model.ObjectTypeStructuredOutputPartial, evt.Object)
+		require.True(t, evt.IsPartial)
+		require.NotNil(t, evt.PartialStructuredOutput)
+		assert.Equal(t, "rsp-1", evt.Response.ID)
+		assert.Nil(t, evt.StructuredOutput)
+		snapshots = append(snapshots, string(evt.PartialStructuredOutput.JSON))
+		last = evt
+	}
+	// Deltas not changing the snapshot emit nothing.
+	assert.Equal(t, []string{
+		`{"title": "Go"}`,
+		`{"title": "Go tips"}`,
+		`{"title": "Go tips", "tags": ["a"]}`,
+	}, snapshots)
+	require.NotNil(t, last)
+	assert.Equal(t, &articleOut{Title: "Go tips", Tags: []string{"a"}}, last.PartialStructuredOutput.Value)
+}
+
+func TestOutputResponseProcessor_PartialStructuredOutputResets(t *testing.T) {
+	ctx := context.Background()
+	proc := NewOutputResponseProcessor("", nil)
+	inv := &agent.Invocation{
+		InvocationID:     "inv",
+		AgentName:        "writer",
+		StructuredOutput: &model.StructuredOutput{Type: model.StructuredOutputJSONSchema},
+	}
+	ch := make(chan *event.Event, 16)
+	proc.ProcessResponse(ctx, inv, &model.Request{}, deltaResponse("rsp-1", `{"title": "a`), ch)
+	// A new response starts a new document.
+	proc.ProcessResponse(ctx, inv, &model.Request{}, deltaResponse("rsp-2", `{"title": "b`), ch)
+	// The final response drops the parser.
+	proc.ProcessResponse(ctx, inv, &model.Request{}, &model.Response{ID: "rsp-2"}, ch)
+	assert.Empty(t, proc.partials)
+	proc.ProcessResponse(ctx, inv, &model.Request{}, deltaResponse("rsp-2", `{"x": 1,`), ch)
+	close(ch)
+
+	var snapshots []string
+	for evt := range ch {
+		snapshots = append(snapshots, string(evt.PartialStructuredOutput.JSON))
+		assert.Nil(t, evt.PartialStructuredOutput.Value)
+	}
+	assert.Equal(t, []string{`{"title": "a"}`, `{"title": "b"}`, `{"x": 1}`}, snapshots)
+
+	// Without structured output nothing is emitted.
+	ch = make(chan *event.Event, 1)
+	proc.ProcessResponse(ctx, &agent.Invocation{InvocationID: "plain"}, &model.Request{}, deltaResponse("rsp", `{"a": "b"}`), ch)
+	assert.Empty(t, ch)
+}
+
+func TestOutputResponseProcessor_StructuredOutputSchemaErrors(t *testing.T) {
+	ctx := context.Background()
+	proc := NewOutputResponseProcessor("", nil)
+	inv := &agent.Invocation{
+		InvocationID:         "inv",
+		AgentName:            "writer",
+		StructuredOutputType: reflect.TypeOf((*articleOut)(nil)),
+		StructuredOutput: &model.StructuredOutput{
+			Type: model.StructuredOutputJSONSchema,
+			JSONSchema: &model.JSONSchemaConfig{Schema: map[string]any{
+				"type":     "object",
+				"required": []string{"title", "tags"},
+				"properties": map[string]any{
+					"title": map[string]any{"type": "string"},
+					"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+			}},
+		},
+	}
+
+	emit := func(content string) *event.Event {
+		ch := make(chan *event.Event, 1)
+		rsp := &model.Response{Choices: []model.Choice{{Message: model.NewAssistantMessage(content)}}}
+		proc.ProcessResponse(ctx, inv, &model.Request{}, rsp, ch)
+		require.Len(t, ch, 1)
+		return <-ch
+	}
+	valid := emit(`{"title": "t", "tags": ["x"]}`)
+	assert.NotNil(t, valid.StructuredOutput)
+	assert.Empty(t, valid.StructuredOutputErrors)
+
+	invalid := emit(`{"title": "t"}`)
+	assert.NotNil(t, invalid.StructuredOutput)
+	assert.Equal(t, []string{"$.tags: required property is missing"}, invalid.StructuredOutputErrors)
+}
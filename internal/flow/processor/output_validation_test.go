@@ -0,0 +1,158 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+var personSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name": map[string]any{"type": "string"},
+		"age":  map[string]any{"type": "integer", "minimum": 0},
+	},
+	"required": []any{"name", "age"},
+}
+
+func finalResponse(content string) *model.Response {
+	return &model.Response{
+		Done:    true,
+		Choices: []model.Choice{{Message: model.NewAssistantMessage(content)}},
+	}
+}
+
+func processFinal(proc *OutputResponseProcessor, inv *agent.Invocation, content string) []*event.Event {
+	ch := make(chan *event.Event, 16)
+	proc.ProcessResponse(context.Background(), inv, &model.Request{}, finalResponse(content), ch)
+	close(ch)
+	var events []*event.Event
+	for evt := range ch {
+		events = append(events, evt)
+	}
+	return events
+}
+
+func TestOutputResponseProcessor_ValidationRepair(t *testing.T) {
+	proc := NewOutputResponseProcessor("person", personSchema, WithOutputValidation(2))
+	inv := &agent.Invocation{InvocationID: "inv", AgentName: "extractor"}
+
+	events := processFinal(proc, inv, `{"name": "Ann", "age": "forty"}`)
+	require.Len(t, events, 1)
+	repair := events[0]
+	assert.Equal(t, model.ObjectTypeStructuredOutputRepair, repair.Object)
+	assert.Nil(t, repair.StateDelta)
+	assert.Equal(t, []string{"$.age: expected integer, got string"}, repair.StructuredOutputErrors)
+	require.Len(t, repair.Choices, 1)
+	assert.Equal(t, model.RoleUser, repair.Choices[0].Message.Role)
+	assert.Contains(t, repair.Choices[0].Message.Content, "- $.age: expected integer, got string")
+	assert.True(t, proc.RetryRequested(inv))
+	assert.False(t, proc.RetryRequested(inv), "the request is reset once reported")
+
+	events = processFinal(proc, inv, `Sure: {"name": "Ann", "age": 40}`)
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string][]byte{"person": []byte(`{"name": "Ann", "age": 40}`)}, events[0].StateDelta)
+	assert.False(t, proc.RetryRequested(inv))
+}
+
+func TestOutputResponseProcessor_EndInvocation(t *testing.T) {
+	proc := NewOutputResponseProcessor("person", personSchema, WithOutputValidation(2))
+	inv := &agent.Invocation{
+		InvocationID:     "inv",
+		AgentName:        "extractor",
+		StructuredOutput: &model.StructuredOutput{},
+	}
+	ch := make(chan *event.Event, 16)
+	proc.ProcessResponse(context.Background(), inv, &model.Request{}, &model.Response{
+		ID:        "rsp",
+		IsPartial: true,
+		Choices:   []model.Choice{{Delta: model.Message{Content: `{"name": "A`}}},
+	}, ch)
+	processFinal(proc, inv, `{"name": "Ann"}`)
+	require.Len(t, proc.repairs, 1)
+
+	// A run stopped before a valid reply, e.g. by a cancellation.
+	proc.ProcessResponse(context.Background(), inv, &model.Request{}, &model.Response{
+		ID:        "rsp2",
+		IsPartial: true,
+		Choices:   []model.Choice{{Delta: model.Message{Content: `{"name": "A`}}},
+	}, ch)
+	require.Len(t, proc.partials, 1)
+	proc.EndInvocation(inv)
+	assert.Empty(t, proc.partials)
+	assert.Empty(t, proc.repairs)
+}
+
+func TestOutputResponseProcessor_ValidationExhausted(t *testing.T) {
+	proc := NewOutputResponseProcessor("person", personSchema, WithOutputValidation(1))
+	inv := &agent.Invocation{InvocationID: "inv", AgentName: "extractor"}
+
+	assert.True(t, proc.RejectsResponse(inv, finalResponse("I do not know.")))
+	events := processFinal(proc, inv, "I do not know.")
+	require.Len(t, events, 1)
+	assert.Equal(t, []string{"$: the reply does not contain a JSON object"}, events[0].StructuredOutputErrors)
+	assert.True(t, proc.RetryRequested(inv))
+
+	// The retries are exhausted: the next invalid output ends the run.
+	assert.False(t, proc.RejectsResponse(inv, finalResponse(`{"name": "Ann"}`)))
+	assert.False(t, proc.RejectsResponse(inv, finalResponse(`{"name": "Ann", "age": 40}`)))
+
+	events = processFinal(proc, inv, `{"name": "Ann"}`)
+	require.Len(t, events, 1)
+	evt := events[0]
+	require.NotNil(t, evt.Error)
+	assert.Equal(t, model.ErrorTypeStructuredOutputError, evt.Error.Type)
+	assert.Contains(t, evt.Error.Message, "after 1 retries")
+	assert.Equal(t, []string{"$.age: required property is missing"}, evt.StructuredOutputErrors)
+	assert.Nil(t, evt.StateDelta)
+	assert.False(t, proc.RetryRequested(inv))
+
+	// A new attempt count starts with the next invalid output.
+	events = processFinal(proc, inv, `{"name": "Ann"}`)
+	require.Len(t, events, 1)
+	assert.Equal(t, model.ObjectTypeStructuredOutputRepair, events[0].Object)
+}
+
+func TestOutputResponseProcessor_ValidationPrefersStructuredOutputSchema(t *testing.T) {
+	proc := NewOutputResponseProcessor("", nil, WithOutputValidation(0))
+	inv := &agent.Invocation{
+		InvocationID: "inv",
+		AgentName:    "extractor",
+		StructuredOutput: &model.StructuredOutput{
+			Type:       model.StructuredOutputJSONSchema,
+			JSONSchema: &model.JSONSchemaConfig{Name: "person", Schema: personSchema},
+		},
+	}
+	// Without an output key or type the processor has nothing to do.
+	assert.Empty(t, processFinal(proc, inv, `{}`))
+
+	proc = NewOutputResponseProcessor("out", nil, WithOutputValidation(0))
+	events := processFinal(proc, inv, `{"name": "Ann", "age": -1}`)
+	require.Len(t, events, 1)
+	require.NotNil(t, events[0].Error)
+	assert.Equal(t, []string{"$.age: must be >= 0"}, events[0].StructuredOutputErrors)
+}
+
+func TestOutputResponseProcessor_NoValidationKeepsOutput(t *testing.T) {
+	proc := NewOutputResponseProcessor("person", personSchema)
+	inv := &agent.Invocation{InvocationID: "inv", AgentName: "extractor"}
+	events := processFinal(proc, inv, `{"name": "Ann"}`)
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string][]byte{"person": []byte(`{"name": "Ann"}`)}, events[0].StateDelta)
+	assert.False(t, proc.RetryRequested(inv))
+}
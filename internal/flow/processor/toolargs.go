@@ -0,0 +1,128 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"trpc.group/trpc-go/trpc-agent-go/internal/jsonschema"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// invalidArgumentsResult is the tool result returned to the model instead of
+// calling a tool with invalid arguments, so that it can fix the call.
+type invalidArgumentsResult struct {
+	Error      string                 `json:"error"`
+	Violations []jsonschema.Violation `json:"violations"`
+}
+
+// checkToolArguments repairs the arguments of a call to the tool declared by
+// decl and validates them against its input schema. Trailing commas are
+// removed, empty arguments become an empty object and numbers or booleans
+// sent as strings are converted. It returns the arguments to call the tool
+// with, or the violations found.
+func checkToolArguments(decl *tool.Declaration, args []byte) ([]byte, []jsonschema.Violation) {
+	args = bytes.TrimSpace(args)
+	if len(args) == 0 {
+		args = []byte("{}")
+	}
+	if !json.Valid(args) {
+		if repaired := removeTrailingCommas(args); json.Valid(repaired) {
+			args = repaired
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, []jsonschema.Violation{{Path: "$", Message: "invalid JSON: " + err.Error()}}
+	}
+	if decl == nil || decl.InputSchema == nil {
+		return args, nil
+	}
+	schema, err := schemaMap(decl.InputSchema)
+	if err != nil {
+		// A schema that cannot be checked is not the model's fault.
+		return args, nil
+	}
+	if converted, changed := jsonschema.Coerce(schema, value); changed {
+		b, err := json.Marshal(converted)
+		if err != nil {
+			return args, nil
+		}
+		args, value = b, converted
+	}
+	var verr *jsonschema.ValidationError
+	if errors.As(jsonschema.Validate(schema, value), &verr) {
+		return nil, verr.Violations
+	}
+	return args, nil
+}
+
+// schemaMap converts s to the generic form the validator works on.
+func schemaMap(s *tool.Schema) (map[string]any, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// removeTrailingCommas drops the commas directly followed, ignoring white
+// space, by a closing brace or bracket outside strings.
+func removeTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString, escaped := false, false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			j := i + 1
+			for j < len(data) && (data[j] == ' ' || data[j] == '\t' || data[j] == '\n' || data[j] == '\r') {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// invalidArgumentsContent returns the tool result reporting violations.
+func invalidArgumentsContent(toolName string, violations []jsonschema.Violation) string {
+	b, err := json.Marshal(invalidArgumentsResult{
+		Error:      fmt.Sprintf("invalid arguments for tool %s, fix them and call the tool again", toolName),
+		Violations: violations,
+	})
+	if err != nil {
+		return ErrorInvalidArguments
+	}
+	return string(b)
+}
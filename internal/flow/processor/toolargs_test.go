@@ -0,0 +1,112 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/internal/jsonschema"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+var forecastDecl = &tool.Declaration{
+	Name: "forecast",
+	InputSchema: &tool.Schema{
+		Type:     "object",
+		Required: []string{"city", "days"},
+		Properties: map[string]*tool.Schema{
+			"city":  {Type: "string"},
+			"days":  {Type: "integer"},
+			"units": {Type: "string", Enum: []any{"metric", "imperial"}},
+			"hours": {Type: "array", Items: &tool.Schema{Type: "integer"}},
+		},
+	},
+}
+
+func TestCheckToolArguments(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       string
+		want       string
+		violations []string
+	}{
+		{"valid", `{"city": "Paris", "days": 3}`, `{"city": "Paris", "days": 3}`, nil},
+		{"stringified number", `{"city": "Paris", "days": "3"}`, `{"city":"Paris","days":3}`, nil},
+		{"trailing commas", `{"city": "Paris,", "days": 3, "hours": [1, 2,],}`,
+			`{"city": "Paris,", "days": 3, "hours": [1, 2]}`, nil},
+		{"nested items", `{"city": "Paris", "days": 1, "hours": ["6", "x"]}`, "", []string{
+			"$.hours[1]: expected integer, got string",
+		}},
+		{"missing and enum", `{"days": 2, "units": "kelvin"}`, "", []string{
+			"$.city: required property is missing",
+			`$.units: must be one of ["metric", "imperial"]`,
+		}},
+		{"broken JSON", `{"city": "Paris"`, "", []string{"$: invalid JSON: unexpected EOF"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, violations := checkToolArguments(forecastDecl, []byte(tt.args))
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.String())
+			}
+			assert.Equal(t, tt.violations, got)
+			assert.Equal(t, tt.want, string(args))
+		})
+	}
+}
+
+func TestCheckToolArguments_NoSchema(t *testing.T) {
+	args, violations := checkToolArguments(&tool.Declaration{Name: "ping"}, nil)
+	assert.Empty(t, violations)
+	assert.Equal(t, "{}", string(args))
+}
+
+func TestFunctionCallResponseProcessor_ToolArgumentValidation(t *testing.T) {
+	type forecastArgs struct {
+		City string `json:"city"`
+		Days int    `json:"days"`
+	}
+	var called []forecastArgs
+	ft := function.NewFunctionTool(func(_ context.Context, in forecastArgs) (string, error) {
+		called = append(called, in)
+		return "sunny", nil
+	}, function.WithName("forecast"))
+	tools := map[string]tool.Tool{"forecast": ft}
+	inv := &agent.Invocation{InvocationID: "inv", AgentName: "weather", Model: &mockModel{}}
+	p := NewFunctionCallResponseProcessor(false, nil, WithToolArgumentValidation(true))
+
+	call := func(args string) *model.Choice {
+		choice, _, err := p.executeToolCall(context.Background(), inv, model.ToolCall{
+			ID:       "call_1",
+			Function: model.FunctionDefinitionParam{Name: "forecast", Arguments: []byte(args)},
+		}, tools, 0, nil)
+		require.NoError(t, err)
+		require.NotNil(t, choice)
+		return choice
+	}
+
+	assert.Equal(t, `"sunny"`, call(`{"city": "Paris", "days": "3",}`).Message.Content)
+	assert.Equal(t, []forecastArgs{{City: "Paris", Days: 3}}, called)
+
+	var result invalidArgumentsResult
+	require.NoError(t, json.Unmarshal([]byte(call(`{"city": "Paris", "days": "three"}`).Message.Content), &result))
+	assert.Contains(t, result.Error, "forecast")
+	assert.Equal(t, []jsonschema.Violation{{Path: "$.days", Message: "expected integer, got string"}}, result.Violations)
+	assert.Len(t, called, 1, "the tool is not called with invalid arguments")
+}
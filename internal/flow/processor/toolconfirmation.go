@@ -0,0 +1,233 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent"
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	itool "trpc.group/trpc-go/trpc-agent-go/internal/tool"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// defaultRejectionReason is returned to the model for paused tool calls the
+// human did not decide on.
+const defaultRejectionReason = "the user did not approve the tool call"
+
+// rejectedToolCallResult is the tool result of a rejected tool call.
+type rejectedToolCallResult struct {
+	Error  string `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// pendingToolCalls collects the tool calls of a response paused for
+// confirmation, possibly from concurrent goroutines.
+type pendingToolCalls struct {
+	mu    sync.Mutex
+	calls map[int]model.ToolCall
+}
+
+func (p *pendingToolCalls) add(index int, tc model.ToolCall) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.calls == nil {
+		p.calls = make(map[int]model.ToolCall)
+	}
+	p.calls[index] = tc
+}
+
+// list returns the paused calls in the order of the response.
+func (p *pendingToolCalls) list() []model.ToolCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	indexes := make([]int, 0, len(p.calls))
+	for i := range p.calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	calls := make([]model.ToolCall, 0, len(indexes))
+	for _, i := range indexes {
+		calls = append(calls, p.calls[i])
+	}
+	return calls
+}
+
+// requiresConfirmation reports whether the calls of tl must be approved by
+// a human before they run.
+func requiresConfirmation(tl tool.Tool) bool {
+	if named, ok := tl.(*itool.NamedTool); ok {
+		tl = named.Original()
+	}
+	r, ok := tl.(tool.ConfirmationRequirer)
+	return ok && r.RequiresConfirmation()
+}
+
+// requestConfirmation persists the paused tool calls in the session state,
+// emits the event asking a human to decide on them and ends the invocation.
+// The results of the other calls of the response are kept with them, so
+// that the model receives the results of the whole response at once.
+func (p *FunctionCallResponseProcessor) requestConfirmation(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	calls []model.ToolCall,
+	results *event.Event,
+	ch chan<- *event.Event,
+) {
+	confirmations := make([]event.ToolConfirmation, 0, len(calls))
+	for _, tc := range calls {
+		confirmations = append(confirmations, event.ToolConfirmation{
+			ToolCallID: tc.ID,
+			ToolName:   tc.Function.Name,
+			Arguments:  json.RawMessage(tc.Function.Arguments),
+		})
+	}
+	pending := agent.ToolConfirmationState{Calls: confirmations}
+	if results != nil && results.Response != nil {
+		pending.Results = results.Choices
+	}
+	state, err := json.Marshal(pending)
+	if err != nil {
+		log.Errorf("Failed to marshal tool confirmations for agent %s: %v", invocation.AgentName, err)
+		return
+	}
+	evt := event.New(
+		invocation.InvocationID,
+		invocation.AgentName,
+		event.WithObject(model.ObjectTypeToolConfirmationRequest),
+		event.WithStateDelta(map[string][]byte{
+			agent.ToolConfirmationStateKey(invocation.AgentName): state,
+		}),
+	)
+	evt.ToolConfirmations = confirmations
+	invocation.EndInvocation = true
+	agent.EmitEvent(ctx, invocation, ch, evt)
+}
+
+// ToolConfirmationRequestProcessor resumes the tool calls paused for
+// confirmation by a previous run of the agent. It applies the decisions of
+// the run options, set with agent.WithToolConfirmationDecisions, and adds the
+// tool results to the session before the contents of the request are
+// assembled.
+type ToolConfirmationRequestProcessor struct {
+	toolCalls *FunctionCallResponseProcessor
+}
+
+// NewToolConfirmationRequestProcessor creates a processor executing the
+// approved tool calls with toolCalls.
+func NewToolConfirmationRequestProcessor(toolCalls *FunctionCallResponseProcessor) *ToolConfirmationRequestProcessor {
+	return &ToolConfirmationRequestProcessor{toolCalls: toolCalls}
+}
+
+// ProcessRequest implements the flow.RequestProcessor interface.
+func (p *ToolConfirmationRequestProcessor) ProcessRequest(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	req *model.Request,
+	ch chan<- *event.Event,
+) {
+	if invocation == nil || invocation.Session == nil {
+		return
+	}
+	pending := agent.LoadToolConfirmationState(invocation.Session, invocation.AgentName)
+	if pending == nil {
+		return
+	}
+	decisions := invocation.RunOptions.ToolConfirmationDecisions
+	byID := make(map[string]agent.ToolConfirmationDecision, len(decisions))
+	for _, d := range decisions {
+		byID[d.ToolCallID] = d
+	}
+	tools := make(map[string]tool.Tool)
+	if invocation.Agent != nil {
+		for _, t := range invocation.Agent.Tools() {
+			tools[t.Declaration().Name] = t
+		}
+	}
+
+	choices := append([]model.Choice(nil), pending.Results...)
+	for _, c := range pending.Calls {
+		choice := p.resume(ctx, invocation, tools, len(choices), c, byID[c.ToolCallID], ch)
+		if choice == nil {
+			continue
+		}
+		choice.Message.ToolName = c.ToolName
+		choices = append(choices, *choice)
+	}
+
+	var modelName string
+	if invocation.Model != nil {
+		modelName = invocation.Model.Info().Name
+	}
+	evt := newToolCallResponseEvent(invocation, &model.Response{Model: modelName}, choices)
+	// Clear the paused calls with the same event adding their results.
+	evt.StateDelta = map[string][]byte{agent.ToolConfirmationStateKey(invocation.AgentName): nil}
+	evt.RequiresCompletion = true
+	if err := agent.EmitEvent(ctx, invocation, ch, evt); err != nil {
+		return
+	}
+	// The content processor reads the results from the session.
+	completionID := agent.GetAppendEventNoticeKey(evt.ID)
+	if err := invocation.AddNoticeChannelAndWait(ctx, completionID,
+		agent.WaitNoticeWithoutTimeout); err != nil {
+		log.Warnf("Failed to add notice channel for completion ID %s: %v", completionID, err)
+	}
+}
+
+// resume applies decision to the paused call c and returns its result.
+func (p *ToolConfirmationRequestProcessor) resume(
+	ctx context.Context,
+	invocation *agent.Invocation,
+	tools map[string]tool.Tool,
+	index int,
+	c event.ToolConfirmation,
+	decision agent.ToolConfirmationDecision,
+	ch chan<- *event.Event,
+) *model.Choice {
+	if decision.Approved && len(decision.Arguments) > 0 && !json.Valid(decision.Arguments) {
+		decision = agent.RejectToolCall(decision.ToolCallID, "invalid arguments")
+	}
+	if !decision.Approved {
+		reason := decision.Reason
+		if reason == "" {
+			reason = defaultRejectionReason
+		}
+		b, _ := json.Marshal(rejectedToolCallResult{
+			Error:  "the user rejected the call of tool " + c.ToolName,
+			Reason: reason,
+		})
+		return p.toolCalls.createErrorChoice(index, c.ToolCallID, string(b))
+	}
+	args := c.Arguments
+	if len(decision.Arguments) > 0 {
+		args = decision.Arguments
+	}
+	call := model.ToolCall{
+		Type: "function",
+		ID:   c.ToolCallID,
+		Function: model.FunctionDefinitionParam{
+			Name:      c.ToolName,
+			Arguments: args,
+		},
+	}
+	choice, _, err := p.toolCalls.executeToolCall(tool.NewConfirmedContext(ctx), invocation, call, tools, index, ch)
+	if err != nil {
+		if _, ok := agent.AsStopError(err); ok {
+			invocation.EndInvocation = true
+		}
+		return p.toolCalls.createErrorChoice(index, c.ToolCallID, err.Error())
+	}
+	return choice
+}
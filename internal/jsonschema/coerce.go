@@ -0,0 +1,107 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+// Coerce converts the strings of value that schema types as a number,
+// integer or boolean, such as "42" or "true", a common mistake of models
+// writing tool arguments. Strings are left alone where the schema also
+// accepts a string, and strings that do not parse are left for Validate to
+// report. Objects and arrays are converted in place; Coerce returns the
+// converted value and whether anything changed.
+func Coerce(schema map[string]any, value any) (any, bool) {
+	c := &coercer{validator: validator{root: schema}}
+	return c.coerce(schema, value, 0)
+}
+
+type coercer struct {
+	validator
+}
+
+func (c *coercer) coerce(schema map[string]any, value any, depth int) (any, bool) {
+	if schema == nil {
+		return value, false
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			return value, false
+		}
+		target, err := c.resolve(ref)
+		if err != nil {
+			return value, false
+		}
+		return c.coerce(target, value, depth+1)
+	}
+
+	switch val := value.(type) {
+	case string:
+		return coerceString(stringList(schema["type"]), val)
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		changed := false
+		for k, item := range val {
+			sub, _ := props[k].(map[string]any)
+			if sub == nil {
+				sub = additional
+			}
+			if converted, ok := c.coerce(sub, item, depth); ok {
+				val[k] = converted
+				changed = true
+			}
+		}
+		return val, changed
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		changed := false
+		for i, item := range val {
+			if converted, ok := c.coerce(items, item, depth); ok {
+				val[i] = converted
+				changed = true
+			}
+		}
+		return val, changed
+	}
+	return value, false
+}
+
+// coerceString converts s to the first of types it parses as, unless types
+// allow a string.
+func coerceString(types []string, s string) (any, bool) {
+	for _, t := range types {
+		if t == "string" {
+			return s, false
+		}
+	}
+	trimmed := strings.TrimSpace(s)
+	for _, t := range types {
+		switch t {
+		case "integer", "number":
+			var n json.Number
+			if err := json.Unmarshal([]byte(trimmed), &n); err != nil {
+				continue
+			}
+			if f, err := n.Float64(); err != nil || t == "integer" && f != math.Trunc(f) {
+				continue
+			}
+			return n, true
+		case "boolean":
+			if trimmed == "true" || trimmed == "false" {
+				return trimmed == "true", true
+			}
+		}
+	}
+	return s, false
+}
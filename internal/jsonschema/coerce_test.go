@@ -0,0 +1,65 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerce(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"count":  map[string]any{"type": "integer"},
+			"ratio":  map[string]any{"type": "number"},
+			"dry":    map[string]any{"type": "boolean"},
+			"label":  map[string]any{"type": "string"},
+			"either": map[string]any{"type": []any{"string", "integer"}},
+			"items":  map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/item"}},
+		},
+		"additionalProperties": map[string]any{"type": "number"},
+		"$defs": map[string]any{
+			"item": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"n": map[string]any{"type": "integer"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		changed bool
+	}{
+		{"numbers and booleans", `{"count": " 3 ", "ratio": "0.5", "dry": "true"}`,
+			`{"count":3,"dry":true,"ratio":0.5}`, true},
+		{"strings allowed", `{"label": "7", "either": "7"}`, `{"either":"7","label":"7"}`, false},
+		{"not a number", `{"count": "three", "dry": "yes"}`, `{"count":"three","dry":"yes"}`, false},
+		{"fraction for integer", `{"count": "1.5"}`, `{"count":"1.5"}`, false},
+		{"nested through ref", `{"items": [{"n": "1"}, {"n": 2}]}`, `{"items":[{"n":1},{"n":2}]}`, true},
+		{"additional properties", `{"extra": "2e3"}`, `{"extra":2e3}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			assert.NoError(t, json.Unmarshal([]byte(tt.in), &value))
+			got, changed := Coerce(schema, value)
+			assert.Equal(t, tt.changed, changed)
+			b, err := json.Marshal(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(b))
+			assert.NoError(t, Validate(map[string]any{"type": "object"}, got))
+		})
+	}
+}
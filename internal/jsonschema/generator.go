@@ -7,10 +7,6 @@
 //
 //
 
-// Package jsonschema provides utilities to generate JSON Schema documents
-// from Go types. The generator is recursive and production-ready with
-// support for nested structs, arrays/slices, maps, enums, time formats,
-// and $defs/$ref for de-duplication and recursion safety.
 package jsonschema
 
 import (
@@ -0,0 +1,473 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package jsonschema generates JSON Schema documents from Go types and
+// validates JSON values against them.
+//
+// The generator is recursive with support for nested structs,
+// arrays/slices, maps, enums, time formats, and $defs/$ref for
+// de-duplication and recursion safety.
+//
+// The validator supports the subset of JSON Schema used for structured
+// output and tool declarations: type, enum, const, properties, required,
+// additionalProperties, items, the length, size and range bounds, pattern,
+// anyOf, oneOf, allOf and local $ref.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRefDepth bounds $ref resolution to stop on cyclic references that do
+// not consume any input.
+const maxRefDepth = 64
+
+// Violation is a single validation failure.
+type Violation struct {
+	// Path locates the value, e.g. "$.items[0].name".
+	Path string `json:"path"`
+	// Message describes the failure.
+	Message string `json:"message"`
+}
+
+// String returns the violation as "path: message".
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError lists the violations of a value.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks value, as decoded by encoding/json, against schema. It
+// returns a *ValidationError listing every violation, or nil.
+func Validate(schema map[string]any, value any) error {
+	v := &validator{root: schema}
+	v.validate(schema, value, "$", 0)
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+// ValidateJSON decodes data and validates it against schema.
+func ValidateJSON(schema map[string]any, data []byte) error {
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return &ValidationError{Violations: []Violation{{Path: "$", Message: "invalid JSON: " + err.Error()}}}
+	}
+	return Validate(schema, value)
+}
+
+type validator struct {
+	root       map[string]any
+	violations []Violation
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(schema map[string]any, value any, path string, depth int) {
+	if schema == nil {
+		return
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			v.fail(path, "reference %s nested too deeply", ref)
+			return
+		}
+		target, err := v.resolve(ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		v.validate(target, value, path, depth+1)
+		return
+	}
+
+	if types := stringList(schema["type"]); len(types) > 0 {
+		if !matchesAnyType(types, value) {
+			v.fail(path, "expected %s, got %s", strings.Join(types, " or "), typeName(value))
+			return
+		}
+	} else if nullable, _ := schema["nullable"].(bool); nullable && value == nil {
+		return
+	}
+	if enum, ok := schema["enum"]; ok {
+		values := anyList(enum)
+		if !containsValue(values, value) {
+			v.fail(path, "must be one of %s", formatValues(values))
+		}
+	}
+	if c, ok := schema["const"]; ok && !equalValues(c, value) {
+		v.fail(path, "must be %s", formatValue(c))
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		v.validateObject(schema, val, path, depth)
+	case []any:
+		v.validateArray(schema, val, path, depth)
+	case string:
+		v.validateString(schema, val, path)
+	default:
+		if n, ok := toFloat(value); ok {
+			v.validateNumber(schema, n, path)
+		}
+	}
+
+	for _, sub := range schemaList(schema["allOf"]) {
+		v.validate(sub, value, path, depth)
+	}
+	if subs := schemaList(schema["anyOf"]); len(subs) > 0 && v.countMatches(subs, value, depth) == 0 {
+		v.fail(path, "does not match any of the allowed schemas")
+	}
+	if subs := schemaList(schema["oneOf"]); len(subs) > 0 {
+		if n := v.countMatches(subs, value, depth); n != 1 {
+			v.fail(path, "must match exactly one of the allowed schemas, matched %d", n)
+		}
+	}
+}
+
+func (v *validator) validateObject(schema map[string]any, obj map[string]any, path string, depth int) {
+	props, _ := schema["properties"].(map[string]any)
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			v.fail(childPath(path, name), "required property is missing")
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if sub, ok := props[k]; ok {
+			if s, ok := sub.(map[string]any); ok {
+				v.validate(s, obj[k], childPath(path, k), depth)
+			}
+			continue
+		}
+		switch ap := schema["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				v.fail(childPath(path, k), "property is not allowed")
+			}
+		case map[string]any:
+			v.validate(ap, obj[k], childPath(path, k), depth)
+		}
+	}
+	if n, ok := toInt(schema["minProperties"]); ok && len(obj) < n {
+		v.fail(path, "must have at least %d properties", n)
+	}
+	if n, ok := toInt(schema["maxProperties"]); ok && len(obj) > n {
+		v.fail(path, "must have at most %d properties", n)
+	}
+}
+
+func (v *validator) validateArray(schema map[string]any, arr []any, path string, depth int) {
+	if items, ok := schema["items"].(map[string]any); ok {
+		for i, item := range arr {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", path, i), depth)
+		}
+	}
+	if n, ok := toInt(schema["minItems"]); ok && len(arr) < n {
+		v.fail(path, "must have at least %d items, got %d", n, len(arr))
+	}
+	if n, ok := toInt(schema["maxItems"]); ok && len(arr) > n {
+		v.fail(path, "must have at most %d items, got %d", n, len(arr))
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if equalValues(arr[i], arr[j]) {
+					v.fail(path, "items %d and %d are equal", i, j)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) validateString(schema map[string]any, s, path string) {
+	length := utf8.RuneCountInString(s)
+	if n, ok := toInt(schema["minLength"]); ok && length < n {
+		v.fail(path, "must be at least %d characters long", n)
+	}
+	if n, ok := toInt(schema["maxLength"]); ok && length > n {
+		v.fail(path, "must be at most %d characters long", n)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil && !re.MatchString(s) {
+			v.fail(path, "must match pattern %q", pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(schema map[string]any, n float64, path string) {
+	if min, ok := toFloat(schema["minimum"]); ok && n < min {
+		v.fail(path, "must be >= %v", min)
+	}
+	if max, ok := toFloat(schema["maximum"]); ok && n > max {
+		v.fail(path, "must be <= %v", max)
+	}
+	if min, ok := toFloat(schema["exclusiveMinimum"]); ok && n <= min {
+		v.fail(path, "must be > %v", min)
+	}
+	if max, ok := toFloat(schema["exclusiveMaximum"]); ok && n >= max {
+		v.fail(path, "must be < %v", max)
+	}
+	if m, ok := toFloat(schema["multipleOf"]); ok && m > 0 {
+		if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "must be a multiple of %v", m)
+		}
+	}
+}
+
+// countMatches returns how many of schemas value matches.
+func (v *validator) countMatches(schemas []map[string]any, value any, depth int) int {
+	n := 0
+	for _, s := range schemas {
+		sub := &validator{root: v.root}
+		sub.validate(s, value, "$", depth)
+		if len(sub.violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve returns the schema a local reference such as "#/$defs/Item"
+// points to.
+func (v *validator) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference %s", ref)
+	}
+	var cur any = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable reference %s", ref)
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolvable reference %s", ref)
+		}
+	}
+	target, ok := cur.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unresolvable reference %s", ref)
+	}
+	return target, nil
+}
+
+func matchesAnyType(types []string, value any) bool {
+	for _, t := range types {
+		if matchesType(t, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(t string, value any) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n)
+	}
+	return true
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if n, ok := toFloat(value); ok {
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func childPath(path, name string) string {
+	for _, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return path + "[" + strconv.Quote(name) + "]"
+		}
+	}
+	return path + "." + name
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toInt(v any) (int, bool) {
+	f, ok := toFloat(v)
+	return int(f), ok
+}
+
+// anyList returns v as a list, for keywords holding []any or a typed slice
+// such as []string.
+func anyList(v any) []any {
+	if l, ok := v.([]any); ok {
+		return l
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	l := make([]any, rv.Len())
+	for i := range l {
+		l[i] = rv.Index(i).Interface()
+	}
+	return l
+}
+
+func stringList(v any) []string {
+	if s, ok := v.(string); ok {
+		return []string{s}
+	}
+	var l []string
+	for _, item := range anyList(v) {
+		if s, ok := item.(string); ok {
+			l = append(l, s)
+		}
+	}
+	return l
+}
+
+func schemaList(v any) []map[string]any {
+	var l []map[string]any
+	for _, item := range anyList(v) {
+		if s, ok := item.(map[string]any); ok {
+			l = append(l, s)
+		}
+	}
+	return l
+}
+
+func containsValue(values []any, value any) bool {
+	for _, e := range values {
+		if equalValues(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares JSON values, treating numbers by value.
+func equalValues(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	if _, ok := toFloat(b); ok {
+		return false
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func formatValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func formatValues(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatValue(v)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
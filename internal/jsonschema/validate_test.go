@@ -0,0 +1,140 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustSchema(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal([]byte(s), &schema))
+	return schema
+}
+
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	out := make([]string, len(verr.Violations))
+	for i, v := range verr.Violations {
+		out[i] = v.String()
+	}
+	return out
+}
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"role": {"type": "string", "enum": ["admin", "user"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+		"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+		"manager": {"$ref": "#/$defs/Person"},
+		"weird key": {"type": "boolean"}
+	},
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"$defs": {
+		"Person": {
+			"type": "object",
+			"properties": {"name": {"type": "string"}},
+			"required": ["name"]
+		}
+	}
+}`
+
+func TestValidateJSON(t *testing.T) {
+	schema := mustSchema(t, personSchema)
+	tests := []struct {
+		doc  string
+		want []string
+	}{
+		{`{"name": "Ada", "age": 36, "role": "admin", "tags": ["a"], "email": "a@b.c", "manager": {"name": "B"}}`, nil},
+		{`{"name": "Ada"}`, []string{"$.age: required property is missing"}},
+		{`{"name": "", "age": 3.5}`, []string{
+			"$.age: expected integer, got number",
+			"$.name: must be at least 1 characters long",
+		}},
+		{`{"name": "Ada", "age": 200, "role": "root"}`, []string{
+			"$.age: must be <= 150",
+			`$.role: must be one of ["admin", "user"]`,
+		}},
+		{`{"name": "Ada", "age": 1, "tags": ["a", 2, "c"]}`, []string{
+			"$.tags[1]: expected string, got integer",
+			"$.tags: must have at most 2 items, got 3",
+		}},
+		{`{"name": "Ada", "age": 1, "email": "nope", "extra": 1}`, []string{
+			`$.email: must match pattern "^[^@]+@[^@]+$"`,
+			"$.extra: property is not allowed",
+		}},
+		{`{"name": "Ada", "age": 1, "manager": {}, "weird key": "x"}`, []string{
+			"$.manager.name: required property is missing",
+			`$["weird key"]: expected boolean, got string`,
+		}},
+		{`[]`, []string{"$: expected object, got array"}},
+		{`{"name": `, nil},
+	}
+	for _, tt := range tests {
+		err := ValidateJSON(schema, []byte(tt.doc))
+		if tt.doc == `{"name": ` {
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid JSON")
+			continue
+		}
+		assert.Equal(t, tt.want, violations(t, err), tt.doc)
+	}
+}
+
+func TestValidate_Combinators(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": ["object", "null"],
+		"properties": {
+			"id": {"anyOf": [{"type": "string"}, {"type": "integer"}]},
+			"kind": {"oneOf": [{"const": "a"}, {"type": "string", "maxLength": 1}]},
+			"size": {"allOf": [{"minimum": 1}, {"exclusiveMaximum": 10}]}
+		}
+	}`)
+	assert.NoError(t, ValidateJSON(schema, []byte(`null`)))
+	assert.NoError(t, ValidateJSON(schema, []byte(`{"id": 3, "kind": "b", "size": 9}`)))
+	assert.Equal(t, []string{
+		"$.id: does not match any of the allowed schemas",
+		"$.kind: must match exactly one of the allowed schemas, matched 2",
+		"$.size: must be < 10",
+	}, violations(t, ValidateJSON(schema, []byte(`{"id": true, "kind": "a", "size": 10}`))))
+}
+
+func TestValidate_GoValues(t *testing.T) {
+	// Schemas built in Go may hold typed slices.
+	schema := map[string]any{
+		"type":     "object",
+		"required": []string{"n"},
+		"properties": map[string]any{
+			"n": map[string]any{"type": "number", "enum": []any{1, 2}},
+		},
+	}
+	assert.NoError(t, Validate(schema, map[string]any{"n": 2.0}))
+	assert.Equal(t, []string{"$.n: must be one of [1, 2]"}, violations(t, Validate(schema, map[string]any{"n": 3})))
+
+	// Cyclic references stop.
+	cyclic := map[string]any{"$ref": "#"}
+	assert.Error(t, Validate(cyclic, 1))
+	assert.Error(t, Validate(map[string]any{"$ref": "#/$defs/missing"}, 1))
+}
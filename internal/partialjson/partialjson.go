@@ -0,0 +1,295 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package partialjson incrementally parses a JSON object or array received
+// in chunks, such as structured output streamed by a model, and completes
+// the prefix received so far into valid JSON.
+package partialjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// state is what the parser expects next.
+type state int
+
+const (
+	stateValue      state = iota // any value
+	stateValueOrEnd              // a value or ']', after '['
+	stateKeyOrEnd                // a key or '}', after '{'
+	stateKey                     // a key, after ','
+	stateColon                   // ':' after a key
+	stateCommaOrEnd              // ',' or the end of the container
+	stateString                  // inside a string
+	stateNumber                  // inside a number
+	stateLiteral                 // inside true, false or null
+	stateDone                    // the root value is complete
+)
+
+// Parser consumes the text of a JSON document chunk by chunk. Text before
+// the first '{' or '[', such as a markdown fence, and text after the root
+// value are ignored.
+type Parser struct {
+	buf   []byte
+	pos   int // next byte to scan
+	start int // offset of the root value, -1 before it is found
+	state state
+	stack []byte // open containers
+	err   error
+
+	// String being scanned.
+	isKey    bool
+	escape   int // bytes of an escape sequence still expected
+	escStart int
+
+	// Start of the number or literal being scanned.
+	scalarStart int
+
+	// The document is complete up to commit once closers are appended.
+	commit  int
+	closers []byte
+}
+
+// NewParser creates a parser.
+func NewParser() *Parser {
+	return &Parser{start: -1}
+}
+
+// Write consumes the next chunk. It returns an error, also returned by
+// later calls, when the text is not valid JSON.
+func (p *Parser) Write(chunk string) error {
+	if p.err != nil || p.state == stateDone {
+		return p.err
+	}
+	p.buf = append(p.buf, chunk...)
+	for p.pos < len(p.buf) && p.err == nil && p.state != stateDone {
+		p.step(p.buf[p.pos])
+	}
+	return p.err
+}
+
+// Done reports whether the root value is complete.
+func (p *Parser) Done() bool {
+	return p.state == stateDone
+}
+
+// Err returns the syntax error met, if any.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// Snapshot returns the value received so far as valid JSON: open strings
+// and containers are closed, and keys, numbers and literals not complete
+// yet are left out. It returns false before the root value starts.
+func (p *Parser) Snapshot() ([]byte, bool) {
+	if p.start < 0 {
+		return nil, false
+	}
+	if p.state == stateString && !p.isKey {
+		end := p.pos
+		if p.escape > 0 {
+			end = p.escStart
+		}
+		out := make([]byte, 0, end-p.start+len(p.stack)+1)
+		out = append(out, p.buf[p.start:end]...)
+		out = append(out, '"')
+		return appendClosers(out, p.stack), true
+	}
+	out := make([]byte, 0, p.commit-p.start+len(p.closers))
+	out = append(out, p.buf[p.start:p.commit]...)
+	return append(out, p.closers...), true
+}
+
+// step scans the byte at pos. It leaves pos unchanged when the byte ends a
+// number or literal, so that it is scanned again in the new state.
+func (p *Parser) step(c byte) {
+	switch p.state {
+	case stateString:
+		p.pos++
+		p.scanString(c)
+		return
+	case stateNumber, stateLiteral:
+		if isScalarByte(p.state, c) {
+			p.pos++
+			// A literal ends with its last letter, unlike a number.
+			if lit := string(p.buf[p.scalarStart:p.pos]); p.state == stateLiteral &&
+				(lit == "true" || lit == "false" || lit == "null") {
+				p.valueDone(p.pos)
+			}
+			return
+		}
+		if scalar := p.buf[p.scalarStart:p.pos]; !json.Valid(scalar) {
+			p.err = fmt.Errorf("partialjson: invalid value %q at offset %d", scalar, p.scalarStart-p.start)
+			return
+		}
+		p.valueDone(p.pos)
+		return
+	}
+	if p.start < 0 {
+		if c == '{' || c == '[' {
+			p.start = p.pos
+		} else {
+			p.pos++
+			return
+		}
+	}
+	p.pos++
+	if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+		return
+	}
+	switch p.state {
+	case stateValueOrEnd:
+		if c == ']' {
+			p.close('[')
+			return
+		}
+		p.beginValue(c)
+	case stateValue:
+		p.beginValue(c)
+	case stateKeyOrEnd:
+		if c == '}' {
+			p.close('{')
+			return
+		}
+		p.beginKey(c)
+	case stateKey:
+		p.beginKey(c)
+	case stateColon:
+		if c != ':' {
+			p.fail(c, "':'")
+			return
+		}
+		p.state = stateValue
+	case stateCommaOrEnd:
+		switch c {
+		case ',':
+			if p.stack[len(p.stack)-1] == '{' {
+				p.state = stateKey
+			} else {
+				p.state = stateValue
+			}
+		case '}':
+			p.close('{')
+		case ']':
+			p.close('[')
+		default:
+			p.fail(c, "',' or the end of the container")
+		}
+	}
+}
+
+func (p *Parser) beginValue(c byte) {
+	switch {
+	case c == '{':
+		p.stack = append(p.stack, '{')
+		p.state = stateKeyOrEnd
+		p.setCommit(p.pos)
+	case c == '[':
+		p.stack = append(p.stack, '[')
+		p.state = stateValueOrEnd
+		p.setCommit(p.pos)
+	case c == '"':
+		p.state, p.isKey = stateString, false
+	case c == '-' || c >= '0' && c <= '9':
+		p.state, p.scalarStart = stateNumber, p.pos-1
+	case c == 't' || c == 'f' || c == 'n':
+		p.state, p.scalarStart = stateLiteral, p.pos-1
+	default:
+		p.fail(c, "a value")
+	}
+}
+
+func (p *Parser) beginKey(c byte) {
+	if c != '"' {
+		p.fail(c, "a key")
+		return
+	}
+	p.state, p.isKey = stateString, true
+}
+
+func (p *Parser) scanString(c byte) {
+	switch {
+	case p.escape > 0:
+		if p.escape == 1 && c == 'u' {
+			p.escape = 4
+			return
+		}
+		p.escape--
+	case c == '\\':
+		p.escape, p.escStart = 1, p.pos-1
+	case c == '"':
+		if p.isKey {
+			p.state = stateColon
+			return
+		}
+		p.valueDone(p.pos)
+	}
+}
+
+func (p *Parser) close(open byte) {
+	if p.stack[len(p.stack)-1] != open {
+		p.fail(p.buf[p.pos-1], "a matching bracket")
+		return
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+	p.valueDone(p.pos)
+}
+
+// valueDone records a value ending at end.
+func (p *Parser) valueDone(end int) {
+	if len(p.stack) == 0 {
+		p.state = stateDone
+	} else {
+		p.state = stateCommaOrEnd
+	}
+	p.setCommit(end)
+}
+
+func (p *Parser) setCommit(end int) {
+	p.commit = end
+	p.closers = appendClosers(p.closers[:0], p.stack)
+}
+
+func (p *Parser) fail(c byte, want string) {
+	p.err = fmt.Errorf("partialjson: unexpected %q at offset %d, want %s", c, p.pos-1-p.start, want)
+}
+
+func appendClosers(out, stack []byte) []byte {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			out = append(out, '}')
+		} else {
+			out = append(out, ']')
+		}
+	}
+	return out
+}
+
+func isScalarByte(s state, c byte) bool {
+	if s == stateLiteral {
+		return c >= 'a' && c <= 'z'
+	}
+	return c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
+
+// ErrIncomplete is returned by Complete when the root value has not ended.
+var ErrIncomplete = errors.New("partialjson: incomplete JSON")
+
+// Complete returns the root value once it is complete.
+func (p *Parser) Complete() ([]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.state != stateDone {
+		return nil, ErrIncomplete
+	}
+	return p.buf[p.start:p.commit], nil
+}
@@ -0,0 +1,97 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package partialjson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Snapshots(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", ""},
+		{"```json\n", ""},
+		{"```json\n{", `{}`},
+		{`{"ti`, `{}`},
+		{`{"title"`, `{}`},
+		{`{"title":`, `{}`},
+		{`{"title": "Hel`, `{"title": "Hel"}`},
+		{`{"title": "a\`, `{"title": "a"}`},
+		{`{"title": "a\u00`, `{"title": "a"}`},
+		{`{"title": "aé`, `{"title": "aé"}`},
+		{`{"title": "x", "n": 12`, `{"title": "x"}`},
+		{`{"title": "x", "n": 12,`, `{"title": "x", "n": 12}`},
+		{`{"title": "x", "ok": tru`, `{"title": "x"}`},
+		{`{"title": "x", "tags": [`, `{"title": "x", "tags": []}`},
+		{`{"title": "x", "tags": ["a", "b`, `{"title": "x", "tags": ["a", "b"]}`},
+		{`{"title": "x", "tags": ["a"], "meta": {"k": null`, `{"title": "x", "tags": ["a"], "meta": {"k": null}}`},
+		{`{"title": "x"} trailing`, `{"title": "x"}`},
+		{`[{"a": 1}, {"b"`, `[{"a": 1}, {}]`},
+	}
+	for _, tt := range tests {
+		p := NewParser()
+		require.NoError(t, p.Write(tt.prefix), tt.prefix)
+		got, ok := p.Snapshot()
+		if tt.want == "" {
+			assert.False(t, ok, tt.prefix)
+			continue
+		}
+		require.True(t, ok, tt.prefix)
+		assert.Equal(t, tt.want, string(got), tt.prefix)
+		assert.True(t, json.Valid(got), tt.prefix)
+	}
+}
+
+func TestParser_ByteByByte(t *testing.T) {
+	doc := `Here you go: {"name": "Ada \"the first\"", "age": 36, "langs": ["en", "fr"], ` +
+		`"active": false, "score": -1.5e3, "nested": {"deep": [[], {}]}}`
+	p := NewParser()
+	for i := 0; i < len(doc); i++ {
+		require.NoError(t, p.Write(doc[i:i+1]))
+		if snap, ok := p.Snapshot(); ok {
+			require.True(t, json.Valid(snap), "after %q: %s", doc[:i+1], snap)
+		}
+	}
+	assert.True(t, p.Done())
+	complete, err := p.Complete()
+	require.NoError(t, err)
+	var want, got any
+	require.NoError(t, json.Unmarshal([]byte(doc[len("Here you go: "):]), &want))
+	require.NoError(t, json.Unmarshal(complete, &got))
+	assert.Equal(t, want, got)
+
+	// Text after the root value is ignored.
+	require.NoError(t, p.Write(` more {"x": 1}`))
+	again, _ := p.Complete()
+	assert.Equal(t, complete, again)
+}
+
+func TestParser_Errors(t *testing.T) {
+	for _, doc := range []string{`{"a" 1}`, `{"a": 1]`, `{1: 2}`, `{"a": nul,`, `{"a": 1.,`, `[1 2]`, `{"a": }`} {
+		p := NewParser()
+		err := p.Write(doc)
+		assert.Error(t, err, doc)
+		assert.Equal(t, err, p.Err())
+		assert.Equal(t, err, p.Write("more"))
+		_, err = p.Complete()
+		assert.Error(t, err)
+	}
+
+	p := NewParser()
+	require.NoError(t, p.Write(`{"a": `))
+	_, err := p.Complete()
+	assert.ErrorIs(t, err, ErrIncomplete)
+}
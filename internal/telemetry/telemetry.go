@@ -95,6 +95,20 @@ var (
 	KeyRunnerInput     = "trpc.go.agent.runner.input"
 	KeyRunnerOutput    = "trpc.go.agent.runner.output"
 
+	// Model router attributes
+	KeyRouterName      = "trpc.go.agent.router.name"
+	KeyRouterRule      = "trpc.go.agent.router.rule"
+	KeyRouterModel     = "trpc.go.agent.router.model"
+	KeyRouterAttempts  = "trpc.go.agent.router.attempts"
+	KeyRouterFallbacks = "trpc.go.agent.router.fallbacks"
+
+	// Model rate limiter metrics and attributes
+	MetricRateLimitRequests     = "trpc.go.agent.ratelimit.requests"
+	MetricRateLimitWaitDuration = "trpc.go.agent.ratelimit.wait.duration"
+	MetricRateLimitInFlight     = "trpc.go.agent.ratelimit.in_flight"
+	KeyRateLimitKey             = "trpc.go.agent.ratelimit.key"
+	KeyRateLimitOutcome         = "trpc.go.agent.ratelimit.outcome"
+
 	// GenAI operation attributes
 	KeyGenAIOperationName = "gen_ai.operation.name"
 	KeyGenAISystem        = "gen_ai.system"
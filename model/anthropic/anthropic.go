@@ -0,0 +1,492 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package anthropic provides a model.Model implementation that talks to the
+// Anthropic Messages API directly.
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/model/internal/sse"
+)
+
+const (
+	// defaultBaseURL is the default endpoint of the Anthropic API.
+	defaultBaseURL = "https://api.anthropic.com"
+	// defaultAPIVersion is the value sent in the anthropic-version header.
+	defaultAPIVersion = "2023-06-01"
+	// defaultMaxTokens is used when the request does not specify MaxTokens,
+	// because the Messages API requires max_tokens on every call.
+	defaultMaxTokens = 4096
+	// defaultThinkingTokens is the thinking budget used when thinking is
+	// enabled without an explicit ThinkingTokens value.
+	defaultThinkingTokens = 1024
+	// defaultChannelBufferSize is the default channel buffer size.
+	defaultChannelBufferSize = 256
+	// maxThinkingCacheSize bounds the number of remembered thinking blocks.
+	maxThinkingCacheSize = 1024
+	// messagesPath is the path of the Messages API.
+	messagesPath = "/v1/messages"
+	// functionToolType is the tool call type reported to the framework.
+	functionToolType = "function"
+)
+
+// HTTPClient is the interface for the HTTP client.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// RequestCallbackFunc is called with the wire request before it is sent.
+type RequestCallbackFunc func(ctx context.Context, req *MessagesRequest)
+
+// StreamEventCallbackFunc is called for every decoded streaming event.
+type StreamEventCallbackFunc func(ctx context.Context, req *MessagesRequest, evt *StreamEvent)
+
+// options contains configuration options for creating a Model.
+type options struct {
+	// API key sent in the x-api-key header.
+	APIKey string
+	// Base URL of the Anthropic API.
+	BaseURL string
+	// APIVersion is sent in the anthropic-version header.
+	APIVersion string
+	// Beta features sent in the anthropic-beta header.
+	Betas []string
+	// Extra headers added to every request.
+	Headers map[string]string
+	// Extra fields merged into the request body.
+	ExtraFields map[string]any
+	// HTTP client used to send requests.
+	HTTPClient HTTPClient
+	// Buffer size for response channels (default: 256).
+	ChannelBufferSize int
+	// DefaultMaxTokens is used when the request does not set MaxTokens.
+	DefaultMaxTokens int
+	// Callback for the wire request.
+	RequestCallback RequestCallbackFunc
+	// Callback for streaming events.
+	StreamEventCallback StreamEventCallbackFunc
+}
+
+// Option is a function that configures an Anthropic model.
+type Option func(*options)
+
+// WithAPIKey sets the API key.
+func WithAPIKey(key string) Option {
+	return func(opts *options) {
+		opts.APIKey = key
+	}
+}
+
+// WithBaseURL sets the base URL of the API, e.g. a proxy or a test server.
+func WithBaseURL(url string) Option {
+	return func(opts *options) {
+		opts.BaseURL = url
+	}
+}
+
+// WithAPIVersion overrides the anthropic-version header.
+func WithAPIVersion(version string) Option {
+	return func(opts *options) {
+		opts.APIVersion = version
+	}
+}
+
+// WithBetas enables beta features through the anthropic-beta header.
+func WithBetas(betas ...string) Option {
+	return func(opts *options) {
+		opts.Betas = append(opts.Betas, betas...)
+	}
+}
+
+// WithHeaders sets extra HTTP headers added to every request.
+func WithHeaders(headers map[string]string) Option {
+	return func(opts *options) {
+		if opts.Headers == nil {
+			opts.Headers = make(map[string]string)
+		}
+		for k, v := range headers {
+			opts.Headers[k] = v
+		}
+	}
+}
+
+// WithExtraFields sets extra fields merged into every request body.
+// E.g. WithExtraFields(map[string]any{"top_k": 40}).
+func WithExtraFields(extraFields map[string]any) Option {
+	return func(opts *options) {
+		if opts.ExtraFields == nil {
+			opts.ExtraFields = make(map[string]any)
+		}
+		for k, v := range extraFields {
+			opts.ExtraFields[k] = v
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(client HTTPClient) Option {
+	return func(opts *options) {
+		opts.HTTPClient = client
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.ChannelBufferSize = size
+	}
+}
+
+// WithDefaultMaxTokens sets max_tokens for requests that do not set MaxTokens.
+func WithDefaultMaxTokens(maxTokens int) Option {
+	return func(opts *options) {
+		if maxTokens > 0 {
+			opts.DefaultMaxTokens = maxTokens
+		}
+	}
+}
+
+// WithRequestCallback sets the function called before a request is sent.
+func WithRequestCallback(fn RequestCallbackFunc) Option {
+	return func(opts *options) {
+		opts.RequestCallback = fn
+	}
+}
+
+// WithStreamEventCallback sets the function called for each streaming event.
+func WithStreamEventCallback(fn StreamEventCallbackFunc) Option {
+	return func(opts *options) {
+		opts.StreamEventCallback = fn
+	}
+}
+
+// Model implements the model.Model interface for the Anthropic Messages API.
+type Model struct {
+	name                string
+	baseURL             string
+	apiKey              string
+	apiVersion          string
+	betas               []string
+	headers             map[string]string
+	extraFields         map[string]any
+	httpClient          HTTPClient
+	channelBufferSize   int
+	defaultMaxTokens    int
+	requestCallback     RequestCallbackFunc
+	streamEventCallback StreamEventCallbackFunc
+
+	// thinkingMu guards thinkingByToolID.
+	thinkingMu sync.Mutex
+	// thinkingByToolID remembers signed thinking blocks produced together
+	// with tool calls. The API requires them to be sent back with the
+	// assistant turn when extended thinking is combined with tool use, and
+	// model.Message has no place to carry the signature.
+	thinkingByToolID map[string][]contentBlock
+}
+
+// New creates a new Anthropic model.
+func New(name string, opts ...Option) *Model {
+	o := &options{
+		BaseURL:           defaultBaseURL,
+		APIVersion:        defaultAPIVersion,
+		ChannelBufferSize: defaultChannelBufferSize,
+		DefaultMaxTokens:  defaultMaxTokens,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return &Model{
+		name:                name,
+		baseURL:             strings.TrimRight(o.BaseURL, "/"),
+		apiKey:              o.APIKey,
+		apiVersion:          o.APIVersion,
+		betas:               o.Betas,
+		headers:             o.Headers,
+		extraFields:         o.ExtraFields,
+		httpClient:          o.HTTPClient,
+		channelBufferSize:   o.ChannelBufferSize,
+		defaultMaxTokens:    o.DefaultMaxTokens,
+		requestCallback:     o.RequestCallback,
+		streamEventCallback: o.StreamEventCallback,
+		thinkingByToolID:    make(map[string][]contentBlock),
+	}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return model.Info{
+		Name: m.name,
+	}
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	wireRequest := m.buildRequest(request)
+	body, err := m.marshalRequest(wireRequest)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: marshal request: %w", err)
+	}
+
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+
+		if m.requestCallback != nil {
+			m.requestCallback(ctx, wireRequest)
+		}
+
+		httpRsp, err := m.send(ctx, body, request.Stream)
+		if err != nil {
+			m.sendError(ctx, responseChan, err.Error(), model.ErrorTypeAPIError, nil)
+			return
+		}
+		defer httpRsp.Body.Close()
+
+		if httpRsp.StatusCode/100 != 2 {
+			m.sendHTTPError(ctx, responseChan, httpRsp)
+			return
+		}
+		if request.Stream {
+			m.handleStreamingResponse(ctx, wireRequest, httpRsp.Body, responseChan)
+			return
+		}
+		m.handleNonStreamingResponse(ctx, httpRsp.Body, responseChan)
+	}()
+	return responseChan, nil
+}
+
+// marshalRequest encodes the wire request and merges extra fields.
+func (m *Model) marshalRequest(req *MessagesRequest) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.extraFields) == 0 {
+		return body, nil
+	}
+	var merged map[string]any
+	if err := json.Unmarshal(body, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range m.extraFields {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+// send performs the HTTP call.
+func (m *Model) send(ctx context.Context, body []byte, stream bool) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+messagesPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("anthropic-version", m.apiVersion)
+	if m.apiKey != "" {
+		httpReq.Header.Set("x-api-key", m.apiKey)
+	}
+	if len(m.betas) > 0 {
+		httpReq.Header.Set("anthropic-beta", strings.Join(m.betas, ","))
+	}
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	for k, v := range m.headers {
+		httpReq.Header.Set(k, v)
+	}
+	rsp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: send request: %w", err)
+	}
+	return rsp, nil
+}
+
+// sendHTTPError converts a non-2xx HTTP response into an error response.
+func (m *Model) sendHTTPError(ctx context.Context, responseChan chan<- *model.Response, httpRsp *http.Response) {
+	raw, _ := io.ReadAll(httpRsp.Body)
+	var apiErr errorEnvelope
+	message := strings.TrimSpace(string(raw))
+	var code *string
+	if err := json.Unmarshal(raw, &apiErr); err == nil && apiErr.Error.Message != "" {
+		message = apiErr.Error.Message
+		errType := apiErr.Error.Type
+		code = &errType
+	} else {
+		status := strconv.Itoa(httpRsp.StatusCode)
+		code = &status
+	}
+	m.sendError(ctx, responseChan,
+		fmt.Sprintf("anthropic: status %d: %s", httpRsp.StatusCode, message),
+		model.ErrorTypeAPIError, code)
+}
+
+// sendError delivers an error response on the channel.
+func (m *Model) sendError(
+	ctx context.Context,
+	responseChan chan<- *model.Response,
+	message, errType string,
+	code *string,
+) {
+	rsp := &model.Response{
+		Object: model.ObjectTypeError,
+		Error: &model.ResponseError{
+			Message: message,
+			Type:    errType,
+			Code:    code,
+		},
+		Timestamp: time.Now(),
+		Done:      true,
+	}
+	select {
+	case responseChan <- rsp:
+	case <-ctx.Done():
+	}
+}
+
+// handleNonStreamingResponse decodes a single Messages API response.
+func (m *Model) handleNonStreamingResponse(
+	ctx context.Context,
+	body io.Reader,
+	responseChan chan<- *model.Response,
+) {
+	var msg messageResponse
+	if err := json.NewDecoder(body).Decode(&msg); err != nil {
+		m.sendError(ctx, responseChan, fmt.Sprintf("anthropic: decode response: %v", err), model.ErrorTypeAPIError, nil)
+		return
+	}
+	m.rememberThinking(msg.Content)
+	rsp := convertMessageResponse(&msg)
+	rsp.Done = true
+	select {
+	case responseChan <- rsp:
+	case <-ctx.Done():
+	}
+}
+
+// handleStreamingResponse consumes the server-sent events of a streaming call.
+func (m *Model) handleStreamingResponse(
+	ctx context.Context,
+	wireRequest *MessagesRequest,
+	body io.Reader,
+	responseChan chan<- *model.Response,
+) {
+	acc := newStreamAccumulator()
+	err := sse.Read(body, func(data []byte) error {
+		var evt StreamEvent
+		if err := json.Unmarshal(data, &evt); err != nil {
+			log.Warnf("anthropic: skip undecodable stream event: %v", err)
+			return nil
+		}
+		if m.streamEventCallback != nil {
+			m.streamEventCallback(ctx, wireRequest, &evt)
+		}
+		if evt.Type == eventTypeError && evt.Error != nil {
+			return &streamError{errType: evt.Error.Type, message: evt.Error.Message}
+		}
+		partial := acc.add(&evt)
+		if partial == nil {
+			return nil
+		}
+		select {
+		case responseChan <- partial:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+		var code *string
+		var se *streamError
+		if errors.As(err, &se) {
+			code = &se.errType
+		}
+		m.sendError(ctx, responseChan, err.Error(), model.ErrorTypeStreamError, code)
+		return
+	}
+
+	m.rememberThinking(acc.message.Content)
+	final := convertMessageResponse(&acc.message)
+	final.Done = !final.IsToolCallResponse()
+	select {
+	case responseChan <- final:
+	case <-ctx.Done():
+	}
+}
+
+// rememberThinking stores signed thinking blocks keyed by the tool calls
+// they precede so that they can be replayed on the next turn.
+func (m *Model) rememberThinking(blocks []contentBlock) {
+	var thinking []contentBlock
+	var toolIDs []string
+	for _, b := range blocks {
+		switch b.Type {
+		case blockTypeThinking, blockTypeRedactedThinking:
+			thinking = append(thinking, b)
+		case blockTypeToolUse:
+			toolIDs = append(toolIDs, b.ID)
+		}
+	}
+	if len(thinking) == 0 || len(toolIDs) == 0 {
+		return
+	}
+	m.thinkingMu.Lock()
+	defer m.thinkingMu.Unlock()
+	if len(m.thinkingByToolID) >= maxThinkingCacheSize {
+		m.thinkingByToolID = make(map[string][]contentBlock)
+	}
+	for _, id := range toolIDs {
+		m.thinkingByToolID[id] = thinking
+	}
+}
+
+// lookupThinking returns the remembered thinking blocks for a tool call.
+func (m *Model) lookupThinking(toolID string) []contentBlock {
+	m.thinkingMu.Lock()
+	defer m.thinkingMu.Unlock()
+	return m.thinkingByToolID[toolID]
+}
+
+// streamError is an error event received in the middle of a stream.
+type streamError struct {
+	errType string
+	message string
+}
+
+func (e *streamError) Error() string {
+	return fmt.Sprintf("anthropic: stream error %s: %s", e.errType, e.message)
+}
@@ -0,0 +1,253 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+type stubTool struct {
+	decl *tool.Declaration
+}
+
+func (s stubTool) Declaration() *tool.Declaration { return s.decl }
+
+func collect(t *testing.T, ch <-chan *model.Response) []*model.Response {
+	t.Helper()
+	var out []*model.Response
+	for rsp := range ch {
+		out = append(out, rsp)
+	}
+	return out
+}
+
+func TestModel_GenerateContent_NilRequest(t *testing.T) {
+	m := New("claude-test")
+	_, err := m.GenerateContent(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestModel_GenerateContent_NonStreaming(t *testing.T) {
+	var captured map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, messagesPath, r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, defaultAPIVersion, r.Header.Get("anthropic-version"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &captured))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-test",
+			"content": [
+				{"type": "thinking", "thinking": "let me think", "signature": "sig"},
+				{"type": "text", "text": "calling tool"},
+				{"type": "tool_use", "id": "toolu_1", "name": "calc", "input": {"a": 1}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 2}
+		}`)
+	}))
+	defer srv.Close()
+
+	m := New("claude-test", WithAPIKey("test-key"), WithBaseURL(srv.URL))
+	maxTokens := 128
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage("be helpful"),
+			model.NewUserMessage("hi"),
+		},
+		GenerationConfig: model.GenerationConfig{MaxTokens: &maxTokens},
+		Tools: map[string]tool.Tool{
+			"calc": stubTool{decl: &tool.Declaration{Name: "calc", Description: "adds", InputSchema: &tool.Schema{Type: "object"}}},
+		},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	rsp := rsps[0]
+	require.Nil(t, rsp.Error)
+	assert.True(t, rsp.Done)
+	assert.Equal(t, "msg_1", rsp.ID)
+	msg := rsp.Choices[0].Message
+	assert.Equal(t, "calling tool", msg.Content)
+	assert.Equal(t, "let me think", msg.ReasoningContent)
+	require.Len(t, msg.ToolCalls, 1)
+	assert.Equal(t, "toolu_1", msg.ToolCalls[0].ID)
+	assert.Equal(t, "calc", msg.ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"a":1}`, string(msg.ToolCalls[0].Function.Arguments))
+	require.NotNil(t, rsp.Choices[0].FinishReason)
+	assert.Equal(t, finishReasonToolCalls, *rsp.Choices[0].FinishReason)
+	assert.Equal(t, &model.Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}, rsp.Usage)
+
+	assert.Equal(t, float64(128), captured["max_tokens"])
+	system := captured["system"].([]any)
+	require.Len(t, system, 1)
+	assert.Equal(t, "be helpful", system[0].(map[string]any)["text"])
+	tools := captured["tools"].([]any)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "calc", tools[0].(map[string]any)["name"])
+
+	// The signed thinking block is replayed before the tool call on the next turn.
+	blocks := m.convertAssistantMessage(msg)
+	require.Len(t, blocks, 3)
+	assert.Equal(t, blockTypeThinking, blocks[0].Type)
+	assert.Equal(t, "sig", blocks[0].Signature)
+}
+
+func TestModel_GenerateContent_Streaming(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_2","type":"message","role":"assistant","model":"claude-test","content":[],"usage":{"input_tokens":7,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"abc"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hel"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"lo"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_9","name":"search","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"go\"}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`,
+		`{"type":"message_stop"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, true, body["stream"])
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			var typ struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(e), &typ)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ.Type, e)
+		}
+		fmt.Fprint(w, ": keepalive\n\n")
+	}))
+	defer srv.Close()
+
+	var seen []string
+	m := New("claude-test", WithBaseURL(srv.URL),
+		WithStreamEventCallback(func(_ context.Context, _ *MessagesRequest, evt *StreamEvent) {
+			seen = append(seen, evt.Type)
+		}))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{Stream: true},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 4)
+
+	assert.True(t, rsps[0].IsPartial)
+	assert.Equal(t, "hmm", rsps[0].Choices[0].Delta.ReasoningContent)
+	assert.Equal(t, "Hel", rsps[1].Choices[0].Delta.Content)
+	assert.Equal(t, "lo", rsps[2].Choices[0].Delta.Content)
+
+	final := rsps[3]
+	assert.False(t, final.IsPartial)
+	assert.False(t, final.Done, "tool call responses are not terminal")
+	assert.Equal(t, "Hello", final.Choices[0].Message.Content)
+	assert.Equal(t, "hmm", final.Choices[0].Message.ReasoningContent)
+	require.Len(t, final.Choices[0].Message.ToolCalls, 1)
+	assert.JSONEq(t, `{"q":"go"}`, string(final.Choices[0].Message.ToolCalls[0].Function.Arguments))
+	assert.Equal(t, finishReasonToolCalls, *final.Choices[0].FinishReason)
+	assert.Equal(t, 7, final.Usage.PromptTokens)
+	assert.Equal(t, 20, final.Usage.CompletionTokens)
+	assert.Len(t, seen, len(events))
+}
+
+func TestModel_GenerateContent_StreamErrorEvent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"m\",\"content\":[]}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	m := New("claude-test", WithBaseURL(srv.URL))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{Stream: true},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Equal(t, model.ErrorTypeStreamError, rsps[0].Error.Type)
+	require.NotNil(t, rsps[0].Error.Code)
+	assert.Equal(t, "overloaded_error", *rsps[0].Error.Code)
+}
+
+func TestModel_GenerateContent_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	}))
+	defer srv.Close()
+
+	m := New("claude-test", WithBaseURL(srv.URL))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Equal(t, model.ErrorTypeAPIError, rsps[0].Error.Type)
+	assert.Contains(t, rsps[0].Error.Message, "429")
+	assert.Contains(t, rsps[0].Error.Message, "slow down")
+	assert.Equal(t, "rate_limit_error", *rsps[0].Error.Code)
+}
+
+func TestModel_ExtraFieldsHeadersAndBetas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "b1,b2", r.Header.Get("anthropic-beta"))
+		assert.Equal(t, "v", r.Header.Get("X-Custom"))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, float64(40), body["top_k"])
+		fmt.Fprint(w, `{"id":"m","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{}}`)
+	}))
+	defer srv.Close()
+
+	var callbackModel string
+	m := New("claude-test",
+		WithBaseURL(srv.URL+"/"),
+		WithBetas("b1", "b2"),
+		WithHeaders(map[string]string{"X-Custom": "v"}),
+		WithExtraFields(map[string]any{"top_k": 40}),
+		WithRequestCallback(func(_ context.Context, req *MessagesRequest) { callbackModel = req.Model }),
+	)
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.Nil(t, rsps[0].Error)
+	assert.Equal(t, "ok", rsps[0].Choices[0].Message.Content)
+	assert.Equal(t, finishReasonStop, *rsps[0].Choices[0].FinishReason)
+	assert.Equal(t, "claude-test", callbackModel)
+}
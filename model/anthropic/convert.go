@@ -0,0 +1,543 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package anthropic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Content block types of the Messages API.
+const (
+	blockTypeText             = "text"
+	blockTypeImage            = "image"
+	blockTypeDocument         = "document"
+	blockTypeToolUse          = "tool_use"
+	blockTypeToolResult       = "tool_result"
+	blockTypeThinking         = "thinking"
+	blockTypeRedactedThinking = "redacted_thinking"
+)
+
+// Streaming event types of the Messages API.
+const (
+	eventTypeMessageStart      = "message_start"
+	eventTypeMessageDelta      = "message_delta"
+	eventTypeContentBlockStart = "content_block_start"
+	eventTypeContentBlockDelta = "content_block_delta"
+	eventTypeContentBlockStop  = "content_block_stop"
+	eventTypeError             = "error"
+)
+
+// Delta types carried by content_block_delta events.
+const (
+	deltaTypeText      = "text_delta"
+	deltaTypeInputJSON = "input_json_delta"
+	deltaTypeThinking  = "thinking_delta"
+	deltaTypeSignature = "signature_delta"
+)
+
+// Stop reasons of the Messages API.
+const (
+	stopReasonEndTurn      = "end_turn"
+	stopReasonMaxTokens    = "max_tokens"
+	stopReasonStopSequence = "stop_sequence"
+	stopReasonToolUse      = "tool_use"
+	stopReasonRefusal      = "refusal"
+)
+
+// Finish reasons reported on model.Choice, aligned with the OpenAI values
+// used elsewhere in the framework.
+const (
+	finishReasonStop          = "stop"
+	finishReasonLength        = "length"
+	finishReasonToolCalls     = "tool_calls"
+	finishReasonContentFilter = "content_filter"
+)
+
+// MessagesRequest is the body of a Messages API call.
+type MessagesRequest struct {
+	Model         string          `json:"model"`
+	Messages      []wireMessage   `json:"messages"`
+	System        []contentBlock  `json:"system,omitempty"`
+	MaxTokens     int             `json:"max_tokens"`
+	Temperature   *float64        `json:"temperature,omitempty"`
+	TopP          *float64        `json:"top_p,omitempty"`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
+	Stream        bool            `json:"stream,omitempty"`
+	Tools         []toolParam     `json:"tools,omitempty"`
+	Thinking      *thinkingConfig `json:"thinking,omitempty"`
+}
+
+// wireMessage is a single message of the Messages API.
+type wireMessage struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is the union of all content block shapes used by the API.
+type contentBlock struct {
+	Type string `json:"type"`
+	// text.
+	Text string `json:"text,omitempty"`
+	// image / document.
+	Source *blockSource `json:"source,omitempty"`
+	Title  string       `json:"title,omitempty"`
+	// tool_use.
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	// thinking / redacted_thinking.
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// blockSource is the source of an image or document block.
+type blockSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+	FileID    string `json:"file_id,omitempty"`
+}
+
+// toolParam declares a client tool.
+type toolParam struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	InputSchema *tool.Schema `json:"input_schema"`
+}
+
+// thinkingConfig enables extended thinking.
+type thinkingConfig struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens,omitempty"`
+}
+
+// messageResponse is a complete (non-streaming) response.
+type messageResponse struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Role         string         `json:"role"`
+	Model        string         `json:"model"`
+	Content      []contentBlock `json:"content"`
+	StopReason   string         `json:"stop_reason"`
+	StopSequence string         `json:"stop_sequence"`
+	Usage        usage          `json:"usage"`
+}
+
+// usage is the token accounting reported by the API.
+type usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// StreamEvent is a decoded server-sent event of a streaming call.
+type StreamEvent struct {
+	Type         string           `json:"type"`
+	Index        int              `json:"index"`
+	Message      *messageResponse `json:"message,omitempty"`
+	ContentBlock *contentBlock    `json:"content_block,omitempty"`
+	Delta        *streamDelta     `json:"delta,omitempty"`
+	Usage        *usage           `json:"usage,omitempty"`
+	Error        *apiError        `json:"error,omitempty"`
+}
+
+// streamDelta carries incremental content or the final stop reason.
+type streamDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	StopReason  string `json:"stop_reason,omitempty"`
+}
+
+// apiError is the error object returned by the API.
+type apiError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// errorEnvelope wraps apiError in HTTP error bodies.
+type errorEnvelope struct {
+	Type  string   `json:"type"`
+	Error apiError `json:"error"`
+}
+
+// buildRequest converts a model.Request into a Messages API request.
+func (m *Model) buildRequest(request *model.Request) *MessagesRequest {
+	req := &MessagesRequest{
+		Model:         m.name,
+		MaxTokens:     m.defaultMaxTokens,
+		Temperature:   request.Temperature,
+		TopP:          request.TopP,
+		StopSequences: request.Stop,
+		Stream:        request.Stream,
+		Tools:         convertTools(request.Tools),
+	}
+	if request.MaxTokens != nil && *request.MaxTokens > 0 {
+		req.MaxTokens = *request.MaxTokens
+	}
+	if request.ThinkingEnabled != nil && *request.ThinkingEnabled {
+		budget := defaultThinkingTokens
+		if request.ThinkingTokens != nil && *request.ThinkingTokens > 0 {
+			budget = *request.ThinkingTokens
+		}
+		// The API requires max_tokens to be larger than the thinking budget.
+		if req.MaxTokens <= budget {
+			req.MaxTokens = budget + m.defaultMaxTokens
+		}
+		req.Thinking = &thinkingConfig{Type: "enabled", BudgetTokens: budget}
+	}
+	req.System, req.Messages = m.convertMessages(request.Messages)
+	return req
+}
+
+// convertMessages splits system messages out and converts the rest into
+// alternating user/assistant turns. Consecutive messages that map to the same
+// role, such as parallel tool results, are merged into one turn.
+func (m *Model) convertMessages(messages []model.Message) ([]contentBlock, []wireMessage) {
+	var system []contentBlock
+	var result []wireMessage
+	appendTurn := func(role string, blocks []contentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Content = append(result[n-1].Content, blocks...)
+			return
+		}
+		result = append(result, wireMessage{Role: role, Content: blocks})
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case model.RoleSystem:
+			system = append(system, textBlocks(msg)...)
+		case model.RoleAssistant:
+			appendTurn(string(model.RoleAssistant), m.convertAssistantMessage(msg))
+		case model.RoleTool:
+			appendTurn(string(model.RoleUser), []contentBlock{{
+				Type:      blockTypeToolResult,
+				ToolUseID: msg.ToolID,
+				Content:   msg.Content,
+			}})
+		default:
+			appendTurn(string(model.RoleUser), convertUserMessage(msg))
+		}
+	}
+	return system, result
+}
+
+// textBlocks collects the text content of a message.
+func textBlocks(msg model.Message) []contentBlock {
+	var blocks []contentBlock
+	if msg.Content != "" {
+		blocks = append(blocks, contentBlock{Type: blockTypeText, Text: msg.Content})
+	}
+	for _, part := range msg.ContentParts {
+		if part.Type == model.ContentTypeText && part.Text != nil && *part.Text != "" {
+			blocks = append(blocks, contentBlock{Type: blockTypeText, Text: *part.Text})
+		}
+	}
+	return blocks
+}
+
+// convertUserMessage converts text, image and file parts of a user message.
+func convertUserMessage(msg model.Message) []contentBlock {
+	var blocks []contentBlock
+	if msg.Content != "" {
+		blocks = append(blocks, contentBlock{Type: blockTypeText, Text: msg.Content})
+	}
+	for _, part := range msg.ContentParts {
+		if b, ok := convertContentPart(part); ok {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+// convertContentPart converts one multimodal part. Audio is not supported by
+// the Messages API and is dropped with a warning.
+func convertContentPart(part model.ContentPart) (contentBlock, bool) {
+	switch part.Type {
+	case model.ContentTypeText:
+		if part.Text != nil && *part.Text != "" {
+			return contentBlock{Type: blockTypeText, Text: *part.Text}, true
+		}
+	case model.ContentTypeImage:
+		if part.Image == nil {
+			break
+		}
+		if part.Image.URL != "" {
+			return contentBlock{Type: blockTypeImage, Source: &blockSource{Type: "url", URL: part.Image.URL}}, true
+		}
+		return contentBlock{Type: blockTypeImage, Source: &blockSource{
+			Type:      "base64",
+			MediaType: imageMediaType(part.Image.Format),
+			Data:      base64.StdEncoding.EncodeToString(part.Image.Data),
+		}}, true
+	case model.ContentTypeFile:
+		if part.File == nil {
+			break
+		}
+		b := contentBlock{Type: blockTypeDocument, Title: part.File.Name}
+		switch {
+		case part.File.FileID != "":
+			b.Source = &blockSource{Type: "file", FileID: part.File.FileID}
+		case strings.HasPrefix(part.File.MimeType, "text/"):
+			b.Source = &blockSource{Type: "text", MediaType: "text/plain", Data: string(part.File.Data)}
+		default:
+			b.Source = &blockSource{
+				Type:      "base64",
+				MediaType: part.File.MimeType,
+				Data:      base64.StdEncoding.EncodeToString(part.File.Data),
+			}
+		}
+		return b, true
+	case model.ContentTypeAudio:
+		log.Warn("anthropic: audio content parts are not supported and will be skipped")
+	}
+	return contentBlock{}, false
+}
+
+// imageMediaType maps an image format to its MIME type.
+func imageMediaType(format string) string {
+	switch strings.ToLower(format) {
+	case "jpg", "jpeg":
+		return "image/jpeg"
+	case "gif":
+		return "image/gif"
+	case "webp":
+		return "image/webp"
+	default:
+		return "image/png"
+	}
+}
+
+// convertAssistantMessage converts assistant text and tool calls. Thinking
+// blocks remembered for the tool calls are replayed first, as required by
+// the API for extended thinking with tool use.
+func (m *Model) convertAssistantMessage(msg model.Message) []contentBlock {
+	var blocks []contentBlock
+	if len(msg.ToolCalls) > 0 {
+		blocks = append(blocks, m.lookupThinking(msg.ToolCalls[0].ID)...)
+	}
+	blocks = append(blocks, textBlocks(msg)...)
+	for _, tc := range msg.ToolCalls {
+		input := json.RawMessage(tc.Function.Arguments)
+		if len(input) == 0 || !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		blocks = append(blocks, contentBlock{
+			Type:  blockTypeToolUse,
+			ID:    tc.ID,
+			Name:  tc.Function.Name,
+			Input: input,
+		})
+	}
+	return blocks
+}
+
+// convertTools converts tool declarations, ordered by name for stable requests.
+func convertTools(tools map[string]tool.Tool) []toolParam {
+	if len(tools) == 0 {
+		return nil
+	}
+	result := make([]toolParam, 0, len(tools))
+	for _, t := range tools {
+		decl := t.Declaration()
+		if decl == nil {
+			continue
+		}
+		schema := decl.InputSchema
+		if schema == nil {
+			schema = &tool.Schema{Type: "object"}
+		}
+		result = append(result, toolParam{
+			Name:        decl.Name,
+			Description: decl.Description,
+			InputSchema: schema,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// convertMessageResponse converts an API message into a model.Response.
+func convertMessageResponse(msg *messageResponse) *model.Response {
+	message := model.Message{Role: model.RoleAssistant}
+	var text, reasoning strings.Builder
+	for _, b := range msg.Content {
+		switch b.Type {
+		case blockTypeText:
+			text.WriteString(b.Text)
+		case blockTypeThinking:
+			reasoning.WriteString(b.Thinking)
+		case blockTypeToolUse:
+			args := []byte(b.Input)
+			if len(args) == 0 {
+				args = []byte("{}")
+			}
+			idx := len(message.ToolCalls)
+			message.ToolCalls = append(message.ToolCalls, model.ToolCall{
+				Type:  functionToolType,
+				ID:    b.ID,
+				Index: &idx,
+				Function: model.FunctionDefinitionParam{
+					Name:      b.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+	message.Content = text.String()
+	message.ReasoningContent = reasoning.String()
+
+	choice := model.Choice{Index: 0, Message: message}
+	if reason := convertStopReason(msg.StopReason); reason != "" {
+		choice.FinishReason = &reason
+	}
+	return &model.Response{
+		ID:        msg.ID,
+		Object:    model.ObjectTypeChatCompletion,
+		Created:   time.Now().Unix(),
+		Model:     msg.Model,
+		Choices:   []model.Choice{choice},
+		Usage:     convertUsage(msg.Usage),
+		Timestamp: time.Now(),
+	}
+}
+
+// convertStopReason maps API stop reasons to framework finish reasons.
+func convertStopReason(reason string) string {
+	switch reason {
+	case "":
+		return ""
+	case stopReasonEndTurn, stopReasonStopSequence:
+		return finishReasonStop
+	case stopReasonMaxTokens:
+		return finishReasonLength
+	case stopReasonToolUse:
+		return finishReasonToolCalls
+	case stopReasonRefusal:
+		return finishReasonContentFilter
+	default:
+		return reason
+	}
+}
+
+// convertUsage maps API usage to model.Usage. Cached input tokens are part of
+// the prompt from the caller's point of view.
+func convertUsage(u usage) *model.Usage {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &model.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+	}
+}
+
+// streamAccumulator rebuilds the full message from streaming events.
+type streamAccumulator struct {
+	message     messageResponse
+	partialJSON map[int]*strings.Builder
+}
+
+func newStreamAccumulator() *streamAccumulator {
+	return &streamAccumulator{partialJSON: make(map[int]*strings.Builder)}
+}
+
+// add folds an event into the accumulated message and returns a partial
+// response for events that carry visible text or thinking deltas.
+func (a *streamAccumulator) add(evt *StreamEvent) *model.Response {
+	switch evt.Type {
+	case eventTypeMessageStart:
+		if evt.Message != nil {
+			a.message = *evt.Message
+			a.message.Content = nil
+		}
+	case eventTypeContentBlockStart:
+		if evt.ContentBlock == nil {
+			return nil
+		}
+		for len(a.message.Content) <= evt.Index {
+			a.message.Content = append(a.message.Content, contentBlock{})
+		}
+		block := *evt.ContentBlock
+		if block.Type == blockTypeToolUse {
+			// Input arrives through input_json_delta events.
+			block.Input = nil
+			a.partialJSON[evt.Index] = &strings.Builder{}
+		}
+		a.message.Content[evt.Index] = block
+	case eventTypeContentBlockDelta:
+		if evt.Delta == nil || evt.Index >= len(a.message.Content) {
+			return nil
+		}
+		block := &a.message.Content[evt.Index]
+		switch evt.Delta.Type {
+		case deltaTypeText:
+			block.Text += evt.Delta.Text
+			return a.partial(model.Message{Role: model.RoleAssistant, Content: evt.Delta.Text})
+		case deltaTypeThinking:
+			block.Thinking += evt.Delta.Thinking
+			return a.partial(model.Message{Role: model.RoleAssistant, ReasoningContent: evt.Delta.Thinking})
+		case deltaTypeSignature:
+			block.Signature += evt.Delta.Signature
+		case deltaTypeInputJSON:
+			if sb, ok := a.partialJSON[evt.Index]; ok {
+				sb.WriteString(evt.Delta.PartialJSON)
+			}
+		}
+	case eventTypeContentBlockStop:
+		if sb, ok := a.partialJSON[evt.Index]; ok && evt.Index < len(a.message.Content) {
+			a.message.Content[evt.Index].Input = json.RawMessage(sb.String())
+			delete(a.partialJSON, evt.Index)
+		}
+	case eventTypeMessageDelta:
+		if evt.Delta != nil && evt.Delta.StopReason != "" {
+			a.message.StopReason = evt.Delta.StopReason
+		}
+		if evt.Usage != nil {
+			// message_delta usage is cumulative for output tokens.
+			a.message.Usage.OutputTokens = evt.Usage.OutputTokens
+			if evt.Usage.InputTokens > 0 {
+				a.message.Usage.InputTokens = evt.Usage.InputTokens
+			}
+		}
+	}
+	return nil
+}
+
+// partial builds a streaming chunk response.
+func (a *streamAccumulator) partial(delta model.Message) *model.Response {
+	return &model.Response{
+		ID:        a.message.ID,
+		Object:    model.ObjectTypeChatCompletionChunk,
+		Created:   time.Now().Unix(),
+		Model:     a.message.Model,
+		Choices:   []model.Choice{{Index: 0, Delta: delta}},
+		Timestamp: time.Now(),
+		IsPartial: true,
+	}
+}
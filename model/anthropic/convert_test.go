@@ -0,0 +1,110 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package anthropic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestBuildRequest_Thinking(t *testing.T) {
+	m := New("claude-test", WithDefaultMaxTokens(1000))
+	enabled := true
+	budget := 2048
+	req := m.buildRequest(&model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{
+			ThinkingEnabled: &enabled,
+			ThinkingTokens:  &budget,
+		},
+	})
+	require.NotNil(t, req.Thinking)
+	assert.Equal(t, "enabled", req.Thinking.Type)
+	assert.Equal(t, 2048, req.Thinking.BudgetTokens)
+	assert.Greater(t, req.MaxTokens, 2048)
+}
+
+func TestConvertMessages_MergesTurnsAndToolResults(t *testing.T) {
+	m := New("claude-test")
+	text := "part text"
+	system, msgs := m.convertMessages([]model.Message{
+		model.NewSystemMessage("sys1"),
+		model.NewUserMessage("question"),
+		{
+			Role: model.RoleAssistant,
+			ToolCalls: []model.ToolCall{
+				{ID: "t1", Function: model.FunctionDefinitionParam{Name: "a", Arguments: []byte(`{"x":1}`)}},
+				{ID: "t2", Function: model.FunctionDefinitionParam{Name: "b"}},
+			},
+		},
+		model.NewToolMessage("t1", "a", "r1"),
+		model.NewToolMessage("t2", "b", "r2"),
+		{Role: model.RoleUser, ContentParts: []model.ContentPart{{Type: model.ContentTypeText, Text: &text}}},
+		model.NewSystemMessage("sys2"),
+	})
+	require.Len(t, system, 2)
+	assert.Equal(t, "sys2", system[1].Text)
+
+	require.Len(t, msgs, 3)
+	assert.Equal(t, "user", msgs[0].Role)
+	assert.Equal(t, "assistant", msgs[1].Role)
+	require.Len(t, msgs[1].Content, 2)
+	assert.Equal(t, blockTypeToolUse, msgs[1].Content[0].Type)
+	assert.JSONEq(t, `{"x":1}`, string(msgs[1].Content[0].Input))
+	assert.JSONEq(t, `{}`, string(msgs[1].Content[1].Input))
+
+	// Both tool results and the follow-up user text share one user turn.
+	assert.Equal(t, "user", msgs[2].Role)
+	require.Len(t, msgs[2].Content, 3)
+	assert.Equal(t, blockTypeToolResult, msgs[2].Content[0].Type)
+	assert.Equal(t, "t1", msgs[2].Content[0].ToolUseID)
+	assert.Equal(t, "r2", msgs[2].Content[1].Content)
+	assert.Equal(t, "part text", msgs[2].Content[2].Text)
+}
+
+func TestConvertContentPart(t *testing.T) {
+	var msg model.Message
+	msg.AddImageURL("https://example.com/a.png", "auto")
+	msg.AddImageData([]byte("img"), "auto", "jpg")
+	msg.AddFileData("doc.pdf", []byte("pdf"), "application/pdf")
+	msg.AddFileData("notes.txt", []byte("plain"), "text/plain")
+	msg.AddFileID("file_1")
+	msg.AddAudioData([]byte("wav"), "wav")
+
+	blocks := convertUserMessage(msg)
+	require.Len(t, blocks, 5)
+	assert.Equal(t, "url", blocks[0].Source.Type)
+	assert.Equal(t, "image/jpeg", blocks[1].Source.MediaType)
+	assert.Equal(t, "aW1n", blocks[1].Source.Data)
+	assert.Equal(t, blockTypeDocument, blocks[2].Type)
+	assert.Equal(t, "application/pdf", blocks[2].Source.MediaType)
+	assert.Equal(t, "text", blocks[3].Source.Type)
+	assert.Equal(t, "plain", blocks[3].Source.Data)
+	assert.Equal(t, "file_1", blocks[4].Source.FileID)
+}
+
+func TestConvertStopReason(t *testing.T) {
+	tests := map[string]string{
+		"":                     "",
+		stopReasonEndTurn:      finishReasonStop,
+		stopReasonStopSequence: finishReasonStop,
+		stopReasonMaxTokens:    finishReasonLength,
+		stopReasonToolUse:      finishReasonToolCalls,
+		stopReasonRefusal:      finishReasonContentFilter,
+		"pause_turn":           "pause_turn",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, convertStopReason(in), in)
+	}
+}
@@ -0,0 +1,378 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package cache provides a model.Model decorator that caches complete
+// responses by a canonical hash of the request and replays them, chunk by
+// chunk for streaming requests, instead of calling the model again.
+//
+//	store, err := cache.NewFileStore(".cache/llm")
+//	...
+//	m := cache.New(openai.New("gpt-4o-mini"),
+//		cache.WithStore(store), cache.WithTTL(24*time.Hour))
+//
+// Replayed responses have Response.CacheHit set. Use NewBypassContext to
+// skip the cache for a single call.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+const (
+	// defaultChannelBufferSize is the default channel buffer size.
+	defaultChannelBufferSize = 256
+	// defaultMemoryEntries is the capacity of the default in-memory store.
+	defaultMemoryEntries = 1024
+	// entryVersion is the version of the serialized cache entries.
+	entryVersion = 1
+)
+
+// Store persists cache entries. Implementations must be safe for concurrent
+// use. Get returns false for missing and expired entries.
+type Store interface {
+	// Get returns the value stored under key.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key. A zero ttl means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// KeyFunc computes the cache key of a request.
+type KeyFunc func(modelName string, request *model.Request) (string, error)
+
+// options contains configuration options for creating a Model.
+type options struct {
+	store             Store
+	ttl               time.Duration
+	namespace         string
+	keyFunc           KeyFunc
+	channelBufferSize int
+}
+
+// Option is a function that configures a cache Model.
+type Option func(*options)
+
+// WithStore sets the store. Defaults to an in-memory LRU store.
+func WithStore(store Store) Option {
+	return func(opts *options) {
+		opts.store = store
+	}
+}
+
+// WithTTL sets how long entries stay valid. Zero, the default, means no
+// expiry.
+func WithTTL(ttl time.Duration) Option {
+	return func(opts *options) {
+		opts.ttl = ttl
+	}
+}
+
+// WithNamespace prefixes every key, e.g. to separate environments or to
+// invalidate all entries after a prompt change.
+func WithNamespace(namespace string) Option {
+	return func(opts *options) {
+		opts.namespace = namespace
+	}
+}
+
+// WithKeyFunc replaces the request hash, e.g. to ignore volatile parts of
+// the system prompt. Defaults to RequestKey.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(opts *options) {
+		opts.keyFunc = fn
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.channelBufferSize = size
+	}
+}
+
+type bypassKey struct{}
+
+// NewBypassContext returns a context whose model calls skip the cache: the
+// model is always called and its response is not stored.
+func NewBypassContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// IsBypassed reports whether ctx skips the cache.
+func IsBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// Model caches the responses of a wrapped model.
+type Model struct {
+	model             model.Model
+	store             Store
+	ttl               time.Duration
+	namespace         string
+	keyFunc           KeyFunc
+	channelBufferSize int
+}
+
+// New wraps m with a response cache.
+func New(m model.Model, opts ...Option) *Model {
+	o := &options{
+		keyFunc:           RequestKey,
+		channelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryStore(defaultMemoryEntries)
+	}
+	return &Model{
+		model:             m,
+		store:             o.store,
+		ttl:               o.ttl,
+		namespace:         o.namespace,
+		keyFunc:           o.keyFunc,
+		channelBufferSize: o.channelBufferSize,
+	}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return m.model.Info()
+}
+
+// entry is the serialized form of a cached response stream.
+type entry struct {
+	Version   int               `json:"version"`
+	Model     string            `json:"model"`
+	CreatedAt time.Time         `json:"created_at"`
+	Responses []*model.Response `json:"responses"`
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *Model) GenerateContent(ctx context.Context, request *model.Request) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	if IsBypassed(ctx) {
+		return m.model.GenerateContent(ctx, request)
+	}
+	key, err := m.keyFunc(m.model.Info().Name, request)
+	if err != nil {
+		log.Warnf("cache: computing request key, calling the model directly: %v", err)
+		return m.model.GenerateContent(ctx, request)
+	}
+	key = m.namespace + key
+
+	if e, ok := m.load(ctx, key); ok {
+		return m.replay(ctx, request, e), nil
+	}
+	src, err := m.model.GenerateContent(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return m.record(ctx, key, src), nil
+}
+
+// load reads and decodes the entry stored under key.
+func (m *Model) load(ctx context.Context, key string) (*entry, bool) {
+	data, ok, err := m.store.Get(ctx, key)
+	if err != nil {
+		log.Warnf("cache: get %s: %v", key, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Version != entryVersion || len(e.Responses) == 0 {
+		return nil, false
+	}
+	return &e, true
+}
+
+// replay streams a cached entry. Non-streaming requests only get the
+// complete responses. Streaming requests replaying an entry recorded by a
+// non-streaming call get the complete responses split into partial chunks
+// first, so that streaming consumers behave the same on hits and misses.
+func (m *Model) replay(ctx context.Context, request *model.Request, e *entry) <-chan *model.Response {
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		now := time.Now()
+		split := request.Stream && !hasPartial(e.Responses)
+		send := func(r *model.Response) bool {
+			rsp := r.Clone()
+			rsp.CacheHit = true
+			rsp.Timestamp = now
+			select {
+			case responseChan <- rsp:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, r := range e.Responses {
+			if r.IsPartial && !request.Stream {
+				continue
+			}
+			if split && r.Error == nil {
+				for _, chunk := range chunks(r) {
+					if !send(chunk) {
+						return
+					}
+				}
+			}
+			if !send(r) {
+				return
+			}
+		}
+	}()
+	return responseChan
+}
+
+// hasPartial reports whether responses contain partial chunks.
+func hasPartial(responses []*model.Response) bool {
+	for _, r := range responses {
+		if r.IsPartial {
+			return true
+		}
+	}
+	return false
+}
+
+// chunks splits the text of a complete response into partial responses
+// carrying one word each as delta, reasoning first.
+func chunks(r *model.Response) []*model.Response {
+	var out []*model.Response
+	add := func(index int, delta model.Message) {
+		out = append(out, &model.Response{
+			ID:        r.ID,
+			Object:    model.ObjectTypeChatCompletionChunk,
+			Created:   r.Created,
+			Model:     r.Model,
+			Choices:   []model.Choice{{Index: index, Delta: delta}},
+			IsPartial: true,
+		})
+	}
+	for _, choice := range r.Choices {
+		for _, word := range words(choice.Message.ReasoningContent) {
+			add(choice.Index, model.Message{Role: model.RoleAssistant, ReasoningContent: word})
+		}
+		for _, word := range words(choice.Message.Content) {
+			add(choice.Index, model.Message{Role: model.RoleAssistant, Content: word})
+		}
+	}
+	return out
+}
+
+// words splits s after each space.
+func words(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.SplitAfter(s, " ")
+}
+
+// record forwards the model responses and stores them once the stream
+// completed without error.
+func (m *Model) record(ctx context.Context, key string, src <-chan *model.Response) <-chan *model.Response {
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		e := &entry{Version: entryVersion, Model: m.model.Info().Name, CreatedAt: time.Now()}
+		complete, failed := false, false
+		for rsp := range src {
+			if rsp == nil {
+				continue
+			}
+			if rsp.Error != nil {
+				failed = true
+			}
+			if !rsp.IsPartial {
+				complete = true
+			}
+			e.Responses = append(e.Responses, rsp.Clone())
+			select {
+			case responseChan <- rsp:
+			case <-ctx.Done():
+				failed = true
+			}
+		}
+		if failed || !complete || ctx.Err() != nil {
+			return
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			log.Warnf("cache: encode entry %s: %v", key, err)
+			return
+		}
+		if err := m.store.Set(ctx, key, data, m.ttl); err != nil {
+			log.Warnf("cache: set %s: %v", key, err)
+		}
+	}()
+	return responseChan
+}
+
+// canonicalRequest is the part of a request that determines its response.
+type canonicalRequest struct {
+	Model            string                  `json:"model"`
+	Messages         []model.Message         `json:"messages"`
+	GenerationConfig model.GenerationConfig  `json:"generation_config"`
+	StructuredOutput *model.StructuredOutput `json:"structured_output,omitempty"`
+	Tools            []*tool.Declaration     `json:"tools,omitempty"`
+}
+
+// RequestKey hashes the model name, messages, generation config, structured
+// output and tool declarations of a request. The stream flag is ignored so
+// that streaming and non-streaming calls share entries.
+func RequestKey(modelName string, request *model.Request) (string, error) {
+	c := canonicalRequest{
+		Model:            modelName,
+		Messages:         request.Messages,
+		GenerationConfig: request.GenerationConfig,
+		StructuredOutput: request.StructuredOutput,
+	}
+	c.GenerationConfig.Stream = false
+	names := make([]string, 0, len(request.Tools))
+	for name := range request.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := request.Tools[name]
+		if t == nil {
+			continue
+		}
+		if decl := t.Declaration(); decl != nil {
+			c.Tools = append(c.Tools, decl)
+		}
+	}
+	// encoding/json sorts map keys, so equal requests encode identically.
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("cache: encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
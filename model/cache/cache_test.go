@@ -0,0 +1,250 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// countingModel streams two chunks and a final response, or fails when
+// failWith is set. The final response says answer, "hello" by default.
+type countingModel struct {
+	mu       sync.Mutex
+	calls    int
+	failWith *model.ResponseError
+	answer   string
+}
+
+func (m *countingModel) Info() model.Info { return model.Info{Name: "counting"} }
+
+func (m *countingModel) GenerateContent(_ context.Context, request *model.Request) (<-chan *model.Response, error) {
+	m.mu.Lock()
+	m.calls++
+	m.mu.Unlock()
+	ch := make(chan *model.Response, 3)
+	defer close(ch)
+	if m.failWith != nil {
+		ch <- &model.Response{Error: m.failWith, Done: true}
+		return ch, nil
+	}
+	answer := m.answer
+	if answer == "" {
+		answer = "hello"
+	}
+	if request.Stream {
+		ch <- &model.Response{IsPartial: true, Choices: []model.Choice{{Delta: model.Message{Content: "hel"}}}}
+		ch <- &model.Response{IsPartial: true, Choices: []model.Choice{{Delta: model.Message{Content: "lo"}}}}
+	}
+	ch <- &model.Response{
+		ID:      "rsp-1",
+		Done:    true,
+		Usage:   &model.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		Choices: []model.Choice{{Message: model.NewAssistantMessage(answer)}},
+	}
+	return ch, nil
+}
+
+func (m *countingModel) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func collect(t *testing.T, m model.Model, ctx context.Context, request *model.Request) []*model.Response {
+	t.Helper()
+	ch, err := m.GenerateContent(ctx, request)
+	require.NoError(t, err)
+	var rsps []*model.Response
+	for rsp := range ch {
+		rsps = append(rsps, rsp)
+	}
+	return rsps
+}
+
+func userRequest(text string, stream bool) *model.Request {
+	return &model.Request{
+		Messages:         []model.Message{model.NewUserMessage(text)},
+		GenerationConfig: model.GenerationConfig{Stream: stream},
+	}
+}
+
+func TestModel_CachesAndReplaysStream(t *testing.T) {
+	inner := &countingModel{}
+	m := New(inner)
+	ctx := context.Background()
+
+	first := collect(t, m, ctx, userRequest("hi", true))
+	require.Len(t, first, 3)
+	for _, rsp := range first {
+		assert.False(t, rsp.CacheHit)
+	}
+
+	second := collect(t, m, ctx, userRequest("hi", true))
+	require.Len(t, second, 3)
+	assert.Equal(t, 1, inner.count())
+	for i, rsp := range second {
+		assert.True(t, rsp.CacheHit)
+		assert.Equal(t, first[i].IsPartial, rsp.IsPartial)
+		assert.Equal(t, first[i].Choices, rsp.Choices)
+	}
+	assert.Equal(t, "rsp-1", second[2].ID)
+	assert.Equal(t, first[2].Usage, second[2].Usage)
+
+	// A non-streaming request shares the entry but only gets the final response.
+	third := collect(t, m, ctx, userRequest("hi", false))
+	require.Len(t, third, 1)
+	assert.True(t, third[0].CacheHit)
+	assert.Equal(t, "hello", third[0].Choices[0].Message.Content)
+	assert.Equal(t, 1, inner.count())
+
+	// Different messages miss.
+	collect(t, m, ctx, userRequest("bye", true))
+	assert.Equal(t, 2, inner.count())
+}
+
+func TestModel_SplitsNonStreamEntryForStreaming(t *testing.T) {
+	inner := &countingModel{answer: "hello there"}
+	m := New(inner)
+	ctx := context.Background()
+
+	first := collect(t, m, ctx, userRequest("hi", false))
+	require.Len(t, first, 1)
+
+	// The entry has no chunks; a streaming call still gets them.
+	second := collect(t, m, ctx, userRequest("hi", true))
+	assert.Equal(t, 1, inner.count())
+	require.Len(t, second, 3)
+	var text string
+	for _, rsp := range second[:2] {
+		assert.True(t, rsp.IsPartial)
+		assert.True(t, rsp.CacheHit)
+		assert.Equal(t, "rsp-1", rsp.ID)
+		text += rsp.Choices[0].Delta.Content
+	}
+	assert.Equal(t, "hello there", text)
+	assert.False(t, second[2].IsPartial)
+	assert.Equal(t, first[0].Choices, second[2].Choices)
+}
+
+func TestModel_DoesNotCacheErrors(t *testing.T) {
+	inner := &countingModel{failWith: &model.ResponseError{Message: "rate limited", Type: model.ErrorTypeAPIError}}
+	m := New(inner)
+	for i := 0; i < 2; i++ {
+		rsps := collect(t, m, context.Background(), userRequest("hi", false))
+		require.Len(t, rsps, 1)
+		assert.NotNil(t, rsps[0].Error)
+		assert.False(t, rsps[0].CacheHit)
+	}
+	assert.Equal(t, 2, inner.count())
+}
+
+func TestModel_Bypass(t *testing.T) {
+	inner := &countingModel{}
+	store := NewMemoryStore(10)
+	m := New(inner, WithStore(store))
+	ctx := NewBypassContext(context.Background())
+	assert.True(t, IsBypassed(ctx))
+	assert.False(t, IsBypassed(context.Background()))
+
+	collect(t, m, ctx, userRequest("hi", false))
+	collect(t, m, ctx, userRequest("hi", false))
+	assert.Equal(t, 2, inner.count())
+	assert.Zero(t, store.Len())
+}
+
+func TestModel_TTLAndNamespace(t *testing.T) {
+	inner := &countingModel{}
+	store := NewMemoryStore(10)
+	a := New(inner, WithStore(store), WithNamespace("a:"), WithTTL(time.Millisecond))
+	b := New(inner, WithStore(store), WithNamespace("b:"))
+	ctx := context.Background()
+
+	collect(t, a, ctx, userRequest("hi", false))
+	collect(t, b, ctx, userRequest("hi", false))
+	assert.Equal(t, 2, inner.count())
+	collect(t, b, ctx, userRequest("hi", false))
+	assert.Equal(t, 2, inner.count())
+
+	time.Sleep(5 * time.Millisecond)
+	collect(t, a, ctx, userRequest("hi", false))
+	assert.Equal(t, 3, inner.count())
+}
+
+func TestModel_KeyFuncError(t *testing.T) {
+	inner := &countingModel{}
+	m := New(inner, WithKeyFunc(func(string, *model.Request) (string, error) {
+		return "", errors.New("boom")
+	}))
+	collect(t, m, context.Background(), userRequest("hi", false))
+	collect(t, m, context.Background(), userRequest("hi", false))
+	assert.Equal(t, 2, inner.count())
+
+	_, err := m.GenerateContent(context.Background(), nil)
+	assert.Error(t, err)
+	assert.Equal(t, "counting", m.Info().Name)
+}
+
+// declTool is a tool with a fixed declaration.
+type declTool struct{ decl *tool.Declaration }
+
+func (d declTool) Declaration() *tool.Declaration { return d.decl }
+
+func TestRequestKey(t *testing.T) {
+	base := func() *model.Request {
+		temp := 0.2
+		return &model.Request{
+			Messages:         []model.Message{model.NewSystemMessage("sys"), model.NewUserMessage("hi")},
+			GenerationConfig: model.GenerationConfig{Temperature: &temp},
+			Tools: map[string]tool.Tool{
+				"b": declTool{decl: &tool.Declaration{Name: "b", InputSchema: &tool.Schema{Type: "object"}}},
+				"a": declTool{decl: &tool.Declaration{Name: "a", InputSchema: &tool.Schema{Type: "object"}}},
+			},
+		}
+	}
+	key := func(name string, r *model.Request) string {
+		k, err := RequestKey(name, r)
+		require.NoError(t, err)
+		return k
+	}
+	ref := key("m", base())
+	assert.Equal(t, ref, key("m", base()))
+
+	streamed := base()
+	streamed.Stream = true
+	assert.Equal(t, ref, key("m", streamed), "stream flag is ignored")
+
+	assert.NotEqual(t, ref, key("other", base()), "model name")
+
+	hotter := base()
+	temp := 0.9
+	hotter.Temperature = &temp
+	assert.NotEqual(t, ref, key("m", hotter), "generation config")
+
+	fewerTools := base()
+	delete(fewerTools.Tools, "b")
+	assert.NotEqual(t, ref, key("m", fewerTools), "tools")
+
+	structured := base()
+	structured.StructuredOutput = &model.StructuredOutput{
+		Type:       model.StructuredOutputJSONSchema,
+		JSONSchema: &model.JSONSchemaConfig{Name: "out", Schema: map[string]any{"type": "object"}},
+	}
+	assert.NotEqual(t, ref, key("m", structured), "structured output")
+}
@@ -0,0 +1,78 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_GetSet(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "cache")
+	s, err := NewFileStore(dir)
+	require.NoError(t, err)
+
+	_, ok, err := s.Get(ctx, "ns:key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.Set(ctx, "ns:key", []byte(`{"a":1}`), 0))
+	v, ok, err := s.Get(ctx, "ns:key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"a":1}`, string(v))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, ".json", filepath.Ext(files[0].Name()))
+}
+
+func TestFileStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, s.Set(ctx, "k", []byte(`1`), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err := s.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, statErr := os.Stat(s.path("k"))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestFileStore_Corrupt(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(s.path("k"), []byte("not json"), 0o644))
+	_, _, err = s.Get(context.Background(), "k")
+	assert.Error(t, err)
+}
+
+func TestFileStore_WithModel(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	inner := &countingModel{}
+	collect(t, New(inner, WithStore(s)), context.Background(), userRequest("hi", true))
+
+	// A new cache model over the same directory replays the stored stream.
+	rsps := collect(t, New(inner, WithStore(s)), context.Background(), userRequest("hi", true))
+	require.Len(t, rsps, 3)
+	assert.True(t, rsps[0].CacheHit)
+	assert.Equal(t, "hel", rsps[0].Choices[0].Delta.Content)
+	assert.Equal(t, 1, inner.count())
+}
@@ -0,0 +1,57 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_LRU(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2)
+	require.NoError(t, s.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, s.Set(ctx, "b", []byte("2"), 0))
+
+	// Touch a so that b is the least recently used.
+	_, ok, _ := s.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, s.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = s.Get(ctx, "b")
+	assert.False(t, ok)
+	v, ok, _ := s.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(v))
+	assert.Equal(t, 2, s.Len())
+
+	require.NoError(t, s.Set(ctx, "a", []byte("updated"), 0))
+	v, _, _ = s.Get(ctx, "a")
+	assert.Equal(t, "updated", string(v))
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestMemoryStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(0)
+	require.NoError(t, s.Set(ctx, "a", []byte("1"), time.Millisecond))
+	require.NoError(t, s.Set(ctx, "b", []byte("2"), 0))
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok, _ := s.Get(ctx, "a")
+	assert.False(t, ok)
+	_, ok, _ = s.Get(ctx, "b")
+	assert.True(t, ok)
+	assert.Equal(t, 1, s.Len())
+}
@@ -0,0 +1,129 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package redis provides a Redis backed cache.Store for the model response
+// cache.
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-agent-go/model/cache"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+)
+
+// defaultKeyPrefix is prepended to every cache key.
+const defaultKeyPrefix = "model_cache:"
+
+var _ cache.Store = (*Store)(nil)
+
+// options contains configuration options for creating a Store.
+type options struct {
+	url          string
+	instanceName string
+	extraOptions []any
+	keyPrefix    string
+}
+
+// Option is a function that configures a Store.
+type Option func(*options)
+
+// WithRedisClientURL creates the redis client from URL.
+func WithRedisClientURL(url string) Option {
+	return func(opts *options) {
+		opts.url = url
+	}
+}
+
+// WithRedisInstance uses a redis instance registered in storage/redis.
+// Note: WithRedisClientURL has higher priority than WithRedisInstance.
+func WithRedisInstance(instanceName string) Option {
+	return func(opts *options) {
+		opts.instanceName = instanceName
+	}
+}
+
+// WithExtraOptions sets the extra options passed to the redis client builder.
+func WithExtraOptions(extraOptions ...any) Option {
+	return func(opts *options) {
+		opts.extraOptions = append(opts.extraOptions, extraOptions...)
+	}
+}
+
+// WithKeyPrefix sets the prefix of the redis keys. Defaults to "model_cache:".
+func WithKeyPrefix(prefix string) Option {
+	return func(opts *options) {
+		opts.keyPrefix = prefix
+	}
+}
+
+// Store is a cache.Store keeping entries in Redis. Entry TTLs are enforced
+// by Redis expiry.
+type Store struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+// New creates a Redis store.
+func New(opts ...Option) (*Store, error) {
+	o := &options{keyPrefix: defaultKeyPrefix}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	builder := storage.GetClientBuilder()
+	var builderOpts []storage.ClientBuilderOpt
+	if o.url == "" && o.instanceName != "" {
+		instanceOpts, ok := storage.GetRedisInstance(o.instanceName)
+		if !ok {
+			return nil, fmt.Errorf("redis instance %s not found", o.instanceName)
+		}
+		builderOpts = instanceOpts
+	} else {
+		builderOpts = []storage.ClientBuilderOpt{
+			storage.WithClientBuilderURL(o.url),
+			storage.WithExtraOptions(o.extraOptions...),
+		}
+	}
+	client, err := builder(builderOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create redis client failed: %w", err)
+	}
+	return &Store{client: client, keyPrefix: o.keyPrefix}, nil
+}
+
+// Get implements cache.Store.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := s.client.Get(ctx, s.keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("redis get: %w", err)
+	}
+	return data, true, nil
+}
+
+// Set implements cache.Store.
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.keyPrefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+	return nil
+}
+
+// Close closes the redis client.
+func (s *Store) Close() error {
+	return s.client.Close()
+}
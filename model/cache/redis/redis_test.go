@@ -0,0 +1,104 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/model/cache"
+	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
+)
+
+func newStore(t *testing.T, opts ...Option) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	s, err := New(append([]Option{WithRedisClientURL("redis://" + mr.Addr())}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, mr
+}
+
+func TestStore_GetSet(t *testing.T) {
+	s, mr := newStore(t, WithKeyPrefix("test:"))
+	ctx := context.Background()
+
+	_, ok, err := s.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.Set(ctx, "k", []byte(`{"a":1}`), time.Minute))
+	data, ok, err := s.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"a":1}`, string(data))
+	assert.True(t, mr.Exists("test:k"))
+
+	mr.FastForward(2 * time.Minute)
+	_, ok, err = s.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestStore_Instance(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	storage.RegisterRedisInstance("model-cache-test", storage.WithClientBuilderURL("redis://"+mr.Addr()))
+	s, err := New(WithRedisInstance("model-cache-test"))
+	require.NoError(t, err)
+	defer s.Close()
+	require.NoError(t, s.Set(context.Background(), "k", []byte("{}"), 0))
+
+	_, err = New(WithRedisInstance("missing"))
+	assert.Error(t, err)
+}
+
+// staticModel answers every request with the same text.
+type staticModel struct{ calls int }
+
+func (m *staticModel) Info() model.Info { return model.Info{Name: "static"} }
+
+func (m *staticModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	m.calls++
+	ch := make(chan *model.Response, 1)
+	ch <- &model.Response{Done: true, Choices: []model.Choice{{Message: model.NewAssistantMessage("hi")}}}
+	close(ch)
+	return ch, nil
+}
+
+func TestStore_WithCacheModel(t *testing.T) {
+	s, _ := newStore(t)
+	inner := &staticModel{}
+	m := cache.New(inner, cache.WithStore(s))
+	request := &model.Request{Messages: []model.Message{model.NewUserMessage("hello")}}
+
+	for i := 0; i < 2; i++ {
+		ch, err := m.GenerateContent(context.Background(), request)
+		require.NoError(t, err)
+		var got []*model.Response
+		for rsp := range ch {
+			got = append(got, rsp)
+		}
+		require.Len(t, got, 1)
+		assert.Equal(t, "hi", got[0].Choices[0].Message.Content)
+		assert.Equal(t, i == 1, got[0].CacheHit)
+	}
+	assert.Equal(t, 1, inner.calls)
+}
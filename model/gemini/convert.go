@@ -0,0 +1,778 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Roles of the generateContent API.
+const (
+	roleUser  = "user"
+	roleModel = "model"
+)
+
+// Finish reasons reported by the API. They are also used as the Code of
+// Response.Error when a response is blocked or cut short.
+const (
+	FinishReasonStop                  = "STOP"
+	FinishReasonMaxTokens             = "MAX_TOKENS"
+	FinishReasonSafety                = "SAFETY"
+	FinishReasonRecitation            = "RECITATION"
+	FinishReasonLanguage              = "LANGUAGE"
+	FinishReasonOther                 = "OTHER"
+	FinishReasonBlocklist             = "BLOCKLIST"
+	FinishReasonProhibitedContent     = "PROHIBITED_CONTENT"
+	FinishReasonSPII                  = "SPII"
+	FinishReasonImageSafety           = "IMAGE_SAFETY"
+	FinishReasonMalformedFunctionCall = "MALFORMED_FUNCTION_CALL"
+)
+
+// Finish reasons reported on model.Choice, aligned with the OpenAI values
+// used elsewhere in the framework.
+const (
+	finishReasonStop          = "stop"
+	finishReasonLength        = "length"
+	finishReasonToolCalls     = "tool_calls"
+	finishReasonContentFilter = "content_filter"
+)
+
+// jsonMimeType is the response MIME type used for structured output.
+const jsonMimeType = "application/json"
+
+// GenerateContentRequest is the body of a generateContent call.
+type GenerateContentRequest struct {
+	Contents          []content         `json:"contents"`
+	SystemInstruction *content          `json:"systemInstruction,omitempty"`
+	Tools             []toolDecl        `json:"tools,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+	SafetySettings    []SafetySetting   `json:"safetySettings,omitempty"`
+}
+
+// SafetySetting adjusts the blocking threshold of a harm category,
+// e.g. {Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_ONLY_HIGH"}.
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// content is a single turn of the conversation.
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+// part is the union of all part shapes used by the API.
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	Thought          bool              `json:"thought,omitempty"`
+	ThoughtSignature string            `json:"thoughtSignature,omitempty"`
+	InlineData       *blob             `json:"inlineData,omitempty"`
+	FileData         *fileData         `json:"fileData,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+// blob is inline binary data.
+type blob struct {
+	MimeType string `json:"mimeType"`
+	Data     []byte `json:"data"`
+}
+
+// fileData references uploaded or remote data.
+type fileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// functionCall is a tool call produced by the model.
+type functionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// functionResponse carries a tool result back to the model.
+type functionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// toolDecl groups function declarations.
+type toolDecl struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+// functionDeclaration declares a client tool.
+type functionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// generationConfig holds sampling and output options.
+type generationConfig struct {
+	MaxOutputTokens  *int            `json:"maxOutputTokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"topP,omitempty"`
+	StopSequences    []string        `json:"stopSequences,omitempty"`
+	PresencePenalty  *float64        `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequencyPenalty,omitempty"`
+	ResponseMimeType string          `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]any  `json:"responseSchema,omitempty"`
+	ThinkingConfig   *thinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// thinkingConfig controls thinking models.
+type thinkingConfig struct {
+	IncludeThoughts bool   `json:"includeThoughts,omitempty"`
+	ThinkingBudget  *int   `json:"thinkingBudget,omitempty"`
+	ThinkingLevel   string `json:"thinkingLevel,omitempty"`
+}
+
+// GenerateContentResponse is a complete response or a single streaming chunk.
+type GenerateContentResponse struct {
+	Candidates     []candidate     `json:"candidates"`
+	PromptFeedback *promptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *usageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	ResponseID     string          `json:"responseId,omitempty"`
+}
+
+// candidate is one generated answer.
+type candidate struct {
+	Content       *content       `json:"content,omitempty"`
+	FinishReason  string         `json:"finishReason,omitempty"`
+	FinishMessage string         `json:"finishMessage,omitempty"`
+	SafetyRatings []safetyRating `json:"safetyRatings,omitempty"`
+}
+
+// promptFeedback reports whether the prompt itself was blocked.
+type promptFeedback struct {
+	BlockReason        string         `json:"blockReason,omitempty"`
+	BlockReasonMessage string         `json:"blockReasonMessage,omitempty"`
+	SafetyRatings      []safetyRating `json:"safetyRatings,omitempty"`
+}
+
+// safetyRating is the rating of one harm category.
+type safetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// usageMetadata is the token accounting reported by the API.
+type usageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// apiError is the error object returned by Google APIs.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+// errorEnvelope wraps apiError in HTTP error bodies.
+type errorEnvelope struct {
+	Error apiError `json:"error"`
+}
+
+// buildRequest converts a model.Request into a generateContent request.
+func (m *Model) buildRequest(request *model.Request) *GenerateContentRequest {
+	req := &GenerateContentRequest{
+		Tools:          convertTools(request.Tools),
+		SafetySettings: m.safetySettings,
+	}
+	req.SystemInstruction, req.Contents = m.convertMessages(request.Messages)
+
+	cfg := &generationConfig{
+		MaxOutputTokens:  request.MaxTokens,
+		Temperature:      request.Temperature,
+		TopP:             request.TopP,
+		StopSequences:    request.Stop,
+		PresencePenalty:  request.PresencePenalty,
+		FrequencyPenalty: request.FrequencyPenalty,
+		ThinkingConfig:   convertThinking(&request.GenerationConfig),
+	}
+	if so := request.StructuredOutput; so != nil && so.Type == model.StructuredOutputJSONSchema && so.JSONSchema != nil {
+		cfg.ResponseMimeType = jsonMimeType
+		cfg.ResponseSchema = sanitizeSchema(so.JSONSchema.Schema)
+	}
+	req.GenerationConfig = cfg
+	return req
+}
+
+// convertThinking maps the framework thinking options. An explicit
+// ThinkingEnabled=false sets a zero budget, which turns thinking off on
+// models that allow it.
+func convertThinking(cfg *model.GenerationConfig) *thinkingConfig {
+	if cfg.ThinkingEnabled != nil && !*cfg.ThinkingEnabled {
+		budget := 0
+		return &thinkingConfig{ThinkingBudget: &budget}
+	}
+	if cfg.ThinkingEnabled == nil && cfg.ThinkingTokens == nil && cfg.ReasoningEffort == nil {
+		return nil
+	}
+	tc := &thinkingConfig{IncludeThoughts: true}
+	switch {
+	case cfg.ThinkingTokens != nil:
+		tc.ThinkingBudget = cfg.ThinkingTokens
+	case cfg.ReasoningEffort != nil:
+		// The API rejects thinkingLevel together with thinkingBudget.
+		tc.ThinkingLevel = *cfg.ReasoningEffort
+	}
+	return tc
+}
+
+// convertMessages splits system messages out and converts the rest into
+// user/model turns. Consecutive messages that map to the same role, such as
+// parallel tool results, are merged into one turn.
+func (m *Model) convertMessages(messages []model.Message) (*content, []content) {
+	var system *content
+	var result []content
+	appendTurn := func(role string, parts []part) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Parts = append(result[n-1].Parts, parts...)
+			return
+		}
+		result = append(result, content{Role: role, Parts: parts})
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case model.RoleSystem:
+			parts := textParts(msg)
+			if len(parts) == 0 {
+				continue
+			}
+			if system == nil {
+				system = &content{}
+			}
+			system.Parts = append(system.Parts, parts...)
+		case model.RoleAssistant:
+			appendTurn(roleModel, m.convertAssistantMessage(msg))
+		case model.RoleTool:
+			appendTurn(roleUser, []part{{FunctionResponse: &functionResponse{
+				ID:       msg.ToolID,
+				Name:     msg.ToolName,
+				Response: toolResponse(msg.Content),
+			}}})
+		default:
+			appendTurn(roleUser, convertUserMessage(msg))
+		}
+	}
+	return system, result
+}
+
+// toolResponse converts a tool result into the object expected by the API.
+// JSON objects are sent as is, anything else is wrapped under "result".
+func toolResponse(result string) json.RawMessage {
+	trimmed := strings.TrimSpace(result)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	wrapped, _ := json.Marshal(map[string]string{"result": result})
+	return wrapped
+}
+
+// textParts collects the text content of a message.
+func textParts(msg model.Message) []part {
+	var parts []part
+	if msg.Content != "" {
+		parts = append(parts, part{Text: msg.Content})
+	}
+	for _, p := range msg.ContentParts {
+		if p.Type == model.ContentTypeText && p.Text != nil && *p.Text != "" {
+			parts = append(parts, part{Text: *p.Text})
+		}
+	}
+	return parts
+}
+
+// convertUserMessage converts text, image, audio and file parts.
+func convertUserMessage(msg model.Message) []part {
+	var parts []part
+	if msg.Content != "" {
+		parts = append(parts, part{Text: msg.Content})
+	}
+	for _, p := range msg.ContentParts {
+		if converted, ok := convertContentPart(p); ok {
+			parts = append(parts, converted)
+		}
+	}
+	return parts
+}
+
+// convertContentPart converts one multimodal part.
+func convertContentPart(p model.ContentPart) (part, bool) {
+	switch p.Type {
+	case model.ContentTypeText:
+		if p.Text != nil && *p.Text != "" {
+			return part{Text: *p.Text}, true
+		}
+	case model.ContentTypeImage:
+		if p.Image == nil {
+			break
+		}
+		if p.Image.URL != "" {
+			return part{FileData: &fileData{
+				MimeType: imageMimeType(strings.TrimPrefix(path.Ext(p.Image.URL), ".")),
+				FileURI:  p.Image.URL,
+			}}, true
+		}
+		return part{InlineData: &blob{MimeType: imageMimeType(p.Image.Format), Data: p.Image.Data}}, true
+	case model.ContentTypeAudio:
+		if p.Audio == nil {
+			break
+		}
+		return part{InlineData: &blob{MimeType: audioMimeType(p.Audio.Format), Data: p.Audio.Data}}, true
+	case model.ContentTypeFile:
+		if p.File == nil {
+			break
+		}
+		if p.File.FileID != "" {
+			return part{FileData: &fileData{MimeType: p.File.MimeType, FileURI: p.File.FileID}}, true
+		}
+		return part{InlineData: &blob{MimeType: p.File.MimeType, Data: p.File.Data}}, true
+	default:
+		log.Warnf("gemini: unsupported content part type %q will be skipped", p.Type)
+	}
+	return part{}, false
+}
+
+// imageMimeType maps an image format to its MIME type.
+func imageMimeType(format string) string {
+	switch strings.ToLower(format) {
+	case "jpg", "jpeg":
+		return "image/jpeg"
+	case "webp":
+		return "image/webp"
+	case "heic":
+		return "image/heic"
+	case "heif":
+		return "image/heif"
+	default:
+		return "image/png"
+	}
+}
+
+// audioMimeType maps an audio format to its MIME type.
+func audioMimeType(format string) string {
+	switch strings.ToLower(format) {
+	case "mp3":
+		return "audio/mp3"
+	case "":
+		return "audio/wav"
+	default:
+		return "audio/" + strings.ToLower(format)
+	}
+}
+
+// convertAssistantMessage converts assistant text and tool calls. Thought
+// signatures remembered for the tool calls are attached to the function
+// call parts, as required by thinking models.
+func (m *Model) convertAssistantMessage(msg model.Message) []part {
+	parts := textParts(msg)
+	for _, tc := range msg.ToolCalls {
+		args := json.RawMessage(tc.Function.Arguments)
+		if len(args) == 0 || !json.Valid(args) {
+			args = json.RawMessage("{}")
+		}
+		parts = append(parts, part{
+			FunctionCall:     &functionCall{ID: tc.ID, Name: tc.Function.Name, Args: args},
+			ThoughtSignature: m.lookupSignature(tc.ID),
+		})
+	}
+	return parts
+}
+
+// convertTools converts tool declarations, ordered by name for stable requests.
+func convertTools(tools map[string]tool.Tool) []toolDecl {
+	if len(tools) == 0 {
+		return nil
+	}
+	decls := make([]functionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		decl := t.Declaration()
+		if decl == nil {
+			continue
+		}
+		decls = append(decls, functionDeclaration{
+			Name:        decl.Name,
+			Description: decl.Description,
+			Parameters:  convertToolSchema(decl.InputSchema),
+		})
+	}
+	if len(decls) == 0 {
+		return nil
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].Name < decls[j].Name })
+	return []toolDecl{{FunctionDeclarations: decls}}
+}
+
+// convertToolSchema converts a tool schema into the API schema dialect.
+// Tools without parameters are declared without a schema.
+func convertToolSchema(schema *tool.Schema) map[string]any {
+	if schema == nil || (schema.Type == "object" && len(schema.Properties) == 0) {
+		return nil
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	return sanitizeSchema(m)
+}
+
+// supportedSchemaKeys is the subset of JSON Schema understood by the
+// OpenAPI style schema of the API.
+var supportedSchemaKeys = map[string]bool{
+	"type": true, "format": true, "title": true, "description": true,
+	"nullable": true, "enum": true, "items": true, "properties": true,
+	"required": true, "anyOf": true, "minItems": true, "maxItems": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "minProperties": true,
+	"maxProperties": true, "propertyOrdering": true, "default": true,
+}
+
+// sanitizeSchema rewrites a JSON schema into the form accepted by the API:
+// local $ref are inlined, type names are upper-cased, type unions with null
+// become nullable and unsupported keywords are dropped.
+func sanitizeSchema(schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	defs := map[string]any{}
+	for _, key := range []string{"$defs", "definitions"} {
+		if d, ok := schema[key].(map[string]any); ok {
+			for name, def := range d {
+				defs[name] = def
+			}
+		}
+	}
+	return sanitizeNode(schema, defs, map[string]bool{})
+}
+
+func sanitizeNode(node map[string]any, defs map[string]any, resolving map[string]bool) map[string]any {
+	if ref, ok := node["$ref"].(string); ok {
+		name := ref[strings.LastIndex(ref, "/")+1:]
+		def, found := defs[name].(map[string]any)
+		if !found || resolving[name] {
+			// Unknown or recursive references cannot be expressed.
+			return map[string]any{"type": "OBJECT"}
+		}
+		resolving[name] = true
+		defer delete(resolving, name)
+		return sanitizeNode(def, defs, resolving)
+	}
+	out := make(map[string]any, len(node))
+	for key, value := range node {
+		if !supportedSchemaKeys[key] {
+			continue
+		}
+		switch key {
+		case "type":
+			switch t := value.(type) {
+			case string:
+				out["type"] = strings.ToUpper(t)
+			case []any:
+				for _, item := range t {
+					s, _ := item.(string)
+					if s == "null" {
+						out["nullable"] = true
+					} else if s != "" {
+						out["type"] = strings.ToUpper(s)
+					}
+				}
+			}
+		case "properties":
+			props, _ := value.(map[string]any)
+			converted := make(map[string]any, len(props))
+			for name, prop := range props {
+				if p, ok := prop.(map[string]any); ok {
+					converted[name] = sanitizeNode(p, defs, resolving)
+				}
+			}
+			out[key] = converted
+		case "items":
+			if items, ok := value.(map[string]any); ok {
+				out[key] = sanitizeNode(items, defs, resolving)
+			}
+		case "anyOf":
+			list, _ := value.([]any)
+			converted := make([]any, 0, len(list))
+			for _, item := range list {
+				if s, ok := item.(map[string]any); ok {
+					converted = append(converted, sanitizeNode(s, defs, resolving))
+				}
+			}
+			out[key] = converted
+		case "enum":
+			// Enum values must be strings.
+			list, _ := value.([]any)
+			converted := make([]any, 0, len(list))
+			for _, item := range list {
+				converted = append(converted, fmt.Sprint(item))
+			}
+			out[key] = converted
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// convertResponse converts an API response into a model.Response. Blocked
+// prompts and candidates that stopped for safety or other non-regular
+// reasons are reported through Response.Error.
+func convertResponse(rsp *GenerateContentResponse) *model.Response {
+	result := &model.Response{
+		ID:        rsp.ResponseID,
+		Object:    model.ObjectTypeChatCompletion,
+		Created:   time.Now().Unix(),
+		Model:     rsp.ModelVersion,
+		Usage:     convertUsage(rsp.UsageMetadata),
+		Timestamp: time.Now(),
+	}
+	if fb := rsp.PromptFeedback; fb != nil && fb.BlockReason != "" {
+		reason := finishReasonContentFilter
+		result.Choices = []model.Choice{{
+			Index:        0,
+			Message:      model.Message{Role: model.RoleAssistant},
+			FinishReason: &reason,
+		}}
+		result.Error = blockedError("prompt blocked", fb.BlockReason, fb.BlockReasonMessage, fb.SafetyRatings)
+		return result
+	}
+
+	message := model.Message{Role: model.RoleAssistant}
+	var finish string
+	var cand candidate
+	if len(rsp.Candidates) > 0 {
+		cand = rsp.Candidates[0]
+		finish = cand.FinishReason
+	}
+	if cand.Content != nil {
+		var text, reasoning strings.Builder
+		for _, p := range cand.Content.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				message.ToolCalls = append(message.ToolCalls, convertFunctionCall(p.FunctionCall, len(message.ToolCalls)))
+			case p.Thought:
+				reasoning.WriteString(p.Text)
+			default:
+				text.WriteString(p.Text)
+			}
+		}
+		message.Content = text.String()
+		message.ReasoningContent = reasoning.String()
+	}
+
+	choice := model.Choice{Index: 0, Message: message}
+	if reason := convertFinishReason(finish, len(message.ToolCalls) > 0); reason != "" {
+		choice.FinishReason = &reason
+	}
+	result.Choices = []model.Choice{choice}
+	if isAbnormalFinish(finish) {
+		result.Error = blockedError("response stopped", finish, cand.FinishMessage, cand.SafetyRatings)
+	}
+	return result
+}
+
+// convertFunctionCall converts a function call part into a tool call. The
+// API only returns call IDs on some models, so a local one is generated
+// when it is missing.
+func convertFunctionCall(fc *functionCall, idx int) model.ToolCall {
+	id := fc.ID
+	if id == "" {
+		id = "call_" + uuid.NewString()
+	}
+	args := []byte(fc.Args)
+	if len(args) == 0 || string(args) == "null" {
+		args = []byte("{}")
+	}
+	return model.ToolCall{
+		Type:  functionToolType,
+		ID:    id,
+		Index: &idx,
+		Function: model.FunctionDefinitionParam{
+			Name:      fc.Name,
+			Arguments: args,
+		},
+	}
+}
+
+// convertFinishReason maps API finish reasons to framework finish reasons.
+// The API reports STOP for tool calls, which is mapped to tool_calls.
+func convertFinishReason(reason string, hasToolCalls bool) string {
+	switch reason {
+	case "", "FINISH_REASON_UNSPECIFIED":
+		return ""
+	case FinishReasonStop:
+		if hasToolCalls {
+			return finishReasonToolCalls
+		}
+		return finishReasonStop
+	case FinishReasonMaxTokens:
+		return finishReasonLength
+	case FinishReasonSafety, FinishReasonRecitation, FinishReasonBlocklist,
+		FinishReasonProhibitedContent, FinishReasonSPII, FinishReasonImageSafety:
+		return finishReasonContentFilter
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+// isAbnormalFinish reports whether a finish reason should surface as an error.
+func isAbnormalFinish(reason string) bool {
+	switch reason {
+	case "", "FINISH_REASON_UNSPECIFIED", FinishReasonStop, FinishReasonMaxTokens:
+		return false
+	default:
+		return true
+	}
+}
+
+// blockedError builds the Response.Error for a blocked prompt or candidate.
+// The Code is the raw API reason so callers can branch on it.
+func blockedError(what, reason, detail string, ratings []safetyRating) *model.ResponseError {
+	msg := fmt.Sprintf("gemini: %s: %s", what, reason)
+	var blocked []string
+	for _, r := range ratings {
+		if r.Blocked {
+			blocked = append(blocked, r.Category)
+		}
+	}
+	if len(blocked) > 0 {
+		msg += " (categories: " + strings.Join(blocked, ", ") + ")"
+	}
+	if detail != "" {
+		msg += ": " + detail
+	}
+	code := reason
+	return &model.ResponseError{
+		Message: msg,
+		Type:    model.ErrorTypeAPIError,
+		Code:    &code,
+	}
+}
+
+// convertUsage maps API usage to model.Usage. Thought tokens are billed as
+// output and counted as completion tokens.
+func convertUsage(u *usageMetadata) *model.Usage {
+	if u == nil {
+		return nil
+	}
+	completion := u.CandidatesTokenCount + u.ThoughtsTokenCount
+	total := u.TotalTokenCount
+	if total == 0 {
+		total = u.PromptTokenCount + completion
+	}
+	return &model.Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+	}
+}
+
+// streamAccumulator rebuilds the full response from streaming chunks.
+type streamAccumulator struct {
+	response GenerateContentResponse
+	parts    []part
+}
+
+// add folds a chunk into the accumulated response and returns a partial
+// response for chunks that carry visible text or thoughts.
+func (a *streamAccumulator) add(chunk *GenerateContentResponse) *model.Response {
+	if chunk.ResponseID != "" {
+		a.response.ResponseID = chunk.ResponseID
+	}
+	if chunk.ModelVersion != "" {
+		a.response.ModelVersion = chunk.ModelVersion
+	}
+	if chunk.UsageMetadata != nil {
+		a.response.UsageMetadata = chunk.UsageMetadata
+	}
+	if chunk.PromptFeedback != nil {
+		a.response.PromptFeedback = chunk.PromptFeedback
+	}
+	if len(chunk.Candidates) == 0 {
+		return nil
+	}
+	cand := chunk.Candidates[0]
+	if len(a.response.Candidates) == 0 {
+		a.response.Candidates = []candidate{{}}
+	}
+	acc := &a.response.Candidates[0]
+	if cand.FinishReason != "" {
+		acc.FinishReason = cand.FinishReason
+		acc.FinishMessage = cand.FinishMessage
+	}
+	if len(cand.SafetyRatings) > 0 {
+		acc.SafetyRatings = cand.SafetyRatings
+	}
+	if cand.Content == nil {
+		return nil
+	}
+	delta := model.Message{Role: model.RoleAssistant}
+	for _, p := range cand.Content.Parts {
+		a.parts = append(a.parts, p)
+		switch {
+		case p.FunctionCall != nil:
+		case p.Thought:
+			delta.ReasoningContent += p.Text
+		default:
+			delta.Content += p.Text
+		}
+	}
+	if delta.Content == "" && delta.ReasoningContent == "" {
+		return nil
+	}
+	return &model.Response{
+		ID:        a.response.ResponseID,
+		Object:    model.ObjectTypeChatCompletionChunk,
+		Created:   time.Now().Unix(),
+		Model:     a.response.ModelVersion,
+		Choices:   []model.Choice{{Index: 0, Delta: delta}},
+		Timestamp: time.Now(),
+		IsPartial: true,
+	}
+}
+
+// result returns the accumulated response.
+func (a *streamAccumulator) result() *GenerateContentResponse {
+	rsp := a.response
+	if len(rsp.Candidates) > 0 {
+		rsp.Candidates = []candidate{rsp.Candidates[0]}
+		rsp.Candidates[0].Content = &content{Role: roleModel, Parts: a.parts}
+	}
+	return &rsp
+}
@@ -0,0 +1,134 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package gemini
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestBuildRequest_ThinkingAndStructuredOutput(t *testing.T) {
+	m := New("gemini-test")
+	enabled := true
+	budget := 2048
+	req := m.buildRequest(&model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{
+			ThinkingEnabled: &enabled,
+			ThinkingTokens:  &budget,
+		},
+		StructuredOutput: &model.StructuredOutput{
+			Type: model.StructuredOutputJSONSchema,
+			JSONSchema: &model.JSONSchemaConfig{
+				Name: "answer",
+				Schema: map[string]any{
+					"type":                 "object",
+					"additionalProperties": false,
+					"properties": map[string]any{
+						"item": map[string]any{"$ref": "#/$defs/item"},
+					},
+					"$defs": map[string]any{
+						"item": map[string]any{
+							"type": []any{"string", "null"},
+							"enum": []any{"a", 1},
+						},
+					},
+				},
+			},
+		},
+	})
+	cfg := req.GenerationConfig
+	require.NotNil(t, cfg.ThinkingConfig)
+	assert.True(t, cfg.ThinkingConfig.IncludeThoughts)
+	assert.Equal(t, 2048, *cfg.ThinkingConfig.ThinkingBudget)
+	assert.Equal(t, jsonMimeType, cfg.ResponseMimeType)
+	assert.Equal(t, map[string]any{
+		"type": "OBJECT",
+		"properties": map[string]any{
+			"item": map[string]any{"type": "STRING", "nullable": true, "enum": []any{"a", "1"}},
+		},
+	}, cfg.ResponseSchema)
+
+	disabled := false
+	req = m.buildRequest(&model.Request{GenerationConfig: model.GenerationConfig{ThinkingEnabled: &disabled}})
+	assert.Equal(t, 0, *req.GenerationConfig.ThinkingConfig.ThinkingBudget)
+}
+
+func TestConvertMessages_ToolResults(t *testing.T) {
+	m := New("gemini-test")
+	system, contents := m.convertMessages([]model.Message{
+		model.NewSystemMessage("sys"),
+		model.NewUserMessage("question"),
+		{
+			Role: model.RoleAssistant,
+			ToolCalls: []model.ToolCall{
+				{ID: "t1", Function: model.FunctionDefinitionParam{Name: "a", Arguments: []byte(`{"x":1}`)}},
+				{ID: "t2", Function: model.FunctionDefinitionParam{Name: "b"}},
+			},
+		},
+		model.NewToolMessage("t1", "a", `{"ok":true}`),
+		model.NewToolMessage("t2", "b", "plain"),
+	})
+	require.NotNil(t, system)
+	assert.Equal(t, "sys", system.Parts[0].Text)
+
+	require.Len(t, contents, 3)
+	assert.Equal(t, roleModel, contents[1].Role)
+	assert.JSONEq(t, `{}`, string(contents[1].Parts[1].FunctionCall.Args))
+
+	assert.Equal(t, roleUser, contents[2].Role)
+	require.Len(t, contents[2].Parts, 2)
+	assert.Equal(t, "a", contents[2].Parts[0].FunctionResponse.Name)
+	assert.JSONEq(t, `{"ok":true}`, string(contents[2].Parts[0].FunctionResponse.Response))
+	assert.JSONEq(t, `{"result":"plain"}`, string(contents[2].Parts[1].FunctionResponse.Response))
+}
+
+func TestConvertContentPart(t *testing.T) {
+	var msg model.Message
+	msg.AddImageURL("https://example.com/a.jpg", "auto")
+	msg.AddImageData([]byte("img"), "auto", "webp")
+	msg.AddAudioData([]byte("wav"), "wav")
+	msg.AddFileData("doc.pdf", []byte("pdf"), "application/pdf")
+	msg.AddFileID("files/abc")
+
+	parts := convertUserMessage(msg)
+	require.Len(t, parts, 5)
+	assert.Equal(t, "image/jpeg", parts[0].FileData.MimeType)
+	assert.Equal(t, "https://example.com/a.jpg", parts[0].FileData.FileURI)
+	assert.Equal(t, "image/webp", parts[1].InlineData.MimeType)
+	assert.Equal(t, "audio/wav", parts[2].InlineData.MimeType)
+	assert.Equal(t, "application/pdf", parts[3].InlineData.MimeType)
+	assert.Equal(t, "files/abc", parts[4].FileData.FileURI)
+}
+
+func TestConvertFinishReason(t *testing.T) {
+	tests := []struct {
+		reason    string
+		toolCalls bool
+		want      string
+		abnormal  bool
+	}{
+		{"", false, "", false},
+		{FinishReasonStop, false, finishReasonStop, false},
+		{FinishReasonStop, true, finishReasonToolCalls, false},
+		{FinishReasonMaxTokens, false, finishReasonLength, false},
+		{FinishReasonSafety, false, finishReasonContentFilter, true},
+		{FinishReasonRecitation, false, finishReasonContentFilter, true},
+		{FinishReasonMalformedFunctionCall, false, "malformed_function_call", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, convertFinishReason(tt.reason, tt.toolCalls), tt.reason)
+		assert.Equal(t, tt.abnormal, isAbnormalFinish(tt.reason), tt.reason)
+	}
+}
@@ -0,0 +1,466 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package gemini provides a model.Model implementation that talks to the
+// Gemini generateContent API directly.
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/model/internal/sse"
+)
+
+const (
+	// defaultBaseURL is the default endpoint of the Gemini API.
+	defaultBaseURL = "https://generativelanguage.googleapis.com"
+	// defaultAPIVersion is the API version used in request paths.
+	defaultAPIVersion = "v1beta"
+	// defaultChannelBufferSize is the default channel buffer size.
+	defaultChannelBufferSize = 256
+	// maxSignatureCacheSize bounds the number of remembered thought signatures.
+	maxSignatureCacheSize = 1024
+	// functionToolType is the tool call type reported to the framework.
+	functionToolType = "function"
+)
+
+// HTTPClient is the interface for the HTTP client.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// RequestCallbackFunc is called with the wire request before it is sent.
+type RequestCallbackFunc func(ctx context.Context, req *GenerateContentRequest)
+
+// ChunkCallbackFunc is called for every decoded streaming chunk.
+type ChunkCallbackFunc func(ctx context.Context, req *GenerateContentRequest, chunk *GenerateContentResponse)
+
+// options contains configuration options for creating a Model.
+type options struct {
+	// API key sent in the x-goog-api-key header.
+	APIKey string
+	// Base URL of the Gemini API.
+	BaseURL string
+	// APIVersion is the version segment of the request path.
+	APIVersion string
+	// Extra headers added to every request.
+	Headers map[string]string
+	// Extra fields merged into the request body.
+	ExtraFields map[string]any
+	// Safety settings sent with every request.
+	SafetySettings []SafetySetting
+	// HTTP client used to send requests.
+	HTTPClient HTTPClient
+	// Buffer size for response channels (default: 256).
+	ChannelBufferSize int
+	// Callback for the wire request.
+	RequestCallback RequestCallbackFunc
+	// Callback for streaming chunks.
+	ChunkCallback ChunkCallbackFunc
+}
+
+// Option is a function that configures a Gemini model.
+type Option func(*options)
+
+// WithAPIKey sets the API key.
+func WithAPIKey(key string) Option {
+	return func(opts *options) {
+		opts.APIKey = key
+	}
+}
+
+// WithBaseURL sets the base URL of the API, e.g. a proxy or a test server.
+func WithBaseURL(url string) Option {
+	return func(opts *options) {
+		opts.BaseURL = url
+	}
+}
+
+// WithAPIVersion overrides the API version, e.g. "v1".
+func WithAPIVersion(version string) Option {
+	return func(opts *options) {
+		opts.APIVersion = version
+	}
+}
+
+// WithHeaders sets extra HTTP headers added to every request.
+func WithHeaders(headers map[string]string) Option {
+	return func(opts *options) {
+		if opts.Headers == nil {
+			opts.Headers = make(map[string]string)
+		}
+		for k, v := range headers {
+			opts.Headers[k] = v
+		}
+	}
+}
+
+// WithExtraFields sets extra fields merged into every request body.
+// E.g. WithExtraFields(map[string]any{"cachedContent": "cachedContents/abc"}).
+func WithExtraFields(extraFields map[string]any) Option {
+	return func(opts *options) {
+		if opts.ExtraFields == nil {
+			opts.ExtraFields = make(map[string]any)
+		}
+		for k, v := range extraFields {
+			opts.ExtraFields[k] = v
+		}
+	}
+}
+
+// WithSafetySettings sets the safety settings sent with every request.
+func WithSafetySettings(settings ...SafetySetting) Option {
+	return func(opts *options) {
+		opts.SafetySettings = append(opts.SafetySettings, settings...)
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(client HTTPClient) Option {
+	return func(opts *options) {
+		opts.HTTPClient = client
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.ChannelBufferSize = size
+	}
+}
+
+// WithRequestCallback sets the function called before a request is sent.
+func WithRequestCallback(fn RequestCallbackFunc) Option {
+	return func(opts *options) {
+		opts.RequestCallback = fn
+	}
+}
+
+// WithChunkCallback sets the function called for each streaming chunk.
+func WithChunkCallback(fn ChunkCallbackFunc) Option {
+	return func(opts *options) {
+		opts.ChunkCallback = fn
+	}
+}
+
+// Model implements the model.Model interface for the Gemini API.
+type Model struct {
+	name              string
+	baseURL           string
+	apiKey            string
+	apiVersion        string
+	headers           map[string]string
+	extraFields       map[string]any
+	safetySettings    []SafetySetting
+	httpClient        HTTPClient
+	channelBufferSize int
+	requestCallback   RequestCallbackFunc
+	chunkCallback     ChunkCallbackFunc
+
+	// signatureMu guards signatureByToolID.
+	signatureMu sync.Mutex
+	// signatureByToolID remembers thought signatures attached to function
+	// calls. Thinking models expect them back on the next turn and
+	// model.Message has no place to carry them.
+	signatureByToolID map[string]string
+}
+
+// New creates a new Gemini model.
+func New(name string, opts ...Option) *Model {
+	o := &options{
+		BaseURL:           defaultBaseURL,
+		APIVersion:        defaultAPIVersion,
+		ChannelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return &Model{
+		name:              name,
+		baseURL:           strings.TrimRight(o.BaseURL, "/"),
+		apiKey:            o.APIKey,
+		apiVersion:        o.APIVersion,
+		headers:           o.Headers,
+		extraFields:       o.ExtraFields,
+		safetySettings:    o.SafetySettings,
+		httpClient:        o.HTTPClient,
+		channelBufferSize: o.ChannelBufferSize,
+		requestCallback:   o.RequestCallback,
+		chunkCallback:     o.ChunkCallback,
+		signatureByToolID: make(map[string]string),
+	}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return model.Info{
+		Name: m.name,
+	}
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	wireRequest := m.buildRequest(request)
+	body, err := m.marshalRequest(wireRequest)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: marshal request: %w", err)
+	}
+
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+
+		if m.requestCallback != nil {
+			m.requestCallback(ctx, wireRequest)
+		}
+
+		httpRsp, err := m.send(ctx, body, request.Stream)
+		if err != nil {
+			m.sendError(ctx, responseChan, err.Error(), model.ErrorTypeAPIError, nil)
+			return
+		}
+		defer httpRsp.Body.Close()
+
+		if httpRsp.StatusCode/100 != 2 {
+			m.sendHTTPError(ctx, responseChan, httpRsp)
+			return
+		}
+		if request.Stream {
+			m.handleStreamingResponse(ctx, wireRequest, httpRsp.Body, responseChan)
+			return
+		}
+		m.handleNonStreamingResponse(ctx, httpRsp.Body, responseChan)
+	}()
+	return responseChan, nil
+}
+
+// marshalRequest encodes the wire request and merges extra fields.
+func (m *Model) marshalRequest(req *GenerateContentRequest) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.extraFields) == 0 {
+		return body, nil
+	}
+	var merged map[string]any
+	if err := json.Unmarshal(body, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range m.extraFields {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+// endpoint returns the URL of the generate or stream method.
+func (m *Model) endpoint(stream bool) string {
+	method := "generateContent"
+	if stream {
+		method = "streamGenerateContent?alt=sse"
+	}
+	return fmt.Sprintf("%s/%s/models/%s:%s", m.baseURL, m.apiVersion, url.PathEscape(strings.TrimPrefix(m.name, "models/")), method)
+}
+
+// send performs the HTTP call.
+func (m *Model) send(ctx context.Context, body []byte, stream bool) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint(stream), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("gemini: create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		httpReq.Header.Set("x-goog-api-key", m.apiKey)
+	}
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	for k, v := range m.headers {
+		httpReq.Header.Set(k, v)
+	}
+	rsp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: send request: %w", err)
+	}
+	return rsp, nil
+}
+
+// sendHTTPError converts a non-2xx HTTP response into an error response.
+func (m *Model) sendHTTPError(ctx context.Context, responseChan chan<- *model.Response, httpRsp *http.Response) {
+	raw, _ := io.ReadAll(httpRsp.Body)
+	message := strings.TrimSpace(string(raw))
+	code := strconv.Itoa(httpRsp.StatusCode)
+	// Streaming errors may be wrapped in a JSON array.
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var list []errorEnvelope
+		if err := json.Unmarshal(trimmed, &list); err == nil && len(list) > 0 {
+			trimmed, _ = json.Marshal(list[0])
+		}
+	}
+	var apiErr errorEnvelope
+	if err := json.Unmarshal(trimmed, &apiErr); err == nil && apiErr.Error.Message != "" {
+		message = apiErr.Error.Message
+		if apiErr.Error.Status != "" {
+			code = apiErr.Error.Status
+		}
+	}
+	m.sendError(ctx, responseChan,
+		fmt.Sprintf("gemini: status %d: %s", httpRsp.StatusCode, message),
+		model.ErrorTypeAPIError, &code)
+}
+
+// sendError delivers an error response on the channel.
+func (m *Model) sendError(
+	ctx context.Context,
+	responseChan chan<- *model.Response,
+	message, errType string,
+	code *string,
+) {
+	rsp := &model.Response{
+		Object: model.ObjectTypeError,
+		Error: &model.ResponseError{
+			Message: message,
+			Type:    errType,
+			Code:    code,
+		},
+		Timestamp: time.Now(),
+		Done:      true,
+	}
+	select {
+	case responseChan <- rsp:
+	case <-ctx.Done():
+	}
+}
+
+// handleNonStreamingResponse decodes a single generateContent response.
+func (m *Model) handleNonStreamingResponse(
+	ctx context.Context,
+	body io.Reader,
+	responseChan chan<- *model.Response,
+) {
+	var wire GenerateContentResponse
+	if err := json.NewDecoder(body).Decode(&wire); err != nil {
+		m.sendError(ctx, responseChan, fmt.Sprintf("gemini: decode response: %v", err), model.ErrorTypeAPIError, nil)
+		return
+	}
+	rsp := m.convertResponse(&wire)
+	rsp.Done = true
+	select {
+	case responseChan <- rsp:
+	case <-ctx.Done():
+	}
+}
+
+// handleStreamingResponse consumes the server-sent events of a streaming call.
+func (m *Model) handleStreamingResponse(
+	ctx context.Context,
+	wireRequest *GenerateContentRequest,
+	body io.Reader,
+	responseChan chan<- *model.Response,
+) {
+	acc := &streamAccumulator{}
+	err := sse.Read(body, func(data []byte) error {
+		var chunk GenerateContentResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			log.Warnf("gemini: skip undecodable stream chunk: %v", err)
+			return nil
+		}
+		if m.chunkCallback != nil {
+			m.chunkCallback(ctx, wireRequest, &chunk)
+		}
+		partial := acc.add(&chunk)
+		if partial == nil {
+			return nil
+		}
+		select {
+		case responseChan <- partial:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+		m.sendError(ctx, responseChan, fmt.Sprintf("gemini: read stream: %v", err), model.ErrorTypeStreamError, nil)
+		return
+	}
+
+	final := m.convertResponse(acc.result())
+	final.Done = final.Error != nil || !final.IsToolCallResponse()
+	select {
+	case responseChan <- final:
+	case <-ctx.Done():
+	}
+}
+
+// convertResponse converts the wire response and remembers the thought
+// signatures of its function calls.
+func (m *Model) convertResponse(wire *GenerateContentResponse) *model.Response {
+	rsp := convertResponse(wire)
+	if len(wire.Candidates) == 0 || wire.Candidates[0].Content == nil || len(rsp.Choices) == 0 {
+		return rsp
+	}
+	toolCalls := rsp.Choices[0].Message.ToolCalls
+	idx := 0
+	for _, p := range wire.Candidates[0].Content.Parts {
+		if p.FunctionCall == nil {
+			continue
+		}
+		if idx < len(toolCalls) && p.ThoughtSignature != "" {
+			m.rememberSignature(toolCalls[idx].ID, p.ThoughtSignature)
+		}
+		idx++
+	}
+	return rsp
+}
+
+// rememberSignature stores the thought signature of a tool call.
+func (m *Model) rememberSignature(toolID, signature string) {
+	m.signatureMu.Lock()
+	defer m.signatureMu.Unlock()
+	if len(m.signatureByToolID) >= maxSignatureCacheSize {
+		m.signatureByToolID = make(map[string]string)
+	}
+	m.signatureByToolID[toolID] = signature
+}
+
+// lookupSignature returns the remembered thought signature of a tool call.
+func (m *Model) lookupSignature(toolID string) string {
+	m.signatureMu.Lock()
+	defer m.signatureMu.Unlock()
+	return m.signatureByToolID[toolID]
+}
@@ -0,0 +1,226 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+type stubTool struct {
+	decl *tool.Declaration
+}
+
+func (s stubTool) Declaration() *tool.Declaration { return s.decl }
+
+func collect(t *testing.T, ch <-chan *model.Response) []*model.Response {
+	t.Helper()
+	var out []*model.Response
+	for rsp := range ch {
+		out = append(out, rsp)
+	}
+	return out
+}
+
+func TestModel_GenerateContent_NilRequest(t *testing.T) {
+	m := New("gemini-test")
+	_, err := m.GenerateContent(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestModel_GenerateContent_NonStreaming(t *testing.T) {
+	var captured map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1beta/models/gemini-test:generateContent", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-goog-api-key"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &captured))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"responseId": "rsp_1", "modelVersion": "gemini-test-001",
+			"candidates": [{
+				"content": {"role": "model", "parts": [
+					{"text": "thinking...", "thought": true},
+					{"text": "calling tool"},
+					{"functionCall": {"name": "calc", "args": {"a": 1}}, "thoughtSignature": "c2ln"}
+				]},
+				"finishReason": "STOP"
+			}],
+			"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5, "thoughtsTokenCount": 3, "totalTokenCount": 18}
+		}`)
+	}))
+	defer srv.Close()
+
+	m := New("gemini-test", WithAPIKey("test-key"), WithBaseURL(srv.URL),
+		WithSafetySettings(SafetySetting{Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_ONLY_HIGH"}))
+	maxTokens := 128
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage("be helpful"),
+			model.NewUserMessage("hi"),
+		},
+		GenerationConfig: model.GenerationConfig{MaxTokens: &maxTokens},
+		Tools: map[string]tool.Tool{
+			"calc": stubTool{decl: &tool.Declaration{Name: "calc", Description: "adds", InputSchema: &tool.Schema{
+				Type:       "object",
+				Properties: map[string]*tool.Schema{"a": {Type: "integer"}},
+			}}},
+		},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	rsp := rsps[0]
+	require.Nil(t, rsp.Error)
+	assert.True(t, rsp.Done)
+	assert.Equal(t, "rsp_1", rsp.ID)
+	msg := rsp.Choices[0].Message
+	assert.Equal(t, "calling tool", msg.Content)
+	assert.Equal(t, "thinking...", msg.ReasoningContent)
+	require.Len(t, msg.ToolCalls, 1)
+	assert.NotEmpty(t, msg.ToolCalls[0].ID)
+	assert.Equal(t, "calc", msg.ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"a":1}`, string(msg.ToolCalls[0].Function.Arguments))
+	assert.Equal(t, finishReasonToolCalls, *rsp.Choices[0].FinishReason)
+	assert.Equal(t, &model.Usage{PromptTokens: 10, CompletionTokens: 8, TotalTokens: 18}, rsp.Usage)
+
+	assert.Equal(t, float64(128), captured["generationConfig"].(map[string]any)["maxOutputTokens"])
+	system := captured["systemInstruction"].(map[string]any)["parts"].([]any)
+	assert.Equal(t, "be helpful", system[0].(map[string]any)["text"])
+	decls := captured["tools"].([]any)[0].(map[string]any)["functionDeclarations"].([]any)
+	params := decls[0].(map[string]any)["parameters"].(map[string]any)
+	assert.Equal(t, "OBJECT", params["type"])
+	assert.Len(t, captured["safetySettings"], 1)
+
+	// The thought signature is replayed with the function call on the next turn.
+	parts := m.convertAssistantMessage(msg)
+	require.Len(t, parts, 2)
+	assert.Equal(t, "c2ln", parts[1].ThoughtSignature)
+}
+
+func TestModel_GenerateContent_Streaming(t *testing.T) {
+	chunks := []string{
+		`{"responseId":"r2","candidates":[{"content":{"role":"model","parts":[{"text":"hmm","thought":true}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"fc_1","name":"search","args":{"q":"go"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":20,"totalTokenCount":27}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1beta/models/gemini-test:streamGenerateContent", r.URL.Path)
+		assert.Equal(t, "sse", r.URL.Query().Get("alt"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range chunks {
+			fmt.Fprintf(w, "data: %s\r\n\r\n", c)
+		}
+	}))
+	defer srv.Close()
+
+	var seen int
+	m := New("gemini-test", WithBaseURL(srv.URL),
+		WithChunkCallback(func(context.Context, *GenerateContentRequest, *GenerateContentResponse) { seen++ }))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{Stream: true},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 4)
+
+	assert.True(t, rsps[0].IsPartial)
+	assert.Equal(t, "hmm", rsps[0].Choices[0].Delta.ReasoningContent)
+	assert.Equal(t, "Hel", rsps[1].Choices[0].Delta.Content)
+	assert.Equal(t, "lo", rsps[2].Choices[0].Delta.Content)
+
+	final := rsps[3]
+	require.Nil(t, final.Error)
+	assert.False(t, final.IsPartial)
+	assert.False(t, final.Done, "tool call responses are not terminal")
+	assert.Equal(t, "r2", final.ID)
+	assert.Equal(t, "Hello", final.Choices[0].Message.Content)
+	assert.Equal(t, "hmm", final.Choices[0].Message.ReasoningContent)
+	require.Len(t, final.Choices[0].Message.ToolCalls, 1)
+	assert.Equal(t, "fc_1", final.Choices[0].Message.ToolCalls[0].ID)
+	assert.Equal(t, 27, final.Usage.TotalTokens)
+	assert.Equal(t, len(chunks), seen)
+}
+
+func TestModel_GenerateContent_SafetyFinish(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"partial"}]}}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"candidates":[{"finishReason":"SAFETY","safetyRatings":[{"category":"HARM_CATEGORY_HARASSMENT","probability":"HIGH","blocked":true}]}]}`+"\n\n")
+	}))
+	defer srv.Close()
+
+	m := New("gemini-test", WithBaseURL(srv.URL))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{Stream: true},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 2)
+	final := rsps[1]
+	require.NotNil(t, final.Error)
+	assert.True(t, final.Done)
+	assert.Equal(t, model.ErrorTypeAPIError, final.Error.Type)
+	assert.Equal(t, FinishReasonSafety, *final.Error.Code)
+	assert.Contains(t, final.Error.Message, "HARM_CATEGORY_HARASSMENT")
+	assert.Equal(t, "partial", final.Choices[0].Message.Content)
+	assert.Equal(t, finishReasonContentFilter, *final.Choices[0].FinishReason)
+}
+
+func TestModel_GenerateContent_PromptBlocked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"promptFeedback":{"blockReason":"PROHIBITED_CONTENT"}}`)
+	}))
+	defer srv.Close()
+
+	m := New("gemini-test", WithBaseURL(srv.URL))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Equal(t, FinishReasonProhibitedContent, *rsps[0].Error.Code)
+	assert.Equal(t, finishReasonContentFilter, *rsps[0].Choices[0].FinishReason)
+}
+
+func TestModel_GenerateContent_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"code":429,"message":"quota exceeded","status":"RESOURCE_EXHAUSTED"}}`)
+	}))
+	defer srv.Close()
+
+	m := New("gemini-test", WithBaseURL(srv.URL))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Equal(t, "RESOURCE_EXHAUSTED", *rsps[0].Error.Code)
+	assert.Contains(t, rsps[0].Error.Message, "quota exceeded")
+}
@@ -0,0 +1,62 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package sse provides a minimal server-sent events reader shared by the
+// HTTP based model providers.
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// maxLineSize bounds a single SSE line, large enough for base64 payloads.
+const maxLineSize = 16 * 1024 * 1024
+
+// Read parses an SSE body and calls fn with the data payload of every event.
+// Multi-line data fields are joined with a newline; comments and fields other
+// than data are ignored. Reading stops at the first error returned by fn.
+func Read(body io.Reader, fn func(data []byte) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var data bytes.Buffer
+	flush := func() error {
+		if data.Len() == 0 {
+			return nil
+		}
+		payload := make([]byte, data.Len())
+		copy(payload, data.Bytes())
+		data.Reset()
+		return fn(payload)
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		if payload, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(payload, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
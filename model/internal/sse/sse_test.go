@@ -0,0 +1,41 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package sse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead_MultiLineDataAndComments(t *testing.T) {
+	var got []string
+	body := ": comment\nevent: x\ndata: a\ndata: b\n\ndata: c\n"
+	err := Read(strings.NewReader(body), func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a\nb", "c"}, got)
+}
+
+func TestRead_StopsOnCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := Read(strings.NewReader("data: 1\n\ndata: 2\n\n"), func([]byte) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,383 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package ollama
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Done reasons reported by the chat API.
+const (
+	doneReasonStop   = "stop"
+	doneReasonLength = "length"
+)
+
+// Finish reasons reported on model.Choice, aligned with the OpenAI values
+// used elsewhere in the framework.
+const (
+	finishReasonStop      = "stop"
+	finishReasonLength    = "length"
+	finishReasonToolCalls = "tool_calls"
+)
+
+// Keys of the runtime options object.
+const (
+	optionNumCtx           = "num_ctx"
+	optionNumPredict       = "num_predict"
+	optionTemperature      = "temperature"
+	optionTopP             = "top_p"
+	optionStop             = "stop"
+	optionPresencePenalty  = "presence_penalty"
+	optionFrequencyPenalty = "frequency_penalty"
+)
+
+// ChatRequest is the body of a /api/chat call.
+type ChatRequest struct {
+	Model     string         `json:"model"`
+	Messages  []chatMessage  `json:"messages"`
+	Tools     []toolParam    `json:"tools,omitempty"`
+	Stream    bool           `json:"stream"`
+	Format    any            `json:"format,omitempty"`
+	Think     any            `json:"think,omitempty"`
+	KeepAlive string         `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+}
+
+// chatMessage is a single message of the chat API.
+type chatMessage struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"`
+	Images    [][]byte   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+// toolCall is a tool call produced by the model.
+type toolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Function toolCallFunction `json:"function"`
+}
+
+// toolCallFunction is the function of a tool call. Arguments is a JSON object.
+type toolCallFunction struct {
+	Index     int             `json:"index,omitempty"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// toolParam declares a client tool.
+type toolParam struct {
+	Type     string       `json:"type"`
+	Function functionDecl `json:"function"`
+}
+
+// functionDecl is the function of a tool declaration.
+type functionDecl struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Parameters  *tool.Schema `json:"parameters"`
+}
+
+// ChatResponse is a complete response or a single streaming chunk.
+type ChatResponse struct {
+	Model              string      `json:"model"`
+	CreatedAt          time.Time   `json:"created_at"`
+	Message            chatMessage `json:"message"`
+	Done               bool        `json:"done"`
+	DoneReason         string      `json:"done_reason,omitempty"`
+	TotalDuration      int64       `json:"total_duration,omitempty"`
+	LoadDuration       int64       `json:"load_duration,omitempty"`
+	PromptEvalCount    int         `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64       `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int         `json:"eval_count,omitempty"`
+	EvalDuration       int64       `json:"eval_duration,omitempty"`
+	Error              string      `json:"error,omitempty"`
+}
+
+// buildRequest converts a model.Request into a chat request.
+func (m *Model) buildRequest(request *model.Request) *ChatRequest {
+	req := &ChatRequest{
+		Model:     m.name,
+		Messages:  convertMessages(request.Messages),
+		Tools:     convertTools(request.Tools),
+		Stream:    request.Stream,
+		KeepAlive: m.keepAlive,
+		Options:   m.buildOptions(&request.GenerationConfig),
+	}
+	if so := request.StructuredOutput; so != nil && so.Type == model.StructuredOutputJSONSchema && so.JSONSchema != nil {
+		req.Format = so.JSONSchema.Schema
+	}
+	switch {
+	case request.ReasoningEffort != nil && *request.ReasoningEffort != "":
+		// Models such as gpt-oss take a level instead of a switch.
+		req.Think = *request.ReasoningEffort
+	case request.ThinkingEnabled != nil:
+		req.Think = *request.ThinkingEnabled
+	}
+	return req
+}
+
+// buildOptions merges the configured runtime options with the sampling
+// parameters of the request, which take precedence.
+func (m *Model) buildOptions(cfg *model.GenerationConfig) map[string]any {
+	opts := make(map[string]any, len(m.options)+4)
+	for k, v := range m.options {
+		opts[k] = v
+	}
+	if m.contextWindow > 0 {
+		opts[optionNumCtx] = m.contextWindow
+	}
+	if cfg.MaxTokens != nil {
+		opts[optionNumPredict] = *cfg.MaxTokens
+	}
+	if cfg.Temperature != nil {
+		opts[optionTemperature] = *cfg.Temperature
+	}
+	if cfg.TopP != nil {
+		opts[optionTopP] = *cfg.TopP
+	}
+	if len(cfg.Stop) > 0 {
+		opts[optionStop] = cfg.Stop
+	}
+	if cfg.PresencePenalty != nil {
+		opts[optionPresencePenalty] = *cfg.PresencePenalty
+	}
+	if cfg.FrequencyPenalty != nil {
+		opts[optionFrequencyPenalty] = *cfg.FrequencyPenalty
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
+// convertMessages converts framework messages into chat messages.
+func convertMessages(messages []model.Message) []chatMessage {
+	result := make([]chatMessage, 0, len(messages))
+	for _, msg := range messages {
+		cm := chatMessage{
+			Role:     string(msg.Role),
+			Content:  messageText(msg),
+			ToolName: msg.ToolName,
+		}
+		for _, part := range msg.ContentParts {
+			switch part.Type {
+			case model.ContentTypeText:
+			case model.ContentTypeImage:
+				if part.Image == nil {
+					continue
+				}
+				if len(part.Image.Data) == 0 {
+					log.Warn("ollama: image URLs are not supported, only inline image data will be sent")
+					continue
+				}
+				cm.Images = append(cm.Images, part.Image.Data)
+			default:
+				log.Warnf("ollama: unsupported content part type %q will be skipped", part.Type)
+			}
+		}
+		for _, tc := range msg.ToolCalls {
+			args := json.RawMessage(tc.Function.Arguments)
+			if len(args) == 0 || !json.Valid(args) {
+				args = json.RawMessage("{}")
+			}
+			cm.ToolCalls = append(cm.ToolCalls, toolCall{
+				Function: toolCallFunction{Name: tc.Function.Name, Arguments: args},
+			})
+		}
+		result = append(result, cm)
+	}
+	return result
+}
+
+// messageText joins the text content of a message.
+func messageText(msg model.Message) string {
+	texts := make([]string, 0, len(msg.ContentParts)+1)
+	if msg.Content != "" {
+		texts = append(texts, msg.Content)
+	}
+	for _, part := range msg.ContentParts {
+		if part.Type == model.ContentTypeText && part.Text != nil && *part.Text != "" {
+			texts = append(texts, *part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// convertTools converts tool declarations, ordered by name for stable requests.
+func convertTools(tools map[string]tool.Tool) []toolParam {
+	if len(tools) == 0 {
+		return nil
+	}
+	result := make([]toolParam, 0, len(tools))
+	for _, t := range tools {
+		decl := t.Declaration()
+		if decl == nil {
+			continue
+		}
+		schema := decl.InputSchema
+		if schema == nil {
+			schema = &tool.Schema{Type: "object"}
+		}
+		result = append(result, toolParam{
+			Type: functionToolType,
+			Function: functionDecl{
+				Name:        decl.Name,
+				Description: decl.Description,
+				Parameters:  schema,
+			},
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Function.Name < result[j].Function.Name })
+	return result
+}
+
+// convertToolCalls converts tool calls of a chat message. The chat API does
+// not always return call IDs, so a local one is generated when missing.
+func convertToolCalls(calls []toolCall) []model.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	result := make([]model.ToolCall, 0, len(calls))
+	for i, tc := range calls {
+		id := tc.ID
+		if id == "" {
+			id = "call_" + uuid.NewString()
+		}
+		args := []byte(tc.Function.Arguments)
+		if len(args) == 0 || string(args) == "null" {
+			args = []byte("{}")
+		}
+		idx := i
+		result = append(result, model.ToolCall{
+			Type:  functionToolType,
+			ID:    id,
+			Index: &idx,
+			Function: model.FunctionDefinitionParam{
+				Name:      tc.Function.Name,
+				Arguments: args,
+			},
+		})
+	}
+	return result
+}
+
+// convertDoneReason maps API done reasons to framework finish reasons.
+func convertDoneReason(reason string, hasToolCalls bool) string {
+	if hasToolCalls {
+		return finishReasonToolCalls
+	}
+	switch reason {
+	case doneReasonStop:
+		return finishReasonStop
+	case doneReasonLength:
+		return finishReasonLength
+	default:
+		return reason
+	}
+}
+
+// convertUsage maps eval counts to model.Usage.
+func convertUsage(rsp *ChatResponse) *model.Usage {
+	return &model.Usage{
+		PromptTokens:     rsp.PromptEvalCount,
+		CompletionTokens: rsp.EvalCount,
+		TotalTokens:      rsp.PromptEvalCount + rsp.EvalCount,
+	}
+}
+
+// convertChatResponse converts a complete chat response into a model.Response.
+func convertChatResponse(rsp *ChatResponse) *model.Response {
+	message := model.Message{
+		Role:             model.RoleAssistant,
+		Content:          rsp.Message.Content,
+		ReasoningContent: rsp.Message.Thinking,
+		ToolCalls:        convertToolCalls(rsp.Message.ToolCalls),
+	}
+	choice := model.Choice{Index: 0, Message: message}
+	if reason := convertDoneReason(rsp.DoneReason, len(message.ToolCalls) > 0); reason != "" {
+		choice.FinishReason = &reason
+	}
+	created := rsp.CreatedAt.Unix()
+	if rsp.CreatedAt.IsZero() {
+		created = time.Now().Unix()
+	}
+	return &model.Response{
+		Object:    model.ObjectTypeChatCompletion,
+		Created:   created,
+		Model:     rsp.Model,
+		Choices:   []model.Choice{choice},
+		Usage:     convertUsage(rsp),
+		Timestamp: time.Now(),
+	}
+}
+
+// streamAccumulator rebuilds the full response from streaming chunks.
+type streamAccumulator struct {
+	response ChatResponse
+	content  strings.Builder
+	thinking strings.Builder
+}
+
+// add folds a chunk into the accumulated response and returns a partial
+// response for chunks that carry visible content or thinking.
+func (a *streamAccumulator) add(chunk *ChatResponse) *model.Response {
+	if a.response.Model == "" {
+		a.response.Model = chunk.Model
+		a.response.CreatedAt = chunk.CreatedAt
+	}
+	a.content.WriteString(chunk.Message.Content)
+	a.thinking.WriteString(chunk.Message.Thinking)
+	a.response.Message.ToolCalls = append(a.response.Message.ToolCalls, chunk.Message.ToolCalls...)
+	if chunk.Done {
+		a.response.Done = true
+		a.response.DoneReason = chunk.DoneReason
+		a.response.PromptEvalCount = chunk.PromptEvalCount
+		a.response.EvalCount = chunk.EvalCount
+		a.response.TotalDuration = chunk.TotalDuration
+		a.response.LoadDuration = chunk.LoadDuration
+		a.response.PromptEvalDuration = chunk.PromptEvalDuration
+		a.response.EvalDuration = chunk.EvalDuration
+	}
+	if chunk.Message.Content == "" && chunk.Message.Thinking == "" {
+		return nil
+	}
+	return &model.Response{
+		Object:  model.ObjectTypeChatCompletionChunk,
+		Created: time.Now().Unix(),
+		Model:   chunk.Model,
+		Choices: []model.Choice{{Index: 0, Delta: model.Message{
+			Role:             model.RoleAssistant,
+			Content:          chunk.Message.Content,
+			ReasoningContent: chunk.Message.Thinking,
+		}}},
+		Timestamp: time.Now(),
+		IsPartial: true,
+	}
+}
+
+// result returns the accumulated response.
+func (a *streamAccumulator) result() *ChatResponse {
+	rsp := a.response
+	rsp.Message.Role = string(model.RoleAssistant)
+	rsp.Message.Content = a.content.String()
+	rsp.Message.Thinking = a.thinking.String()
+	return &rsp
+}
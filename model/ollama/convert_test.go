@@ -0,0 +1,78 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package ollama
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestBuildRequest(t *testing.T) {
+	m := New("llama-test")
+	enabled := true
+	maxTokens := 64
+	schema := map[string]any{"type": "object"}
+	req := m.buildRequest(&model.Request{
+		GenerationConfig: model.GenerationConfig{
+			ThinkingEnabled: &enabled,
+			MaxTokens:       &maxTokens,
+			Stop:            []string{"END"},
+		},
+		StructuredOutput: &model.StructuredOutput{
+			Type:       model.StructuredOutputJSONSchema,
+			JSONSchema: &model.JSONSchemaConfig{Schema: schema},
+		},
+	})
+	assert.Equal(t, true, req.Think)
+	assert.Equal(t, schema, req.Format)
+	assert.Equal(t, 64, req.Options[optionNumPredict])
+	assert.Equal(t, []string{"END"}, req.Options[optionStop])
+
+	effort := "high"
+	req = m.buildRequest(&model.Request{GenerationConfig: model.GenerationConfig{ReasoningEffort: &effort}})
+	assert.Equal(t, "high", req.Think)
+	assert.Nil(t, req.Options)
+}
+
+func TestConvertMessages(t *testing.T) {
+	user := model.NewUserMessage("look")
+	user.AddImageData([]byte("img"), "auto", "png")
+	user.AddImageURL("https://example.com/a.png", "auto")
+	msgs := convertMessages([]model.Message{
+		model.NewSystemMessage("sys"),
+		user,
+		{
+			Role: model.RoleAssistant,
+			ToolCalls: []model.ToolCall{
+				{ID: "t1", Function: model.FunctionDefinitionParam{Name: "a"}},
+			},
+		},
+		model.NewToolMessage("t1", "a", "r1"),
+	})
+	require.Len(t, msgs, 4)
+	assert.Equal(t, "system", msgs[0].Role)
+	require.Len(t, msgs[1].Images, 1)
+	assert.Equal(t, []byte("img"), msgs[1].Images[0])
+	assert.JSONEq(t, `{}`, string(msgs[2].ToolCalls[0].Function.Arguments))
+	assert.Equal(t, "tool", msgs[3].Role)
+	assert.Equal(t, "a", msgs[3].ToolName)
+	assert.Equal(t, "r1", msgs[3].Content)
+}
+
+func TestConvertDoneReason(t *testing.T) {
+	assert.Equal(t, finishReasonStop, convertDoneReason(doneReasonStop, false))
+	assert.Equal(t, finishReasonLength, convertDoneReason(doneReasonLength, false))
+	assert.Equal(t, finishReasonToolCalls, convertDoneReason(doneReasonStop, true))
+	assert.Equal(t, "load", convertDoneReason("load", false))
+}
@@ -0,0 +1,481 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package ollama provides a model.Model implementation for the Ollama
+// /api/chat endpoint, used to run local models.
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	imodel "trpc.group/trpc-go/trpc-agent-go/model/internal/model"
+)
+
+const (
+	// defaultHost is the address of a local Ollama server.
+	defaultHost = "http://localhost:11434"
+	// hostEnv is the environment variable read by the Ollama CLI.
+	hostEnv = "OLLAMA_HOST"
+	// chatPath is the path of the chat API.
+	chatPath = "/api/chat"
+	// defaultChannelBufferSize is the default channel buffer size.
+	defaultChannelBufferSize = 256
+	// functionToolType is the tool call type reported to the framework.
+	functionToolType = "function"
+	// maxLineSize bounds a single NDJSON line.
+	maxLineSize = 16 * 1024 * 1024
+	// Token tailoring budget. The server silently drops the beginning of
+	// prompts that exceed num_ctx, so messages are tailored before sending.
+	defaultReserveOutputTokens = 1024
+	defaultSafetyMarginRatio   = 0.10
+	defaultInputTokensFloor    = 512
+)
+
+// HTTPClient is the interface for the HTTP client.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// RequestCallbackFunc is called with the wire request before it is sent.
+type RequestCallbackFunc func(ctx context.Context, req *ChatRequest)
+
+// ChunkCallbackFunc is called for every decoded streaming chunk.
+type ChunkCallbackFunc func(ctx context.Context, req *ChatRequest, chunk *ChatResponse)
+
+// options contains configuration options for creating a Model.
+type options struct {
+	// Host is the base URL of the Ollama server.
+	Host string
+	// Extra headers added to every request.
+	Headers map[string]string
+	// KeepAlive controls how long the model stays loaded after a request.
+	KeepAlive *time.Duration
+	// Options are runtime options passed through to the server.
+	Options map[string]any
+	// ContextWindow is sent as num_ctx and registered for token tailoring.
+	ContextWindow int
+	// EnableTokenTailoring enables tailoring messages to the context window.
+	EnableTokenTailoring bool
+	// TokenCounter counts tokens for token tailoring.
+	TokenCounter model.TokenCounter
+	// TailoringStrategy defines the strategy for token tailoring.
+	TailoringStrategy model.TailoringStrategy
+	// HTTP client used to send requests.
+	HTTPClient HTTPClient
+	// Buffer size for response channels (default: 256).
+	ChannelBufferSize int
+	// Callback for the wire request.
+	RequestCallback RequestCallbackFunc
+	// Callback for streaming chunks.
+	ChunkCallback ChunkCallbackFunc
+}
+
+// Option is a function that configures an Ollama model.
+type Option func(*options)
+
+// WithHost sets the base URL of the Ollama server.
+// Defaults to $OLLAMA_HOST or http://localhost:11434.
+func WithHost(host string) Option {
+	return func(opts *options) {
+		opts.Host = host
+	}
+}
+
+// WithHeaders sets extra HTTP headers added to every request.
+func WithHeaders(headers map[string]string) Option {
+	return func(opts *options) {
+		if opts.Headers == nil {
+			opts.Headers = make(map[string]string)
+		}
+		for k, v := range headers {
+			opts.Headers[k] = v
+		}
+	}
+}
+
+// WithKeepAlive sets how long the model stays loaded after a request.
+// A negative duration keeps it loaded, zero unloads it immediately.
+func WithKeepAlive(d time.Duration) Option {
+	return func(opts *options) {
+		opts.KeepAlive = &d
+	}
+}
+
+// WithOptions sets runtime options passed through to the server,
+// e.g. WithOptions(map[string]any{"seed": 42, "top_k": 20}).
+// Sampling parameters set on the request take precedence.
+func WithOptions(runtimeOptions map[string]any) Option {
+	return func(opts *options) {
+		if opts.Options == nil {
+			opts.Options = make(map[string]any)
+		}
+		for k, v := range runtimeOptions {
+			opts.Options[k] = v
+		}
+	}
+}
+
+// WithContextWindow sets the context window of the model. It is sent as
+// num_ctx and registered with model.RegisterModelContextWindow so that token
+// tailoring uses the size the server actually runs with.
+func WithContextWindow(size int) Option {
+	return func(opts *options) {
+		opts.ContextWindow = size
+	}
+}
+
+// WithEnableTokenTailoring enables automatic token tailoring based on the
+// context window of the model.
+func WithEnableTokenTailoring(enabled bool) Option {
+	return func(opts *options) {
+		opts.EnableTokenTailoring = enabled
+	}
+}
+
+// WithTokenCounter sets the TokenCounter used for token tailoring.
+// If not provided, a SimpleTokenCounter will be used.
+func WithTokenCounter(counter model.TokenCounter) Option {
+	return func(opts *options) {
+		opts.TokenCounter = counter
+	}
+}
+
+// WithTailoringStrategy sets the TailoringStrategy used for token tailoring.
+// If not provided, a MiddleOutStrategy will be used.
+func WithTailoringStrategy(strategy model.TailoringStrategy) Option {
+	return func(opts *options) {
+		opts.TailoringStrategy = strategy
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(client HTTPClient) Option {
+	return func(opts *options) {
+		opts.HTTPClient = client
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.ChannelBufferSize = size
+	}
+}
+
+// WithRequestCallback sets the function called before a request is sent.
+func WithRequestCallback(fn RequestCallbackFunc) Option {
+	return func(opts *options) {
+		opts.RequestCallback = fn
+	}
+}
+
+// WithChunkCallback sets the function called for each streaming chunk.
+func WithChunkCallback(fn ChunkCallbackFunc) Option {
+	return func(opts *options) {
+		opts.ChunkCallback = fn
+	}
+}
+
+// Model implements the model.Model interface for the Ollama chat API.
+type Model struct {
+	name                 string
+	host                 string
+	headers              map[string]string
+	keepAlive            string
+	options              map[string]any
+	contextWindow        int
+	enableTokenTailoring bool
+	tokenCounter         model.TokenCounter
+	tailoringStrategy    model.TailoringStrategy
+	httpClient           HTTPClient
+	channelBufferSize    int
+	requestCallback      RequestCallbackFunc
+	chunkCallback        ChunkCallbackFunc
+}
+
+// New creates a new Ollama model.
+func New(name string, opts ...Option) *Model {
+	o := &options{
+		Host:              os.Getenv(hostEnv),
+		ChannelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Host == "" {
+		o.Host = defaultHost
+	}
+	if !strings.Contains(o.Host, "://") {
+		o.Host = "http://" + o.Host
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	if o.ContextWindow > 0 {
+		// Context windows are looked up by lower-cased name.
+		model.RegisterModelContextWindow(strings.ToLower(name), o.ContextWindow)
+	}
+	if o.EnableTokenTailoring {
+		if o.TokenCounter == nil {
+			o.TokenCounter = model.NewSimpleTokenCounter()
+		}
+		if o.TailoringStrategy == nil {
+			o.TailoringStrategy = model.NewMiddleOutStrategy(o.TokenCounter)
+		}
+	}
+	var keepAlive string
+	if o.KeepAlive != nil {
+		keepAlive = o.KeepAlive.String()
+	}
+	return &Model{
+		name:                 name,
+		host:                 strings.TrimRight(o.Host, "/"),
+		headers:              o.Headers,
+		keepAlive:            keepAlive,
+		options:              o.Options,
+		contextWindow:        o.ContextWindow,
+		enableTokenTailoring: o.EnableTokenTailoring,
+		tokenCounter:         o.TokenCounter,
+		tailoringStrategy:    o.TailoringStrategy,
+		httpClient:           o.HTTPClient,
+		channelBufferSize:    o.ChannelBufferSize,
+		requestCallback:      o.RequestCallback,
+		chunkCallback:        o.ChunkCallback,
+	}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return model.Info{
+		Name: m.name,
+	}
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	m.applyTokenTailoring(ctx, request)
+	wireRequest := m.buildRequest(request)
+	body, err := json.Marshal(wireRequest)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: marshal request: %w", err)
+	}
+
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+
+		if m.requestCallback != nil {
+			m.requestCallback(ctx, wireRequest)
+		}
+
+		httpRsp, err := m.send(ctx, body)
+		if err != nil {
+			m.sendError(ctx, responseChan, err.Error(), model.ErrorTypeAPIError, nil)
+			return
+		}
+		defer httpRsp.Body.Close()
+
+		if httpRsp.StatusCode/100 != 2 {
+			m.sendHTTPError(ctx, responseChan, httpRsp)
+			return
+		}
+		id := "chatcmpl-" + uuid.NewString()
+		if request.Stream {
+			m.handleStreamingResponse(ctx, id, wireRequest, httpRsp.Body, responseChan)
+			return
+		}
+		m.handleNonStreamingResponse(ctx, id, httpRsp.Body, responseChan)
+	}()
+	return responseChan, nil
+}
+
+// applyTokenTailoring trims messages to fit the context window, leaving room
+// for the output and a safety margin for token counting inaccuracies.
+func (m *Model) applyTokenTailoring(ctx context.Context, request *model.Request) {
+	if !m.enableTokenTailoring || len(request.Messages) == 0 {
+		return
+	}
+	contextWindow := imodel.ResolveContextWindow(m.name)
+	reserve := defaultReserveOutputTokens
+	if request.MaxTokens != nil && *request.MaxTokens > 0 {
+		reserve = *request.MaxTokens
+	}
+	safetyMargin := int(float64(contextWindow) * defaultSafetyMarginRatio)
+	maxInputTokens := max(contextWindow-reserve-safetyMargin, defaultInputTokensFloor)
+	maxInputTokens = max(maxInputTokens-model.RequestOverheadTokens(ctx, m.tokenCounter, request), 0)
+	tailored, err := m.tailoringStrategy.TailorMessages(ctx, request.Messages, maxInputTokens)
+	if err != nil {
+		log.Warn("token tailoring failed in ollama.Model", err)
+		return
+	}
+	request.Messages = tailored
+}
+
+// send performs the HTTP call.
+func (m *Model) send(ctx context.Context, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.host+chatPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ollama: create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/x-ndjson")
+	for k, v := range m.headers {
+		httpReq.Header.Set(k, v)
+	}
+	rsp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: send request: %w", err)
+	}
+	return rsp, nil
+}
+
+// sendHTTPError converts a non-2xx HTTP response into an error response.
+func (m *Model) sendHTTPError(ctx context.Context, responseChan chan<- *model.Response, httpRsp *http.Response) {
+	raw, _ := io.ReadAll(httpRsp.Body)
+	message := strings.TrimSpace(string(raw))
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &apiErr); err == nil && apiErr.Error != "" {
+		message = apiErr.Error
+	}
+	code := strconv.Itoa(httpRsp.StatusCode)
+	m.sendError(ctx, responseChan,
+		fmt.Sprintf("ollama: status %d: %s", httpRsp.StatusCode, message),
+		model.ErrorTypeAPIError, &code)
+}
+
+// sendError delivers an error response on the channel.
+func (m *Model) sendError(
+	ctx context.Context,
+	responseChan chan<- *model.Response,
+	message, errType string,
+	code *string,
+) {
+	rsp := &model.Response{
+		Object: model.ObjectTypeError,
+		Error: &model.ResponseError{
+			Message: message,
+			Type:    errType,
+			Code:    code,
+		},
+		Timestamp: time.Now(),
+		Done:      true,
+	}
+	select {
+	case responseChan <- rsp:
+	case <-ctx.Done():
+	}
+}
+
+// handleNonStreamingResponse decodes a single chat response.
+func (m *Model) handleNonStreamingResponse(
+	ctx context.Context,
+	id string,
+	body io.Reader,
+	responseChan chan<- *model.Response,
+) {
+	var wire ChatResponse
+	if err := json.NewDecoder(body).Decode(&wire); err != nil {
+		m.sendError(ctx, responseChan, fmt.Sprintf("ollama: decode response: %v", err), model.ErrorTypeAPIError, nil)
+		return
+	}
+	if wire.Error != "" {
+		m.sendError(ctx, responseChan, "ollama: "+wire.Error, model.ErrorTypeAPIError, nil)
+		return
+	}
+	rsp := convertChatResponse(&wire)
+	rsp.ID = id
+	rsp.Done = true
+	select {
+	case responseChan <- rsp:
+	case <-ctx.Done():
+	}
+}
+
+// handleStreamingResponse consumes the newline-delimited JSON chunks of a
+// streaming call.
+func (m *Model) handleStreamingResponse(
+	ctx context.Context,
+	id string,
+	wireRequest *ChatRequest,
+	body io.Reader,
+	responseChan chan<- *model.Response,
+) {
+	acc := &streamAccumulator{}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			log.Warnf("ollama: skip undecodable stream chunk: %v", err)
+			continue
+		}
+		if chunk.Error != "" {
+			m.sendError(ctx, responseChan, "ollama: "+chunk.Error, model.ErrorTypeStreamError, nil)
+			return
+		}
+		if m.chunkCallback != nil {
+			m.chunkCallback(ctx, wireRequest, &chunk)
+		}
+		partial := acc.add(&chunk)
+		if partial == nil {
+			continue
+		}
+		partial.ID = id
+		select {
+		case responseChan <- partial:
+		case <-ctx.Done():
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+		m.sendError(ctx, responseChan, fmt.Sprintf("ollama: read stream: %v", err), model.ErrorTypeStreamError, nil)
+		return
+	}
+
+	final := convertChatResponse(acc.result())
+	final.ID = id
+	final.Done = !final.IsToolCallResponse()
+	select {
+	case responseChan <- final:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,186 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	imodel "trpc.group/trpc-go/trpc-agent-go/model/internal/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+type stubTool struct {
+	decl *tool.Declaration
+}
+
+func (s stubTool) Declaration() *tool.Declaration { return s.decl }
+
+func collect(t *testing.T, ch <-chan *model.Response) []*model.Response {
+	t.Helper()
+	var out []*model.Response
+	for rsp := range ch {
+		out = append(out, rsp)
+	}
+	return out
+}
+
+func TestModel_GenerateContent_NilRequest(t *testing.T) {
+	m := New("llama-test")
+	_, err := m.GenerateContent(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestModel_GenerateContent_NonStreaming(t *testing.T) {
+	var captured map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, chatPath, r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&captured))
+		fmt.Fprint(w, `{
+			"model": "llama-test", "created_at": "2025-01-01T00:00:00Z",
+			"message": {"role": "assistant", "content": "", "thinking": "plan",
+				"tool_calls": [{"function": {"name": "calc", "arguments": {"a": 1}}}]},
+			"done": true, "done_reason": "stop", "prompt_eval_count": 12, "eval_count": 4
+		}`)
+	}))
+	defer srv.Close()
+
+	m := New("llama-test", WithHost(srv.URL), WithKeepAlive(5*time.Minute),
+		WithOptions(map[string]any{"seed": 42, "temperature": 0.1}))
+	temperature := 0.7
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{Temperature: &temperature},
+		Tools: map[string]tool.Tool{
+			"calc": stubTool{decl: &tool.Declaration{Name: "calc", InputSchema: &tool.Schema{Type: "object"}}},
+		},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	rsp := rsps[0]
+	require.Nil(t, rsp.Error)
+	assert.True(t, rsp.Done)
+	assert.NotEmpty(t, rsp.ID)
+	msg := rsp.Choices[0].Message
+	assert.Equal(t, "plan", msg.ReasoningContent)
+	require.Len(t, msg.ToolCalls, 1)
+	assert.NotEmpty(t, msg.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"a":1}`, string(msg.ToolCalls[0].Function.Arguments))
+	assert.Equal(t, finishReasonToolCalls, *rsp.Choices[0].FinishReason)
+	assert.Equal(t, &model.Usage{PromptTokens: 12, CompletionTokens: 4, TotalTokens: 16}, rsp.Usage)
+
+	assert.Equal(t, false, captured["stream"])
+	assert.Equal(t, "5m0s", captured["keep_alive"])
+	opts := captured["options"].(map[string]any)
+	assert.Equal(t, float64(42), opts["seed"])
+	assert.Equal(t, 0.7, opts["temperature"], "request parameters override configured options")
+	tools := captured["tools"].([]any)
+	assert.Equal(t, "calc", tools[0].(map[string]any)["function"].(map[string]any)["name"])
+}
+
+func TestModel_GenerateContent_Streaming(t *testing.T) {
+	chunks := []string{
+		`{"model":"llama-test","message":{"role":"assistant","content":"","thinking":"hmm"},"done":false}`,
+		`{"model":"llama-test","message":{"role":"assistant","content":"Hel"},"done":false}`,
+		`{"model":"llama-test","message":{"role":"assistant","content":"lo"},"done":false}`,
+		`{"model":"llama-test","message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":7,"eval_count":20}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, c := range chunks {
+			fmt.Fprintln(w, c)
+		}
+	}))
+	defer srv.Close()
+
+	var seen int
+	m := New("llama-test", WithHost(srv.URL),
+		WithChunkCallback(func(context.Context, *ChatRequest, *ChatResponse) { seen++ }))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{Stream: true},
+	})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 4)
+	assert.Equal(t, "hmm", rsps[0].Choices[0].Delta.ReasoningContent)
+	assert.Equal(t, "Hel", rsps[1].Choices[0].Delta.Content)
+
+	final := rsps[3]
+	assert.True(t, final.Done)
+	assert.Equal(t, rsps[0].ID, final.ID)
+	assert.Equal(t, "Hello", final.Choices[0].Message.Content)
+	assert.Equal(t, finishReasonLength, *final.Choices[0].FinishReason)
+	assert.Equal(t, 27, final.Usage.TotalTokens)
+	assert.Equal(t, len(chunks), seen)
+}
+
+func TestModel_GenerateContent_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Stream {
+			fmt.Fprintln(w, `{"model":"llama-test","message":{"role":"assistant","content":"a"},"done":false}`)
+			fmt.Fprintln(w, `{"error":"model crashed"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"llama-test\" not found, try pulling it first"}`)
+	}))
+	defer srv.Close()
+	m := New("llama-test", WithHost(srv.URL))
+
+	ch, err := m.GenerateContent(context.Background(), &model.Request{Messages: []model.Message{model.NewUserMessage("hi")}})
+	require.NoError(t, err)
+	rsps := collect(t, ch)
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Equal(t, "404", *rsps[0].Error.Code)
+	assert.Contains(t, rsps[0].Error.Message, "not found")
+
+	ch, err = m.GenerateContent(context.Background(), &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hi")},
+		GenerationConfig: model.GenerationConfig{Stream: true},
+	})
+	require.NoError(t, err)
+	rsps = collect(t, ch)
+	require.Len(t, rsps, 2)
+	require.NotNil(t, rsps[1].Error)
+	assert.Equal(t, model.ErrorTypeStreamError, rsps[1].Error.Type)
+}
+
+func TestNew_ContextWindow(t *testing.T) {
+	var captured ChatRequest
+	m := New("Local-Model:7B", WithContextWindow(8192), WithEnableTokenTailoring(true),
+		WithRequestCallback(func(_ context.Context, req *ChatRequest) { captured = *req }),
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("offline")
+		})}))
+	assert.Equal(t, 8192, imodel.ResolveContextWindow("Local-Model:7B"))
+
+	ch, err := m.GenerateContent(context.Background(), &model.Request{Messages: []model.Message{model.NewUserMessage("hi")}})
+	require.NoError(t, err)
+	collect(t, ch)
+	assert.Equal(t, 8192, captured.Options[optionNumCtx])
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
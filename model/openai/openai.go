@@ -29,6 +29,7 @@ import (
 	openaiopt "github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/packages/respjson"
 	"github.com/openai/openai-go/packages/ssestream"
+	"github.com/openai/openai-go/responses"
 	"github.com/openai/openai-go/shared"
 	"trpc.group/trpc-go/trpc-agent-go/log"
 	"trpc.group/trpc-go/trpc-agent-go/model"
@@ -208,6 +209,21 @@ type Model struct {
 	tokenCounter               model.TokenCounter      // Token counter for token tailoring.
 	tailoringStrategyOnce      sync.Once               // sync.Once for lazy initialization of tailoringStrategy.
 	tailoringStrategy          model.TailoringStrategy // Tailoring strategy for token tailoring.
+
+	api                       API
+	responsesStore            *bool
+	responsesChaining         bool
+	encryptedReasoning        bool
+	builtinTools              []responses.ToolUnionParam
+	responsesRequestCallback  ResponsesRequestCallbackFunc
+	responsesResponseCallback ResponsesResponseCallbackFunc
+	responsesEventCallback    ResponsesEventCallbackFunc
+	// responsesMu guards responsesTurns.
+	responsesMu sync.Mutex
+	// responsesTurns remembers Responses API turns by the key of the
+	// conversation ending with their output, for previous_response_id
+	// chaining and reasoning item passthrough.
+	responsesTurns map[string]*responsesTurn
 }
 
 // ChatRequestCallbackFunc is the function type for the chat request callback.
@@ -277,6 +293,22 @@ type options struct {
 	TailoringStrategy model.TailoringStrategy
 	// MaxInputTokens is the max input tokens for token tailoring.
 	MaxInputTokens int
+	// API selects the endpoint used for generation.
+	API API
+	// ResponsesStore sets the store flag of Responses API requests.
+	ResponsesStore *bool
+	// ResponsesChaining enables previous_response_id chaining.
+	ResponsesChaining bool
+	// EncryptedReasoning requests encrypted reasoning content.
+	EncryptedReasoning bool
+	// BuiltinTools are the Responses API built-in tools to enable.
+	BuiltinTools []responses.ToolUnionParam
+	// Callback for the Responses API request.
+	ResponsesRequestCallback ResponsesRequestCallbackFunc
+	// Callback for the Responses API response.
+	ResponsesResponseCallback ResponsesResponseCallbackFunc
+	// Callback for the Responses API stream events.
+	ResponsesEventCallback ResponsesEventCallbackFunc
 }
 
 // Option is a function that configures an OpenAI model.
@@ -452,10 +484,79 @@ func WithTailoringStrategy(strategy model.TailoringStrategy) Option {
 	}
 }
 
+// WithAPI selects the endpoint used for generation. Defaults to
+// APIChatCompletions. APIResponses is required by models that expose some
+// features, such as reasoning items, only through /v1/responses.
+func WithAPI(api API) Option {
+	return func(opts *options) {
+		opts.API = api
+	}
+}
+
+// WithResponsesStore sets whether the Responses API stores responses.
+// When unset the API default applies.
+func WithResponsesStore(store bool) Option {
+	return func(opts *options) {
+		opts.ResponsesStore = &store
+	}
+}
+
+// WithResponsesChaining enables previous_response_id chaining for the
+// Responses API. When the conversation continues a response produced by
+// this model, only the new messages are sent. Responses must be stored.
+func WithResponsesChaining(enabled bool) Option {
+	return func(opts *options) {
+		opts.ResponsesChaining = enabled
+	}
+}
+
+// WithEncryptedReasoning requests encrypted reasoning content from the
+// Responses API so that reasoning items can be passed back on later turns
+// without server-side storage.
+func WithEncryptedReasoning(enabled bool) Option {
+	return func(opts *options) {
+		opts.EncryptedReasoning = enabled
+	}
+}
+
+// WithBuiltinTools enables Responses API built-in tools such as web search,
+// file search or code interpreter. Their progress is reported as partial
+// responses whose Object is the stream event type.
+func WithBuiltinTools(tools ...responses.ToolUnionParam) Option {
+	return func(opts *options) {
+		opts.BuiltinTools = append(opts.BuiltinTools, tools...)
+	}
+}
+
+// WithResponsesRequestCallback sets the function to be called before
+// sending a Responses API request.
+func WithResponsesRequestCallback(fn ResponsesRequestCallbackFunc) Option {
+	return func(opts *options) {
+		opts.ResponsesRequestCallback = fn
+	}
+}
+
+// WithResponsesResponseCallback sets the function to be called with the
+// completed Responses API response.
+func WithResponsesResponseCallback(fn ResponsesResponseCallbackFunc) Option {
+	return func(opts *options) {
+		opts.ResponsesResponseCallback = fn
+	}
+}
+
+// WithResponsesEventCallback sets the function to be called for each
+// Responses API stream event.
+func WithResponsesEventCallback(fn ResponsesEventCallbackFunc) Option {
+	return func(opts *options) {
+		opts.ResponsesEventCallback = fn
+	}
+}
+
 // New creates a new OpenAI-like model.
 func New(name string, opts ...Option) *Model {
 	o := &options{
 		Variant:           VariantOpenAI, // The default variant is VariantOpenAI.
+		API:               APIChatCompletions,
 		ChannelBufferSize: defaultChannelBufferSize,
 	}
 	for _, opt := range opts {
@@ -513,6 +614,14 @@ func New(name string, opts ...Option) *Model {
 		tokenCounter:               o.TokenCounter,
 		tailoringStrategy:          o.TailoringStrategy,
 		maxInputTokens:             o.MaxInputTokens,
+		api:                        o.API,
+		responsesStore:             o.ResponsesStore,
+		responsesChaining:          o.ResponsesChaining,
+		encryptedReasoning:         o.EncryptedReasoning,
+		builtinTools:               o.BuiltinTools,
+		responsesRequestCallback:   o.ResponsesRequestCallback,
+		responsesResponseCallback:  o.ResponsesResponseCallback,
+		responsesEventCallback:     o.ResponsesEventCallback,
 	}
 }
 
@@ -537,6 +646,14 @@ func (m *Model) GenerateContent(
 
 	responseChan := make(chan *model.Response, m.channelBufferSize)
 
+	if m.api == APIResponses {
+		go func() {
+			defer close(responseChan)
+			m.generateWithResponses(ctx, request, responseChan)
+		}()
+		return responseChan, nil
+	}
+
 	chatRequest, opts := m.buildChatRequest(request)
 
 	go func() {
@@ -619,8 +736,12 @@ func (m *Model) applyTokenTailoring(ctx context.Context, request *model.Request)
 		tailoringStrategy = m.tailoringStrategy
 	}
 
+	// Leave room for tool declarations and other parts outside the messages
+	// when the counter can account for them.
+	overheadTokens := model.RequestOverheadTokens(ctx, tokenCounter, request)
+
 	// Apply token tailoring.
-	tailored, err := tailoringStrategy.TailorMessages(ctx, request.Messages, maxInputTokens)
+	tailored, err := tailoringStrategy.TailorMessages(ctx, request.Messages, max(maxInputTokens-overheadTokens, 0))
 	if err != nil {
 		log.Warn("token tailoring failed in openai.Model", err)
 		return
@@ -634,6 +755,7 @@ func (m *Model) applyTokenTailoring(ctx context.Context, request *model.Request)
 		log.Warn("failed to count tokens after tailoring", err)
 		return
 	}
+	usedTokens += overheadTokens
 
 	remainingTokens := maxInputTokens - usedTokens
 	if remainingTokens <= 0 {
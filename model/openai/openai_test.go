@@ -2419,6 +2419,41 @@ func TestOpenAI_InitializationPriority(t *testing.T) {
 	assert.Equalf(t, userTailoringStrategy, client.tailoringStrategy, "expected user-provided tailoringStrategy to be preserved")
 }
 
+// TestApplyTokenTailoring_RequestOverhead verifies that tool declarations
+// counted by a RequestTokenCounter are taken out of the message budget.
+func TestApplyTokenTailoring_RequestOverhead(t *testing.T) {
+	counter := model.NewAccountingTokenCounter(model.OpenAIAccounting())
+	client := &Model{
+		name:                 "gpt-4o",
+		enableTokenTailoring: true,
+		maxInputTokens:       200,
+		tokenCounter:         counter,
+		tailoringStrategy:    model.NewHeadOutStrategy(counter),
+	}
+	request := &model.Request{
+		Tools: map[string]tool.Tool{"search": stubTool{decl: &tool.Declaration{
+			Name:        "search",
+			Description: strings.Repeat("search the knowledge base ", 10),
+			InputSchema: &tool.Schema{Type: "object"},
+		}}},
+	}
+	request.Messages = append(request.Messages, model.NewSystemMessage("system"))
+	for i := 0; i < 20; i++ {
+		request.Messages = append(request.Messages,
+			model.NewUserMessage(strings.Repeat("q", 40)),
+			model.NewAssistantMessage(strings.Repeat("a", 40)))
+	}
+
+	client.applyTokenTailoring(context.Background(), request)
+
+	total, err := counter.CountRequestTokens(context.Background(), request)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, total, 200)
+	assert.Less(t, len(request.Messages), 41)
+	overhead := model.RequestOverheadTokens(context.Background(), counter, request)
+	assert.Greater(t, overhead, 50)
+}
+
 // TestWithHTTPClientTransport tests the WithHTTPClientTransport option.
 func TestWithHTTPClientTransport(t *testing.T) {
 	// Create a custom transport
@@ -0,0 +1,629 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	openai "github.com/openai/openai-go"
+	openaiopt "github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/responses"
+	"github.com/openai/openai-go/shared"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// API selects the OpenAI endpoint used for generation.
+type API string
+
+const (
+	// APIChatCompletions uses /v1/chat/completions. It is the default.
+	APIChatCompletions API = "chat_completions"
+	// APIResponses uses /v1/responses.
+	APIResponses API = "responses"
+)
+
+const (
+	// maxResponsesCacheSize bounds the number of remembered response turns.
+	maxResponsesCacheSize = 1024
+
+	// Stream event types of the Responses API handled by the model.
+	responsesEventCreated         = "response.created"
+	responsesEventOutputTextDelta = "response.output_text.delta"
+	responsesEventRefusalDelta    = "response.refusal.delta"
+	responsesEventReasoningDelta  = "response.reasoning_summary_text.delta"
+	responsesEventCompleted       = "response.completed"
+	responsesEventIncomplete      = "response.incomplete"
+	responsesEventFailed          = "response.failed"
+	responsesEventError           = "error"
+
+	// Output item types of the Responses API.
+	responsesItemMessage      = "message"
+	responsesItemReasoning    = "reasoning"
+	responsesItemFunctionCall = "function_call"
+)
+
+// builtinToolEventPrefixes are the stream event prefixes of built-in tools.
+// Those events are forwarded as partial responses whose Object is the event
+// type, e.g. "response.web_search_call.searching".
+var builtinToolEventPrefixes = []string{
+	"response.web_search_call.",
+	"response.file_search_call.",
+	"response.code_interpreter_call",
+	"response.image_generation_call.",
+	"response.mcp_call",
+	"response.mcp_list_tools.",
+}
+
+// ResponsesRequestCallbackFunc is the function type for the Responses API
+// request callback.
+type ResponsesRequestCallbackFunc func(
+	ctx context.Context,
+	request *responses.ResponseNewParams,
+)
+
+// ResponsesResponseCallbackFunc is the function type for the Responses API
+// response callback. It is called with the completed response in both
+// streaming and non-streaming mode.
+type ResponsesResponseCallbackFunc func(
+	ctx context.Context,
+	request *responses.ResponseNewParams,
+	response *responses.Response,
+)
+
+// ResponsesEventCallbackFunc is the function type for the Responses API
+// stream event callback. It receives every semantic stream event.
+type ResponsesEventCallbackFunc func(
+	ctx context.Context,
+	request *responses.ResponseNewParams,
+	event *responses.ResponseStreamEventUnion,
+)
+
+// responsesTurn is what the model remembers about a response so that the
+// next request of the same conversation can chain to it.
+type responsesTurn struct {
+	responseID string
+	reasoning  []responses.ResponseReasoningItemParam
+}
+
+// generateWithResponses serves a request through the Responses API.
+func (m *Model) generateWithResponses(
+	ctx context.Context,
+	request *model.Request,
+	responseChan chan<- *model.Response,
+) {
+	params, opts := m.buildResponsesRequest(request)
+	if m.responsesRequestCallback != nil {
+		m.responsesRequestCallback(ctx, &params)
+	}
+	// The key of the conversation so far; the output is chained to it.
+	prefix := conversationKey(request.Messages)
+	if request.Stream {
+		m.handleResponsesStream(ctx, params, prefix, responseChan, opts...)
+	} else {
+		m.handleResponsesNonStream(ctx, params, prefix, responseChan, opts...)
+	}
+}
+
+// buildResponsesRequest converts our Request to Responses API params.
+//
+// System messages become the instructions, which the API does not carry
+// over between chained responses. When the conversation continues a
+// response remembered by this model and chaining is enabled, only the
+// messages after it are sent together with previous_response_id. Otherwise
+// the whole history is sent and remembered reasoning items are replayed in
+// front of the assistant turns that produced them.
+func (m *Model) buildResponsesRequest(request *model.Request) (responses.ResponseNewParams, []openaiopt.RequestOption) {
+	params := responses.ResponseNewParams{
+		Model: shared.ResponsesModel(m.name),
+	}
+
+	var instructions []string
+	keys := conversationKeys(request.Messages)
+	start := 0
+	if m.responsesChaining {
+		for i := len(request.Messages) - 1; i >= 0; i-- {
+			if request.Messages[i].Role != model.RoleAssistant {
+				continue
+			}
+			if turn := m.lookupResponsesTurn(keys[i]); turn != nil {
+				params.PreviousResponseID = openai.String(turn.responseID)
+				start = i + 1
+			}
+			break
+		}
+	}
+
+	var input responses.ResponseInputParam
+	for i, msg := range request.Messages {
+		if msg.Role == model.RoleSystem {
+			if text := messageText(msg); text != "" {
+				instructions = append(instructions, text)
+			}
+			continue
+		}
+		if i < start {
+			continue
+		}
+		input = append(input, m.convertResponsesMessage(msg, keys[i])...)
+	}
+	if len(instructions) > 0 {
+		params.Instructions = openai.String(strings.Join(instructions, "\n\n"))
+	}
+	params.Input = responses.ResponseNewParamsInputUnion{OfInputItemList: input}
+	params.Tools = append(m.convertResponsesTools(request.Tools), m.builtinTools...)
+
+	if request.StructuredOutput != nil &&
+		request.StructuredOutput.Type == model.StructuredOutputJSONSchema &&
+		request.StructuredOutput.JSONSchema != nil {
+		js := request.StructuredOutput.JSONSchema
+		params.Text = responses.ResponseTextConfigParam{
+			Format: responses.ResponseFormatTextConfigUnionParam{
+				OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+					Name:        js.Name,
+					Schema:      js.Schema,
+					Strict:      openai.Bool(js.Strict),
+					Description: openai.String(js.Description),
+				},
+			},
+		}
+	}
+
+	if request.MaxTokens != nil {
+		params.MaxOutputTokens = openai.Int(int64(*request.MaxTokens))
+	}
+	if request.Temperature != nil {
+		params.Temperature = openai.Float(*request.Temperature)
+	}
+	if request.TopP != nil {
+		params.TopP = openai.Float(*request.TopP)
+	}
+	if request.ReasoningEffort != nil {
+		params.Reasoning.Effort = shared.ReasoningEffort(*request.ReasoningEffort)
+	}
+	if request.ThinkingEnabled != nil && *request.ThinkingEnabled {
+		params.Reasoning.Summary = shared.ReasoningSummaryAuto
+	}
+	if m.responsesStore != nil {
+		params.Store = openai.Bool(*m.responsesStore)
+	}
+	if m.encryptedReasoning {
+		params.Include = append(params.Include, responses.ResponseIncludableReasoningEncryptedContent)
+	}
+
+	var opts []openaiopt.RequestOption
+	for key, value := range m.extraFields {
+		opts = append(opts, openaiopt.WithJSONSet(key, value))
+	}
+	return params, opts
+}
+
+// convertResponsesMessage converts a non-system message to input items.
+func (m *Model) convertResponsesMessage(msg model.Message, key string) []responses.ResponseInputItemUnionParam {
+	switch msg.Role {
+	case model.RoleUser:
+		if len(msg.ContentParts) == 0 {
+			return []responses.ResponseInputItemUnionParam{
+				responses.ResponseInputItemParamOfMessage(msg.Content, responses.EasyInputMessageRoleUser),
+			}
+		}
+		return []responses.ResponseInputItemUnionParam{
+			responses.ResponseInputItemParamOfMessage(m.convertResponsesContent(msg), responses.EasyInputMessageRoleUser),
+		}
+	case model.RoleTool:
+		return []responses.ResponseInputItemUnionParam{
+			responses.ResponseInputItemParamOfFunctionCallOutput(msg.ToolID, msg.Content),
+		}
+	case model.RoleAssistant:
+		var items []responses.ResponseInputItemUnionParam
+		if turn := m.lookupResponsesTurn(key); turn != nil {
+			for i := range turn.reasoning {
+				items = append(items, responses.ResponseInputItemUnionParam{OfReasoning: &turn.reasoning[i]})
+			}
+		}
+		if text := messageText(msg); text != "" {
+			items = append(items, responses.ResponseInputItemParamOfMessage(text, responses.EasyInputMessageRoleAssistant))
+		}
+		for _, tc := range msg.ToolCalls {
+			items = append(items, responses.ResponseInputItemParamOfFunctionCall(
+				string(tc.Function.Arguments), tc.ID, tc.Function.Name))
+		}
+		return items
+	}
+	return nil
+}
+
+// convertResponsesContent converts user content parts. Audio is not
+// accepted by the Responses API and is skipped.
+func (m *Model) convertResponsesContent(msg model.Message) responses.ResponseInputMessageContentListParam {
+	var parts responses.ResponseInputMessageContentListParam
+	if msg.Content != "" {
+		parts = append(parts, responses.ResponseInputContentParamOfInputText(msg.Content))
+	}
+	for _, part := range msg.ContentParts {
+		switch {
+		case part.Type == model.ContentTypeText && part.Text != nil:
+			parts = append(parts, responses.ResponseInputContentParamOfInputText(*part.Text))
+		case part.Type == model.ContentTypeImage && part.Image != nil:
+			detail := responses.ResponseInputImageDetailAuto
+			if part.Image.Detail != "" {
+				detail = responses.ResponseInputImageDetail(part.Image.Detail)
+			}
+			parts = append(parts, responses.ResponseInputContentUnionParam{
+				OfInputImage: &responses.ResponseInputImageParam{
+					Detail:   detail,
+					ImageURL: openai.String(imageToURLOrBase64(part.Image)),
+				},
+			})
+		case part.Type == model.ContentTypeFile && part.File != nil:
+			file := fileToParams(part.File)
+			parts = append(parts, responses.ResponseInputContentUnionParam{
+				OfInputFile: &responses.ResponseInputFileParam{
+					FileID:   file.FileID,
+					FileData: file.FileData,
+					Filename: file.Filename,
+				},
+			})
+		case part.Type == model.ContentTypeAudio:
+			log.Warnf("openai: audio content is not supported by the Responses API, skipping")
+		}
+	}
+	return parts
+}
+
+// convertResponsesTools converts function tools.
+func (m *Model) convertResponsesTools(tools map[string]tool.Tool) []responses.ToolUnionParam {
+	var result []responses.ToolUnionParam
+	for _, t := range tools {
+		declaration := t.Declaration()
+		schemaBytes, err := json.Marshal(declaration.InputSchema)
+		if err != nil {
+			log.Errorf("failed to marshal tool schema for %s: %v", declaration.Name, err)
+			continue
+		}
+		var parameters map[string]any
+		if err := json.Unmarshal(schemaBytes, &parameters); err != nil {
+			log.Errorf("failed to unmarshal tool schema for %s: %v", declaration.Name, err)
+			continue
+		}
+		result = append(result, responses.ToolUnionParam{
+			OfFunction: &responses.FunctionToolParam{
+				Name:        declaration.Name,
+				Description: openai.String(declaration.Description),
+				Parameters:  parameters,
+				Strict:      openai.Bool(false),
+			},
+		})
+	}
+	return result
+}
+
+// handleResponsesNonStream handles a non-streaming Responses API call.
+func (m *Model) handleResponsesNonStream(
+	ctx context.Context,
+	params responses.ResponseNewParams,
+	prefix string,
+	responseChan chan<- *model.Response,
+	opts ...openaiopt.RequestOption,
+) {
+	resp, err := m.client.Responses.New(ctx, params, opts...)
+	if err != nil {
+		sendResponse(ctx, responseChan, &model.Response{
+			Error: &model.ResponseError{
+				Message: err.Error(),
+				Type:    model.ErrorTypeAPIError,
+			},
+			Timestamp: time.Now(),
+			Done:      true,
+		})
+		return
+	}
+	if m.responsesResponseCallback != nil {
+		m.responsesResponseCallback(ctx, &params, resp)
+	}
+	response := m.convertResponsesResponse(resp, prefix)
+	response.Done = true
+	sendResponse(ctx, responseChan, response)
+}
+
+// handleResponsesStream handles a streaming Responses API call. Text and
+// reasoning summary deltas become partial responses, function call
+// arguments are only surfaced in the final response.
+func (m *Model) handleResponsesStream(
+	ctx context.Context,
+	params responses.ResponseNewParams,
+	prefix string,
+	responseChan chan<- *model.Response,
+	opts ...openaiopt.RequestOption,
+) {
+	stream := m.client.Responses.NewStreaming(ctx, params, opts...)
+	defer stream.Close()
+
+	var (
+		id      string
+		created int64
+		name    string
+	)
+	for stream.Next() {
+		evt := stream.Current()
+		if m.responsesEventCallback != nil {
+			m.responsesEventCallback(ctx, &params, &evt)
+		}
+		switch evt.Type {
+		case responsesEventCreated:
+			id, created, name = evt.Response.ID, int64(evt.Response.CreatedAt), string(evt.Response.Model)
+		case responsesEventOutputTextDelta, responsesEventRefusalDelta, responsesEventReasoningDelta:
+			delta := model.Message{Role: model.RoleAssistant}
+			if evt.Type == responsesEventReasoningDelta {
+				delta.ReasoningContent = evt.Delta.OfString
+			} else {
+				delta.Content = evt.Delta.OfString
+			}
+			if !sendResponse(ctx, responseChan, &model.Response{
+				ID:        id,
+				Object:    model.ObjectTypeChatCompletionChunk,
+				Created:   created,
+				Model:     name,
+				Choices:   []model.Choice{{Delta: delta}},
+				Timestamp: time.Now(),
+				IsPartial: true,
+			}) {
+				return
+			}
+		case responsesEventCompleted, responsesEventIncomplete, responsesEventFailed:
+			if m.responsesResponseCallback != nil {
+				m.responsesResponseCallback(ctx, &params, &evt.Response)
+			}
+			final := m.convertResponsesResponse(&evt.Response, prefix)
+			final.Done = !final.IsToolCallResponse()
+			sendResponse(ctx, responseChan, final)
+			return
+		case responsesEventError:
+			sendResponse(ctx, responseChan, &model.Response{
+				ID: id,
+				Error: &model.ResponseError{
+					Message: evt.Message,
+					Type:    model.ErrorTypeAPIError,
+					Code:    optionalString(evt.Code),
+					Param:   optionalString(evt.Param),
+				},
+				Timestamp: time.Now(),
+				Done:      true,
+			})
+			return
+		default:
+			if !isBuiltinToolEvent(evt.Type) {
+				continue
+			}
+			if !sendResponse(ctx, responseChan, &model.Response{
+				ID:        id,
+				Object:    evt.Type,
+				Created:   created,
+				Model:     name,
+				Choices:   []model.Choice{{Delta: model.Message{Role: model.RoleAssistant}}},
+				Timestamp: time.Now(),
+				IsPartial: true,
+			}) {
+				return
+			}
+		}
+	}
+
+	message := "stream ended without a completed response"
+	if err := stream.Err(); err != nil {
+		message = err.Error()
+	}
+	sendResponse(ctx, responseChan, &model.Response{
+		ID: id,
+		Error: &model.ResponseError{
+			Message: message,
+			Type:    model.ErrorTypeStreamError,
+		},
+		Timestamp: time.Now(),
+		Done:      true,
+	})
+}
+
+// convertResponsesResponse converts a completed, incomplete or failed
+// response and remembers it for chaining and reasoning passthrough.
+func (m *Model) convertResponsesResponse(resp *responses.Response, prefix string) *model.Response {
+	response := &model.Response{
+		ID:        resp.ID,
+		Object:    model.ObjectTypeChatCompletion,
+		Created:   int64(resp.CreatedAt),
+		Model:     string(resp.Model),
+		Timestamp: time.Now(),
+	}
+	if resp.Usage.TotalTokens > 0 {
+		response.Usage = &model.Usage{
+			PromptTokens:     int(resp.Usage.InputTokens),
+			CompletionTokens: int(resp.Usage.OutputTokens),
+			TotalTokens:      int(resp.Usage.TotalTokens),
+		}
+	}
+	if resp.Status == responses.ResponseStatusFailed {
+		response.Error = &model.ResponseError{
+			Message: resp.Error.Message,
+			Type:    model.ErrorTypeAPIError,
+			Code:    optionalString(string(resp.Error.Code)),
+		}
+		return response
+	}
+
+	msg := model.Message{Role: model.RoleAssistant}
+	var (
+		content   strings.Builder
+		reasoning strings.Builder
+		items     []responses.ResponseReasoningItemParam
+	)
+	for _, item := range resp.Output {
+		switch item.Type {
+		case responsesItemMessage:
+			for _, c := range item.Content {
+				content.WriteString(c.Text)
+				content.WriteString(c.Refusal)
+			}
+		case responsesItemReasoning:
+			for _, s := range item.Summary {
+				reasoning.WriteString(s.Text)
+			}
+			// Without storage the API only accepts reasoning items that carry
+			// their encrypted content.
+			if item.EncryptedContent != "" || m.responsesStore == nil || *m.responsesStore {
+				items = append(items, item.AsReasoning().ToParam())
+			}
+		case responsesItemFunctionCall:
+			idx := len(msg.ToolCalls)
+			msg.ToolCalls = append(msg.ToolCalls, model.ToolCall{
+				Index: &idx,
+				ID:    item.CallID,
+				Type:  functionToolType,
+				Function: model.FunctionDefinitionParam{
+					Name:      item.Name,
+					Arguments: []byte(item.Arguments),
+				},
+			})
+		}
+	}
+	msg.Content = content.String()
+	msg.ReasoningContent = reasoning.String()
+
+	finishReason := "stop"
+	switch {
+	case len(msg.ToolCalls) > 0:
+		finishReason = "tool_calls"
+	case resp.IncompleteDetails.Reason == "max_output_tokens":
+		finishReason = "length"
+	case resp.IncompleteDetails.Reason == "content_filter":
+		finishReason = "content_filter"
+	}
+	response.Choices = []model.Choice{{Message: msg, FinishReason: &finishReason}}
+
+	if resp.ID != "" {
+		m.rememberResponsesTurn(chainKey(prefix, msg), &responsesTurn{
+			responseID: resp.ID,
+			reasoning:  items,
+		})
+	}
+	return response
+}
+
+// rememberResponsesTurn stores a turn under the key of the conversation
+// ending with its output.
+func (m *Model) rememberResponsesTurn(key string, turn *responsesTurn) {
+	m.responsesMu.Lock()
+	defer m.responsesMu.Unlock()
+	if m.responsesTurns == nil || len(m.responsesTurns) >= maxResponsesCacheSize {
+		m.responsesTurns = make(map[string]*responsesTurn)
+	}
+	m.responsesTurns[key] = turn
+}
+
+// lookupResponsesTurn returns the turn remembered under key.
+func (m *Model) lookupResponsesTurn(key string) *responsesTurn {
+	m.responsesMu.Lock()
+	defer m.responsesMu.Unlock()
+	return m.responsesTurns[key]
+}
+
+// conversationKeys returns, for each message, the key of the conversation
+// ending with it. System messages are left out because instructions often
+// embed volatile values such as the current time.
+func conversationKeys(messages []model.Message) []string {
+	keys := make([]string, len(messages))
+	key := ""
+	for i, msg := range messages {
+		if msg.Role != model.RoleSystem {
+			key = chainKey(key, msg)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// conversationKey returns the key of the whole conversation.
+func conversationKey(messages []model.Message) string {
+	keys := conversationKeys(messages)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[len(keys)-1]
+}
+
+// chainKey extends a conversation key with a message. Only the fields that
+// survive a round trip through the session take part in the key.
+func chainKey(prefix string, msg model.Message) string {
+	type keyedCall struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Args string `json:"args"`
+	}
+	view := struct {
+		Prefix  string      `json:"prefix"`
+		Role    model.Role  `json:"role"`
+		Content string      `json:"content"`
+		ToolID  string      `json:"tool_id,omitempty"`
+		Calls   []keyedCall `json:"calls,omitempty"`
+	}{Prefix: prefix, Role: msg.Role, Content: messageText(msg), ToolID: msg.ToolID}
+	for _, tc := range msg.ToolCalls {
+		view.Calls = append(view.Calls, keyedCall{ID: tc.ID, Name: tc.Function.Name, Args: string(tc.Function.Arguments)})
+	}
+	data, _ := json.Marshal(view)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// messageText returns the content of a message and its text parts.
+func messageText(msg model.Message) string {
+	text := msg.Content
+	for _, part := range msg.ContentParts {
+		if part.Type == model.ContentTypeText && part.Text != nil {
+			if text != "" {
+				text += "\n"
+			}
+			text += *part.Text
+		}
+	}
+	return text
+}
+
+func isBuiltinToolEvent(eventType string) bool {
+	for _, prefix := range builtinToolEventPrefixes {
+		if strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// sendResponse sends a response unless ctx is done, reporting whether it
+// was sent.
+func sendResponse(ctx context.Context, responseChan chan<- *model.Response, response *model.Response) bool {
+	select {
+	case responseChan <- response:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
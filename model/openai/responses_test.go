@@ -0,0 +1,340 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openai/openai-go/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// responsesServer serves canned Responses API replies and records request
+// bodies.
+type responsesServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []map[string]any
+	reply  func(n int, w http.ResponseWriter)
+}
+
+func newResponsesServer(t *testing.T, reply func(n int, w http.ResponseWriter)) *responsesServer {
+	s := &responsesServer{reply: reply}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/responses", r.URL.Path)
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(data, &body))
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		n := len(s.bodies)
+		s.mu.Unlock()
+		s.reply(n, w)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, body)
+}
+
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		var head struct {
+			Type string `json:"type"`
+		}
+		_ = json.Unmarshal([]byte(e), &head)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", head.Type, e)
+	}
+}
+
+const toolCallResponse = `{
+  "id": "resp_1", "object": "response", "created_at": 1700000000, "status": "completed", "model": "o4-mini",
+  "output": [
+    {"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "need weather"}], "encrypted_content": "enc"},
+    {"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "weather", "arguments": "{\"city\":\"Paris\"}", "status": "completed"}
+  ],
+  "usage": {"input_tokens": 10, "output_tokens": 5, "total_tokens": 15, "input_tokens_details": {"cached_tokens": 0}, "output_tokens_details": {"reasoning_tokens": 3}}
+}`
+
+const textResponse = `{
+  "id": "resp_2", "object": "response", "created_at": 1700000001, "status": "completed", "model": "o4-mini",
+  "output": [
+    {"type": "message", "id": "msg_1", "role": "assistant", "status": "completed",
+     "content": [{"type": "output_text", "text": "It is sunny.", "annotations": []}]}
+  ],
+  "usage": {"input_tokens": 20, "output_tokens": 4, "total_tokens": 24, "input_tokens_details": {"cached_tokens": 0}, "output_tokens_details": {"reasoning_tokens": 0}}
+}`
+
+func collectResponses(t *testing.T, ch <-chan *model.Response) []*model.Response {
+	t.Helper()
+	var out []*model.Response
+	for r := range ch {
+		out = append(out, r)
+	}
+	return out
+}
+
+func weatherRequest() *model.Request {
+	return &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage("be brief"),
+			model.NewUserMessage("weather in Paris?"),
+		},
+		Tools: map[string]tool.Tool{"weather": stubTool{decl: &tool.Declaration{
+			Name:        "weather",
+			Description: "look up the weather",
+			InputSchema: &tool.Schema{Type: "object"},
+		}}},
+	}
+}
+
+func TestResponses_NonStreaming(t *testing.T) {
+	srv := newResponsesServer(t, func(n int, w http.ResponseWriter) { writeJSON(w, toolCallResponse) })
+	m := New("o4-mini", WithAPI(APIResponses), WithBaseURL(srv.URL), WithAPIKey("k"),
+		WithResponsesStore(false), WithEncryptedReasoning(true))
+
+	req := weatherRequest()
+	effort := "low"
+	req.ReasoningEffort = &effort
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	rsps := collectResponses(t, ch)
+	require.Len(t, rsps, 1)
+
+	rsp := rsps[0]
+	require.Nil(t, rsp.Error)
+	assert.Equal(t, "resp_1", rsp.ID)
+	assert.Equal(t, &model.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, rsp.Usage)
+	msg := rsp.Choices[0].Message
+	assert.Equal(t, "need weather", msg.ReasoningContent)
+	require.Len(t, msg.ToolCalls, 1)
+	assert.Equal(t, "call_1", msg.ToolCalls[0].ID)
+	assert.Equal(t, "weather", msg.ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"city":"Paris"}`, string(msg.ToolCalls[0].Function.Arguments))
+	assert.Equal(t, "tool_calls", *rsp.Choices[0].FinishReason)
+
+	body := srv.bodies[0]
+	assert.Equal(t, "o4-mini", body["model"])
+	assert.Equal(t, "be brief", body["instructions"])
+	assert.Equal(t, false, body["store"])
+	assert.Equal(t, []any{"reasoning.encrypted_content"}, body["include"])
+	assert.Equal(t, map[string]any{"effort": "low"}, body["reasoning"])
+	input := body["input"].([]any)
+	require.Len(t, input, 1)
+	assert.Equal(t, "user", input[0].(map[string]any)["role"])
+	tools := body["tools"].([]any)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "function", tools[0].(map[string]any)["type"])
+	assert.Equal(t, "weather", tools[0].(map[string]any)["name"])
+}
+
+func TestResponses_ReasoningPassthrough(t *testing.T) {
+	srv := newResponsesServer(t, func(n int, w http.ResponseWriter) {
+		if n == 1 {
+			writeJSON(w, toolCallResponse)
+			return
+		}
+		writeJSON(w, textResponse)
+	})
+	m := New("o4-mini", WithAPI(APIResponses), WithBaseURL(srv.URL), WithAPIKey("k"),
+		WithResponsesStore(false), WithEncryptedReasoning(true))
+
+	req := weatherRequest()
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	first := collectResponses(t, ch)[0]
+
+	// The framework replays the assistant turn and the tool result.
+	req.Messages = append(req.Messages, first.Choices[0].Message, model.NewToolMessage("call_1", "weather", "sunny"))
+	ch, err = m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	second := collectResponses(t, ch)[0]
+	assert.Equal(t, "It is sunny.", second.Choices[0].Message.Content)
+	assert.Equal(t, "stop", *second.Choices[0].FinishReason)
+
+	body := srv.bodies[1]
+	assert.Nil(t, body["previous_response_id"])
+	input := body["input"].([]any)
+	require.Len(t, input, 4)
+	var types []string
+	for _, item := range input {
+		fields := item.(map[string]any)
+		typ, _ := fields["type"].(string)
+		if typ == "" {
+			typ, _ = fields["role"].(string)
+		}
+		types = append(types, typ)
+	}
+	assert.Equal(t, []string{"user", "reasoning", "function_call", "function_call_output"}, types)
+	reasoning := input[1].(map[string]any)
+	assert.Equal(t, "rs_1", reasoning["id"])
+	assert.Equal(t, "enc", reasoning["encrypted_content"])
+	assert.Equal(t, "call_1", input[3].(map[string]any)["call_id"])
+	assert.Equal(t, "sunny", input[3].(map[string]any)["output"])
+}
+
+func TestResponses_Chaining(t *testing.T) {
+	srv := newResponsesServer(t, func(n int, w http.ResponseWriter) {
+		if n == 1 {
+			writeJSON(w, toolCallResponse)
+			return
+		}
+		writeJSON(w, textResponse)
+	})
+	m := New("o4-mini", WithAPI(APIResponses), WithBaseURL(srv.URL), WithAPIKey("k"), WithResponsesChaining(true))
+
+	req := weatherRequest()
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	first := collectResponses(t, ch)[0]
+
+	// A session round trip drops reasoning content; chaining still applies.
+	replayed := first.Choices[0].Message
+	replayed.ReasoningContent = ""
+	req.Messages = append(req.Messages, replayed, model.NewToolMessage("call_1", "weather", "sunny"))
+	ch, err = m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	collectResponses(t, ch)
+
+	body := srv.bodies[1]
+	assert.Equal(t, "resp_1", body["previous_response_id"])
+	assert.Equal(t, "be brief", body["instructions"])
+	input := body["input"].([]any)
+	require.Len(t, input, 1)
+	assert.Equal(t, "function_call_output", input[0].(map[string]any)["type"])
+
+	// A different conversation is not chained.
+	other := &model.Request{Messages: []model.Message{
+		model.NewUserMessage("hello"),
+		model.NewAssistantMessage("It is sunny."),
+		model.NewUserMessage("thanks"),
+	}}
+	ch, err = m.GenerateContent(context.Background(), other)
+	require.NoError(t, err)
+	collectResponses(t, ch)
+	assert.Nil(t, srv.bodies[2]["previous_response_id"])
+	assert.Len(t, srv.bodies[2]["input"], 3)
+}
+
+func TestResponses_Streaming(t *testing.T) {
+	completed := strings.ReplaceAll(toolCallResponse, "\n", "")
+	srv := newResponsesServer(t, func(n int, w http.ResponseWriter) {
+		writeSSE(w,
+			`{"type":"response.created","sequence_number":0,"response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"in_progress","model":"o4-mini","output":[]}}`,
+			`{"type":"response.reasoning_summary_text.delta","sequence_number":1,"item_id":"rs_1","output_index":0,"summary_index":0,"delta":"need "}`,
+			`{"type":"response.output_text.delta","sequence_number":2,"item_id":"msg_1","output_index":1,"content_index":0,"delta":"Checking"}`,
+			`{"type":"response.web_search_call.searching","sequence_number":3,"item_id":"ws_1","output_index":2}`,
+			`{"type":"response.function_call_arguments.delta","sequence_number":4,"item_id":"fc_1","output_index":3,"delta":"{\"city\""}`,
+			`{"type":"response.completed","sequence_number":5,"response":`+completed+`}`,
+		)
+	})
+	var events []string
+	m := New("o4-mini", WithAPI(APIResponses), WithBaseURL(srv.URL), WithAPIKey("k"),
+		WithBuiltinTools(responses.ToolParamOfWebSearchPreview(responses.WebSearchToolTypeWebSearchPreview)),
+		WithResponsesEventCallback(func(_ context.Context, _ *responses.ResponseNewParams, e *responses.ResponseStreamEventUnion) {
+			events = append(events, e.Type)
+		}),
+	)
+	req := weatherRequest()
+	req.Stream = true
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	rsps := collectResponses(t, ch)
+	require.Len(t, rsps, 4)
+
+	assert.True(t, rsps[0].IsPartial)
+	assert.Equal(t, "need ", rsps[0].Choices[0].Delta.ReasoningContent)
+	assert.Equal(t, "resp_1", rsps[0].ID)
+	assert.Equal(t, "Checking", rsps[1].Choices[0].Delta.Content)
+	assert.Equal(t, model.ObjectTypeChatCompletionChunk, rsps[1].Object)
+	assert.Equal(t, "response.web_search_call.searching", rsps[2].Object)
+	assert.True(t, rsps[2].IsPartial)
+
+	final := rsps[3]
+	assert.False(t, final.IsPartial)
+	assert.False(t, final.Done)
+	assert.True(t, final.IsToolCallResponse())
+	assert.Len(t, events, 6)
+
+	body := srv.bodies[0]
+	assert.Equal(t, true, body["stream"])
+	tools := body["tools"].([]any)
+	require.Len(t, tools, 2)
+	assert.Equal(t, "web_search_preview", tools[1].(map[string]any)["type"])
+}
+
+func TestResponses_Errors(t *testing.T) {
+	t.Run("stream error event", func(t *testing.T) {
+		srv := newResponsesServer(t, func(n int, w http.ResponseWriter) {
+			writeSSE(w, `{"type":"error","sequence_number":0,"code":"rate_limit_exceeded","message":"slow down","param":null}`)
+		})
+		m := New("o4-mini", WithAPI(APIResponses), WithBaseURL(srv.URL), WithAPIKey("k"))
+		ch, err := m.GenerateContent(context.Background(), &model.Request{
+			Messages:         []model.Message{model.NewUserMessage("hi")},
+			GenerationConfig: model.GenerationConfig{Stream: true},
+		})
+		require.NoError(t, err)
+		rsps := collectResponses(t, ch)
+		require.Len(t, rsps, 1)
+		require.NotNil(t, rsps[0].Error)
+		assert.Equal(t, "slow down", rsps[0].Error.Message)
+		assert.Equal(t, "rate_limit_exceeded", *rsps[0].Error.Code)
+	})
+
+	t.Run("failed response", func(t *testing.T) {
+		srv := newResponsesServer(t, func(n int, w http.ResponseWriter) {
+			writeJSON(w, `{"id":"resp_x","object":"response","created_at":1,"status":"failed","model":"o4-mini","output":[],
+				"error":{"code":"server_error","message":"boom"}}`)
+		})
+		m := New("o4-mini", WithAPI(APIResponses), WithBaseURL(srv.URL), WithAPIKey("k"))
+		ch, err := m.GenerateContent(context.Background(), &model.Request{
+			Messages: []model.Message{model.NewUserMessage("hi")},
+		})
+		require.NoError(t, err)
+		rsps := collectResponses(t, ch)
+		require.Len(t, rsps, 1)
+		require.NotNil(t, rsps[0].Error)
+		assert.Equal(t, "boom", rsps[0].Error.Message)
+		assert.True(t, rsps[0].Done)
+	})
+
+	t.Run("incomplete response", func(t *testing.T) {
+		srv := newResponsesServer(t, func(n int, w http.ResponseWriter) {
+			writeJSON(w, `{"id":"resp_y","object":"response","created_at":1,"status":"incomplete","model":"o4-mini",
+				"incomplete_details":{"reason":"max_output_tokens"},
+				"output":[{"type":"message","id":"m","role":"assistant","status":"incomplete","content":[{"type":"output_text","text":"partial","annotations":[]}]}]}`)
+		})
+		m := New("o4-mini", WithAPI(APIResponses), WithBaseURL(srv.URL), WithAPIKey("k"))
+		ch, err := m.GenerateContent(context.Background(), &model.Request{
+			Messages: []model.Message{model.NewUserMessage("hi")},
+		})
+		require.NoError(t, err)
+		rsps := collectResponses(t, ch)
+		require.Len(t, rsps, 1)
+		assert.Equal(t, "partial", rsps[0].Choices[0].Message.Content)
+		assert.Equal(t, "length", *rsps[0].Choices[0].FinishReason)
+	})
+}
@@ -0,0 +1,247 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is wrapped by the errors returned when a call cannot be
+// admitted before its deadline or maximum wait.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Limits configures a Limiter. Zero values mean no limit.
+type Limits struct {
+	// RequestsPerMinute limits the rate of model calls.
+	RequestsPerMinute int
+	// TokensPerMinute limits the rate of estimated tokens. The estimate is
+	// corrected with the reported usage once a call completes.
+	TokensPerMinute int
+	// MaxConcurrent limits the number of calls streaming at the same time.
+	MaxConcurrent int
+}
+
+// bucket is a token bucket refilled continuously. Its level may go negative
+// when reservations are made ahead of time.
+type bucket struct {
+	capacity float64
+	level    float64
+	rate     float64 // per second
+	last     time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	return &bucket{
+		capacity: float64(perMinute),
+		level:    float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.level = math.Min(b.capacity, b.level+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// wait returns how long to wait until n units are available.
+func (b *bucket) wait(n float64) time.Duration {
+	if b.level >= n {
+		return 0
+	}
+	return time.Duration((n - b.level) / b.rate * float64(time.Second))
+}
+
+// Limiter admits model calls under request, token and concurrency limits.
+// It is safe for concurrent use and can be shared by several models.
+type Limiter struct {
+	key    string
+	limits Limits
+
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+	slots    chan struct{}
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error // replaced in tests
+}
+
+// NewLimiter creates a limiter.
+func NewLimiter(limits Limits) *Limiter {
+	return newLimiter("", limits, time.Now)
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = make(map[string]*Limiter)
+)
+
+// Shared returns the limiter registered under key, creating it with limits
+// on first use. Models configured with the same key share quotas, e.g. all
+// models using the same provider account. Limits passed after the first
+// call are ignored.
+func Shared(key string, limits Limits) *Limiter {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if l, ok := shared[key]; ok {
+		return l
+	}
+	l := newLimiter(key, limits, time.Now)
+	shared[key] = l
+	return l
+}
+
+func newLimiter(key string, limits Limits, now func() time.Time) *Limiter {
+	l := &Limiter{key: key, limits: limits, now: now, sleep: sleepContext}
+	if limits.RequestsPerMinute > 0 {
+		l.requests = newBucket(limits.RequestsPerMinute, now())
+	}
+	if limits.TokensPerMinute > 0 {
+		l.tokens = newBucket(limits.TokensPerMinute, now())
+	}
+	if limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return l
+}
+
+// Key returns the key of a shared limiter, empty otherwise.
+func (l *Limiter) Key() string {
+	return l.key
+}
+
+// Limits returns the configured limits.
+func (l *Limiter) Limits() Limits {
+	return l.limits
+}
+
+// Acquire waits until a call estimated at tokens can start. It fails without
+// waiting when the required wait would exceed the deadline of ctx. The
+// returned release function must be called once the call completes, with
+// the actual token usage or 0 when unknown.
+func (l *Limiter) Acquire(ctx context.Context, tokens int) (release func(actualTokens int), waited time.Duration, err error) {
+	reserved, wait, err := l.reserve(ctx, tokens)
+	if err != nil {
+		return nil, 0, err
+	}
+	start := l.now()
+	if wait > 0 {
+		if err := l.sleep(ctx, wait); err != nil {
+			l.refund(reserved)
+			return nil, l.now().Sub(start), fmt.Errorf("%w: %v", ErrRateLimited, err)
+		}
+		waited = l.now().Sub(start)
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			select {
+			case l.slots <- struct{}{}:
+				waited = l.now().Sub(start)
+			case <-ctx.Done():
+				l.refund(reserved)
+				return nil, l.now().Sub(start), fmt.Errorf("%w: waiting for a concurrency slot: %v", ErrRateLimited, ctx.Err())
+			}
+		}
+	}
+	var once sync.Once
+	release = func(actualTokens int) {
+		once.Do(func() {
+			if l.slots != nil {
+				<-l.slots
+			}
+			if actualTokens > 0 {
+				l.adjust(reserved - float64(actualTokens))
+			}
+		})
+	}
+	return release, waited, nil
+}
+
+// reserve takes a request and tokens from the buckets and returns the
+// reserved token count and the wait until they are available.
+func (l *Limiter) reserve(ctx context.Context, tokens int) (float64, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var wait time.Duration
+	if l.requests != nil {
+		l.requests.refill(now)
+		wait = l.requests.wait(1)
+	}
+	// A call larger than the bucket could never start; charge a full bucket.
+	n := float64(max(tokens, 0))
+	if l.tokens != nil {
+		n = math.Min(n, l.tokens.capacity)
+		l.tokens.refill(now)
+		wait = max(wait, l.tokens.wait(n))
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		return 0, 0, fmt.Errorf("%w%s: need to wait %s, deadline in %s",
+			ErrRateLimited, l.describe(), wait.Round(time.Millisecond), deadline.Sub(now).Round(time.Millisecond))
+	}
+	if l.requests != nil {
+		l.requests.level--
+	}
+	if l.tokens != nil {
+		l.tokens.level -= n
+	}
+	return n, wait, nil
+}
+
+// refund returns a reservation that was not used.
+func (l *Limiter) refund(tokens float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.requests != nil {
+		l.requests.level = math.Min(l.requests.capacity, l.requests.level+1)
+	}
+	if l.tokens != nil {
+		l.tokens.level = math.Min(l.tokens.capacity, l.tokens.level+tokens)
+	}
+}
+
+// adjust corrects the token bucket by delta once the actual usage is known.
+func (l *Limiter) adjust(delta float64) {
+	if l.tokens == nil || delta == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens.refill(l.now())
+	l.tokens.level = math.Min(l.tokens.capacity, l.tokens.level+delta)
+}
+
+func (l *Limiter) describe() string {
+	if l.key == "" {
+		return ""
+	}
+	return fmt.Sprintf(" for %q", l.key)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,196 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manual clock; sleeping advances it.
+type fakeClock struct {
+	mu     sync.Mutex
+	t      time.Time
+	slept  []time.Duration
+	failOn error
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func (c *fakeClock) sleep(_ context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failOn != nil {
+		return c.failOn
+	}
+	c.slept = append(c.slept, d)
+	c.t = c.t.Add(d)
+	return nil
+}
+
+func newTestLimiter(limits Limits) (*Limiter, *fakeClock) {
+	clock := newFakeClock()
+	l := newLimiter("test", limits, clock.now)
+	l.sleep = clock.sleep
+	return l, clock
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	l := NewLimiter(Limits{})
+	for i := 0; i < 100; i++ {
+		release, waited, err := l.Acquire(context.Background(), 1000000)
+		require.NoError(t, err)
+		assert.Zero(t, waited)
+		release(10)
+	}
+	assert.Empty(t, l.Key())
+}
+
+func TestLimiter_RequestsPerMinute(t *testing.T) {
+	l, clock := newTestLimiter(Limits{RequestsPerMinute: 60})
+	for i := 0; i < 60; i++ {
+		release, _, err := l.Acquire(context.Background(), 0)
+		require.NoError(t, err)
+		release(0)
+	}
+	assert.Empty(t, clock.slept)
+
+	// The bucket is empty; one request refills every second.
+	_, waited, err := l.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second}, clock.slept)
+	assert.Equal(t, time.Second, waited)
+
+	clock.advance(30 * time.Second)
+	_, waited, err = l.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Zero(t, waited)
+}
+
+func TestLimiter_TokensPerMinute(t *testing.T) {
+	l, clock := newTestLimiter(Limits{TokensPerMinute: 600})
+	release, _, err := l.Acquire(context.Background(), 500)
+	require.NoError(t, err)
+	// The call used less than estimated; the difference is returned.
+	release(200)
+
+	_, waited, err := l.Acquire(context.Background(), 400)
+	require.NoError(t, err)
+	assert.Zero(t, waited)
+
+	// The bucket is empty and 10 tokens refill per second.
+	_, waited, err = l.Acquire(context.Background(), 300)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, waited)
+	assert.Len(t, clock.slept, 1)
+}
+
+func TestLimiter_TokensClampedToCapacity(t *testing.T) {
+	l, clock := newTestLimiter(Limits{TokensPerMinute: 100})
+	_, waited, err := l.Acquire(context.Background(), 1000)
+	require.NoError(t, err)
+	assert.Zero(t, waited)
+	assert.Empty(t, clock.slept)
+}
+
+func TestLimiter_DeadlineFailsFast(t *testing.T) {
+	l, clock := newTestLimiter(Limits{RequestsPerMinute: 1})
+	_, _, err := l.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithDeadline(context.Background(), clock.now().Add(10*time.Second))
+	defer cancel()
+	_, _, err = l.Acquire(ctx, 0)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Contains(t, err.Error(), `for "test"`)
+	assert.Empty(t, clock.slept)
+
+	// The rejected call did not consume capacity.
+	clock.advance(time.Minute)
+	_, waited, err := l.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Zero(t, waited)
+}
+
+func TestLimiter_CancelledWaitRefunds(t *testing.T) {
+	l, clock := newTestLimiter(Limits{TokensPerMinute: 60})
+	_, _, err := l.Acquire(context.Background(), 60)
+	require.NoError(t, err)
+
+	clock.failOn = context.Canceled
+	_, _, err = l.Acquire(context.Background(), 30)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	clock.failOn = nil
+	clock.advance(30 * time.Second)
+	_, waited, err := l.Acquire(context.Background(), 30)
+	require.NoError(t, err)
+	assert.Zero(t, waited)
+}
+
+func TestLimiter_MaxConcurrent(t *testing.T) {
+	l := NewLimiter(Limits{MaxConcurrent: 1})
+	release, _, err := l.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, err = l.Acquire(ctx, 0)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	acquired := make(chan struct{})
+	go func() {
+		r, _, err := l.Acquire(context.Background(), 0)
+		if err == nil {
+			r(0)
+		}
+		close(acquired)
+	}()
+	release(0)
+	release(0) // Releasing twice is a no-op.
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("slot was not released")
+	}
+	assert.Empty(t, l.slots)
+}
+
+func TestShared(t *testing.T) {
+	a := Shared("shared-test", Limits{RequestsPerMinute: 10})
+	b := Shared("shared-test", Limits{RequestsPerMinute: 99})
+	assert.Same(t, a, b)
+	assert.Equal(t, 10, b.Limits().RequestsPerMinute)
+	assert.Equal(t, "shared-test", b.Key())
+	assert.NotSame(t, a, Shared("shared-test-other", Limits{}))
+}
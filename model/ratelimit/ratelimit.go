@@ -0,0 +1,262 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package ratelimit provides a model.Model decorator enforcing client-side
+// request, token and concurrency limits, so that parallel agents, graph
+// fan-out and parallel tools stay within provider quotas.
+//
+//	m := ratelimit.New(openai.New("gpt-4o"),
+//		ratelimit.WithSharedLimits("openai-prod", ratelimit.Limits{
+//			RequestsPerMinute: 500,
+//			TokensPerMinute:   200000,
+//			MaxConcurrent:     16,
+//		}))
+//
+// Calls wait for capacity. A call whose wait would exceed the context
+// deadline, or the configured maximum wait, fails at once with an error
+// wrapping ErrRateLimited.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	itelemetry "trpc.group/trpc-go/trpc-agent-go/internal/telemetry"
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	ametric "trpc.group/trpc-go/trpc-agent-go/telemetry/metric"
+)
+
+// defaultChannelBufferSize is the default channel buffer size.
+const defaultChannelBufferSize = 256
+
+// Outcomes recorded by the requests metric.
+const (
+	outcomeAdmitted = "admitted"
+	outcomeRejected = "rejected"
+)
+
+// options contains configuration options for creating a Model.
+type options struct {
+	limiter           *Limiter
+	counter           model.TokenCounter
+	maxWait           time.Duration
+	channelBufferSize int
+}
+
+// Option is a function that configures a rate limited Model.
+type Option func(*options)
+
+// WithLimits limits the model with a limiter of its own.
+func WithLimits(limits Limits) Option {
+	return func(opts *options) {
+		opts.limiter = NewLimiter(limits)
+	}
+}
+
+// WithSharedLimits limits the model with the limiter shared under key. See
+// Shared.
+func WithSharedLimits(key string, limits Limits) Option {
+	return func(opts *options) {
+		opts.limiter = Shared(key, limits)
+	}
+}
+
+// WithLimiter limits the model with l.
+func WithLimiter(l *Limiter) Option {
+	return func(opts *options) {
+		opts.limiter = l
+	}
+}
+
+// WithTokenCounter sets the counter estimating the tokens of a request.
+// Defaults to model.SimpleTokenCounter. A model.RequestTokenCounter also
+// counts tool declarations.
+func WithTokenCounter(counter model.TokenCounter) Option {
+	return func(opts *options) {
+		opts.counter = counter
+	}
+}
+
+// WithMaxWait bounds the time a call waits for capacity, in addition to the
+// context deadline. Zero, the default, waits as long as the context allows.
+func WithMaxWait(d time.Duration) Option {
+	return func(opts *options) {
+		opts.maxWait = d
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.channelBufferSize = size
+	}
+}
+
+// Model rate limits a wrapped model.
+type Model struct {
+	model             model.Model
+	limiter           *Limiter
+	counter           model.TokenCounter
+	maxWait           time.Duration
+	channelBufferSize int
+}
+
+// New wraps m with a rate limiter. Without a limit option the model is not
+// limited.
+func New(m model.Model, opts ...Option) *Model {
+	o := &options{channelBufferSize: defaultChannelBufferSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.limiter == nil {
+		o.limiter = NewLimiter(Limits{})
+	}
+	if o.counter == nil {
+		o.counter = model.NewSimpleTokenCounter()
+	}
+	return &Model{
+		model:             m,
+		limiter:           o.limiter,
+		counter:           o.counter,
+		maxWait:           o.maxWait,
+		channelBufferSize: o.channelBufferSize,
+	}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return m.model.Info()
+}
+
+// Limiter returns the limiter of the model.
+func (m *Model) Limiter() *Limiter {
+	return m.limiter
+}
+
+// GenerateContent implements the model.Model interface. It waits for
+// capacity, calls the wrapped model and holds a concurrency slot until the
+// response stream is closed.
+func (m *Model) GenerateContent(ctx context.Context, request *model.Request) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	attrs := metric.WithAttributes(
+		attribute.String(itelemetry.KeyRateLimitKey, m.limiter.Key()),
+		attribute.String(itelemetry.KeyGenAIRequestModel, m.model.Info().Name),
+	)
+	inst := getInstruments()
+
+	waitCtx := ctx
+	if m.maxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, m.maxWait)
+		defer cancel()
+	}
+	release, waited, err := m.limiter.Acquire(waitCtx, m.estimate(ctx, request))
+	inst.wait.Record(ctx, waited.Seconds(), attrs)
+	if err != nil {
+		inst.requests.Add(ctx, 1, attrs, metric.WithAttributes(attribute.String(itelemetry.KeyRateLimitOutcome, outcomeRejected)))
+		return nil, err
+	}
+	inst.requests.Add(ctx, 1, attrs, metric.WithAttributes(attribute.String(itelemetry.KeyRateLimitOutcome, outcomeAdmitted)))
+	inst.inFlight.Add(ctx, 1, attrs)
+	done := func(actualTokens int) {
+		release(actualTokens)
+		inst.inFlight.Add(context.WithoutCancel(ctx), -1, attrs)
+	}
+
+	src, err := m.model.GenerateContent(ctx, request)
+	if err != nil {
+		done(0)
+		return nil, err
+	}
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		actual := 0
+		defer func() { done(actual) }()
+		for rsp := range src {
+			if rsp != nil && !rsp.IsPartial && rsp.Usage != nil {
+				actual = max(rsp.Usage.TotalTokens, rsp.Usage.PromptTokens+rsp.Usage.CompletionTokens)
+			}
+			select {
+			case responseChan <- rsp:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return responseChan, nil
+}
+
+// estimate returns the tokens charged for a request before it runs: its
+// input and the requested output tokens.
+func (m *Model) estimate(ctx context.Context, request *model.Request) int {
+	tokens := model.RequestOverheadTokens(ctx, m.counter, request)
+	if len(request.Messages) > 0 {
+		n, err := m.counter.CountTokensRange(ctx, request.Messages, 0, len(request.Messages))
+		if err != nil {
+			log.Warnf("ratelimit: counting request tokens: %v", err)
+		}
+		tokens += n
+	}
+	if request.MaxTokens != nil {
+		tokens += *request.MaxTokens
+	}
+	return tokens
+}
+
+// instruments are the rate limiter metrics.
+type instruments struct {
+	requests metric.Int64Counter
+	wait     metric.Float64Histogram
+	inFlight metric.Int64UpDownCounter
+}
+
+var (
+	instrumentsOnce sync.Once
+	globalInst      *instruments
+)
+
+// getInstruments creates the instruments on first use, from the meter set
+// up by telemetry/metric.Start.
+func getInstruments() *instruments {
+	instrumentsOnce.Do(func() {
+		meter := ametric.Meter
+		inst := &instruments{}
+		var err error
+		if inst.requests, err = meter.Int64Counter(itelemetry.MetricRateLimitRequests,
+			metric.WithDescription("Model calls admitted or rejected by the rate limiter.")); err != nil {
+			log.Warnf("ratelimit: create requests counter: %v", err)
+			inst.requests = noop.Int64Counter{}
+		}
+		if inst.wait, err = meter.Float64Histogram(itelemetry.MetricRateLimitWaitDuration,
+			metric.WithDescription("Time model calls waited for rate limiter capacity."),
+			metric.WithUnit("s")); err != nil {
+			log.Warnf("ratelimit: create wait histogram: %v", err)
+			inst.wait = noop.Float64Histogram{}
+		}
+		if inst.inFlight, err = meter.Int64UpDownCounter(itelemetry.MetricRateLimitInFlight,
+			metric.WithDescription("Model calls currently holding a rate limiter slot.")); err != nil {
+			log.Warnf("ratelimit: create in-flight counter: %v", err)
+			inst.inFlight = noop.Int64UpDownCounter{}
+		}
+		globalInst = inst
+	})
+	return globalInst
+}
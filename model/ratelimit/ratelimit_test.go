@@ -0,0 +1,125 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// streamModel streams a partial and a final response reporting usage. The
+// final response is held until unblock is closed, when set.
+type streamModel struct {
+	usage   int
+	unblock chan struct{}
+	err     error
+}
+
+func (m *streamModel) Info() model.Info { return model.Info{Name: "stream"} }
+
+func (m *streamModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	ch := make(chan *model.Response, 2)
+	go func() {
+		defer close(ch)
+		ch <- &model.Response{IsPartial: true}
+		if m.unblock != nil {
+			<-m.unblock
+		}
+		ch <- &model.Response{Done: true, Usage: &model.Usage{TotalTokens: m.usage}}
+	}()
+	return ch, nil
+}
+
+func drain(t *testing.T, ch <-chan *model.Response) []*model.Response {
+	t.Helper()
+	var rsps []*model.Response
+	for rsp := range ch {
+		rsps = append(rsps, rsp)
+	}
+	return rsps
+}
+
+func TestModel_Passthrough(t *testing.T) {
+	m := New(&streamModel{usage: 5})
+	assert.Equal(t, "stream", m.Info().Name)
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+	})
+	require.NoError(t, err)
+	assert.Len(t, drain(t, ch), 2)
+
+	_, err = m.GenerateContent(context.Background(), nil)
+	assert.Error(t, err)
+}
+
+func TestModel_ConcurrencySlotHeldUntilStreamEnds(t *testing.T) {
+	inner := &streamModel{unblock: make(chan struct{})}
+	m := New(inner, WithLimits(Limits{MaxConcurrent: 1}), WithMaxWait(20*time.Millisecond))
+	ch, err := m.GenerateContent(context.Background(), &model.Request{})
+	require.NoError(t, err)
+
+	_, err = m.GenerateContent(context.Background(), &model.Request{})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	close(inner.unblock)
+	drain(t, ch)
+	ch, err = m.GenerateContent(context.Background(), &model.Request{})
+	require.NoError(t, err)
+	drain(t, ch)
+}
+
+func TestModel_ChargesEstimateAndCorrectsWithUsage(t *testing.T) {
+	l, clock := newTestLimiter(Limits{TokensPerMinute: 1000})
+	maxTokens := 400
+	m := New(&streamModel{usage: 100}, WithLimiter(l))
+	assert.Same(t, l, m.Limiter())
+
+	req := &model.Request{
+		Messages:         []model.Message{model.NewUserMessage("hello")},
+		GenerationConfig: model.GenerationConfig{MaxTokens: &maxTokens},
+	}
+	estimate := m.estimate(context.Background(), req)
+	assert.Greater(t, estimate, maxTokens)
+
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	drain(t, ch)
+
+	// Only the reported 100 tokens stay charged, so 900 are available.
+	_, waited, err := l.Acquire(context.Background(), 900)
+	require.NoError(t, err)
+	assert.Zero(t, waited)
+	assert.Empty(t, clock.slept)
+}
+
+func TestModel_InnerErrorReleases(t *testing.T) {
+	m := New(&streamModel{err: errors.New("boom")}, WithLimits(Limits{MaxConcurrent: 1}))
+	for i := 0; i < 2; i++ {
+		_, err := m.GenerateContent(context.Background(), &model.Request{})
+		require.EqualError(t, err, "boom")
+	}
+}
+
+func TestModel_SharedLimits(t *testing.T) {
+	a := New(&streamModel{}, WithSharedLimits("model-shared-test", Limits{MaxConcurrent: 1}))
+	b := New(&streamModel{}, WithSharedLimits("model-shared-test", Limits{MaxConcurrent: 1}))
+	assert.Same(t, a.Limiter(), b.Limiter())
+}
@@ -0,0 +1,96 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// cassetteVersion is the current cassette file format version.
+const cassetteVersion = 1
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	// Version is the file format version.
+	Version int `json:"version"`
+	// Model is the name of the recorded model.
+	Model string `json:"model,omitempty"`
+	// Interactions are the recorded calls in recording order.
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded GenerateContent call.
+type Interaction struct {
+	// Key is the normalized hash used for matching.
+	Key string `json:"key"`
+	// Request is the recorded request.
+	Request *RecordedRequest `json:"request"`
+	// Responses is the full stream of responses, including partial chunks.
+	Responses []*RecordedResponse `json:"responses"`
+}
+
+// RecordedRequest is the serializable part of a model.Request.
+type RecordedRequest struct {
+	Messages         []model.Message         `json:"messages"`
+	GenerationConfig model.GenerationConfig  `json:"generation_config"`
+	StructuredOutput *model.StructuredOutput `json:"structured_output,omitempty"`
+	// Tools are the declarations of the request tools, sorted by name.
+	Tools []*tool.Declaration `json:"tools,omitempty"`
+}
+
+// RecordedResponse is one response of a recorded stream.
+type RecordedResponse struct {
+	// DelayMs is the time elapsed since the previous response, or since the
+	// call for the first one.
+	DelayMs int64 `json:"delay_ms"`
+	// Response is the response as emitted by the model.
+	Response *model.Response `json:"response"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay: read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("replay: decode cassette %s: %w", path, err)
+	}
+	if c.Version > cassetteVersion {
+		return nil, fmt.Errorf("replay: unsupported cassette version %d", c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path atomically.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("replay: encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("replay: create cassette dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("replay: write cassette: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replay: write cassette: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package replay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Matching controls which parts of a request must match a recording.
+type Matching int
+
+const (
+	// MatchStrict requires messages, generation config, structured output
+	// and tool declarations to be equal.
+	MatchStrict Matching = iota
+	// MatchLenient only compares non-system messages, ignoring surrounding
+	// whitespace, reasoning content and tool call IDs. System prompts often
+	// embed the current time and are skipped for that reason.
+	MatchLenient
+)
+
+// NormalizerFunc rewrites a recorded request before it is hashed, e.g. to
+// mask volatile values. It is applied to recordings and incoming requests.
+type NormalizerFunc func(req *RecordedRequest)
+
+// newRecordedRequest captures the serializable part of a request. The
+// request goes through a JSON round trip so that recorded and incoming
+// requests compare the same way.
+func newRecordedRequest(req *model.Request) (*RecordedRequest, error) {
+	rec := &RecordedRequest{
+		Messages:         req.Messages,
+		GenerationConfig: req.GenerationConfig,
+		StructuredOutput: req.StructuredOutput,
+	}
+	names := make([]string, 0, len(req.Tools))
+	for name := range req.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := req.Tools[name]
+		if t == nil {
+			continue
+		}
+		if decl := t.Declaration(); decl != nil {
+			rec.Tools = append(rec.Tools, decl)
+		}
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	var out RecordedRequest
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// matchView returns the part of a request that takes part in matching.
+func matchView(rec *RecordedRequest, matching Matching, normalize NormalizerFunc) *RecordedRequest {
+	view := *rec
+	view.Messages = append([]model.Message(nil), rec.Messages...)
+	if normalize != nil {
+		normalize(&view)
+	}
+	if matching == MatchStrict {
+		return &view
+	}
+	lenient := &RecordedRequest{}
+	for _, msg := range view.Messages {
+		if msg.Role == model.RoleSystem {
+			continue
+		}
+		msg.Content = strings.TrimSpace(msg.Content)
+		msg.ReasoningContent = ""
+		msg.ToolID = ""
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]model.ToolCall, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				tc.ID = ""
+				calls[i] = tc
+			}
+			msg.ToolCalls = calls
+		}
+		lenient.Messages = append(lenient.Messages, msg)
+	}
+	return lenient
+}
+
+// requestKey hashes the match view of a request.
+func requestKey(view *RecordedRequest) string {
+	data, _ := json.Marshal(view)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// viewsEqual confirms a hash hit with a field by field comparison.
+func viewsEqual(a, b *RecordedRequest) bool {
+	if len(a.Messages) != len(b.Messages) {
+		return false
+	}
+	for i := range a.Messages {
+		if !model.MessagesEqual(a.Messages[i], b.Messages[i]) {
+			return false
+		}
+	}
+	return jsonEqual(a.GenerationConfig, b.GenerationConfig) &&
+		jsonEqual(a.StructuredOutput, b.StructuredOutput) &&
+		jsonEqual(a.Tools, b.Tools)
+}
+
+func jsonEqual(a, b any) bool {
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return string(da) == string(db)
+}
+
+// diffViews describes the differences between a recorded and an incoming
+// request, one line per difference.
+func diffViews(recorded, got *RecordedRequest) string {
+	var lines []string
+	n := max(len(recorded.Messages), len(got.Messages))
+	for i := 0; i < n; i++ {
+		switch {
+		case i >= len(recorded.Messages):
+			lines = append(lines, fmt.Sprintf("+ messages[%d]: %s", i, describeMessage(got.Messages[i])))
+		case i >= len(got.Messages):
+			lines = append(lines, fmt.Sprintf("- messages[%d]: %s", i, describeMessage(recorded.Messages[i])))
+		case !model.MessagesEqual(recorded.Messages[i], got.Messages[i]):
+			lines = append(lines,
+				fmt.Sprintf("- messages[%d]: %s", i, describeMessage(recorded.Messages[i])),
+				fmt.Sprintf("+ messages[%d]: %s", i, describeMessage(got.Messages[i])))
+		}
+	}
+	for _, f := range []struct {
+		name string
+		a, b any
+	}{
+		{"generation_config", recorded.GenerationConfig, got.GenerationConfig},
+		{"structured_output", recorded.StructuredOutput, got.StructuredOutput},
+		{"tools", recorded.Tools, got.Tools},
+	} {
+		if !jsonEqual(f.a, f.b) {
+			da, _ := json.Marshal(f.a)
+			db, _ := json.Marshal(f.b)
+			lines = append(lines, fmt.Sprintf("- %s: %s", f.name, da), fmt.Sprintf("+ %s: %s", f.name, db))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// similarity scores how close two requests are, used to pick the recording
+// shown in a mismatch diff.
+func similarity(a, b *RecordedRequest) int {
+	score := 0
+	for i := 0; i < len(a.Messages) && i < len(b.Messages); i++ {
+		if !model.MessagesEqual(a.Messages[i], b.Messages[i]) {
+			break
+		}
+		score++
+	}
+	return score
+}
+
+func describeMessage(msg model.Message) string {
+	data, _ := json.Marshal(msg)
+	const limit = 300
+	if len(data) > limit {
+		return string(data[:limit]) + "..."
+	}
+	return string(data)
+}
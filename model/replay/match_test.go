@@ -0,0 +1,67 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package replay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestMatchView_Lenient(t *testing.T) {
+	rec, err := newRecordedRequest(&model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage("now is 10:00"),
+			model.NewUserMessage(" hi "),
+			{Role: model.RoleAssistant, ReasoningContent: "think", ToolCalls: []model.ToolCall{
+				{ID: "call_1", Function: model.FunctionDefinitionParam{Name: "f"}},
+			}},
+			model.NewToolMessage("call_1", "f", "ok"),
+		},
+	})
+	require.NoError(t, err)
+	view := matchView(rec, MatchLenient, nil)
+	require.Len(t, view.Messages, 3)
+	assert.Equal(t, "hi", view.Messages[0].Content)
+	assert.Empty(t, view.Messages[1].ReasoningContent)
+	assert.Empty(t, view.Messages[1].ToolCalls[0].ID)
+	assert.Empty(t, view.Messages[2].ToolID)
+	// The recorded request itself is left untouched.
+	assert.Equal(t, "call_1", rec.Messages[2].ToolCalls[0].ID)
+}
+
+func TestMatchView_Normalizer(t *testing.T) {
+	a, _ := newRecordedRequest(&model.Request{Messages: []model.Message{model.NewUserMessage("id=1")}})
+	b, _ := newRecordedRequest(&model.Request{Messages: []model.Message{model.NewUserMessage("id=2")}})
+	mask := func(req *RecordedRequest) {
+		for i := range req.Messages {
+			req.Messages[i].Content = "id=*"
+		}
+	}
+	assert.NotEqual(t, requestKey(matchView(a, MatchStrict, nil)), requestKey(matchView(b, MatchStrict, nil)))
+	assert.Equal(t, requestKey(matchView(a, MatchStrict, mask)), requestKey(matchView(b, MatchStrict, mask)))
+	assert.Equal(t, "id=1", a.Messages[0].Content)
+}
+
+func TestDiffViews(t *testing.T) {
+	temp := 0.5
+	a, _ := newRecordedRequest(&model.Request{Messages: []model.Message{model.NewUserMessage("a")}})
+	b, _ := newRecordedRequest(&model.Request{
+		Messages:         []model.Message{model.NewUserMessage("a"), model.NewUserMessage("b")},
+		GenerationConfig: model.GenerationConfig{Temperature: &temp},
+	})
+	diff := diffViews(a, b)
+	assert.Contains(t, diff, `+ messages[1]: {"role":"user","content":"b"}`)
+	assert.Contains(t, diff, `+ generation_config:`)
+	assert.Equal(t, 1, similarity(a, b))
+}
@@ -0,0 +1,333 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package replay provides a model.Model that records the traffic of a real
+// model to a cassette file and replays it deterministically in tests.
+//
+// Record once against the real provider:
+//
+//	m, _ := replay.New("testdata/weather.json",
+//		replay.WithMode(replay.ModeRecord), replay.WithModel(openaiModel))
+//
+// Then replay without network access:
+//
+//	m, _ := replay.New("testdata/weather.json")
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// defaultChannelBufferSize is the default channel buffer size.
+const defaultChannelBufferSize = 256
+
+// Mode selects between recording and replaying.
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette.
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests to the wrapped model and records them.
+	ModeRecord
+)
+
+// ErrNoMatch is wrapped by the error returned when no recording matches
+// a request in replay mode.
+var ErrNoMatch = errors.New("replay: no matching recording")
+
+// MismatchError is returned when no recording matches a request. Diff
+// compares the request with the closest recording.
+type MismatchError struct {
+	// Key is the hash of the unmatched request.
+	Key string
+	// Diff lists the differences with the closest recording, empty when the
+	// cassette has no recording at all.
+	Diff string
+}
+
+// Error implements the error interface.
+func (e *MismatchError) Error() string {
+	if e.Diff == "" {
+		return fmt.Sprintf("%v for request %s", ErrNoMatch, e.Key)
+	}
+	return fmt.Sprintf("%v for request %s, closest recording differs:\n%s", ErrNoMatch, e.Key, e.Diff)
+}
+
+// Unwrap returns ErrNoMatch.
+func (e *MismatchError) Unwrap() error { return ErrNoMatch }
+
+// options contains configuration options for creating a Model.
+type options struct {
+	mode              Mode
+	model             model.Model
+	matching          Matching
+	normalizer        NormalizerFunc
+	realtime          bool
+	channelBufferSize int
+}
+
+// Option is a function that configures a replay Model.
+type Option func(*options)
+
+// WithMode sets the mode. Defaults to ModeReplay.
+func WithMode(mode Mode) Option {
+	return func(opts *options) {
+		opts.mode = mode
+	}
+}
+
+// WithModel sets the model that is recorded. Required in ModeRecord.
+func WithModel(m model.Model) Option {
+	return func(opts *options) {
+		opts.model = m
+	}
+}
+
+// WithMatching sets how requests are matched. Defaults to MatchStrict.
+func WithMatching(matching Matching) Option {
+	return func(opts *options) {
+		opts.matching = matching
+	}
+}
+
+// WithNormalizer sets a function applied to requests before matching.
+func WithNormalizer(fn NormalizerFunc) Option {
+	return func(opts *options) {
+		opts.normalizer = fn
+	}
+}
+
+// WithRealtime replays responses with their recorded timing instead of
+// emitting them immediately.
+func WithRealtime(enabled bool) Option {
+	return func(opts *options) {
+		opts.realtime = enabled
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.channelBufferSize = size
+	}
+}
+
+// Model records or replays model interactions.
+//
+// Interactions sharing the same key are replayed in recording order, and
+// the last one is reused once they are exhausted.
+type Model struct {
+	path              string
+	mode              Mode
+	model             model.Model
+	matching          Matching
+	normalizer        NormalizerFunc
+	realtime          bool
+	channelBufferSize int
+
+	mu       sync.Mutex
+	cassette *Cassette
+	// byKey indexes interactions by key, cursor tracks replay progress.
+	byKey  map[string][]*Interaction
+	cursor map[string]int
+}
+
+// New creates a replay model backed by the cassette at path. In replay mode
+// the cassette must exist. In record mode an existing cassette is replaced.
+func New(path string, opts ...Option) (*Model, error) {
+	o := &options{
+		mode:              ModeReplay,
+		matching:          MatchStrict,
+		channelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	m := &Model{
+		path:              path,
+		mode:              o.mode,
+		model:             o.model,
+		matching:          o.matching,
+		normalizer:        o.normalizer,
+		realtime:          o.realtime,
+		channelBufferSize: o.channelBufferSize,
+		byKey:             make(map[string][]*Interaction),
+		cursor:            make(map[string]int),
+	}
+	switch o.mode {
+	case ModeRecord:
+		if o.model == nil {
+			return nil, errors.New("replay: record mode requires WithModel")
+		}
+		m.cassette = &Cassette{Version: cassetteVersion, Model: o.model.Info().Name}
+	default:
+		c, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		m.cassette = c
+		for _, in := range c.Interactions {
+			view := matchView(in.Request, m.matching, m.normalizer)
+			key := requestKey(view)
+			m.byKey[key] = append(m.byKey[key], in)
+		}
+	}
+	return m, nil
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	if m.model != nil {
+		return m.model.Info()
+	}
+	return model.Info{Name: m.cassette.Model}
+}
+
+// Cassette returns the recorded interactions.
+func (m *Model) Cassette() *Cassette {
+	return m.cassette
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	rec, err := newRecordedRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("replay: capture request: %w", err)
+	}
+	if m.mode == ModeRecord {
+		return m.record(ctx, request, rec)
+	}
+	return m.replay(ctx, rec)
+}
+
+// record forwards the request and records the full response stream. The
+// cassette is written once the stream is complete.
+func (m *Model) record(
+	ctx context.Context,
+	request *model.Request,
+	rec *RecordedRequest,
+) (<-chan *model.Response, error) {
+	src, err := m.model.GenerateContent(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		in := &Interaction{
+			Key:     requestKey(matchView(rec, m.matching, m.normalizer)),
+			Request: rec,
+		}
+		last := time.Now()
+		for rsp := range src {
+			now := time.Now()
+			in.Responses = append(in.Responses, &RecordedResponse{
+				DelayMs:  now.Sub(last).Milliseconds(),
+				Response: rsp.Clone(),
+			})
+			last = now
+			select {
+			case responseChan <- rsp:
+			case <-ctx.Done():
+			}
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.cassette.Interactions = append(m.cassette.Interactions, in)
+		if err := m.cassette.Save(m.path); err != nil {
+			log.Errorf("replay: save cassette %s: %v", m.path, err)
+		}
+	}()
+	return responseChan, nil
+}
+
+// replay streams the recorded responses of the matching interaction.
+func (m *Model) replay(ctx context.Context, rec *RecordedRequest) (<-chan *model.Response, error) {
+	in, err := m.match(rec)
+	if err != nil {
+		return nil, err
+	}
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		for _, r := range in.Responses {
+			if m.realtime && r.DelayMs > 0 {
+				timer := time.NewTimer(time.Duration(r.DelayMs) * time.Millisecond)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+			}
+			select {
+			case responseChan <- r.Response.Clone():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return responseChan, nil
+}
+
+// match finds the next interaction recorded for a request.
+func (m *Model) match(rec *RecordedRequest) (*Interaction, error) {
+	view := matchView(rec, m.matching, m.normalizer)
+	key := requestKey(view)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var hits []*Interaction
+	for _, in := range m.byKey[key] {
+		// Guard against hash collisions.
+		if viewsEqual(matchView(in.Request, m.matching, m.normalizer), view) {
+			hits = append(hits, in)
+		}
+	}
+	if len(hits) == 0 {
+		return nil, m.mismatch(key, view)
+	}
+	idx := min(m.cursor[key], len(hits)-1)
+	m.cursor[key]++
+	return hits[idx], nil
+}
+
+// mismatch builds the error for an unmatched request against the closest
+// recording.
+func (m *Model) mismatch(key string, view *RecordedRequest) error {
+	var closest *RecordedRequest
+	best := -1
+	for _, in := range m.cassette.Interactions {
+		candidate := matchView(in.Request, m.matching, m.normalizer)
+		if s := similarity(candidate, view); s > best {
+			best = s
+			closest = candidate
+		}
+	}
+	if closest == nil {
+		return &MismatchError{Key: key}
+	}
+	return &MismatchError{Key: key, Diff: diffViews(closest, view)}
+}
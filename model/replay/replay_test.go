@@ -0,0 +1,149 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// echoModel streams the last user message back in two chunks.
+type echoModel struct {
+	calls int
+}
+
+func (e *echoModel) Info() model.Info { return model.Info{Name: "echo"} }
+
+func (e *echoModel) GenerateContent(_ context.Context, req *model.Request) (<-chan *model.Response, error) {
+	e.calls++
+	text := req.Messages[len(req.Messages)-1].Content
+	ch := make(chan *model.Response, 2)
+	ch <- &model.Response{IsPartial: true, Choices: []model.Choice{{Delta: model.Message{Content: text}}}}
+	ch <- &model.Response{Done: true, Choices: []model.Choice{{Message: model.NewAssistantMessage(text)}}}
+	close(ch)
+	return ch, nil
+}
+
+type stubTool struct{ name string }
+
+func (s stubTool) Declaration() *tool.Declaration { return &tool.Declaration{Name: s.name} }
+
+func collect(t *testing.T, m model.Model, req *model.Request) []*model.Response {
+	t.Helper()
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	var out []*model.Response
+	for rsp := range ch {
+		out = append(out, rsp)
+	}
+	return out
+}
+
+func request(system, user string) *model.Request {
+	return &model.Request{
+		Messages: []model.Message{model.NewSystemMessage(system), model.NewUserMessage(user)},
+		Tools:    map[string]tool.Tool{"b": stubTool{"b"}, "a": stubTool{"a"}},
+	}
+}
+
+func record(t *testing.T, path string, reqs ...*model.Request) {
+	t.Helper()
+	real := &echoModel{}
+	m, err := New(path, WithMode(ModeRecord), WithModel(real))
+	require.NoError(t, err)
+	for _, req := range reqs {
+		collect(t, m, req)
+	}
+	assert.Equal(t, len(reqs), real.calls)
+}
+
+func TestModel_RecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	record(t, path, request("sys", "hello"), request("sys", "world"))
+
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 2)
+	assert.Equal(t, "echo", c.Model)
+	assert.Len(t, c.Interactions[0].Responses, 2)
+	require.Len(t, c.Interactions[0].Request.Tools, 2)
+	assert.Equal(t, "a", c.Interactions[0].Request.Tools[0].Name)
+
+	m, err := New(path)
+	require.NoError(t, err)
+	assert.Equal(t, "echo", m.Info().Name)
+	rsps := collect(t, m, request("sys", "world"))
+	require.Len(t, rsps, 2)
+	assert.True(t, rsps[0].IsPartial)
+	assert.Equal(t, "world", rsps[1].Choices[0].Message.Content)
+}
+
+func TestModel_StrictMismatchDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	record(t, path, request("sys", "hello"))
+
+	m, err := New(path)
+	require.NoError(t, err)
+	_, err = m.GenerateContent(context.Background(), request("sys at 10:00", "hello"))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNoMatch))
+	var mismatch *MismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Contains(t, mismatch.Diff, `- messages[0]: {"role":"system","content":"sys"}`)
+	assert.Contains(t, mismatch.Diff, `+ messages[0]: {"role":"system","content":"sys at 10:00"}`)
+}
+
+func TestModel_LenientMatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	record(t, path, request("sys", "hello"))
+
+	m, err := New(path, WithMatching(MatchLenient))
+	require.NoError(t, err)
+	req := request("sys at 10:00", "  hello\n")
+	req.Tools = nil
+	rsps := collect(t, m, req)
+	require.Len(t, rsps, 2)
+	assert.Equal(t, "hello", rsps[1].Choices[0].Message.Content)
+
+	_, err = m.GenerateContent(context.Background(), request("sys", "bye"))
+	assert.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestModel_RepeatedRequestsReplayInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	real := &echoModel{}
+	rec, err := New(path, WithMode(ModeRecord), WithModel(real))
+	require.NoError(t, err)
+	collect(t, rec, request("sys", "same"))
+	collect(t, rec, request("sys", "same"))
+	rec.Cassette().Interactions[1].Responses[1].Response.Choices[0].Message.Content = "second"
+	require.NoError(t, rec.Cassette().Save(path))
+
+	m, err := New(path)
+	require.NoError(t, err)
+	assert.Equal(t, "same", collect(t, m, request("sys", "same"))[1].Choices[0].Message.Content)
+	assert.Equal(t, "second", collect(t, m, request("sys", "same"))[1].Choices[0].Message.Content)
+	assert.Equal(t, "second", collect(t, m, request("sys", "same"))[1].Choices[0].Message.Content)
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+	_, err = New("x.json", WithMode(ModeRecord))
+	require.Error(t, err)
+}
@@ -18,6 +18,23 @@ const (
 	ErrorTypeStreamError = "stream_error"
 	ErrorTypeAPIError    = "api_error"
 	ErrorTypeFlowError   = "flow_error"
+	// ErrorTypeStructuredOutputError is used when the final output still does
+	// not match the structured output schema after the allowed retries.
+	ErrorTypeStructuredOutputError = "structured_output_error"
+	// ErrorTypeMaxLLMCallsExceeded is used when an invocation reaches its limit of model calls.
+	ErrorTypeMaxLLMCallsExceeded = "max_llm_calls_exceeded"
+	// ErrorTypeMaxToolCallsExceeded is used when an invocation exceeds its limit of tool calls.
+	ErrorTypeMaxToolCallsExceeded = "max_tool_calls_exceeded"
+	// ErrorTypeInvocationTimeout is used when an invocation runs longer than its timeout.
+	ErrorTypeInvocationTimeout = "invocation_timeout"
+	// ErrorTypeToolCallLoop is used when a tool is called repeatedly with the same arguments.
+	ErrorTypeToolCallLoop = "tool_call_loop"
+	// ErrorTypeGuardrailTripped is used when a guardrail rejects the input or the output of an agent.
+	ErrorTypeGuardrailTripped = "guardrail_tripped"
+	// ErrorTypePlanFailed is used when a plan cannot be completed, even after replanning.
+	ErrorTypePlanFailed = "plan_failed"
+	// ErrorTypeAggregationFailed is used when the results of parallel branches cannot be aggregated.
+	ErrorTypeAggregationFailed = "aggregation_failed"
 )
 
 // Object type constants for Response.Object field.
@@ -45,6 +62,20 @@ const (
 	ObjectTypeRunnerCompletion = "runner.completion"
 	// ObjectTypeStateUpdate is the object type for state update events.
 	ObjectTypeStateUpdate = "state.update"
+	// ObjectTypeStructuredOutputPartial is the object type for partial structured output events.
+	ObjectTypeStructuredOutputPartial = "structured_output.partial"
+	// ObjectTypeStructuredOutputRepair is the object type for events asking the model to repair invalid structured output.
+	ObjectTypeStructuredOutputRepair = "structured_output.repair"
+	// ObjectTypeToolConfirmationRequest is the object type for events asking a human to approve tool calls.
+	ObjectTypeToolConfirmationRequest = "tool.confirmation_request"
+	// ObjectTypeGuardrail is the object type for events reporting guardrail checks.
+	ObjectTypeGuardrail = "guardrail.check"
+	// ObjectTypeRouting is the object type for events reporting the sub-agent chosen by a router.
+	ObjectTypeRouting = "agent.routing"
+	// ObjectTypePlanUpdate is the object type for events carrying the plan of a supervisor agent in their state delta.
+	ObjectTypePlanUpdate = "plan.update"
+	// ObjectTypeCritique is the object type for events reporting the review of a draft by a critic.
+	ObjectTypeCritique = "agent.critique"
 
 	// ObjectTypeChatCompletionChunk is the object type for chat completion chunk events.
 	ObjectTypeChatCompletionChunk = "chat.completion.chunk"
@@ -133,6 +164,10 @@ type Response struct {
 
 	// IsPartial indicates if this is a partial response.
 	IsPartial bool `json:"is_partial"`
+
+	// CacheHit indicates that the response was replayed from a response cache
+	// instead of being generated by the model.
+	CacheHit bool `json:"cache_hit,omitempty"`
 }
 
 // Clone creates a deep copy of the response.
@@ -0,0 +1,132 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package router
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// ErrorClass is the category of a model error used to decide on fallback.
+type ErrorClass string
+
+// Error classes.
+const (
+	// ErrorClassNone means there is no error.
+	ErrorClassNone ErrorClass = ""
+	// ErrorClassRateLimit covers rate limits and exhausted quotas.
+	ErrorClassRateLimit ErrorClass = "rate_limit"
+	// ErrorClassContextLength covers prompts exceeding the context window.
+	ErrorClassContextLength ErrorClass = "context_length"
+	// ErrorClassContentFilter covers responses blocked by safety filters.
+	ErrorClassContentFilter ErrorClass = "content_filter"
+	// ErrorClassServer covers 5xx and overloaded errors of the provider.
+	ErrorClassServer ErrorClass = "server"
+	// ErrorClassTransport covers network and stream failures.
+	ErrorClassTransport ErrorClass = "transport"
+	// ErrorClassUnknown covers everything else, e.g. invalid requests.
+	ErrorClassUnknown ErrorClass = "unknown"
+)
+
+// ClassifierFunc classifies a response error.
+type ClassifierFunc func(err *model.ResponseError) ErrorClass
+
+// statusPattern finds HTTP status lines such as "429 Too Many Requests"
+// embedded in provider error messages.
+var statusPattern = regexp.MustCompile(`\b([45]\d\d) [A-Z]`)
+
+var (
+	rateLimitHints = []string{
+		"rate_limit", "rate limit", "ratelimit", "too many requests",
+		"resource_exhausted", "quota",
+	}
+	contextLengthHints = []string{
+		"context_length", "context length", "context window", "maximum context",
+		"prompt is too long", "too many tokens", "input is too long",
+		"reduce the length", "token limit",
+	}
+	contentFilterHints = []string{
+		"content_filter", "content filter", "content management policy",
+		"safety", "prohibited_content", "blocklist", "spii", "recitation",
+	}
+	serverHints = []string{
+		"overloaded", "internal server error", "internal_error", "bad gateway",
+		"service unavailable", "gateway timeout", "unavailable",
+	}
+	transportHints = []string{
+		"connection refused", "connection reset", "broken pipe", "eof",
+		"no such host", "timeout", "deadline exceeded", "tls handshake",
+	}
+)
+
+// ClassifyError classifies a response error using its code, type and
+// message. It understands the error shapes of the providers in this
+// module and falls back to ErrorClassUnknown.
+func ClassifyError(err *model.ResponseError) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+	var code string
+	if err.Code != nil {
+		code = strings.ToLower(*err.Code)
+	}
+	if status, convErr := strconv.Atoi(code); convErr == nil {
+		if class := classifyStatus(status); class != ErrorClassUnknown {
+			return class
+		}
+	}
+	if m := statusPattern.FindStringSubmatch(err.Message); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		if class := classifyStatus(status); class != ErrorClassUnknown {
+			return class
+		}
+	}
+	text := code + " " + strings.ToLower(err.Message)
+	switch {
+	case containsAny(text, rateLimitHints):
+		return ErrorClassRateLimit
+	case containsAny(text, contextLengthHints):
+		return ErrorClassContextLength
+	case containsAny(text, contentFilterHints):
+		return ErrorClassContentFilter
+	case containsAny(text, serverHints):
+		return ErrorClassServer
+	case err.Type == model.ErrorTypeStreamError, containsAny(text, transportHints):
+		return ErrorClassTransport
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// classifyStatus maps HTTP status codes with an unambiguous meaning.
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == 429:
+		return ErrorClassRateLimit
+	case status == 413:
+		return ErrorClassContextLength
+	case status >= 500:
+		return ErrorClassServer
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+func containsAny(s string, hints []string) bool {
+	for _, h := range hints {
+		if strings.Contains(s, h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestClassifyError(t *testing.T) {
+	code := func(s string) *string { return &s }
+	tests := []struct {
+		name string
+		err  *model.ResponseError
+		want ErrorClass
+	}{
+		{"nil", nil, ErrorClassNone},
+		{"status 429", &model.ResponseError{Code: code("429")}, ErrorClassRateLimit},
+		{"openai message", &model.ResponseError{Message: `POST "https://x/v1/chat/completions": 429 Too Many Requests`}, ErrorClassRateLimit},
+		{"gemini quota", &model.ResponseError{Code: code("RESOURCE_EXHAUSTED"), Message: "quota exceeded"}, ErrorClassRateLimit},
+		{"anthropic overloaded", &model.ResponseError{Code: code("overloaded_error"), Message: "Overloaded"}, ErrorClassServer},
+		{"status 502", &model.ResponseError{Message: `POST "https://x": 502 Bad Gateway`}, ErrorClassServer},
+		{"context length", &model.ResponseError{Code: code("context_length_exceeded"), Message: "maximum context length is 8192 tokens"}, ErrorClassContextLength},
+		{"prompt too long", &model.ResponseError{Code: code("invalid_request_error"), Message: "prompt is too long: 210000 tokens"}, ErrorClassContextLength},
+		{"gemini safety", &model.ResponseError{Code: code("SAFETY"), Message: "gemini: response stopped: SAFETY"}, ErrorClassContentFilter},
+		{"stream", &model.ResponseError{Type: model.ErrorTypeStreamError, Message: "unexpected EOF"}, ErrorClassTransport},
+		{"invalid", &model.ResponseError{Code: code("400"), Message: "invalid schema"}, ErrorClassUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,332 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package router provides a model.Model that routes requests across several
+// models and falls back to the next one when a model fails.
+//
+// Typical usage:
+//
+//	m := router.New("chat", []model.Model{primary, backup},
+//		router.WithRule("long-context", router.MinTokens(100000), longContextModel),
+//	)
+//	agent := llmagent.New("assistant", llmagent.WithModel(m))
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	itelemetry "trpc.group/trpc-go/trpc-agent-go/internal/telemetry"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/telemetry/trace"
+)
+
+// defaultChannelBufferSize is the default channel buffer size.
+const defaultChannelBufferSize = 256
+
+// defaultFallbackClasses are the error classes that trigger a fallback when
+// WithFallbackOn is not used. Content filter errors are excluded because
+// another model answering a blocked prompt is a policy decision.
+var defaultFallbackClasses = []ErrorClass{
+	ErrorClassRateLimit,
+	ErrorClassContextLength,
+	ErrorClassServer,
+	ErrorClassTransport,
+}
+
+// Attempt records a failed call to one model.
+type Attempt struct {
+	// Model is the name of the model that failed.
+	Model string
+	// Class is the classified error.
+	Class ErrorClass
+	// Message is the error message.
+	Message string
+}
+
+// Decision records how a request was routed.
+type Decision struct {
+	// Rule is the name of the matched rule, empty when no rule matched.
+	Rule string
+	// Model is the name of the model that produced the result.
+	Model string
+	// Fallbacks lists the failed attempts before Model, in order.
+	Fallbacks []Attempt
+}
+
+// DecisionCallbackFunc is called once per request with the final decision.
+type DecisionCallbackFunc func(ctx context.Context, req *model.Request, decision *Decision)
+
+// options contains configuration options for creating a Model.
+type options struct {
+	rules             []Rule
+	classifier        ClassifierFunc
+	fallbackOn        []ErrorClass
+	tokenCounter      model.TokenCounter
+	decisionCallback  DecisionCallbackFunc
+	channelBufferSize int
+}
+
+// Option is a function that configures a router Model.
+type Option func(*options)
+
+// WithRule adds a routing rule. Rules are evaluated in the order they are
+// added and the first match wins.
+func WithRule(name string, match Matcher, m model.Model) Option {
+	return func(opts *options) {
+		opts.rules = append(opts.rules, Rule{Name: name, Match: match, Model: m})
+	}
+}
+
+// WithClassifier replaces ClassifyError.
+func WithClassifier(fn ClassifierFunc) Option {
+	return func(opts *options) {
+		opts.classifier = fn
+	}
+}
+
+// WithFallbackOn sets the error classes that trigger a fallback.
+// Defaults to rate limit, context length, server and transport errors.
+func WithFallbackOn(classes ...ErrorClass) Option {
+	return func(opts *options) {
+		opts.fallbackOn = classes
+	}
+}
+
+// WithTokenCounter sets the TokenCounter used to compute Features.Tokens.
+// If not provided, a SimpleTokenCounter will be used.
+func WithTokenCounter(counter model.TokenCounter) Option {
+	return func(opts *options) {
+		opts.tokenCounter = counter
+	}
+}
+
+// WithDecisionCallback sets the function called with every routing decision.
+func WithDecisionCallback(fn DecisionCallbackFunc) Option {
+	return func(opts *options) {
+		opts.decisionCallback = fn
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.channelBufferSize = size
+	}
+}
+
+// Model routes requests to one of several models and falls back to the
+// next model on retryable errors.
+//
+// A fallback happens when a model fails before it has produced its final
+// response. If some partial chunks were already forwarded, the partial
+// chunks of the following models are dropped and only their final response
+// is forwarded, so that no content is streamed twice.
+type Model struct {
+	name              string
+	models            []model.Model
+	rules             []Rule
+	classifier        ClassifierFunc
+	fallbackOn        map[ErrorClass]bool
+	tokenCounter      model.TokenCounter
+	decisionCallback  DecisionCallbackFunc
+	channelBufferSize int
+}
+
+// New creates a router over models, which are tried in order.
+func New(name string, models []model.Model, opts ...Option) *Model {
+	o := &options{
+		classifier:        ClassifyError,
+		fallbackOn:        defaultFallbackClasses,
+		channelBufferSize: defaultChannelBufferSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tokenCounter == nil && len(o.rules) > 0 {
+		o.tokenCounter = model.NewSimpleTokenCounter()
+	}
+	fallbackOn := make(map[ErrorClass]bool, len(o.fallbackOn))
+	for _, c := range o.fallbackOn {
+		fallbackOn[c] = true
+	}
+	return &Model{
+		name:              name,
+		models:            models,
+		rules:             o.rules,
+		classifier:        o.classifier,
+		fallbackOn:        fallbackOn,
+		tokenCounter:      o.tokenCounter,
+		decisionCallback:  o.decisionCallback,
+		channelBufferSize: o.channelBufferSize,
+	}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return model.Info{
+		Name: m.name,
+	}
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	request *model.Request,
+) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	rule, candidates := m.route(ctx, request)
+	if len(candidates) == 0 {
+		return nil, errors.New("router: no model configured")
+	}
+
+	ctx, span := trace.Tracer.Start(ctx, fmt.Sprintf("route %s", m.name))
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		defer span.End()
+		decision := m.run(ctx, request, candidates, responseChan)
+		decision.Rule = rule
+		m.record(ctx, span, request, decision)
+	}()
+	return responseChan, nil
+}
+
+// route returns the matched rule name and the ordered candidate models.
+func (m *Model) route(ctx context.Context, request *model.Request) (string, []model.Model) {
+	if len(m.rules) == 0 {
+		return "", m.models
+	}
+	features := extractFeatures(ctx, request, m.tokenCounter)
+	for _, r := range m.rules {
+		if r.Match == nil || r.Model == nil || !r.Match(ctx, request, features) {
+			continue
+		}
+		candidates := []model.Model{r.Model}
+		for _, mdl := range m.models {
+			if mdl != r.Model {
+				candidates = append(candidates, mdl)
+			}
+		}
+		return r.Name, candidates
+	}
+	return "", m.models
+}
+
+// run tries the candidates in order and forwards the responses of the
+// first one that does not fail with a retryable error.
+func (m *Model) run(
+	ctx context.Context,
+	request *model.Request,
+	candidates []model.Model,
+	responseChan chan<- *model.Response,
+) *Decision {
+	decision := &Decision{}
+	streamed := false
+	for i, candidate := range candidates {
+		name := candidate.Info().Name
+		decision.Model = name
+		last := i == len(candidates)-1
+
+		ch, err := candidate.GenerateContent(ctx, request)
+		if err != nil {
+			rspErr := &model.ResponseError{Message: err.Error(), Type: model.ErrorTypeAPIError}
+			class := m.classifier(rspErr)
+			if class == ErrorClassUnknown {
+				// Failing to even start a call is a transport problem.
+				class = ErrorClassTransport
+			}
+			if !last && m.fallbackOn[class] {
+				decision.Fallbacks = append(decision.Fallbacks, Attempt{Model: name, Class: class, Message: err.Error()})
+				continue
+			}
+			m.send(ctx, responseChan, &model.Response{
+				Object:    model.ObjectTypeError,
+				Error:     rspErr,
+				Timestamp: time.Now(),
+				Done:      true,
+			})
+			return decision
+		}
+
+		fellBack := false
+		// Chunks of a fallback model would repeat content the caller
+		// already got from a previous one.
+		suppressPartials := streamed
+		for rsp := range ch {
+			if rsp.Error != nil && !last {
+				if class := m.classifier(rsp.Error); m.fallbackOn[class] {
+					decision.Fallbacks = append(decision.Fallbacks, Attempt{Model: name, Class: class, Message: rsp.Error.Message})
+					fellBack = true
+					go drain(ch)
+					break
+				}
+			}
+			if rsp.IsPartial && suppressPartials {
+				continue
+			}
+			if rsp.IsPartial {
+				streamed = true
+			}
+			if !m.send(ctx, responseChan, rsp) {
+				go drain(ch)
+				return decision
+			}
+		}
+		if !fellBack {
+			return decision
+		}
+	}
+	return decision
+}
+
+// send forwards a response unless the context is done.
+func (m *Model) send(ctx context.Context, responseChan chan<- *model.Response, rsp *model.Response) bool {
+	select {
+	case responseChan <- rsp:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// record reports the decision on the span and to the callback.
+func (m *Model) record(ctx context.Context, span oteltrace.Span, request *model.Request, decision *Decision) {
+	fallbacks := make([]string, 0, len(decision.Fallbacks))
+	for _, a := range decision.Fallbacks {
+		fallbacks = append(fallbacks, fmt.Sprintf("%s:%s", a.Model, a.Class))
+	}
+	span.SetAttributes(
+		attribute.String(itelemetry.KeyRouterName, m.name),
+		attribute.String(itelemetry.KeyRouterRule, decision.Rule),
+		attribute.String(itelemetry.KeyRouterModel, decision.Model),
+		attribute.Int(itelemetry.KeyRouterAttempts, len(decision.Fallbacks)+1),
+		attribute.String(itelemetry.KeyRouterFallbacks, strings.Join(fallbacks, ",")),
+	)
+	if m.decisionCallback != nil {
+		m.decisionCallback(ctx, request, decision)
+	}
+}
+
+// drain consumes an abandoned response channel so its producer can exit.
+func drain(ch <-chan *model.Response) {
+	for range ch {
+	}
+}
@@ -0,0 +1,163 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// fakeModel replays a fixed list of responses.
+type fakeModel struct {
+	name  string
+	rsps  []*model.Response
+	err   error
+	calls int
+}
+
+func (f *fakeModel) Info() model.Info { return model.Info{Name: f.name} }
+
+func (f *fakeModel) GenerateContent(context.Context, *model.Request) (<-chan *model.Response, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	ch := make(chan *model.Response, len(f.rsps))
+	for _, r := range f.rsps {
+		ch <- r
+	}
+	close(ch)
+	return ch, nil
+}
+
+func partial(text string) *model.Response {
+	return &model.Response{IsPartial: true, Choices: []model.Choice{{Delta: model.Message{Content: text}}}}
+}
+
+func final(text string) *model.Response {
+	return &model.Response{Done: true, Choices: []model.Choice{{Message: model.NewAssistantMessage(text)}}}
+}
+
+func errorRsp(code, msg string) *model.Response {
+	return &model.Response{Done: true, Error: &model.ResponseError{Type: model.ErrorTypeAPIError, Code: &code, Message: msg}}
+}
+
+func collect(t *testing.T, m model.Model, req *model.Request) []*model.Response {
+	t.Helper()
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	var out []*model.Response
+	for rsp := range ch {
+		out = append(out, rsp)
+	}
+	return out
+}
+
+func TestModel_FallbackBeforeStreaming(t *testing.T) {
+	primary := &fakeModel{name: "primary", rsps: []*model.Response{errorRsp("429", "slow down")}}
+	backup := &fakeModel{name: "backup", rsps: []*model.Response{partial("he"), partial("llo"), final("hello")}}
+	var decision *Decision
+	m := New("chat", []model.Model{primary, backup},
+		WithDecisionCallback(func(_ context.Context, _ *model.Request, d *Decision) { decision = d }))
+
+	rsps := collect(t, m, &model.Request{})
+	require.Len(t, rsps, 3)
+	assert.Equal(t, "he", rsps[0].Choices[0].Delta.Content)
+	assert.Equal(t, "hello", rsps[2].Choices[0].Message.Content)
+
+	require.NotNil(t, decision)
+	assert.Equal(t, "backup", decision.Model)
+	require.Len(t, decision.Fallbacks, 1)
+	assert.Equal(t, Attempt{Model: "primary", Class: ErrorClassRateLimit, Message: "slow down"}, decision.Fallbacks[0])
+}
+
+func TestModel_FallbackAfterPartialChunksDoesNotRepeatThem(t *testing.T) {
+	primary := &fakeModel{name: "primary", rsps: []*model.Response{partial("hel"), errorRsp("503", "unavailable")}}
+	backup := &fakeModel{name: "backup", rsps: []*model.Response{partial("he"), partial("llo"), final("hello")}}
+	m := New("chat", []model.Model{primary, backup})
+
+	rsps := collect(t, m, &model.Request{})
+	require.Len(t, rsps, 2)
+	assert.True(t, rsps[0].IsPartial)
+	assert.Equal(t, "hel", rsps[0].Choices[0].Delta.Content)
+	assert.False(t, rsps[1].IsPartial)
+	assert.Equal(t, "hello", rsps[1].Choices[0].Message.Content)
+}
+
+func TestModel_NonRetryableErrorIsForwarded(t *testing.T) {
+	primary := &fakeModel{name: "primary", rsps: []*model.Response{errorRsp("invalid_request_error", "bad schema")}}
+	backup := &fakeModel{name: "backup", rsps: []*model.Response{final("ok")}}
+	m := New("chat", []model.Model{primary, backup})
+
+	rsps := collect(t, m, &model.Request{})
+	require.Len(t, rsps, 1)
+	require.NotNil(t, rsps[0].Error)
+	assert.Equal(t, 0, backup.calls)
+}
+
+func TestModel_LastModelErrorIsForwarded(t *testing.T) {
+	primary := &fakeModel{name: "primary", err: errors.New("dial tcp: connection refused")}
+	backup := &fakeModel{name: "backup", rsps: []*model.Response{errorRsp("500", "boom")}}
+	m := New("chat", []model.Model{primary, backup})
+
+	rsps := collect(t, m, &model.Request{})
+	require.Len(t, rsps, 1)
+	assert.Equal(t, "boom", rsps[0].Error.Message)
+	assert.Equal(t, 1, primary.calls)
+}
+
+func TestModel_ContentFilterFallbackIsOptIn(t *testing.T) {
+	primary := &fakeModel{name: "primary", rsps: []*model.Response{errorRsp("SAFETY", "blocked")}}
+	backup := &fakeModel{name: "backup", rsps: []*model.Response{final("ok")}}
+	m := New("chat", []model.Model{primary, backup}, WithFallbackOn(ErrorClassContentFilter))
+
+	rsps := collect(t, m, &model.Request{})
+	require.Len(t, rsps, 1)
+	assert.Equal(t, "ok", rsps[0].Choices[0].Message.Content)
+}
+
+type stubTool struct{}
+
+func (stubTool) Declaration() *tool.Declaration { return &tool.Declaration{Name: "t"} }
+
+func TestModel_Rules(t *testing.T) {
+	primary := &fakeModel{name: "primary", rsps: []*model.Response{final("primary")}}
+	toolModel := &fakeModel{name: "tools", rsps: []*model.Response{errorRsp("429", "limited")}}
+	var decision *Decision
+	m := New("chat", []model.Model{primary},
+		WithRule("tools", HasTools(), toolModel),
+		WithDecisionCallback(func(_ context.Context, _ *model.Request, d *Decision) { decision = d }))
+
+	rsps := collect(t, m, &model.Request{Tools: map[string]tool.Tool{"t": stubTool{}}})
+	require.Len(t, rsps, 1)
+	assert.Equal(t, "primary", rsps[0].Choices[0].Message.Content)
+	assert.Equal(t, "tools", decision.Rule)
+	assert.Equal(t, "primary", decision.Model)
+	assert.Equal(t, 1, toolModel.calls)
+
+	rsps = collect(t, m, &model.Request{})
+	require.Len(t, rsps, 1)
+	assert.Equal(t, "", decision.Rule)
+	assert.Equal(t, 1, toolModel.calls)
+}
+
+func TestModel_NilRequest(t *testing.T) {
+	_, err := New("chat", nil).GenerateContent(context.Background(), nil)
+	require.Error(t, err)
+	_, err = New("chat", nil).GenerateContent(context.Background(), &model.Request{})
+	require.Error(t, err)
+}
@@ -0,0 +1,118 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package router
+
+import (
+	"context"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// Features describes the parts of a request routing rules usually care about.
+type Features struct {
+	// Tokens is the estimated prompt size.
+	Tokens int
+	// HasTools reports whether tools are declared.
+	HasTools bool
+	// HasStructuredOutput reports whether structured output is requested.
+	HasStructuredOutput bool
+	// HasImages reports whether any message carries an image.
+	HasImages bool
+	// Stream reports whether streaming is requested.
+	Stream bool
+}
+
+// Matcher decides whether a rule applies to a request.
+type Matcher func(ctx context.Context, req *model.Request, f *Features) bool
+
+// Rule routes matching requests to a model. The model is tried first and
+// the default models follow as fallbacks.
+type Rule struct {
+	// Name identifies the rule in routing decisions.
+	Name string
+	// Match selects the requests the rule applies to.
+	Match Matcher
+	// Model serves the matching requests.
+	Model model.Model
+}
+
+// MinTokens matches requests whose estimated prompt size is at least n tokens.
+func MinTokens(n int) Matcher {
+	return func(_ context.Context, _ *model.Request, f *Features) bool {
+		return f.Tokens >= n
+	}
+}
+
+// HasTools matches requests that declare tools.
+func HasTools() Matcher {
+	return func(_ context.Context, _ *model.Request, f *Features) bool {
+		return f.HasTools
+	}
+}
+
+// HasStructuredOutput matches requests that ask for structured output.
+func HasStructuredOutput() Matcher {
+	return func(_ context.Context, _ *model.Request, f *Features) bool {
+		return f.HasStructuredOutput
+	}
+}
+
+// HasImages matches requests that carry images.
+func HasImages() Matcher {
+	return func(_ context.Context, _ *model.Request, f *Features) bool {
+		return f.HasImages
+	}
+}
+
+// All matches requests matched by every matcher.
+func All(matchers ...Matcher) Matcher {
+	return func(ctx context.Context, req *model.Request, f *Features) bool {
+		for _, m := range matchers {
+			if !m(ctx, req, f) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches requests matched by at least one matcher.
+func Any(matchers ...Matcher) Matcher {
+	return func(ctx context.Context, req *model.Request, f *Features) bool {
+		for _, m := range matchers {
+			if m(ctx, req, f) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// extractFeatures computes the features of a request.
+func extractFeatures(ctx context.Context, req *model.Request, counter model.TokenCounter) *Features {
+	f := &Features{
+		HasTools:            len(req.Tools) > 0,
+		HasStructuredOutput: req.StructuredOutput != nil,
+		Stream:              req.Stream,
+	}
+	for _, msg := range req.Messages {
+		for _, part := range msg.ContentParts {
+			if part.Type == model.ContentTypeImage {
+				f.HasImages = true
+			}
+		}
+	}
+	if counter != nil && len(req.Messages) > 0 {
+		if tokens, err := counter.CountTokensRange(ctx, req.Messages, 0, len(req.Messages)); err == nil {
+			f.Tokens = tokens
+		}
+	}
+	return f
+}
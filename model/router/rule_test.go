@@ -0,0 +1,41 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package router
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestMatchers(t *testing.T) {
+	ctx := context.Background()
+	msg := model.NewUserMessage(strings.Repeat("word ", 400))
+	msg.AddImageURL("https://example.com/a.png", "auto")
+	req := &model.Request{
+		Messages:         []model.Message{msg},
+		StructuredOutput: &model.StructuredOutput{Type: model.StructuredOutputJSONSchema},
+	}
+	f := extractFeatures(ctx, req, model.NewSimpleTokenCounter())
+	assert.Greater(t, f.Tokens, 100)
+	assert.True(t, f.HasImages)
+	assert.False(t, f.HasTools)
+
+	assert.True(t, MinTokens(100)(ctx, req, f))
+	assert.False(t, MinTokens(100000)(ctx, req, f))
+	assert.True(t, All(HasImages(), HasStructuredOutput())(ctx, req, f))
+	assert.False(t, All(HasImages(), HasTools())(ctx, req, f))
+	assert.True(t, Any(HasTools(), HasImages())(ctx, req, f))
+	assert.False(t, Any(HasTools())(ctx, req, f))
+}
@@ -46,6 +46,16 @@ func New(modelName string) (*Counter, error) {
 	return &Counter{encoding: enc}, nil
 }
 
+// CountText returns the token count of text. It implements model.TextTokenizer,
+// so the counter can back a model.AccountingTokenCounter.
+func (c *Counter) CountText(text string) (int, error) {
+	toks, _, err := c.encoding.Encode(text)
+	if err != nil {
+		return 0, fmt.Errorf("encode text failed: %w", err)
+	}
+	return len(toks), nil
+}
+
 // CountTokens returns the token count for a single message using tiktoken-go.
 // It encodes Message.Content, Message.ReasoningContent, and text ContentParts.
 func (c *Counter) CountTokens(_ context.Context, message model.Message) (int, error) {
@@ -271,3 +271,18 @@ func TestTiktokenCounter_DifferentModels(t *testing.T) {
 		require.Greater(t, used, 0)
 	})
 }
+
+func TestTiktokenCounter_CountText(t *testing.T) {
+	counter, err := New("gpt-4o")
+	if err != nil {
+		t.Skip("tiktoken-go not available: ", err)
+	}
+	used, err := counter.CountText("Hello, world!")
+	require.NoError(t, err)
+	require.Greater(t, used, 0)
+
+	accounting := model.NewAccountingTokenCounter(model.OpenAIAccounting(), model.WithTextTokenizer(counter))
+	withOverhead, err := accounting.CountTokens(context.Background(), model.NewUserMessage("Hello, world!"))
+	require.NoError(t, err)
+	require.Equal(t, used+model.OpenAIAccounting().PerMessage, withOverhead)
+}
@@ -0,0 +1,397 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF for image size detection.
+	_ "image/jpeg" // Register JPEG for image size detection.
+	_ "image/png"  // Register PNG for image size detection.
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// RequestTokenCounter is a TokenCounter that can also count a whole request,
+// including the tool declarations, structured output schema and request
+// overhead that are not part of any message.
+type RequestTokenCounter interface {
+	TokenCounter
+
+	// CountRequestTokens returns the estimated token count of the request.
+	CountRequestTokens(ctx context.Context, request *Request) (int, error)
+}
+
+// RequestOverheadTokens returns the tokens of request outside its messages
+// when counter is a RequestTokenCounter, and 0 otherwise. Token tailoring
+// subtracts it from the input budget before trimming messages.
+func RequestOverheadTokens(ctx context.Context, counter TokenCounter, request *Request) int {
+	rc, ok := counter.(RequestTokenCounter)
+	if !ok || request == nil {
+		return 0
+	}
+	tokens, err := rc.CountRequestTokens(ctx, &Request{
+		GenerationConfig: request.GenerationConfig,
+		StructuredOutput: request.StructuredOutput,
+		Tools:            request.Tools,
+	})
+	if err != nil {
+		return 0
+	}
+	return tokens
+}
+
+// TextTokenizer counts the tokens of plain text. The tiktoken counter
+// implements it, so it can be plugged into an AccountingTokenCounter.
+type TextTokenizer interface {
+	CountText(text string) (int, error)
+}
+
+// approxTextTokenizer estimates one token per four runes, rounding up.
+type approxTextTokenizer struct{}
+
+func (approxTextTokenizer) CountText(text string) (int, error) {
+	n := utf8.RuneCountInString(text)
+	return (n + approxRunesPerToken - 1) / approxRunesPerToken, nil
+}
+
+// TokenAccounting holds the provider-specific rules of an
+// AccountingTokenCounter.
+type TokenAccounting struct {
+	// PerMessage is added for every message (role and separators).
+	PerMessage int
+	// PerRequest is added once per request (reply priming).
+	PerRequest int
+	// PerToolCall is added for every tool call in an assistant message.
+	PerToolCall int
+	// PerTool is added for every tool declaration.
+	PerTool int
+	// PerToolsBlock is added once when the request declares tools.
+	PerToolsBlock int
+	// AudioTokensPerSecond is the cost of one second of audio input.
+	AudioTokensPerSecond float64
+	// UnknownFileTokens is the cost of a file whose content cannot be read
+	// as text, such as an uploaded file ID or a binary document.
+	UnknownFileTokens int
+	// ImageTokens returns the cost of an image of the given size and detail.
+	// Width and height are 0 when the size cannot be determined.
+	ImageTokens func(width, height int, detail string) int
+}
+
+// OpenAIAccounting returns the accounting rules of OpenAI chat models.
+func OpenAIAccounting() TokenAccounting {
+	return TokenAccounting{
+		PerMessage:           3,
+		PerRequest:           3,
+		PerToolCall:          3,
+		PerTool:              8,
+		PerToolsBlock:        12,
+		AudioTokensPerSecond: 10,
+		UnknownFileTokens:    1000,
+		ImageTokens:          openAIImageTokens,
+	}
+}
+
+// AnthropicAccounting returns the accounting rules of Anthropic Claude models.
+func AnthropicAccounting() TokenAccounting {
+	return TokenAccounting{
+		PerMessage:        4,
+		PerRequest:        4,
+		PerToolCall:       10,
+		PerTool:           10,
+		PerToolsBlock:     300,
+		UnknownFileTokens: 1500,
+		ImageTokens:       anthropicImageTokens,
+	}
+}
+
+// GeminiAccounting returns the accounting rules of Google Gemini models.
+func GeminiAccounting() TokenAccounting {
+	return TokenAccounting{
+		PerMessage:           4,
+		PerRequest:           2,
+		PerToolCall:          4,
+		PerTool:              6,
+		PerToolsBlock:        10,
+		AudioTokensPerSecond: 32,
+		UnknownFileTokens:    1032,
+		ImageTokens:          geminiImageTokens,
+	}
+}
+
+// AccountingForModel returns the accounting rules matching a model name,
+// defaulting to OpenAIAccounting.
+func AccountingForModel(modelName string) TokenAccounting {
+	name := strings.ToLower(modelName)
+	switch {
+	case strings.Contains(name, "claude"):
+		return AnthropicAccounting()
+	case strings.Contains(name, "gemini"), strings.Contains(name, "gemma"):
+		return GeminiAccounting()
+	default:
+		return OpenAIAccounting()
+	}
+}
+
+// openAIImageTokens implements the tile-based cost of OpenAI vision models:
+// the image is fit into 2048x2048, its short side scaled to 768, and each
+// 512x512 tile costs 170 tokens on top of a base of 85.
+func openAIImageTokens(width, height int, detail string) int {
+	const base, perTile = 85, 170
+	if detail == "low" {
+		return base
+	}
+	if width <= 0 || height <= 0 {
+		// Unknown size: assume a 1024x1024 image.
+		width, height = 1024, 1024
+	}
+	w, h := float64(width), float64(height)
+	if scale := 2048 / math.Max(w, h); scale < 1 {
+		w, h = w*scale, h*scale
+	}
+	if scale := 768 / math.Min(w, h); scale < 1 {
+		w, h = w*scale, h*scale
+	}
+	tiles := int(math.Ceil(w/512)) * int(math.Ceil(h/512))
+	return base + perTile*tiles
+}
+
+// anthropicImageTokens implements width*height/750, with images resized to
+// a long edge of at most 1568 pixels.
+func anthropicImageTokens(width, height int, _ string) int {
+	const maxEdge, maxTokens = 1568, 1600
+	if width <= 0 || height <= 0 {
+		return maxTokens
+	}
+	w, h := float64(width), float64(height)
+	if scale := maxEdge / math.Max(w, h); scale < 1 {
+		w, h = w*scale, h*scale
+	}
+	return min(int(math.Ceil(w*h/750)), maxTokens)
+}
+
+// geminiImageTokens implements 258 tokens for images up to 384x384 and 258
+// tokens per 768x768 tile otherwise.
+func geminiImageTokens(width, height int, _ string) int {
+	const perTile = 258
+	if width <= 384 && height <= 384 {
+		return perTile
+	}
+	return perTile * int(math.Ceil(float64(width)/768)) * int(math.Ceil(float64(height)/768))
+}
+
+// AccountingCounterOption configures an AccountingTokenCounter.
+type AccountingCounterOption func(*AccountingTokenCounter)
+
+// WithTextTokenizer sets the tokenizer of text content. The default estimates
+// one token per four runes.
+func WithTextTokenizer(tokenizer TextTokenizer) AccountingCounterOption {
+	return func(c *AccountingTokenCounter) {
+		c.tokenizer = tokenizer
+	}
+}
+
+// AccountingTokenCounter counts tokens of messages and whole requests with
+// provider-specific rules: per-message overhead, tool declarations, tool
+// call arguments, image tiles, audio duration and file content.
+type AccountingTokenCounter struct {
+	tokenizer  TextTokenizer
+	accounting TokenAccounting
+}
+
+// NewAccountingTokenCounter creates a counter with the given rules.
+func NewAccountingTokenCounter(accounting TokenAccounting, opts ...AccountingCounterOption) *AccountingTokenCounter {
+	c := &AccountingTokenCounter{
+		tokenizer:  approxTextTokenizer{},
+		accounting: accounting,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CountTokens counts the tokens of a message, including its overhead.
+func (c *AccountingTokenCounter) CountTokens(_ context.Context, message Message) (int, error) {
+	total := c.accounting.PerMessage
+	for _, text := range []string{message.Content, message.ReasoningContent, message.ToolName} {
+		n, err := c.text(text)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	for _, part := range message.ContentParts {
+		n, err := c.part(part)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	for _, tc := range message.ToolCalls {
+		n, err := c.text(tc.Function.Name)
+		if err != nil {
+			return 0, err
+		}
+		args, err := c.text(string(tc.Function.Arguments))
+		if err != nil {
+			return 0, err
+		}
+		total += c.accounting.PerToolCall + n + args
+	}
+	return total, nil
+}
+
+// CountTokensRange counts the tokens of messages[start:end].
+func (c *AccountingTokenCounter) CountTokensRange(ctx context.Context, messages []Message, start, end int) (int, error) {
+	if start < 0 || end > len(messages) || start >= end {
+		return 0, fmt.Errorf("invalid range: start=%d, end=%d, len=%d", start, end, len(messages))
+	}
+	total := 0
+	for i := start; i < end; i++ {
+		tokens, err := c.CountTokens(ctx, messages[i])
+		if err != nil {
+			return 0, fmt.Errorf("count tokens for message %d failed: %w", i, err)
+		}
+		total += tokens
+	}
+	return total, nil
+}
+
+// CountRequestTokens counts the messages, tool declarations, structured
+// output schema and request overhead of request.
+func (c *AccountingTokenCounter) CountRequestTokens(ctx context.Context, request *Request) (int, error) {
+	if request == nil {
+		return 0, fmt.Errorf("request cannot be nil")
+	}
+	total := c.accounting.PerRequest
+	if len(request.Messages) > 0 {
+		n, err := c.CountTokensRange(ctx, request.Messages, 0, len(request.Messages))
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	if len(request.Tools) > 0 {
+		total += c.accounting.PerToolsBlock
+	}
+	for name, t := range request.Tools {
+		decl := t.Declaration()
+		if decl == nil {
+			continue
+		}
+		data, err := json.Marshal(decl)
+		if err != nil {
+			return 0, fmt.Errorf("marshal tool %s declaration: %w", name, err)
+		}
+		n, err := c.text(string(data))
+		if err != nil {
+			return 0, err
+		}
+		total += c.accounting.PerTool + n
+	}
+	if so := request.StructuredOutput; so != nil && so.JSONSchema != nil {
+		data, err := json.Marshal(so.JSONSchema.Schema)
+		if err != nil {
+			return 0, fmt.Errorf("marshal structured output schema: %w", err)
+		}
+		n, err := c.text(string(data))
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (c *AccountingTokenCounter) text(text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+	n, err := c.tokenizer.CountText(text)
+	if err != nil {
+		return 0, fmt.Errorf("count text tokens: %w", err)
+	}
+	return n, nil
+}
+
+func (c *AccountingTokenCounter) part(part ContentPart) (int, error) {
+	switch {
+	case part.Text != nil:
+		return c.text(*part.Text)
+	case part.Image != nil:
+		if c.accounting.ImageTokens == nil {
+			return 0, nil
+		}
+		width, height := imageSize(part.Image)
+		return c.accounting.ImageTokens(width, height, part.Image.Detail), nil
+	case part.Audio != nil:
+		return int(math.Ceil(audioSeconds(part.Audio) * c.accounting.AudioTokensPerSecond)), nil
+	case part.File != nil:
+		if len(part.File.Data) > 0 && isTextMimeType(part.File.MimeType) && utf8.Valid(part.File.Data) {
+			return c.text(string(part.File.Data))
+		}
+		return c.accounting.UnknownFileTokens, nil
+	}
+	return 0, nil
+}
+
+// imageSize returns the size of an inline or data-URL image, or zeros when
+// it cannot be determined.
+func imageSize(img *Image) (int, int) {
+	data := img.Data
+	if len(data) == 0 && strings.HasPrefix(img.URL, "data:") {
+		if i := strings.Index(img.URL, ";base64,"); i >= 0 {
+			data, _ = base64.StdEncoding.DecodeString(img.URL[i+len(";base64,"):])
+		}
+	}
+	if len(data) == 0 {
+		return 0, 0
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
+
+// audioSeconds estimates the duration of audio data. WAV durations are read
+// from the header; other formats assume 128 kbit/s.
+func audioSeconds(audio *Audio) float64 {
+	const (
+		wavHeaderSize   = 44
+		compressedBytes = 128 * 1000 / 8
+	)
+	data := audio.Data
+	if len(data) >= wavHeaderSize && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE" {
+		if byteRate := binary.LittleEndian.Uint32(data[28:32]); byteRate > 0 {
+			return float64(len(data)-wavHeaderSize) / float64(byteRate)
+		}
+	}
+	return float64(len(data)) / compressedBytes
+}
+
+// isTextMimeType reports whether files of the MIME type are counted as text.
+func isTextMimeType(mimeType string) bool {
+	switch {
+	case strings.HasPrefix(mimeType, "text/"),
+		mimeType == "application/json",
+		mimeType == "application/xml",
+		mimeType == "application/x-yaml":
+		return true
+	}
+	return false
+}
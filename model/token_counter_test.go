@@ -0,0 +1,177 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// declTool is a tool with a fixed declaration.
+type declTool struct{ decl *tool.Declaration }
+
+func (d declTool) Declaration() *tool.Declaration { return d.decl }
+
+func pngData(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func wavData(seconds, byteRate int) []byte {
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	copy(header[8:12], "WAVE")
+	binary.LittleEndian.PutUint32(header[28:32], uint32(byteRate))
+	return append(header, make([]byte, seconds*byteRate)...)
+}
+
+func TestImageTokens(t *testing.T) {
+	tests := []struct {
+		name          string
+		fn            func(int, int, string) int
+		width, height int
+		detail        string
+		want          int
+	}{
+		{"openai low", openAIImageTokens, 4096, 4096, "low", 85},
+		{"openai square", openAIImageTokens, 1024, 1024, "high", 765},
+		{"openai tall", openAIImageTokens, 2048, 4096, "auto", 1105},
+		{"openai small", openAIImageTokens, 256, 256, "", 255},
+		{"openai unknown", openAIImageTokens, 0, 0, "", 765},
+		{"anthropic", anthropicImageTokens, 1000, 1000, "", 1334},
+		{"anthropic capped", anthropicImageTokens, 4000, 4000, "", 1600},
+		{"anthropic unknown", anthropicImageTokens, 0, 0, "", 1600},
+		{"gemini small", geminiImageTokens, 300, 300, "", 258},
+		{"gemini tiled", geminiImageTokens, 1000, 1000, "", 1032},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.fn(tt.width, tt.height, tt.detail))
+		})
+	}
+}
+
+func TestImageSize(t *testing.T) {
+	data := pngData(t, 640, 480)
+
+	w, h := imageSize(&Image{Data: data})
+	assert.Equal(t, 640, w)
+	assert.Equal(t, 480, h)
+
+	w, h = imageSize(&Image{URL: "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)})
+	assert.Equal(t, 640, w)
+	assert.Equal(t, 480, h)
+
+	w, h = imageSize(&Image{URL: "https://example.com/a.png"})
+	assert.Zero(t, w)
+	assert.Zero(t, h)
+}
+
+func TestAudioSeconds(t *testing.T) {
+	assert.InDelta(t, 2.0, audioSeconds(&Audio{Data: wavData(2, 32000), Format: "wav"}), 1e-9)
+	assert.InDelta(t, 1.0, audioSeconds(&Audio{Data: make([]byte, 16000), Format: "mp3"}), 1e-9)
+}
+
+func TestAccountingTokenCounter_CountTokens(t *testing.T) {
+	ctx := context.Background()
+	counter := NewAccountingTokenCounter(OpenAIAccounting())
+
+	t.Run("text with overhead", func(t *testing.T) {
+		n, err := counter.CountTokens(ctx, NewUserMessage("abcdefgh"))
+		require.NoError(t, err)
+		assert.Equal(t, 3+2, n)
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		msg := Message{Role: RoleAssistant, ToolCalls: []ToolCall{{
+			Type:     "function",
+			Function: FunctionDefinitionParam{Name: "calc", Arguments: []byte(`{"a":1,"b":2}`)},
+		}}}
+		n, err := counter.CountTokens(ctx, msg)
+		require.NoError(t, err)
+		// Message overhead + call overhead + name (1) + arguments (13 runes -> 4).
+		assert.Equal(t, 3+3+1+4, n)
+	})
+
+	t.Run("multimodal parts", func(t *testing.T) {
+		msg := NewUserMessage("")
+		msg.AddImageData(pngData(t, 1024, 1024), "high", "png")
+		msg.AddAudioData(wavData(3, 32000), "wav")
+		msg.AddFileData("notes.txt", []byte("abcd"), "text/plain")
+		msg.AddFileID("file-123")
+		n, err := counter.CountTokens(ctx, msg)
+		require.NoError(t, err)
+		assert.Equal(t, 3+765+30+1+1000, n)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		messages := []Message{NewUserMessage("abcd"), NewAssistantMessage("abcd")}
+		n, err := counter.CountTokensRange(ctx, messages, 0, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 8, n)
+		_, err = counter.CountTokensRange(ctx, messages, 1, 1)
+		assert.Error(t, err)
+	})
+}
+
+type failingTokenizer struct{}
+
+func (failingTokenizer) CountText(string) (int, error) { return 0, assert.AnError }
+
+func TestAccountingTokenCounter_TokenizerError(t *testing.T) {
+	counter := NewAccountingTokenCounter(OpenAIAccounting(), WithTextTokenizer(failingTokenizer{}))
+	_, err := counter.CountTokens(context.Background(), NewUserMessage("hi"))
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestAccountingTokenCounter_CountRequestTokens(t *testing.T) {
+	ctx := context.Background()
+	counter := NewAccountingTokenCounter(AnthropicAccounting())
+	tools := map[string]tool.Tool{
+		"calc": declTool{decl: &tool.Declaration{
+			Name:        "calc",
+			Description: "adds two numbers",
+			InputSchema: &tool.Schema{Type: "object"},
+		}},
+	}
+	request := &Request{Messages: []Message{NewUserMessage("abcd")}, Tools: tools}
+
+	withTools, err := counter.CountRequestTokens(ctx, request)
+	require.NoError(t, err)
+	withoutTools, err := counter.CountRequestTokens(ctx, &Request{Messages: request.Messages})
+	require.NoError(t, err)
+	assert.Equal(t, 4+4+1, withoutTools)
+	assert.Greater(t, withTools-withoutTools, 300+10)
+
+	overhead := RequestOverheadTokens(ctx, counter, request)
+	assert.Equal(t, withTools-(4+1), overhead)
+	assert.Zero(t, RequestOverheadTokens(ctx, NewSimpleTokenCounter(), request))
+
+	_, err = counter.CountRequestTokens(ctx, nil)
+	assert.Error(t, err)
+}
+
+func TestAccountingForModel(t *testing.T) {
+	assert.Equal(t, AnthropicAccounting().PerToolsBlock, AccountingForModel("claude-sonnet-4").PerToolsBlock)
+	assert.Equal(t, GeminiAccounting().AudioTokensPerSecond, AccountingForModel("gemini-2.5-pro").AudioTokensPerSecond)
+	assert.Equal(t, OpenAIAccounting().PerToolsBlock, AccountingForModel("gpt-4o").PerToolsBlock)
+}
@@ -0,0 +1,363 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	// SummaryConversationPlaceholder is replaced by the rendered messages in
+	// the summarization prompt.
+	SummaryConversationPlaceholder = "{conversation_text}"
+
+	// defaultSummaryReserveTokens is the token budget reserved for the summary.
+	defaultSummaryReserveTokens = 512
+	// defaultSummaryRetainRatio is the share of the free budget kept verbatim
+	// when a new summary is generated.
+	defaultSummaryRetainRatio = 0.5
+	// defaultSummaryCacheSize bounds the number of cached summaries.
+	defaultSummaryCacheSize = 1024
+	// summaryMessagePrefix introduces the summary message.
+	summaryMessagePrefix = "Summary of the earlier conversation:\n"
+)
+
+// defaultSummaryPrompt is the default prompt of the summarizing strategy.
+const defaultSummaryPrompt = "Summarize the following conversation between a user, an " +
+	"assistant and its tools so that the assistant can continue the task " +
+	"without it. Keep facts, decisions, tool results, open questions and " +
+	"user preferences. Be concise and do not make anything up.\n\n" +
+	"<conversation>\n" + SummaryConversationPlaceholder + "\n</conversation>\n\n" +
+	"Summary:"
+
+// SummarizingOption configures a SummarizingStrategy.
+type SummarizingOption func(*SummarizingStrategy)
+
+// WithSummaryPrompt sets the summarization prompt. The prompt must contain
+// SummaryConversationPlaceholder.
+func WithSummaryPrompt(prompt string) SummarizingOption {
+	return func(s *SummarizingStrategy) {
+		s.prompt = prompt
+	}
+}
+
+// WithSummaryReserveTokens sets the token budget of the summary message. It is
+// also passed to the summarizer as the max output tokens.
+func WithSummaryReserveTokens(tokens int) SummarizingOption {
+	return func(s *SummarizingStrategy) {
+		s.reserveTokens = tokens
+	}
+}
+
+// WithSummaryRetainRatio sets the share of the free budget kept as verbatim
+// recent messages when a new summary is generated. Lower values summarize
+// more at once, so the summary is reused for more turns.
+func WithSummaryRetainRatio(ratio float64) SummarizingOption {
+	return func(s *SummarizingStrategy) {
+		s.retainRatio = ratio
+	}
+}
+
+// WithSummaryCacheSize bounds the number of cached summaries.
+func WithSummaryCacheSize(size int) SummarizingOption {
+	return func(s *SummarizingStrategy) {
+		s.cacheSize = size
+	}
+}
+
+// SummarizingStrategy replaces the oldest messages with a summary generated
+// by a model instead of dropping them.
+//
+// The system messages at the head and the last turn are preserved like in the
+// other strategies. The span between them is cut at a message boundary that
+// never separates an assistant tool call from its tool results, the older
+// part is summarized into a single system message and the newer part is kept
+// verbatim.
+//
+// Summaries are cached by the hash of the summarized messages. Later turns
+// reuse the cached summary while the messages after it still fit, and extend
+// it incrementally from the previous summary once they no longer do. If the
+// summarizer fails, the strategy falls back to HeadOutStrategy.
+//
+// Summarizer calls are checked and recorded against the UsageRecorder of the
+// context, such as the budget of the run; an exhausted budget makes the
+// strategy fall back without calling the summarizer.
+type SummarizingStrategy struct {
+	tokenCounter  TokenCounter
+	summarizer    Model
+	prompt        string
+	reserveTokens int
+	retainRatio   float64
+	cacheSize     int
+	fallback      TailoringStrategy
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewSummarizingStrategy constructs a summarizing strategy with the given
+// counter and summarizer model.
+func NewSummarizingStrategy(counter TokenCounter, summarizer Model, opts ...SummarizingOption) *SummarizingStrategy {
+	s := &SummarizingStrategy{
+		tokenCounter:  counter,
+		summarizer:    summarizer,
+		prompt:        defaultSummaryPrompt,
+		reserveTokens: defaultSummaryReserveTokens,
+		retainRatio:   defaultSummaryRetainRatio,
+		cacheSize:     defaultSummaryCacheSize,
+		fallback:      NewHeadOutStrategy(counter),
+		cache:         make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.retainRatio < 0 || s.retainRatio > 1 {
+		s.retainRatio = defaultSummaryRetainRatio
+	}
+	return s
+}
+
+// TailorMessages summarizes the oldest messages so the result fits within
+// maxTokens.
+func (s *SummarizingStrategy) TailorMessages(ctx context.Context, messages []Message, maxTokens int) ([]Message, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	prefixSum := buildPrefixSum(ctx, s.tokenCounter, messages)
+	if prefixSum[len(messages)] <= maxTokens {
+		result := messages
+		if result[0].Role == RoleTool {
+			result = result[1:]
+		}
+		return result, nil
+	}
+
+	preservedHead := calculatePreservedHeadCount(messages)
+	preservedTail := calculatePreservedTailCount(messages)
+	tailStart := max(len(messages)-preservedTail, preservedHead)
+
+	// Budget left for the summary and the verbatim middle messages.
+	fixedTokens := prefixSum[preservedHead] + prefixSum[len(messages)] - prefixSum[tailStart]
+	available := maxTokens - fixedTokens - s.reserveTokens
+	if available < 0 || tailStart == preservedHead {
+		return s.fallback.TailorMessages(ctx, messages, maxTokens)
+	}
+	keptTokens := func(cut int) int { return prefixSum[tailStart] - prefixSum[cut] }
+
+	// Smallest cut whose verbatim remainder fits.
+	minCut := s.safeCut(messages, s.firstCutWithin(prefixSum, preservedHead, tailStart, available), tailStart)
+	keys := spanKeys(messages, preservedHead, tailStart)
+
+	// Reuse the cached summary that keeps the most messages verbatim.
+	for cut := minCut; cut <= tailStart; cut++ {
+		summary, ok := s.lookup(keys[cut])
+		if !ok {
+			continue
+		}
+		tokens, err := s.tokenCounter.CountTokens(ctx, summaryMessage(summary))
+		if err == nil && tokens+keptTokens(cut) <= maxTokens-fixedTokens {
+			return buildSummarizedResult(messages, preservedHead, summary, cut), nil
+		}
+	}
+
+	// Summarize more than strictly needed so the summary lasts several turns.
+	target := int(float64(available) * s.retainRatio)
+	cut := s.safeCut(messages, max(s.firstCutWithin(prefixSum, preservedHead, tailStart, target), minCut), tailStart)
+
+	// Extend the longest cached summary of a shorter span, if any.
+	from, previous := preservedHead, ""
+	for c := cut - 1; c > preservedHead; c-- {
+		if summary, ok := s.lookup(keys[c]); ok {
+			from, previous = c, summary
+			break
+		}
+	}
+
+	summary, err := s.summarize(ctx, previous, messages[from:cut])
+	if err != nil {
+		return s.fallback.TailorMessages(ctx, messages, maxTokens)
+	}
+	s.remember(keys[cut], summary)
+	return buildSummarizedResult(messages, preservedHead, summary, cut), nil
+}
+
+// firstCutWithin returns the smallest index in [start, end] such that the
+// tokens of messages[index:end] do not exceed budget.
+func (s *SummarizingStrategy) firstCutWithin(prefixSum []int, start, end, budget int) int {
+	for cut := start; cut < end; cut++ {
+		if prefixSum[end]-prefixSum[cut] <= budget {
+			return cut
+		}
+	}
+	return end
+}
+
+// safeCut moves cut forward past tool results so that a tool call and its
+// results are either both summarized or both kept.
+func (s *SummarizingStrategy) safeCut(messages []Message, cut, end int) int {
+	for cut < end && messages[cut].Role == RoleTool {
+		cut++
+	}
+	return cut
+}
+
+// summarize asks the summarizer model for a summary of messages, extending
+// previous when it is not empty.
+func (s *SummarizingStrategy) summarize(ctx context.Context, previous string, messages []Message) (string, error) {
+	if s.summarizer == nil {
+		return "", errors.New("no summarizer model configured")
+	}
+	var b strings.Builder
+	if previous != "" {
+		fmt.Fprintf(&b, "%s%s\n\n", summaryMessagePrefix, previous)
+	}
+	b.WriteString(renderMessages(messages))
+
+	request := &Request{
+		Messages: []Message{NewUserMessage(strings.Replace(s.prompt, SummaryConversationPlaceholder, b.String(), 1))},
+		GenerationConfig: GenerationConfig{
+			Stream: false,
+		},
+	}
+	if s.reserveTokens > 0 {
+		maxTokens := s.reserveTokens
+		request.MaxTokens = &maxTokens
+	}
+
+	// The summarizer calls count against the budget of the run, if any.
+	recorder, budgeted := UsageRecorderFromContext(ctx)
+	if budgeted {
+		if err := recorder.Check(); err != nil {
+			return "", fmt.Errorf("generate summary: %w", err)
+		}
+	}
+
+	responseChan, err := s.summarizer.GenerateContent(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("generate summary: %w", err)
+	}
+	var summary string
+	for response := range responseChan {
+		if response.Error != nil {
+			// Let the summarizer finish sending instead of blocking on it.
+			go func() {
+				for range responseChan {
+				}
+			}()
+			return "", fmt.Errorf("generate summary: %s", response.Error.Message)
+		}
+		if response.IsPartial {
+			continue
+		}
+		if len(response.Choices) > 0 {
+			summary += response.Choices[0].Message.Content
+		}
+		if budgeted && response.Usage != nil && !response.CacheHit {
+			// An exceeded budget stops the run at its next check; the
+			// summary already paid for is still used.
+			_ = recorder.Record(s.summarizer.Info().Name, response.Usage)
+		}
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", errors.New("generate summary: empty summary")
+	}
+	return summary, nil
+}
+
+func (s *SummarizingStrategy) lookup(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary, ok := s.cache[key]
+	return summary, ok
+}
+
+func (s *SummarizingStrategy) remember(key, summary string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cacheSize > 0 && len(s.cache) >= s.cacheSize {
+		s.cache = make(map[string]string)
+	}
+	s.cache[key] = summary
+}
+
+// summaryMessage builds the message carrying a summary.
+func summaryMessage(summary string) Message {
+	return NewSystemMessage(summaryMessagePrefix + summary)
+}
+
+// buildSummarizedResult builds head + summary + messages[cut:].
+func buildSummarizedResult(messages []Message, preservedHead int, summary string, cut int) []Message {
+	result := make([]Message, 0, preservedHead+1+len(messages)-cut)
+	result = append(result, messages[:preservedHead]...)
+	result = append(result, summaryMessage(summary))
+	return append(result, messages[cut:]...)
+}
+
+// spanKeys returns chained hashes of the spans messages[start:i], indexed by
+// i, for i in (start, end]. Entries at or before start are empty.
+func spanKeys(messages []Message, start, end int) []string {
+	keys := make([]string, end+1)
+	h := sha256.New()
+	for i := start; i < end; i++ {
+		writeMessageKey(h, messages[i])
+		keys[i+1] = hex.EncodeToString(h.Sum(nil))
+	}
+	return keys
+}
+
+// writeMessageKey writes the fields identifying a message to w.
+func writeMessageKey(w io.Writer, msg Message) {
+	fmt.Fprintf(w, "%s\x00%s\x00%s\x00", msg.Role, msg.Content, msg.ToolID)
+	for _, part := range msg.ContentParts {
+		if part.Text != nil {
+			fmt.Fprintf(w, "%s\x00", *part.Text)
+		}
+	}
+	for _, tc := range msg.ToolCalls {
+		fmt.Fprintf(w, "%s\x00%s\x00%s\x00", tc.ID, tc.Function.Name, tc.Function.Arguments)
+	}
+	fmt.Fprint(w, "\x01")
+}
+
+// renderMessages renders messages as plain text for the summarizer.
+func renderMessages(messages []Message) string {
+	lines := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.Content)
+		for _, part := range msg.ContentParts {
+			if part.Text != nil {
+				content = strings.TrimSpace(content + " " + *part.Text)
+			}
+		}
+		switch {
+		case msg.Role == RoleTool:
+			lines = append(lines, fmt.Sprintf("tool %s result: %s", msg.ToolName, content))
+		case len(msg.ToolCalls) > 0:
+			if content != "" {
+				lines = append(lines, fmt.Sprintf("%s: %s", msg.Role, content))
+			}
+			for _, tc := range msg.ToolCalls {
+				lines = append(lines, fmt.Sprintf("%s called tool %s(%s)", msg.Role, tc.Function.Name, tc.Function.Arguments))
+			}
+		case content != "":
+			lines = append(lines, fmt.Sprintf("%s: %s", msg.Role, content))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,288 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedTokenCounter counts every message as the same number of tokens.
+type fixedTokenCounter struct{ perMessage int }
+
+func (c fixedTokenCounter) CountTokens(context.Context, Message) (int, error) {
+	return c.perMessage, nil
+}
+
+func (c fixedTokenCounter) CountTokensRange(_ context.Context, messages []Message, start, end int) (int, error) {
+	return (end - start) * c.perMessage, nil
+}
+
+// summarizerModel answers every request with a numbered summary and records
+// the prompts.
+type summarizerModel struct {
+	mu      sync.Mutex
+	prompts []string
+	err     error
+}
+
+func (m *summarizerModel) Info() Info { return Info{Name: "summarizer"} }
+
+func (m *summarizerModel) GenerateContent(_ context.Context, request *Request) (<-chan *Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.prompts = append(m.prompts, request.Messages[0].Content)
+	ch := make(chan *Response, 1)
+	ch <- &Response{
+		Done:    true,
+		Choices: []Choice{{Message: NewAssistantMessage(fmt.Sprintf("S%d", len(m.prompts)))}},
+		Usage:   &Usage{PromptTokens: 30, CompletionTokens: 5, TotalTokens: 35},
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (m *summarizerModel) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.prompts)
+}
+
+// chatTurns builds a system message followed by n user/assistant turns.
+func chatTurns(n int) []Message {
+	messages := []Message{NewSystemMessage("system")}
+	for i := 0; i < n; i++ {
+		messages = append(messages, NewUserMessage(fmt.Sprintf("question %d", i)), NewAssistantMessage(fmt.Sprintf("answer %d", i)))
+	}
+	return messages
+}
+
+// toolTurns builds a system message followed by n turns that each call a tool.
+func toolTurns(n int) []Message {
+	messages := []Message{NewSystemMessage("system")}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("call_%d", i)
+		messages = append(messages,
+			NewUserMessage(fmt.Sprintf("question %d", i)),
+			Message{Role: RoleAssistant, ToolCalls: []ToolCall{{
+				Type: "function", ID: id,
+				Function: FunctionDefinitionParam{Name: "lookup", Arguments: []byte(`{"q":1}`)},
+			}}},
+			NewToolMessage(id, "lookup", fmt.Sprintf("result %d", i)),
+			NewAssistantMessage(fmt.Sprintf("answer %d", i)),
+		)
+	}
+	return messages
+}
+
+func TestSummarizingStrategy_WithinBudget(t *testing.T) {
+	summarizer := &summarizerModel{}
+	s := NewSummarizingStrategy(fixedTokenCounter{perMessage: 10}, summarizer)
+	messages := chatTurns(3)
+
+	result, err := s.TailorMessages(context.Background(), messages, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, messages, result)
+	assert.Zero(t, summarizer.calls())
+}
+
+func TestSummarizingStrategy_SummarizesOldestMessages(t *testing.T) {
+	summarizer := &summarizerModel{}
+	counter := fixedTokenCounter{perMessage: 10}
+	s := NewSummarizingStrategy(counter, summarizer, WithSummaryReserveTokens(20))
+	messages := chatTurns(10)
+
+	result, err := s.TailorMessages(context.Background(), messages, 120)
+	require.NoError(t, err)
+	require.Equal(t, 1, summarizer.calls())
+
+	assert.Equal(t, messages[0], result[0])
+	assert.Equal(t, RoleSystem, result[1].Role)
+	assert.Equal(t, summaryMessagePrefix+"S1", result[1].Content)
+	assert.Equal(t, messages[len(messages)-2:], result[len(result)-2:])
+	tokens, _ := counter.CountTokensRange(context.Background(), result, 0, len(result))
+	assert.LessOrEqual(t, tokens, 120)
+
+	// The summarized messages are exactly the ones missing from the result.
+	kept := len(result) - 2
+	prompt := summarizer.prompts[0]
+	for i, msg := range messages[1 : len(messages)-kept] {
+		assert.Contains(t, prompt, msg.Content, "message %d", i+1)
+	}
+	assert.NotContains(t, prompt, messages[len(messages)-kept].Content)
+}
+
+func TestSummarizingStrategy_ReusesAndExtendsSummary(t *testing.T) {
+	summarizer := &summarizerModel{}
+	s := NewSummarizingStrategy(fixedTokenCounter{perMessage: 10}, summarizer, WithSummaryReserveTokens(20))
+	ctx := context.Background()
+
+	messages := chatTurns(10)
+	first, err := s.TailorMessages(ctx, messages, 120)
+	require.NoError(t, err)
+	require.Equal(t, 1, summarizer.calls())
+
+	// One more turn still fits after the cached summary.
+	messages = chatTurns(11)
+	second, err := s.TailorMessages(ctx, messages, 120)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summarizer.calls())
+	assert.Equal(t, first[1], second[1])
+	assert.Equal(t, first[2:], second[2:len(second)-2])
+
+	// Eventually the summary is extended from the cached one.
+	for n := 12; summarizer.calls() == 1 && n < 30; n++ {
+		_, err := s.TailorMessages(ctx, chatTurns(n), 120)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, summarizer.calls())
+	prompt := summarizer.prompts[1]
+	assert.Contains(t, prompt, summaryMessagePrefix+"S1")
+	assert.NotContains(t, prompt, "question 0")
+}
+
+func TestSummarizingStrategy_KeepsToolPairs(t *testing.T) {
+	counter := fixedTokenCounter{perMessage: 10}
+	messages := toolTurns(8)
+	for maxTokens := 80; maxTokens <= 320; maxTokens += 10 {
+		s := NewSummarizingStrategy(counter, &summarizerModel{}, WithSummaryReserveTokens(10))
+		result, err := s.TailorMessages(context.Background(), messages, maxTokens)
+		require.NoError(t, err)
+
+		calls := make(map[string]bool)
+		for i, msg := range result {
+			for _, tc := range msg.ToolCalls {
+				calls[tc.ID] = true
+			}
+			if msg.Role == RoleTool {
+				assert.True(t, calls[msg.ToolID], "maxTokens=%d: orphaned tool result at %d", maxTokens, i)
+			}
+		}
+		if strings.HasPrefix(result[1].Content, summaryMessagePrefix) {
+			assert.NotEqual(t, RoleTool, result[2].Role, "maxTokens=%d", maxTokens)
+		}
+	}
+}
+
+func TestSummarizingStrategy_FallsBackOnError(t *testing.T) {
+	counter := fixedTokenCounter{perMessage: 10}
+	s := NewSummarizingStrategy(counter, &summarizerModel{err: errors.New("unavailable")}, WithSummaryReserveTokens(20))
+	messages := chatTurns(10)
+
+	result, err := s.TailorMessages(context.Background(), messages, 120)
+	require.NoError(t, err)
+	expected, err := NewHeadOutStrategy(counter).TailorMessages(context.Background(), messages, 120)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+// erroringModel streams an error response followed by more responses on an
+// unbuffered channel, and closes sent once it could send them all.
+type erroringModel struct {
+	sent chan struct{}
+}
+
+func (m *erroringModel) Info() Info { return Info{Name: "erroring"} }
+
+func (m *erroringModel) GenerateContent(context.Context, *Request) (<-chan *Response, error) {
+	ch := make(chan *Response)
+	go func() {
+		defer close(m.sent)
+		defer close(ch)
+		ch <- &Response{Error: &ResponseError{Message: "overloaded"}}
+		ch <- &Response{Done: true, Choices: []Choice{{Message: NewAssistantMessage("late")}}}
+	}()
+	return ch, nil
+}
+
+func TestSummarizingStrategy_DrainsOnResponseError(t *testing.T) {
+	counter := fixedTokenCounter{perMessage: 10}
+	summarizer := &erroringModel{sent: make(chan struct{})}
+	s := NewSummarizingStrategy(counter, summarizer, WithSummaryReserveTokens(20))
+	messages := chatTurns(10)
+
+	result, err := s.TailorMessages(context.Background(), messages, 120)
+	require.NoError(t, err)
+	expected, err := NewHeadOutStrategy(counter).TailorMessages(context.Background(), messages, 120)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	select {
+	case <-summarizer.sent:
+	case <-time.After(time.Second):
+		t.Fatal("the summarizer is blocked on sending")
+	}
+}
+
+// usageRecorder records usage and fails its checks once exhausted is set.
+type usageRecorder struct {
+	exhausted bool
+	usage     []Usage
+	models    []string
+}
+
+func (r *usageRecorder) Check() error {
+	if r.exhausted {
+		return errors.New("budget exceeded")
+	}
+	return nil
+}
+
+func (r *usageRecorder) Record(modelName string, usage *Usage) error {
+	r.models = append(r.models, modelName)
+	r.usage = append(r.usage, *usage)
+	return r.Check()
+}
+
+func TestSummarizingStrategy_RecordsUsage(t *testing.T) {
+	counter := fixedTokenCounter{perMessage: 10}
+	summarizer := &summarizerModel{}
+	recorder := &usageRecorder{}
+	ctx := NewUsageRecorderContext(context.Background(), recorder)
+
+	s := NewSummarizingStrategy(counter, summarizer, WithSummaryReserveTokens(20))
+	_, err := s.TailorMessages(ctx, chatTurns(10), 120)
+	require.NoError(t, err)
+	require.Equal(t, 1, summarizer.calls())
+	assert.Equal(t, []string{"summarizer"}, recorder.models)
+	assert.Equal(t, []Usage{{PromptTokens: 30, CompletionTokens: 5, TotalTokens: 35}}, recorder.usage)
+
+	// An exhausted budget falls back without calling the summarizer.
+	recorder.exhausted = true
+	s = NewSummarizingStrategy(counter, summarizer, WithSummaryReserveTokens(20))
+	result, err := s.TailorMessages(ctx, chatTurns(10), 120)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summarizer.calls())
+	expected, err := NewHeadOutStrategy(counter).TailorMessages(ctx, chatTurns(10), 120)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestSummarizingStrategy_CacheBound(t *testing.T) {
+	s := NewSummarizingStrategy(fixedTokenCounter{perMessage: 1}, nil, WithSummaryCacheSize(2))
+	s.remember("a", "1")
+	s.remember("b", "2")
+	s.remember("c", "3")
+	_, ok := s.lookup("a")
+	assert.False(t, ok)
+	summary, ok := s.lookup("c")
+	assert.True(t, ok)
+	assert.Equal(t, "3", summary)
+}
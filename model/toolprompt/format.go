@@ -0,0 +1,242 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package toolprompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// Format renders tools, tool calls and tool results as text and parses tool
+// calls back from model output. A tool call in model output is the text
+// between the delimiters returned by Delimiters.
+type Format interface {
+	// Instructions returns the system prompt text describing the tools and
+	// how to call them.
+	Instructions(decls []*tool.Declaration) (string, error)
+	// Delimiters returns the markers opening and closing a tool call.
+	Delimiters() (open, close string)
+	// EncodeCall renders a tool call, delimiters included, for the
+	// conversation history.
+	EncodeCall(name string, arguments []byte) string
+	// DecodeCall parses the text between the delimiters of a tool call.
+	DecodeCall(body string) (name string, arguments []byte, err error)
+	// EncodeResult renders the result of a tool call.
+	EncodeResult(name, id, content string) string
+}
+
+// XMLFormat returns a format wrapping tool calls in <tool_call> tags:
+//
+//	<tool_call>
+//	<name>get_weather</name>
+//	<arguments>{"city": "Paris"}</arguments>
+//	</tool_call>
+func XMLFormat() Format {
+	return xmlFormat{}
+}
+
+// JSONFormat returns a format wrapping tool calls in fenced JSON blocks:
+//
+//	```tool_call
+//	{"name": "get_weather", "arguments": {"city": "Paris"}}
+//	```
+func JSONFormat() Format {
+	return jsonFormat{}
+}
+
+type xmlFormat struct{}
+
+func (xmlFormat) Instructions(decls []*tool.Declaration) (string, error) {
+	var b strings.Builder
+	b.WriteString("You can call the following tools. Each tool has a name, a description " +
+		"and a JSON schema of its arguments.\n\n<tools>\n")
+	for _, decl := range decls {
+		schema, err := marshalSchema(decl.InputSchema)
+		if err != nil {
+			return "", fmt.Errorf("tool %s: %w", decl.Name, err)
+		}
+		fmt.Fprintf(&b, "<tool name=%q>\n<description>%s</description>\n<parameters>%s</parameters>\n</tool>\n",
+			html.EscapeString(decl.Name), html.EscapeString(decl.Description), schema)
+	}
+	b.WriteString("</tools>\n\nTo call a tool, reply with one block per call in exactly this form:\n" +
+		"<tool_call>\n<name>TOOL_NAME</name>\n<arguments>{\"argument\": \"value\"}</arguments>\n</tool_call>\n" +
+		"The arguments must be a JSON object matching the schema of the tool. You may call several tools " +
+		"at once. After your tool calls, stop and wait: the results are returned in <tool_result> blocks. " +
+		"When no tool is needed, answer normally.")
+	return b.String(), nil
+}
+
+func (xmlFormat) Delimiters() (string, string) {
+	return "<tool_call>", "</tool_call>"
+}
+
+func (xmlFormat) EncodeCall(name string, arguments []byte) string {
+	return fmt.Sprintf("<tool_call>\n<name>%s</name>\n<arguments>%s</arguments>\n</tool_call>",
+		html.EscapeString(name), normalizeArguments(arguments))
+}
+
+func (xmlFormat) DecodeCall(body string) (string, []byte, error) {
+	name, ok := innerTag(body, "name")
+	if !ok {
+		// Some models answer with the JSON form inside the tags.
+		return decodeJSONCall(body)
+	}
+	name = html.UnescapeString(strings.TrimSpace(name))
+	if name == "" {
+		return "", nil, errors.New("empty tool name")
+	}
+	args, _ := innerTag(body, "arguments")
+	arguments, err := parseArguments([]byte(strings.TrimSpace(args)))
+	if err != nil {
+		return "", nil, err
+	}
+	return name, arguments, nil
+}
+
+func (xmlFormat) EncodeResult(name, id, content string) string {
+	return fmt.Sprintf("<tool_result name=%q id=%q>\n%s\n</tool_result>",
+		html.EscapeString(name), html.EscapeString(id), content)
+}
+
+type jsonFormat struct{}
+
+// jsonCall is a tool call in the JSON format.
+type jsonCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// jsonTool describes a tool in the JSON format.
+type jsonTool struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Parameters  *tool.Schema `json:"parameters,omitempty"`
+}
+
+// jsonResult is a tool result in the JSON format.
+type jsonResult struct {
+	Name    string `json:"name"`
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content"`
+}
+
+func (jsonFormat) Instructions(decls []*tool.Declaration) (string, error) {
+	tools := make([]jsonTool, 0, len(decls))
+	for _, decl := range decls {
+		tools = append(tools, jsonTool{Name: decl.Name, Description: decl.Description, Parameters: decl.InputSchema})
+	}
+	spec, err := json.MarshalIndent(tools, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal tools: %w", err)
+	}
+	return "You can call the following tools. Each tool has a name, a description " +
+		"and a JSON schema of its parameters:\n\n```json\n" + string(spec) + "\n```\n\n" +
+		"To call a tool, reply with one block per call in exactly this form:\n" +
+		"```tool_call\n{\"name\": \"TOOL_NAME\", \"arguments\": {\"argument\": \"value\"}}\n```\n" +
+		"The arguments must be a JSON object matching the parameters of the tool. You may call several " +
+		"tools at once. After your tool calls, stop and wait: the results are returned in ```tool_result " +
+		"blocks. When no tool is needed, answer normally.", nil
+}
+
+func (jsonFormat) Delimiters() (string, string) {
+	return "```tool_call", "```"
+}
+
+func (jsonFormat) EncodeCall(name string, arguments []byte) string {
+	call, _ := json.Marshal(jsonCall{Name: name, Arguments: normalizeArguments(arguments)})
+	return "```tool_call\n" + string(call) + "\n```"
+}
+
+func (jsonFormat) DecodeCall(body string) (string, []byte, error) {
+	return decodeJSONCall(body)
+}
+
+func (jsonFormat) EncodeResult(name, id, content string) string {
+	result, _ := json.Marshal(jsonResult{Name: name, ID: id, Content: content})
+	return "```tool_result\n" + string(result) + "\n```"
+}
+
+func decodeJSONCall(body string) (string, []byte, error) {
+	var call jsonCall
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &call); err != nil {
+		return "", nil, fmt.Errorf("invalid tool call: %w", err)
+	}
+	if call.Name == "" {
+		return "", nil, errors.New("empty tool name")
+	}
+	// Arguments are sometimes sent as a JSON encoded string.
+	raw := []byte(call.Arguments)
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		raw = []byte(s)
+	}
+	arguments, err := parseArguments(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	return call.Name, arguments, nil
+}
+
+// parseArguments checks that raw is a JSON object and compacts it. Empty
+// arguments become an empty object.
+func parseArguments(raw []byte) ([]byte, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return []byte("{}"), nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("tool arguments are not a JSON object: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeArguments returns arguments as a JSON object for rendering.
+func normalizeArguments(arguments []byte) []byte {
+	if args, err := parseArguments(arguments); err == nil {
+		return args
+	}
+	return []byte("{}")
+}
+
+func marshalSchema(schema *tool.Schema) (string, error) {
+	if schema == nil {
+		return `{"type":"object"}`, nil
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("marshal schema: %w", err)
+	}
+	return string(b), nil
+}
+
+// innerTag returns the text between <tag> and </tag> in s.
+func innerTag(s, tag string) (string, bool) {
+	start := strings.Index(s, "<"+tag+">")
+	if start < 0 {
+		return "", false
+	}
+	start += len(tag) + 2
+	end := strings.Index(s[start:], "</"+tag+">")
+	if end < 0 {
+		return "", false
+	}
+	return s[start : start+end], true
+}
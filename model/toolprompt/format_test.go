@@ -0,0 +1,90 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package toolprompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+var weatherDecl = &tool.Declaration{
+	Name:        "get_weather",
+	Description: "Get the weather of a city.",
+	InputSchema: &tool.Schema{
+		Type:       "object",
+		Properties: map[string]*tool.Schema{"city": {Type: "string"}},
+		Required:   []string{"city"},
+	},
+}
+
+func TestFormats_RoundTrip(t *testing.T) {
+	for name, f := range map[string]Format{"xml": XMLFormat(), "json": JSONFormat()} {
+		t.Run(name, func(t *testing.T) {
+			instructions, err := f.Instructions([]*tool.Declaration{weatherDecl})
+			require.NoError(t, err)
+			assert.Contains(t, instructions, "get_weather")
+			assert.Contains(t, instructions, "Get the weather of a city.")
+			assert.Contains(t, instructions, `"city"`)
+
+			encoded := f.EncodeCall("get_weather", []byte(`{ "city": "Paris" }`))
+			open, close := f.Delimiters()
+			require.True(t, strings.HasPrefix(encoded, open))
+			require.True(t, strings.HasSuffix(encoded, close))
+			body := strings.TrimSuffix(strings.TrimPrefix(encoded, open), close)
+
+			name, args, err := f.DecodeCall(body)
+			require.NoError(t, err)
+			assert.Equal(t, "get_weather", name)
+			assert.Equal(t, `{"city":"Paris"}`, string(args))
+
+			result := f.EncodeResult("get_weather", "call_1", "sunny")
+			assert.Contains(t, result, "sunny")
+			assert.Contains(t, result, "call_1")
+		})
+	}
+}
+
+func TestXMLFormat_DecodeCall(t *testing.T) {
+	f := XMLFormat()
+	name, args, err := f.DecodeCall("\n<name> list_files </name>\n")
+	require.NoError(t, err)
+	assert.Equal(t, "list_files", name)
+	assert.Equal(t, "{}", string(args))
+
+	// The JSON form is accepted inside the tags.
+	name, args, err = f.DecodeCall(`{"name": "get_weather", "arguments": {"city": "Oslo"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "get_weather", name)
+	assert.Equal(t, `{"city":"Oslo"}`, string(args))
+
+	_, _, err = f.DecodeCall("<name>x</name><arguments>[1]</arguments>")
+	assert.Error(t, err)
+	_, _, err = f.DecodeCall("<name></name>")
+	assert.Error(t, err)
+}
+
+func TestJSONFormat_DecodeCall(t *testing.T) {
+	f := JSONFormat()
+	// Arguments encoded as a string are accepted.
+	name, args, err := f.DecodeCall(`{"name": "get_weather", "arguments": "{\"city\": \"Rome\"}"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "get_weather", name)
+	assert.Equal(t, `{"city":"Rome"}`, string(args))
+
+	_, _, err = f.DecodeCall(`{"arguments": {}}`)
+	assert.Error(t, err)
+	_, _, err = f.DecodeCall(`not json`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package toolprompt
+
+import "strings"
+
+// segment is a piece of model output: plain text, or the body of a tool
+// call when call is true.
+type segment struct {
+	text string
+	call bool
+}
+
+// scanner splits streamed model output into text and tool call bodies. Text
+// that may be the start of an opening delimiter is held back until the next
+// chunk tells whether it is.
+type scanner struct {
+	open, close string
+	buf         string
+	inCall      bool
+}
+
+func newScanner(f Format) *scanner {
+	open, close := f.Delimiters()
+	return &scanner{open: open, close: close}
+}
+
+// feed consumes a chunk and returns the segments completed by it.
+func (s *scanner) feed(chunk string) []segment {
+	s.buf += chunk
+	var segs []segment
+	for {
+		if !s.inCall {
+			i := strings.Index(s.buf, s.open)
+			if i < 0 {
+				n := len(s.buf) - partialPrefix(s.buf, s.open)
+				segs = appendText(segs, s.buf[:n])
+				s.buf = s.buf[n:]
+				return segs
+			}
+			segs = appendText(segs, s.buf[:i])
+			s.buf = s.buf[i+len(s.open):]
+			s.inCall = true
+		}
+		j := strings.Index(s.buf, s.close)
+		if j < 0 {
+			return segs
+		}
+		segs = append(segs, segment{text: s.buf[:j], call: true})
+		s.buf = s.buf[j+len(s.close):]
+		s.inCall = false
+	}
+}
+
+// flush returns the held back output as text, including an unterminated
+// tool call.
+func (s *scanner) flush() string {
+	text := s.buf
+	if s.inCall {
+		text = s.open + text
+	}
+	s.buf, s.inCall = "", false
+	return text
+}
+
+func appendText(segs []segment, text string) []segment {
+	if text == "" {
+		return segs
+	}
+	return append(segs, segment{text: text})
+}
+
+// partialPrefix returns the length of the longest suffix of s that is a
+// proper prefix of marker.
+func partialPrefix(s, marker string) int {
+	for n := min(len(s), len(marker)-1); n > 0; n-- {
+		if strings.HasSuffix(s, marker[:n]) {
+			return n
+		}
+	}
+	return 0
+}
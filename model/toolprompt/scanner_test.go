@@ -0,0 +1,60 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package toolprompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanner_Chunks(t *testing.T) {
+	text := "Let me check.<tool_call><name>a</name></tool_call> and <tool_call>b</tool_call>done <tool"
+	// Every chunking of the input yields the same segments.
+	for size := 1; size <= len(text); size++ {
+		s := newScanner(XMLFormat())
+		var segs []segment
+		for i := 0; i < len(text); i += size {
+			segs = append(segs, s.feed(text[i:min(i+size, len(text))])...)
+		}
+		segs = appendText(segs, s.flush())
+
+		var plain string
+		var calls []string
+		for _, seg := range segs {
+			if seg.call {
+				calls = append(calls, seg.text)
+			} else {
+				plain += seg.text
+			}
+		}
+		assert.Equal(t, "Let me check. and done <tool", plain, "chunk size %d", size)
+		assert.Equal(t, []string{"<name>a</name>", "b"}, calls, "chunk size %d", size)
+	}
+}
+
+func TestScanner_HoldsBackMarkerPrefix(t *testing.T) {
+	s := newScanner(JSONFormat())
+	assert.Equal(t, []segment{{text: "see "}}, s.feed("see ``"))
+	assert.Nil(t, s.feed("`tool_call\n{}"))
+	assert.Equal(t, []segment{{text: "\n{}", call: true}, {text: " ok"}}, s.feed("``` ok"))
+
+	// An unterminated call is returned as text.
+	assert.Nil(t, s.feed("```tool_call {"))
+	assert.Equal(t, "```tool_call {", s.flush())
+	assert.Equal(t, "", s.flush())
+}
+
+func TestPartialPrefix(t *testing.T) {
+	assert.Equal(t, 0, partialPrefix("hello", "<tool_call>"))
+	assert.Equal(t, 1, partialPrefix("hello <", "<tool_call>"))
+	assert.Equal(t, 5, partialPrefix("x<tool", "<tool_call>"))
+	assert.Equal(t, 0, partialPrefix("", "<tool_call>"))
+}
@@ -0,0 +1,334 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package toolprompt emulates tool calling for models without native
+// function calling support.
+//
+// The wrapped model does not receive Request.Tools. The tool declarations
+// are described in the system prompt instead, tool calls and tool results in
+// the history are rendered as text, and tool calls written by the model are
+// parsed back into Choice.Message.ToolCalls, so that agents run tools as
+// with any other model:
+//
+//	m := toolprompt.New(ollama.New("gemma3"), toolprompt.WithFormat(toolprompt.JSONFormat()))
+//	agent := llmagent.New("assistant", llmagent.WithModel(m), llmagent.WithTools(tools))
+package toolprompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+)
+
+// defaultChannelBufferSize is the default channel buffer size.
+const defaultChannelBufferSize = 256
+
+// finishReasonToolCalls is the finish reason of a response calling tools.
+const finishReasonToolCalls = "tool_calls"
+
+// options contains configuration options for creating a Model.
+type options struct {
+	format            Format
+	channelBufferSize int
+}
+
+// Option is a function that configures a Model.
+type Option func(*options)
+
+// WithFormat sets how tools are rendered and tool calls parsed. Defaults to
+// XMLFormat.
+func WithFormat(f Format) Option {
+	return func(opts *options) {
+		opts.format = f
+	}
+}
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.channelBufferSize = size
+	}
+}
+
+// Model emulates tool calling on top of a wrapped model.
+type Model struct {
+	model             model.Model
+	format            Format
+	channelBufferSize int
+}
+
+// New wraps m with prompt based tool calling.
+func New(m model.Model, opts ...Option) *Model {
+	o := &options{format: XMLFormat(), channelBufferSize: defaultChannelBufferSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Model{model: m, format: o.format, channelBufferSize: o.channelBufferSize}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return m.model.Info()
+}
+
+// GenerateContent implements the model.Model interface.
+func (m *Model) GenerateContent(ctx context.Context, request *model.Request) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	converted, err := m.convertRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	src, err := m.model.GenerateContent(ctx, converted)
+	if err != nil {
+		return nil, err
+	}
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		p := newResponseParser(m.format)
+		for rsp := range src {
+			for _, out := range p.parse(rsp) {
+				select {
+				case responseChan <- out:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return responseChan, nil
+}
+
+// convertRequest returns a copy of request without tools, with the tools
+// described in the system prompt and tool messages rendered as text.
+func (m *Model) convertRequest(request *model.Request) (*model.Request, error) {
+	converted := *request
+	converted.Tools = nil
+	converted.Messages = make([]model.Message, 0, len(request.Messages)+1)
+	for i, msg := range request.Messages {
+		switch {
+		case msg.Role == model.RoleTool:
+			result := m.format.EncodeResult(msg.ToolName, msg.ToolID, msg.Content)
+			// Consecutive results are sent as one user turn.
+			if i > 0 && request.Messages[i-1].Role == model.RoleTool {
+				converted.Messages[len(converted.Messages)-1].Content += "\n" + result
+				continue
+			}
+			converted.Messages = append(converted.Messages, model.NewUserMessage(result))
+		case msg.Role == model.RoleAssistant && len(msg.ToolCalls) > 0:
+			parts := make([]string, 0, len(msg.ToolCalls)+1)
+			if strings.TrimSpace(msg.Content) != "" {
+				parts = append(parts, msg.Content)
+			}
+			for _, call := range msg.ToolCalls {
+				parts = append(parts, m.format.EncodeCall(call.Function.Name, call.Function.Arguments))
+			}
+			msg.Content = strings.Join(parts, "\n")
+			msg.ToolCalls = nil
+			converted.Messages = append(converted.Messages, msg)
+		default:
+			converted.Messages = append(converted.Messages, msg)
+		}
+	}
+
+	decls := declarations(request.Tools)
+	if len(decls) == 0 {
+		return &converted, nil
+	}
+	instructions, err := m.format.Instructions(decls)
+	if err != nil {
+		return nil, fmt.Errorf("toolprompt: render tools: %w", err)
+	}
+	if len(converted.Messages) > 0 && converted.Messages[0].Role == model.RoleSystem {
+		converted.Messages[0].Content += "\n\n" + instructions
+	} else {
+		converted.Messages = append([]model.Message{model.NewSystemMessage(instructions)}, converted.Messages...)
+	}
+	return &converted, nil
+}
+
+// declarations returns the declarations of tools sorted by name, so that
+// the prompt is stable across requests.
+func declarations(tools map[string]tool.Tool) []*tool.Declaration {
+	decls := make([]*tool.Declaration, 0, len(tools))
+	for _, t := range tools {
+		if t == nil {
+			continue
+		}
+		if decl := t.Declaration(); decl != nil {
+			decls = append(decls, decl)
+		}
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].Name < decls[j].Name })
+	return decls
+}
+
+// responseParser turns tool calls written in model output into ToolCalls.
+// Tool call IDs are assigned by position, so the calls streamed in deltas
+// and those of the final message share their IDs.
+type responseParser struct {
+	format   Format
+	scanners map[int]*scanner
+	streamed map[int][]model.ToolCall
+	ids      map[int][]string
+}
+
+func newResponseParser(f Format) *responseParser {
+	p := &responseParser{format: f}
+	p.reset()
+	return p
+}
+
+// parse returns the responses to forward for rsp.
+func (p *responseParser) parse(rsp *model.Response) []*model.Response {
+	if rsp == nil || len(rsp.Choices) == 0 {
+		return []*model.Response{rsp}
+	}
+	if rsp.IsPartial {
+		out := *rsp
+		out.Choices = make([]model.Choice, len(rsp.Choices))
+		for i, choice := range rsp.Choices {
+			segs := p.scanner(choice.Index).feed(choice.Delta.Content)
+			calls := p.streamed[choice.Index]
+			choice.Delta.Content, choice.Delta.ToolCalls = p.convert(choice.Index, len(calls), segs)
+			p.streamed[choice.Index] = append(calls, choice.Delta.ToolCalls...)
+			out.Choices[i] = choice
+		}
+		return []*model.Response{&out}
+	}
+
+	var result []*model.Response
+	// Output held back while streaming is sent before the final response.
+	if held := p.flush(rsp); held != nil {
+		result = append(result, held)
+	}
+	out := *rsp
+	out.Choices = make([]model.Choice, len(rsp.Choices))
+	for i, choice := range rsp.Choices {
+		if msg := &choice.Message; len(msg.ToolCalls) == 0 {
+			s := newScanner(p.format)
+			segs := appendText(s.feed(msg.Content), s.flush())
+			content, calls := p.convert(choice.Index, 0, segs)
+			// Some providers do not repeat the streamed content at the end.
+			if len(calls) == 0 && strings.TrimSpace(msg.Content) == "" {
+				calls = p.streamed[choice.Index]
+			}
+			if len(calls) > 0 {
+				msg.Content = strings.TrimSpace(content)
+				msg.ToolCalls = calls
+				reason := finishReasonToolCalls
+				choice.FinishReason = &reason
+				// Like native tool calls, the response does not end the turn.
+				out.Done = false
+			}
+		}
+		out.Choices[i] = choice
+	}
+	p.reset()
+	return append(result, &out)
+}
+
+// convert joins the text segments and decodes the tool calls, numbering
+// them from first. Calls that cannot be decoded are kept as text.
+func (p *responseParser) convert(choiceIndex, first int, segs []segment) (string, []model.ToolCall) {
+	var (
+		text  strings.Builder
+		calls []model.ToolCall
+	)
+	open, close := p.format.Delimiters()
+	for _, seg := range segs {
+		if !seg.call {
+			text.WriteString(seg.text)
+			continue
+		}
+		name, args, err := p.format.DecodeCall(seg.text)
+		if err != nil {
+			log.Warnf("toolprompt: keeping malformed tool call as text: %v", err)
+			text.WriteString(open + seg.text + close)
+			continue
+		}
+		n := first + len(calls)
+		calls = append(calls, model.ToolCall{
+			Type:     "function",
+			ID:       p.id(choiceIndex, n),
+			Index:    &n,
+			Function: model.FunctionDefinitionParam{Name: name, Arguments: args},
+		})
+	}
+	return text.String(), calls
+}
+
+// id returns the ID of the n-th tool call of a choice.
+func (p *responseParser) id(choiceIndex, n int) string {
+	ids := p.ids[choiceIndex]
+	for len(ids) <= n {
+		ids = append(ids, "call_"+uuid.NewString())
+	}
+	p.ids[choiceIndex] = ids
+	return ids[n]
+}
+
+func (p *responseParser) scanner(choiceIndex int) *scanner {
+	s, ok := p.scanners[choiceIndex]
+	if !ok {
+		s = newScanner(p.format)
+		p.scanners[choiceIndex] = s
+	}
+	return s
+}
+
+// flush returns a partial response with the output held back by the
+// scanners, or nil when there is none.
+func (p *responseParser) flush(rsp *model.Response) *model.Response {
+	var choices []model.Choice
+	for _, choice := range rsp.Choices {
+		s, ok := p.scanners[choice.Index]
+		if !ok {
+			continue
+		}
+		if text := s.flush(); text != "" {
+			choices = append(choices, model.Choice{Index: choice.Index, Delta: model.Message{
+				Role:    model.RoleAssistant,
+				Content: text,
+			}})
+		}
+	}
+	if len(choices) == 0 {
+		return nil
+	}
+	return &model.Response{
+		ID:        rsp.ID,
+		Object:    rsp.Object,
+		Created:   rsp.Created,
+		Model:     rsp.Model,
+		Timestamp: rsp.Timestamp,
+		Choices:   choices,
+		IsPartial: true,
+	}
+}
+
+// reset starts a new message.
+func (p *responseParser) reset() {
+	p.scanners = make(map[int]*scanner)
+	p.streamed = make(map[int][]model.ToolCall)
+	p.ids = make(map[int][]string)
+}
@@ -0,0 +1,215 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package toolprompt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/agent/agenttest"
+	"trpc.group/trpc-go/trpc-agent-go/agent/llmagent"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/tool"
+	"trpc.group/trpc-go/trpc-agent-go/tool/function"
+)
+
+// chunkModel streams its text in chunks of the given size and ends with
+// the full message.
+type chunkModel struct {
+	text     string
+	size     int
+	requests []*model.Request
+}
+
+func (m *chunkModel) Info() model.Info { return model.Info{Name: "chunk"} }
+
+func (m *chunkModel) GenerateContent(_ context.Context, request *model.Request) (<-chan *model.Response, error) {
+	m.requests = append(m.requests, request)
+	ch := make(chan *model.Response, len(m.text)+1)
+	defer close(ch)
+	for i := 0; i < len(m.text); i += m.size {
+		ch <- &model.Response{IsPartial: true, Choices: []model.Choice{{
+			Delta: model.Message{Role: model.RoleAssistant, Content: m.text[i:min(i+m.size, len(m.text))]},
+		}}}
+	}
+	ch <- &model.Response{Done: true, Choices: []model.Choice{{
+		Message: model.NewAssistantMessage(m.text),
+	}}}
+	return ch, nil
+}
+
+type weatherArgs struct {
+	City string `json:"city"`
+}
+
+func newWeatherTool() tool.Tool {
+	return function.NewFunctionTool(func(_ context.Context, in weatherArgs) (string, error) {
+		return "sunny in " + in.City, nil
+	}, function.WithName("get_weather"), function.WithDescription("Get the weather of a city."))
+}
+
+func TestModel_ConvertRequest(t *testing.T) {
+	m := New(&chunkModel{})
+	temp := 0.1
+	request := &model.Request{
+		Messages: []model.Message{
+			model.NewSystemMessage("Be brief."),
+			model.NewUserMessage("weather?"),
+			{
+				Role:    model.RoleAssistant,
+				Content: "Checking.",
+				ToolCalls: []model.ToolCall{
+					{ID: "c1", Function: model.FunctionDefinitionParam{Name: "get_weather", Arguments: []byte(`{"city":"Paris"}`)}},
+					{ID: "c2", Function: model.FunctionDefinitionParam{Name: "get_weather", Arguments: []byte(`{"city":"Oslo"}`)}},
+				},
+			},
+			{Role: model.RoleTool, ToolID: "c1", ToolName: "get_weather", Content: "sunny"},
+			{Role: model.RoleTool, ToolID: "c2", ToolName: "get_weather", Content: "rainy"},
+		},
+		GenerationConfig: model.GenerationConfig{Temperature: &temp},
+		Tools:            map[string]tool.Tool{"get_weather": newWeatherTool()},
+	}
+	converted, err := m.convertRequest(request)
+	require.NoError(t, err)
+
+	assert.Nil(t, converted.Tools)
+	assert.Equal(t, &temp, converted.Temperature)
+	require.Len(t, converted.Messages, 4)
+	assert.True(t, strings.HasPrefix(converted.Messages[0].Content, "Be brief.\n\n"))
+	assert.Contains(t, converted.Messages[0].Content, `<tool name="get_weather">`)
+
+	assistant := converted.Messages[2]
+	assert.Empty(t, assistant.ToolCalls)
+	assert.Equal(t, "Checking.\n"+
+		"<tool_call>\n<name>get_weather</name>\n<arguments>{\"city\":\"Paris\"}</arguments>\n</tool_call>\n"+
+		"<tool_call>\n<name>get_weather</name>\n<arguments>{\"city\":\"Oslo\"}</arguments>\n</tool_call>",
+		assistant.Content)
+
+	results := converted.Messages[3]
+	assert.Equal(t, model.RoleUser, results.Role)
+	assert.Equal(t, "<tool_result name=\"get_weather\" id=\"c1\">\nsunny\n</tool_result>\n"+
+		"<tool_result name=\"get_weather\" id=\"c2\">\nrainy\n</tool_result>", results.Content)
+
+	// The original request is left untouched.
+	assert.Len(t, request.Messages, 5)
+	assert.Len(t, request.Messages[2].ToolCalls, 2)
+	assert.Equal(t, "Be brief.", request.Messages[0].Content)
+
+	// Without a system message the instructions are prepended.
+	converted, err = m.convertRequest(&model.Request{
+		Messages: []model.Message{model.NewUserMessage("hi")},
+		Tools:    map[string]tool.Tool{"get_weather": newWeatherTool()},
+	})
+	require.NoError(t, err)
+	require.Len(t, converted.Messages, 2)
+	assert.Equal(t, model.RoleSystem, converted.Messages[0].Role)
+}
+
+func TestModel_ParsesStreamedToolCalls(t *testing.T) {
+	text := "Let me check.\n<tool_call>\n<name>get_weather</name>\n<arguments>{\"city\": \"Paris\"}</arguments>\n</tool_call>" +
+		"<tool_call><name>get_weather</name><arguments>{\"city\": \"Oslo\"}</arguments></tool_call>"
+	for _, size := range []int{1, 3, 7, len(text)} {
+		m := New(&chunkModel{text: text, size: size})
+		ch, err := m.GenerateContent(context.Background(), &model.Request{GenerationConfig: model.GenerationConfig{Stream: true}})
+		require.NoError(t, err)
+
+		var (
+			streamedText  string
+			streamedCalls []model.ToolCall
+			final         *model.Response
+		)
+		for rsp := range ch {
+			if rsp.IsPartial {
+				streamedText += rsp.Choices[0].Delta.Content
+				streamedCalls = append(streamedCalls, rsp.Choices[0].Delta.ToolCalls...)
+				continue
+			}
+			final = rsp
+		}
+		require.NotNil(t, final)
+		assert.Equal(t, "Let me check.\n", streamedText, "chunk size %d", size)
+		assert.True(t, final.IsToolCallResponse())
+		assert.False(t, final.Done)
+		assert.Equal(t, finishReasonToolCalls, *final.Choices[0].FinishReason)
+		msg := final.Choices[0].Message
+		assert.Equal(t, "Let me check.", msg.Content)
+		require.Len(t, msg.ToolCalls, 2)
+		assert.Equal(t, `{"city":"Oslo"}`, string(msg.ToolCalls[1].Function.Arguments))
+		assert.Equal(t, 1, *msg.ToolCalls[1].Index)
+		require.Len(t, streamedCalls, 2)
+		for i := range msg.ToolCalls {
+			assert.Equal(t, streamedCalls[i].ID, msg.ToolCalls[i].ID)
+			assert.True(t, strings.HasPrefix(msg.ToolCalls[i].ID, "call_"))
+		}
+		assert.NotEqual(t, msg.ToolCalls[0].ID, msg.ToolCalls[1].ID)
+	}
+}
+
+func TestModel_PlainAndMalformedOutput(t *testing.T) {
+	text := "No tools needed. <tool_call><name>x</name><arguments>oops</arguments></tool_call> <tool"
+	m := New(&chunkModel{text: text, size: 4})
+	ch, err := m.GenerateContent(context.Background(), &model.Request{})
+	require.NoError(t, err)
+	var streamed string
+	var final *model.Response
+	for rsp := range ch {
+		if rsp.IsPartial {
+			streamed += rsp.Choices[0].Delta.Content
+			continue
+		}
+		final = rsp
+	}
+	assert.Equal(t, text, streamed)
+	require.NotNil(t, final)
+	assert.False(t, final.IsToolCallResponse())
+	assert.True(t, final.Done)
+	assert.Equal(t, text, final.Choices[0].Message.Content)
+}
+
+func TestModel_WithAgent(t *testing.T) {
+	for name, f := range map[string]Format{"xml": XMLFormat(), "json": JSONFormat()} {
+		t.Run(name, func(t *testing.T) {
+			inner := agenttest.NewModel(
+				agenttest.Reply(f.EncodeCall("get_weather", []byte(`{"city":"Paris"}`))),
+				agenttest.Reply("It is sunny in Paris."),
+			)
+			ag := llmagent.New("assistant",
+				llmagent.WithModel(New(inner, WithFormat(f))),
+				llmagent.WithTools([]tool.Tool{newWeatherTool()}),
+			)
+			tr := agenttest.NewHarness(ag).MustRun(t, "weather in Paris?")
+
+			agenttest.AssertNoErrors(t, tr)
+			agenttest.AssertToolCalled(t, tr, "get_weather", `{"city":"Paris"}`)
+			agenttest.AssertFinalResponse(t, tr, "It is sunny in Paris.")
+
+			requests := inner.Requests()
+			require.Len(t, requests, 2)
+			for _, r := range requests {
+				assert.Empty(t, r.Tools)
+				assert.Contains(t, r.Messages[0].Content, "get_weather")
+			}
+			last := requests[1].Messages[len(requests[1].Messages)-1]
+			assert.Equal(t, model.RoleUser, last.Role)
+			assert.Contains(t, last.Content, "sunny in Paris")
+		})
+	}
+}
+
+func TestModel_NilRequest(t *testing.T) {
+	m := New(&chunkModel{})
+	_, err := m.GenerateContent(context.Background(), nil)
+	assert.Error(t, err)
+	assert.Equal(t, "chunk", m.Info().Name)
+}
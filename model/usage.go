@@ -0,0 +1,36 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package model
+
+import "context"
+
+// UsageRecorder accounts for the model calls of a run. It lets the model
+// calls made inside a model, such as those of a summarizing tailoring
+// strategy, count against the limits of the run that triggered them.
+type UsageRecorder interface {
+	// Check returns an error once the run must not make more model calls.
+	Check() error
+	// Record records the usage of a call to the named model. It returns an
+	// error once a limit is exceeded.
+	Record(modelName string, usage *Usage) error
+}
+
+type usageRecorderKey struct{}
+
+// NewUsageRecorderContext returns a context carrying r.
+func NewUsageRecorderContext(ctx context.Context, r UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageRecorderKey{}, r)
+}
+
+// UsageRecorderFromContext returns the recorder carried by ctx.
+func UsageRecorderFromContext(ctx context.Context) (UsageRecorder, bool) {
+	r, ok := ctx.Value(usageRecorderKey{}).(UsageRecorder)
+	return r, ok && r != nil
+}
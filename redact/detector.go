@@ -0,0 +1,167 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of the built-in detectors, used in placeholders.
+const (
+	KindEmail      = "EMAIL"
+	KindPhone      = "PHONE"
+	KindCreditCard = "CREDIT_CARD"
+	KindChineseID  = "CN_ID"
+)
+
+// Match is a sensitive value found in a text.
+type Match struct {
+	// Kind names the type of the value, e.g. EMAIL. It is used in the
+	// placeholder replacing the value and must be made of upper case
+	// letters, digits and underscores.
+	Kind string
+	// Start and End are the byte offsets of the value in the text.
+	Start, End int
+}
+
+// Detector finds sensitive values in a text.
+type Detector interface {
+	Detect(text string) []Match
+}
+
+// DetectorFunc adapts a function to the Detector interface, for custom
+// detection such as a lookup of known customer names.
+type DetectorFunc func(text string) []Match
+
+// Detect implements the Detector interface.
+func (f DetectorFunc) Detect(text string) []Match {
+	return f(text)
+}
+
+// Validator tells whether a candidate value really is sensitive, for
+// instance by verifying its checksum.
+type Validator func(value string) bool
+
+type regexDetector struct {
+	kind       string
+	re         *regexp.Regexp
+	validators []Validator
+}
+
+// NewRegexDetector returns a detector matching re. Candidates rejected by
+// one of validators are ignored.
+func NewRegexDetector(kind string, re *regexp.Regexp, validators ...Validator) Detector {
+	return &regexDetector{kind: kind, re: re, validators: validators}
+}
+
+func (d *regexDetector) Detect(text string) []Match {
+	var matches []Match
+	for _, loc := range d.re.FindAllStringIndex(text, -1) {
+		if d.valid(text[loc[0]:loc[1]]) {
+			matches = append(matches, Match{Kind: d.kind, Start: loc[0], End: loc[1]})
+		}
+	}
+	return matches
+}
+
+func (d *regexDetector) valid(value string) bool {
+	for _, v := range d.validators {
+		if !v(value) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	// phonePattern matches Chinese mobile numbers and numbers written with
+	// separators, optionally with a country code.
+	phonePattern = regexp.MustCompile(
+		`(?:\+\d{1,3}[\s-]?)?(?:\(\d{2,4}\)\s?|\b\d{2,4}[\s.-])\d{3,4}[\s.-]\d{4}\b|\b1[3-9]\d{9}\b`)
+	cardPattern      = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	chineseIDPattern = regexp.MustCompile(`\b\d{17}[\dXx]\b`)
+)
+
+// Email detects email addresses.
+func Email() Detector {
+	return NewRegexDetector(KindEmail, emailPattern)
+}
+
+// Phone detects phone numbers.
+func Phone() Detector {
+	return NewRegexDetector(KindPhone, phonePattern)
+}
+
+// CreditCard detects payment card numbers passing the Luhn check.
+func CreditCard() Detector {
+	return NewRegexDetector(KindCreditCard, cardPattern, Luhn)
+}
+
+// ChineseID detects resident identity card numbers of the People's Republic
+// of China with a valid check digit.
+func ChineseID() Detector {
+	return NewRegexDetector(KindChineseID, chineseIDPattern, ChineseIDChecksum)
+}
+
+// DefaultDetectors returns the built-in detectors. On overlapping matches
+// the earlier detector wins, so identity and card numbers are not taken for
+// phone numbers.
+func DefaultDetectors() []Detector {
+	return []Detector{Email(), ChineseID(), CreditCard(), Phone()}
+}
+
+// Luhn validates a number with the Luhn checksum used by payment cards.
+// Spaces and dashes are ignored.
+func Luhn(value string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(value)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// chineseIDWeights are the ISO 7064 MOD 11-2 weights of the first 17
+// digits of a resident identity card number.
+var chineseIDWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// ChineseIDChecksum validates the check digit of an 18-character resident
+// identity card number.
+func ChineseIDChecksum(value string) bool {
+	if len(value) != 18 {
+		return false
+	}
+	sum := 0
+	for i, w := range chineseIDWeights {
+		d := int(value[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		sum += d * w
+	}
+	const checks = "10X98765432"
+	return strings.ToUpper(value[17:]) == string(checks[sum%11])
+}
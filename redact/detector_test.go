@@ -0,0 +1,67 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func values(text string, d Detector) []string {
+	var out []string
+	for _, m := range d.Detect(text) {
+		out = append(out, text[m.Start:m.End])
+	}
+	return out
+}
+
+func TestDetectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		detector Detector
+		text     string
+		want     []string
+	}{
+		{"email", Email(), "write to bob.smith+tag@mail.example.co.uk or a@b", []string{"bob.smith+tag@mail.example.co.uk"}},
+		{"mobile", Phone(), "my number is 13812345678.", []string{"13812345678"}},
+		{"separated", Phone(), "call +86 138 0013 8000 or (555) 123-4567", []string{"+86 138 0013 8000", "(555) 123-4567"}},
+		{"no phone", Phone(), "order 12345 shipped in 2024", nil},
+		{"card", CreditCard(), "card 4111 1111 1111 1111, not 4111 1111 1111 1112", []string{"4111 1111 1111 1111"}},
+		{"chinese id", ChineseID(), "ID 11010519491231002X and 110105194912310021", []string{"11010519491231002X"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, values(tt.text, tt.detector))
+		})
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	assert.True(t, Luhn("4111111111111111"))
+	assert.True(t, Luhn("5500-0000-0000-0004"))
+	assert.False(t, Luhn("4111111111111112"))
+	assert.False(t, Luhn("0000"), "too short")
+	assert.False(t, Luhn("41111111111x1111"))
+}
+
+func TestChineseIDChecksum(t *testing.T) {
+	assert.True(t, ChineseIDChecksum("11010519491231002X"))
+	assert.True(t, ChineseIDChecksum("11010519491231002x"))
+	assert.False(t, ChineseIDChecksum("110105194912310021"))
+	assert.False(t, ChineseIDChecksum("1101051949123100"))
+}
+
+func TestNewRegexDetector_Validators(t *testing.T) {
+	even := func(v string) bool { return (v[len(v)-1]-'0')%2 == 0 }
+	d := NewRegexDetector("EMPLOYEE", regexp.MustCompile(`E\d{4}`), even)
+	assert.Equal(t, []string{"E1002"}, values("E1001 and E1002", d))
+}
@@ -0,0 +1,62 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"trpc.group/trpc-go/trpc-agent-go/event"
+)
+
+// RedactEvent returns a copy of e, keeping its ID, whose messages and state
+// delta values are redacted. The replaced values are recorded in vault, so
+// that a session store can restore the state it reads back, see
+// RestoreState; a nil vault numbers the placeholders of this event only.
+// State delta values are redacted as text, so JSON values stay valid as long
+// as the detected values do not span JSON syntax.
+func (r *Redactor) RedactEvent(e *event.Event, vault *Vault) *event.Event {
+	if e == nil {
+		return nil
+	}
+	if vault == nil {
+		vault = NewVault()
+	}
+	redacted := *e
+	if e.Response != nil {
+		redacted.Response = e.Response.Clone()
+		for i := range redacted.Choices {
+			c := &redacted.Choices[i]
+			c.Message = r.RedactMessage(c.Message, vault)
+			c.Delta = r.RedactMessage(c.Delta, vault)
+		}
+	}
+	if e.StateDelta != nil {
+		redacted.StateDelta = make(map[string][]byte, len(e.StateDelta))
+		for k, v := range e.StateDelta {
+			if v == nil {
+				redacted.StateDelta[k] = v
+				continue
+			}
+			redacted.StateDelta[k] = []byte(r.Redact(string(v), vault))
+		}
+	}
+	return &redacted
+}
+
+// RestoreState restores, in place, the state values redacted by RedactEvent
+// with vault. A nil vault leaves state unchanged.
+func RestoreState(state map[string][]byte, vault *Vault) {
+	if vault == nil || vault.Len() == 0 {
+		return
+	}
+	for k, v := range state {
+		if v != nil {
+			state[k] = []byte(vault.Restore(string(v)))
+		}
+	}
+}
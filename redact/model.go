@@ -0,0 +1,206 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// defaultChannelBufferSize is the default channel buffer size.
+const defaultChannelBufferSize = 256
+
+// options contains configuration options for creating a Model.
+type options struct {
+	channelBufferSize int
+}
+
+// Option is a function that configures a redacting Model.
+type Option func(*options)
+
+// WithChannelBufferSize sets the channel buffer size for responses.
+func WithChannelBufferSize(size int) Option {
+	return func(opts *options) {
+		if size <= 0 {
+			size = defaultChannelBufferSize
+		}
+		opts.channelBufferSize = size
+	}
+}
+
+// Model redacts the messages sent to a wrapped model and restores the
+// redacted values in its responses.
+type Model struct {
+	model             model.Model
+	redactor          *Redactor
+	channelBufferSize int
+}
+
+// NewModel wraps m so that the requests it receives are redacted by r.
+func NewModel(m model.Model, r *Redactor, opts ...Option) *Model {
+	o := &options{channelBufferSize: defaultChannelBufferSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Model{model: m, redactor: r, channelBufferSize: o.channelBufferSize}
+}
+
+// Info implements the model.Model interface.
+func (m *Model) Info() model.Info {
+	return m.model.Info()
+}
+
+// GenerateContent implements the model.Model interface. The messages of
+// request are redacted with a vault of the call, which restores the values
+// in the streamed and final responses. The request itself is not modified.
+func (m *Model) GenerateContent(ctx context.Context, request *model.Request) (<-chan *model.Response, error) {
+	if request == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	vault := NewVault()
+	redacted := *request
+	redacted.Messages = make([]model.Message, len(request.Messages))
+	for i, msg := range request.Messages {
+		redacted.Messages[i] = m.redactor.RedactMessage(msg, vault)
+	}
+
+	src, err := m.model.GenerateContent(ctx, &redacted)
+	if err != nil {
+		return nil, err
+	}
+	if vault.Len() == 0 {
+		return src, nil
+	}
+	responseChan := make(chan *model.Response, m.channelBufferSize)
+	go func() {
+		defer close(responseChan)
+		r := &responseRestorer{vault: vault}
+		for rsp := range src {
+			if rsp != nil && !rsp.IsPartial {
+				// The text held back is streamed before the final response.
+				if rest := r.flush(); rest != nil && !send(ctx, responseChan, rest) {
+					drain(src)
+					return
+				}
+			}
+			if !send(ctx, responseChan, r.restore(rsp)) {
+				drain(src)
+				return
+			}
+		}
+		if rest := r.flush(); rest != nil {
+			send(ctx, responseChan, rest)
+		}
+	}()
+	return responseChan, nil
+}
+
+// responseRestorer restores the placeholders of the responses of a call.
+type responseRestorer struct {
+	vault *Vault
+	// content and reasoning restore the streamed text of each choice.
+	content   map[int]*streamRestorer
+	reasoning map[int]*streamRestorer
+	last      *model.Response
+}
+
+func (r *responseRestorer) restore(rsp *model.Response) *model.Response {
+	if rsp == nil {
+		return nil
+	}
+	rsp = rsp.Clone()
+	r.last = rsp
+	for i := range rsp.Choices {
+		c := &rsp.Choices[i]
+		if !rsp.IsPartial {
+			// A final response carries the whole text.
+			c.Message = r.vault.RestoreMessage(c.Message)
+			c.Delta = r.vault.RestoreMessage(c.Delta)
+			continue
+		}
+		c.Message = r.vault.RestoreMessage(c.Message)
+		content, reasoning := c.Delta.Content, c.Delta.ReasoningContent
+		c.Delta = r.vault.RestoreMessage(c.Delta)
+		c.Delta.Content = r.stream(&r.content, c.Index).next(content)
+		c.Delta.ReasoningContent = r.stream(&r.reasoning, c.Index).next(reasoning)
+	}
+	if !rsp.IsPartial {
+		r.content, r.reasoning = nil, nil
+	}
+	return rsp
+}
+
+func (r *responseRestorer) stream(restorers *map[int]*streamRestorer, index int) *streamRestorer {
+	if *restorers == nil {
+		*restorers = make(map[int]*streamRestorer)
+	}
+	s, ok := (*restorers)[index]
+	if !ok {
+		s = &streamRestorer{vault: r.vault}
+		(*restorers)[index] = s
+	}
+	return s
+}
+
+// flush returns a partial response with the text held back at the end of
+// the stream, or nil.
+func (r *responseRestorer) flush() *model.Response {
+	var choices []model.Choice
+	for _, index := range sortedIndexes(r.content) {
+		if text := r.content[index].flush(); text != "" {
+			choices = append(choices, model.Choice{
+				Index: index,
+				Delta: model.Message{Role: model.RoleAssistant, Content: text},
+			})
+		}
+	}
+	for _, index := range sortedIndexes(r.reasoning) {
+		if text := r.reasoning[index].flush(); text != "" {
+			choices = append(choices, model.Choice{
+				Index: index,
+				Delta: model.Message{Role: model.RoleAssistant, ReasoningContent: text},
+			})
+		}
+	}
+	if len(choices) == 0 {
+		return nil
+	}
+	rsp := &model.Response{Object: model.ObjectTypeChatCompletionChunk, IsPartial: true, Choices: choices}
+	if r.last != nil {
+		rsp.ID, rsp.Model, rsp.Created, rsp.Timestamp = r.last.ID, r.last.Model, r.last.Created, r.last.Timestamp
+	}
+	return rsp
+}
+
+func sortedIndexes(restorers map[int]*streamRestorer) []int {
+	indexes := make([]int, 0, len(restorers))
+	for i := range restorers {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+func send(ctx context.Context, ch chan<- *model.Response, rsp *model.Response) bool {
+	select {
+	case ch <- rsp:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func drain(ch <-chan *model.Response) {
+	for range ch {
+	}
+}
@@ -0,0 +1,142 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// echoModel streams back the content of the last message in chunks.
+type echoModel struct {
+	chunkSize int
+	request   *model.Request
+}
+
+func (m *echoModel) Info() model.Info {
+	return model.Info{Name: "echo"}
+}
+
+func (m *echoModel) GenerateContent(ctx context.Context, req *model.Request) (<-chan *model.Response, error) {
+	m.request = req
+	text := "You said: " + req.Messages[len(req.Messages)-1].Content
+	ch := make(chan *model.Response, len(text)+1)
+	for i := 0; i < len(text); i += m.chunkSize {
+		end := min(i+m.chunkSize, len(text))
+		ch <- &model.Response{IsPartial: true, Choices: []model.Choice{{
+			Delta: model.Message{Role: model.RoleAssistant, Content: text[i:end]},
+		}}}
+	}
+	ch <- &model.Response{Done: true, Choices: []model.Choice{{
+		Message: model.NewAssistantMessage(text),
+	}}}
+	close(ch)
+	return ch, nil
+}
+
+func collect(ch <-chan *model.Response) (streamed, final string) {
+	var b strings.Builder
+	for rsp := range ch {
+		if rsp.IsPartial {
+			b.WriteString(rsp.Choices[0].Delta.Content)
+		} else {
+			final = rsp.Choices[0].Message.Content
+		}
+	}
+	return b.String(), final
+}
+
+func TestModel_RedactsRequestAndRestoresResponses(t *testing.T) {
+	inner := &echoModel{chunkSize: 3}
+	m := NewModel(inner, New())
+	req := &model.Request{Messages: []model.Message{
+		model.NewSystemMessage("Be helpful."),
+		model.NewUserMessage("email alice@example.com or call 13812345678"),
+	}}
+
+	ch, err := m.GenerateContent(context.Background(), req)
+	require.NoError(t, err)
+	streamed, final := collect(ch)
+
+	assert.Equal(t, "email [EMAIL_1] or call [PHONE_1]", inner.request.Messages[1].Content)
+	assert.Equal(t, "email alice@example.com or call 13812345678", req.Messages[1].Content,
+		"the request of the caller is not modified")
+	want := "You said: email alice@example.com or call 13812345678"
+	assert.Equal(t, want, streamed)
+	assert.Equal(t, want, final)
+	assert.Equal(t, "echo", m.Info().Name)
+}
+
+func TestModel_FlushesHeldBackText(t *testing.T) {
+	inner := &unfinishedModel{chunks: []string{"see [EMAIL_1] and [note"}}
+	m := NewModel(inner, New())
+
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("alice@example.com")},
+	})
+	require.NoError(t, err)
+	streamed, _ := collect(ch)
+
+	assert.Equal(t, "see alice@example.com and [note", streamed)
+}
+
+func TestModel_FlushesHeldBackTextBeforeFinalResponse(t *testing.T) {
+	m := NewModel(&echoModel{chunkSize: 3}, New())
+
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("write to alice@example.com [")},
+	})
+	require.NoError(t, err)
+	streamed, final := collect(ch)
+
+	want := "You said: write to alice@example.com ["
+	assert.Equal(t, want, streamed)
+	assert.Equal(t, want, final)
+}
+
+func TestModel_NothingToRedact(t *testing.T) {
+	m := NewModel(&echoModel{chunkSize: 4}, New())
+	ch, err := m.GenerateContent(context.Background(), &model.Request{
+		Messages: []model.Message{model.NewUserMessage("hello")},
+	})
+	require.NoError(t, err)
+	streamed, final := collect(ch)
+	assert.Equal(t, "You said: hello", streamed)
+	assert.Equal(t, "You said: hello", final)
+
+	_, err = m.GenerateContent(context.Background(), nil)
+	assert.Error(t, err)
+}
+
+// unfinishedModel streams chunks without a final response.
+type unfinishedModel struct {
+	chunks []string
+}
+
+func (m *unfinishedModel) Info() model.Info {
+	return model.Info{Name: "unfinished"}
+}
+
+func (m *unfinishedModel) GenerateContent(ctx context.Context, req *model.Request) (<-chan *model.Response, error) {
+	ch := make(chan *model.Response, len(m.chunks))
+	for _, c := range m.chunks {
+		ch <- &model.Response{IsPartial: true, Choices: []model.Choice{{
+			Delta: model.Message{Role: model.RoleAssistant, Content: c},
+		}}}
+	}
+	close(ch)
+	return ch, nil
+}
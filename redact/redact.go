@@ -0,0 +1,265 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+// Package redact keeps personal data such as email addresses, phone numbers
+// and identity numbers away from model providers and session stores.
+//
+// A Redactor replaces the values found by its detectors with placeholders
+// like [EMAIL_1]. The replaced values are kept in a Vault, so that the
+// placeholders can be turned back into the original values:
+//
+//	r := redact.New() // the built-in detectors
+//	m := redact.NewModel(openai.New("gpt-4o"), r)
+//
+// The model m sends redacted messages to the provider and restores the
+// values in its responses. Session services store redacted events with
+// their WithRedactor option.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+// placeholderPattern matches the placeholders of a vault.
+var placeholderPattern = regexp.MustCompile(`\[([A-Z][A-Z0-9_]*)_(\d+)\]`)
+
+// maxPlaceholderLen bounds the text held back while restoring a stream, in
+// case a placeholder is split across chunks.
+const maxPlaceholderLen = 64
+
+// Redactor replaces sensitive values with placeholders.
+type Redactor struct {
+	detectors []Detector
+}
+
+// New returns a redactor using detectors, or the DefaultDetectors when none
+// is given.
+func New(detectors ...Detector) *Redactor {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return &Redactor{detectors: detectors}
+}
+
+// Redact replaces the sensitive values of text with placeholders recorded
+// in vault. A nil vault numbers the placeholders of this text only.
+func (r *Redactor) Redact(text string, vault *Vault) string {
+	if text == "" {
+		return text
+	}
+	matches := r.detect(text)
+	if len(matches) == 0 {
+		return text
+	}
+	if vault == nil {
+		vault = NewVault()
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString(vault.placeholder(m.Kind, text[m.Start:m.End]))
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// detect returns the non-overlapping matches of the detectors, in order.
+// On overlaps the earliest and then the longest match wins, and on ties the
+// match of the earlier detector.
+func (r *Redactor) detect(text string) []Match {
+	var all []Match
+	for _, d := range r.detectors {
+		for _, m := range d.Detect(text) {
+			if m.Start >= 0 && m.End <= len(text) && m.Start < m.End {
+				all = append(all, m)
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Start != all[j].Start {
+			return all[i].Start < all[j].Start
+		}
+		return all[i].End > all[j].End
+	})
+	matches := all[:0]
+	end := 0
+	for _, m := range all {
+		if m.Start >= end {
+			matches = append(matches, m)
+			end = m.End
+		}
+	}
+	return matches
+}
+
+// RedactMessage returns a copy of msg whose text, content parts and tool
+// call arguments are redacted.
+func (r *Redactor) RedactMessage(msg model.Message, vault *Vault) model.Message {
+	if vault == nil {
+		vault = NewVault()
+	}
+	msg.Content = r.Redact(msg.Content, vault)
+	msg.ReasoningContent = r.Redact(msg.ReasoningContent, vault)
+	if len(msg.ContentParts) > 0 {
+		parts := make([]model.ContentPart, len(msg.ContentParts))
+		for i, p := range msg.ContentParts {
+			if p.Text != nil {
+				text := r.Redact(*p.Text, vault)
+				p.Text = &text
+			}
+			parts[i] = p
+		}
+		msg.ContentParts = parts
+	}
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]model.ToolCall, len(msg.ToolCalls))
+		for i, tc := range msg.ToolCalls {
+			if len(tc.Function.Arguments) > 0 {
+				tc.Function.Arguments = []byte(r.Redact(string(tc.Function.Arguments), vault))
+			}
+			calls[i] = tc
+		}
+		msg.ToolCalls = calls
+	}
+	return msg
+}
+
+// Vault maps placeholders to the values they replace. A value gets the same
+// placeholder every time it is redacted with the same vault. It is safe for
+// concurrent use.
+type Vault struct {
+	mu      sync.Mutex
+	byValue map[string]string
+	values  map[string]string
+	counts  map[string]int
+}
+
+// NewVault returns an empty vault.
+func NewVault() *Vault {
+	return &Vault{
+		byValue: make(map[string]string),
+		values:  make(map[string]string),
+		counts:  make(map[string]int),
+	}
+}
+
+func (v *Vault) placeholder(kind, value string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key := kind + "\x00" + value
+	if p, ok := v.byValue[key]; ok {
+		return p
+	}
+	v.counts[kind]++
+	p := fmt.Sprintf("[%s_%d]", kind, v.counts[kind])
+	v.byValue[key] = p
+	v.values[p] = value
+	return p
+}
+
+// Len returns the number of values in the vault.
+func (v *Vault) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.values)
+}
+
+// Restore replaces the placeholders of text with their values. Unknown
+// placeholders are left as they are.
+func (v *Vault) Restore(text string) string {
+	return v.restore(text, false)
+}
+
+// RestoreJSON replaces the placeholders of a JSON document with their
+// values, escaped for use inside JSON strings.
+func (v *Vault) RestoreJSON(text string) string {
+	return v.restore(text, true)
+}
+
+func (v *Vault) restore(text string, escape bool) string {
+	if !strings.Contains(text, "[") {
+		return text
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		value, ok := v.values[p]
+		if !ok {
+			return p
+		}
+		if escape {
+			b, _ := json.Marshal(value)
+			return string(b[1 : len(b)-1])
+		}
+		return value
+	})
+}
+
+// RestoreMessage returns a copy of msg whose placeholders are replaced with
+// their values.
+func (v *Vault) RestoreMessage(msg model.Message) model.Message {
+	msg.Content = v.Restore(msg.Content)
+	msg.ReasoningContent = v.Restore(msg.ReasoningContent)
+	if len(msg.ContentParts) > 0 {
+		parts := make([]model.ContentPart, len(msg.ContentParts))
+		for i, p := range msg.ContentParts {
+			if p.Text != nil {
+				text := v.Restore(*p.Text)
+				p.Text = &text
+			}
+			parts[i] = p
+		}
+		msg.ContentParts = parts
+	}
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]model.ToolCall, len(msg.ToolCalls))
+		for i, tc := range msg.ToolCalls {
+			if len(tc.Function.Arguments) > 0 {
+				tc.Function.Arguments = []byte(v.RestoreJSON(string(tc.Function.Arguments)))
+			}
+			calls[i] = tc
+		}
+		msg.ToolCalls = calls
+	}
+	return msg
+}
+
+// streamRestorer restores the placeholders of streamed text, holding back
+// the end of a chunk that may be the start of a placeholder.
+type streamRestorer struct {
+	vault   *Vault
+	pending string
+}
+
+// next returns the restored text that can be emitted after chunk.
+func (s *streamRestorer) next(chunk string) string {
+	text := s.pending + chunk
+	s.pending = ""
+	if i := strings.LastIndex(text, "["); i >= 0 && !strings.Contains(text[i:], "]") &&
+		len(text)-i < maxPlaceholderLen {
+		text, s.pending = text[:i], text[i:]
+	}
+	return s.vault.Restore(text)
+}
+
+// flush returns the text held back.
+func (s *streamRestorer) flush() string {
+	text := s.pending
+	s.pending = ""
+	return s.vault.Restore(text)
+}
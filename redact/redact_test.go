@@ -0,0 +1,168 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trpc.group/trpc-go/trpc-agent-go/event"
+	"trpc.group/trpc-go/trpc-agent-go/model"
+)
+
+func TestRedactor_RedactAndRestore(t *testing.T) {
+	r := New()
+	vault := NewVault()
+	text := "alice@example.com and bob@example.com wrote; reply to alice@example.com or 13812345678"
+
+	redacted := r.Redact(text, vault)
+
+	assert.Equal(t, "[EMAIL_1] and [EMAIL_2] wrote; reply to [EMAIL_1] or [PHONE_1]", redacted)
+	assert.Equal(t, 3, vault.Len())
+	assert.Equal(t, text, vault.Restore(redacted))
+	assert.Equal(t, "unknown [EMAIL_9] stays", vault.Restore("unknown [EMAIL_9] stays"))
+}
+
+func TestRedactor_OverlapsAndCustomDetectors(t *testing.T) {
+	names := DetectorFunc(func(text string) []Match {
+		var matches []Match
+		for i := strings.Index(text, "Alice"); i >= 0; {
+			matches = append(matches, Match{Kind: "NAME", Start: i, End: i + len("Alice")})
+			next := strings.Index(text[i+1:], "Alice")
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+		return matches
+	})
+	r := New(append(DefaultDetectors(), names)...)
+
+	// The card number is not taken for a phone number, and the name inside
+	// the email address is part of the longer match.
+	got := r.Redact("Alice <Alice@example.com> paid with 4111 1111 1111 1111", nil)
+	assert.Equal(t, "[NAME_1] <[EMAIL_1]> paid with [CREDIT_CARD_1]", got)
+}
+
+func TestRedactor_RedactMessage(t *testing.T) {
+	r := New()
+	vault := NewVault()
+	part := "scan of 11010519491231002X"
+	msg := model.Message{
+		Role:         model.RoleAssistant,
+		Content:      "sending to alice@example.com",
+		ContentParts: []model.ContentPart{{Type: model.ContentTypeText, Text: &part}},
+		ToolCalls: []model.ToolCall{{
+			ID:       "call_1",
+			Function: model.FunctionDefinitionParam{Name: "send", Arguments: []byte(`{"to":"alice@example.com"}`)},
+		}},
+	}
+
+	redacted := r.RedactMessage(msg, vault)
+
+	assert.Equal(t, "sending to [EMAIL_1]", redacted.Content)
+	assert.Equal(t, "scan of [CN_ID_1]", *redacted.ContentParts[0].Text)
+	assert.JSONEq(t, `{"to":"[EMAIL_1]"}`, string(redacted.ToolCalls[0].Function.Arguments))
+	// The original message is not modified.
+	assert.Equal(t, "scan of 11010519491231002X", part)
+	assert.Equal(t, `{"to":"alice@example.com"}`, string(msg.ToolCalls[0].Function.Arguments))
+
+	assert.Equal(t, msg, vault.RestoreMessage(redacted))
+}
+
+func TestVault_RestoreJSON(t *testing.T) {
+	r := New(DetectorFunc(func(text string) []Match {
+		if i := strings.Index(text, `say "hi"`); i >= 0 {
+			return []Match{{Kind: "QUOTE", Start: i, End: i + len(`say "hi"`)}}
+		}
+		return nil
+	}))
+	vault := NewVault()
+	require.Equal(t, "[QUOTE_1]", r.Redact(`say "hi"`, vault))
+
+	restored := vault.RestoreJSON(`{"text":"[QUOTE_1]"}`)
+
+	var args struct{ Text string }
+	require.NoError(t, json.Unmarshal([]byte(restored), &args))
+	assert.Equal(t, `say "hi"`, args.Text)
+}
+
+func TestStreamRestorer(t *testing.T) {
+	vault := NewVault()
+	New().Redact("alice@example.com", vault)
+	s := &streamRestorer{vault: vault}
+
+	var out strings.Builder
+	for _, chunk := range []string{"write to [EMA", "IL_1] or [", "x] today [EM"} {
+		out.WriteString(s.next(chunk))
+	}
+	assert.Equal(t, "write to alice@example.com or [x] today ", out.String())
+	assert.Equal(t, "[EM", s.flush())
+}
+
+func TestRedactor_RedactEvent(t *testing.T) {
+	evt := event.New("inv", "assistant")
+	evt.Response = &model.Response{Choices: []model.Choice{{
+		Message: model.NewAssistantMessage("your card 4111 1111 1111 1111 is saved"),
+	}}}
+	evt.StateDelta = map[string][]byte{
+		"card":    []byte(`{"number":"4111 1111 1111 1111"}`),
+		"deleted": nil,
+	}
+
+	redacted := New().RedactEvent(evt, nil)
+
+	assert.Equal(t, evt.ID, redacted.ID)
+	assert.Equal(t, "your card [CREDIT_CARD_1] is saved", redacted.Choices[0].Message.Content)
+	assert.JSONEq(t, `{"number":"[CREDIT_CARD_1]"}`, string(redacted.StateDelta["card"]))
+	assert.Contains(t, redacted.StateDelta, "deleted")
+	assert.Nil(t, redacted.StateDelta["deleted"])
+	// The original event is not modified.
+	assert.Equal(t, "your card 4111 1111 1111 1111 is saved", evt.Choices[0].Message.Content)
+	assert.Equal(t, `{"number":"4111 1111 1111 1111"}`, string(evt.StateDelta["card"]))
+}
+
+func TestRedactor_RedactEventAndRestoreState(t *testing.T) {
+	call := `{"calls":[{"toolCallId":"call_1","arguments":{"to":"ann@example.com"}}]}`
+	evt := event.New("inv", "assistant")
+	evt.StateDelta = map[string][]byte{
+		"tool_confirmation:mailer": []byte(call),
+		"plan:support":             []byte(`{"goal":"call (555) 123-4567"}`),
+		"contact":                  []byte("ann@example.com"),
+	}
+	vault := NewVault()
+
+	redacted := New().RedactEvent(evt, vault)
+
+	assert.Equal(t, `{"calls":[{"toolCallId":"call_1","arguments":{"to":"[EMAIL_1]"}}]}`,
+		string(redacted.StateDelta["tool_confirmation:mailer"]))
+	assert.NotContains(t, string(redacted.StateDelta["plan:support"]), "555")
+	assert.Equal(t, "[EMAIL_1]", string(redacted.StateDelta["contact"]))
+
+	state := map[string][]byte{}
+	for k, v := range redacted.StateDelta {
+		state[k] = v
+	}
+	state["deleted"] = nil
+	RestoreState(state, vault)
+	assert.Equal(t, call, string(state["tool_confirmation:mailer"]))
+	assert.JSONEq(t, `{"goal":"call (555) 123-4567"}`, string(state["plan:support"]))
+	assert.Equal(t, "ann@example.com", string(state["contact"]))
+	assert.Nil(t, state["deleted"])
+
+	// Without the vault the placeholders stay.
+	state = map[string][]byte{"contact": []byte("[EMAIL_1]")}
+	RestoreState(state, nil)
+	assert.Equal(t, "[EMAIL_1]", string(state["contact"]))
+}
@@ -0,0 +1,100 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"sync"
+	"time"
+)
+
+// Vaults keeps a vault per key, such as a session, for stores that must not
+// hold the redacted values but read them back. The vaults live in memory
+// only: after a restart, or in another process, the placeholders stay. It is
+// safe for concurrent use.
+type Vaults struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	vaults    map[string]*vaultEntry
+	lastPrune time.Time
+}
+
+type vaultEntry struct {
+	vault     *Vault
+	expiredAt time.Time
+}
+
+// NewVaults returns an empty set of vaults. A vault not used for ttl is
+// dropped; a ttl of 0 keeps the vaults until they are deleted.
+func NewVaults(ttl time.Duration) *Vaults {
+	return &Vaults{
+		ttl:       ttl,
+		vaults:    make(map[string]*vaultEntry),
+		lastPrune: time.Now(),
+	}
+}
+
+// Get returns the vault of key, creating it if needed.
+func (v *Vaults) Get(key string) *Vault {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := time.Now()
+	v.prune(now)
+	entry, ok := v.vaults[key]
+	if !ok {
+		entry = &vaultEntry{vault: NewVault()}
+		v.vaults[key] = entry
+	}
+	entry.expiredAt = v.expiredAt(now)
+	return entry.vault
+}
+
+// Lookup returns the vault of key, or nil if there is none.
+func (v *Vaults) Lookup(key string) *Vault {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := time.Now()
+	entry, ok := v.vaults[key]
+	if !ok || entry.expired(now) {
+		return nil
+	}
+	entry.expiredAt = v.expiredAt(now)
+	return entry.vault
+}
+
+// Delete drops the vault of key.
+func (v *Vaults) Delete(key string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.vaults, key)
+}
+
+func (v *Vaults) expiredAt(now time.Time) time.Time {
+	if v.ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(v.ttl)
+}
+
+// prune drops the expired vaults, at most once per ttl.
+func (v *Vaults) prune(now time.Time) {
+	if v.ttl <= 0 || now.Sub(v.lastPrune) < v.ttl {
+		return
+	}
+	v.lastPrune = now
+	for key, entry := range v.vaults {
+		if entry.expired(now) {
+			delete(v.vaults, key)
+		}
+	}
+}
+
+func (e *vaultEntry) expired(now time.Time) bool {
+	return !e.expiredAt.IsZero() && now.After(e.expiredAt)
+}
@@ -0,0 +1,46 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package redact
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaults(t *testing.T) {
+	vaults := NewVaults(0)
+	assert.Nil(t, vaults.Lookup("s1"))
+
+	vault := vaults.Get("s1")
+	require.NotNil(t, vault)
+	assert.Same(t, vault, vaults.Get("s1"))
+	assert.Same(t, vault, vaults.Lookup("s1"))
+	assert.NotSame(t, vault, vaults.Get("s2"))
+
+	vaults.Delete("s1")
+	assert.Nil(t, vaults.Lookup("s1"))
+}
+
+func TestVaults_Expire(t *testing.T) {
+	vaults := NewVaults(20 * time.Millisecond)
+	vault := vaults.Get("s1")
+	vaults.Get("s2")
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(t, vaults.Lookup("s1"))
+	// Getting a vault prunes the expired ones.
+	assert.NotSame(t, vault, vaults.Get("s1"))
+	vaults.mu.Lock()
+	assert.Len(t, vaults.vaults, 1)
+	vaults.mu.Unlock()
+}
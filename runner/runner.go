@@ -20,8 +20,10 @@ import (
 
 	"trpc.group/trpc-go/trpc-agent-go/agent"
 	"trpc.group/trpc-go/trpc-agent-go/artifact"
+	"trpc.group/trpc-go/trpc-agent-go/budget"
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	"trpc.group/trpc-go/trpc-agent-go/graph"
+	"trpc.group/trpc-go/trpc-agent-go/guardrail"
 	"trpc.group/trpc-go/trpc-agent-go/log"
 	"trpc.group/trpc-go/trpc-agent-go/memory"
 	"trpc.group/trpc-go/trpc-agent-go/model"
@@ -58,6 +60,35 @@ func WithArtifactService(service artifact.Service) Option {
 	}
 }
 
+// WithBudget enables token and cost accounting. Usage of all model calls of
+// a run, including sub-agents, AgentTool calls and graph nodes, is
+// accumulated and checked against the budget limits. The totals are reported
+// on the runner completion event and persisted in session state.
+func WithBudget(b *budget.Budget) Option {
+	return func(opts *Options) {
+		opts.budget = b
+	}
+}
+
+// WithInputGuardrails checks the message of each run. The checks are made
+// by the first LLM agent of the run, concurrently with its first model call,
+// which is cancelled when a guardrail trips.
+func WithInputGuardrails(guardrails ...guardrail.Guardrail) Option {
+	return func(opts *Options) {
+		opts.inputGuardrails = append(opts.inputGuardrails, guardrails...)
+	}
+}
+
+// WithOutputGuardrails checks the final responses of every agent of a run
+// before they are persisted. A rejected response is neither persisted nor
+// emitted; the event reporting the check, with an error of type
+// model.ErrorTypeGuardrailTripped, takes its place.
+func WithOutputGuardrails(guardrails ...guardrail.Guardrail) Option {
+	return func(opts *Options) {
+		opts.outputGuardrails = append(opts.outputGuardrails, guardrails...)
+	}
+}
+
 // Runner is the interface for running agents.
 type Runner interface {
 	Run(
@@ -71,18 +102,24 @@ type Runner interface {
 
 // runner runs agents.
 type runner struct {
-	appName         string
-	agent           agent.Agent
-	sessionService  session.Service
-	memoryService   memory.Service
-	artifactService artifact.Service
+	appName          string
+	agent            agent.Agent
+	sessionService   session.Service
+	memoryService    memory.Service
+	artifactService  artifact.Service
+	budget           *budget.Budget
+	inputGuardrails  []guardrail.Guardrail
+	outputGuardrails []guardrail.Guardrail
 }
 
 // Options is the options for the Runner.
 type Options struct {
-	sessionService  session.Service
-	memoryService   memory.Service
-	artifactService artifact.Service
+	sessionService   session.Service
+	memoryService    memory.Service
+	artifactService  artifact.Service
+	budget           *budget.Budget
+	inputGuardrails  []guardrail.Guardrail
+	outputGuardrails []guardrail.Guardrail
 }
 
 // NewRunner creates a new Runner.
@@ -98,11 +135,14 @@ func NewRunner(appName string, agent agent.Agent, opts ...Option) Runner {
 		options.sessionService = inmemory.NewSessionService()
 	}
 	return &runner{
-		appName:         appName,
-		agent:           agent,
-		sessionService:  options.sessionService,
-		memoryService:   options.memoryService,
-		artifactService: options.artifactService,
+		appName:          appName,
+		agent:            agent,
+		sessionService:   options.sessionService,
+		memoryService:    options.memoryService,
+		artifactService:  options.artifactService,
+		budget:           options.budget,
+		inputGuardrails:  options.inputGuardrails,
+		outputGuardrails: options.outputGuardrails,
 	}
 }
 
@@ -164,7 +204,10 @@ func (r *runner) Run(
 	}
 
 	// Append the incoming user message to the session if it has content.
-	if message.Content != "" && shouldAppendUserMessage(message, ro.Messages) {
+	// Decisions on tool calls paused for confirmation are not part of the
+	// conversation: the agent records them as the results of the calls.
+	isConfirmation := ro.ToolConfirmationDecisions != nil
+	if message.Content != "" && !isConfirmation && shouldAppendUserMessage(message, ro.Messages) {
 		evt := event.NewResponseEvent(
 			invocation.InvocationID,
 			authorUser,
@@ -181,6 +224,16 @@ func (r *runner) Run(
 	// transfer_to_agent that rely on agent.InvocationFromContext(ctx).
 	ctx = agent.NewInvocationContext(ctx, invocation)
 
+	// Track usage of the whole run, sub-agents included, through the context.
+	var tracker *budget.Tracker
+	if r.budget != nil {
+		tracker = r.budget.Start(sess)
+		ctx = budget.NewContext(ctx, tracker)
+	}
+
+	// The input guardrails are checked by the agents of the run.
+	ctx = guardrail.NewContext(ctx, r.inputGuardrails)
+
 	// Run the agent and get the event channel.
 	agentEventCh, err := r.agent.Run(ctx, invocation)
 	if err != nil {
@@ -189,7 +242,7 @@ func (r *runner) Run(
 	}
 
 	// Process the agent events and emit them to the output channel.
-	return r.processAgentEvents(ctx, sess, invocation, agentEventCh), nil
+	return r.processAgentEvents(ctx, sess, invocation, tracker, agentEventCh), nil
 }
 
 // getOrCreateSession returns an existing session or creates a new one.
@@ -211,6 +264,7 @@ func (r *runner) processAgentEvents(
 	ctx context.Context,
 	sess *session.Session,
 	invocation *agent.Invocation,
+	tracker *budget.Tracker,
 	agentEventCh <-chan *event.Event,
 ) chan *event.Event {
 	processedEventCh := make(chan *event.Event, cap(agentEventCh))
@@ -237,6 +291,20 @@ func (r *runner) processAgentEvents(
 				continue
 			}
 
+			// Final responses are checked by the output guardrails first; a
+			// rejected response is replaced by the event reporting the check.
+			if checked := r.checkOutput(ctx, agentEvent); checked != nil {
+				if err := event.EmitEvent(ctx, processedEventCh, checked); err != nil {
+					return
+				}
+				if checked.Error != nil {
+					if agentEvent.RequiresCompletion {
+						invocation.NotifyCompletion(ctx, agent.GetAppendEventNoticeKey(agentEvent.ID))
+					}
+					continue
+				}
+			}
+
 			// Append qualifying events to session and trigger summarization.
 			r.handleEventPersistence(ctx, sess, agentEvent)
 
@@ -258,12 +326,33 @@ func (r *runner) processAgentEvents(
 		}
 
 		// Emit final runner completion event.
-		r.emitRunnerCompletion(ctx, invocation, sess, processedEventCh,
+		r.emitRunnerCompletion(ctx, invocation, sess, tracker, processedEventCh,
 			finalStateDelta, finalChoices)
 	}()
 	return processedEventCh
 }
 
+// checkOutput runs the output guardrails on a final response and returns
+// the event reporting the check, or nil when there is nothing to check.
+func (r *runner) checkOutput(ctx context.Context, agentEvent *event.Event) *event.Event {
+	if len(r.outputGuardrails) == 0 || agentEvent.Author == authorUser {
+		return nil
+	}
+	msg, ok := guardrail.OutputMessage(agentEvent.Response)
+	if !ok {
+		return nil
+	}
+	results := guardrail.Check(ctx, r.outputGuardrails, &guardrail.Request{
+		Stage:     guardrail.StageOutput,
+		AgentName: agentEvent.Author,
+		Message:   msg,
+	})
+	checked := guardrail.NewEvent(agentEvent.InvocationID, agentEvent.Author, results)
+	checked.Branch = agentEvent.Branch
+	checked.FilterKey = agentEvent.FilterKey
+	return checked
+}
+
 // handleEventPersistence appends qualifying events to the session and triggers
 // asynchronous summarization.
 func (r *runner) handleEventPersistence(
@@ -325,11 +414,12 @@ func (r *runner) captureGraphCompletion(
 }
 
 // emitRunnerCompletion creates and emits the final runner completion event,
-// optionally propagating graph-level completion data.
+// optionally propagating graph-level completion data and budget totals.
 func (r *runner) emitRunnerCompletion(
 	ctx context.Context,
 	invocation *agent.Invocation,
 	sess *session.Session,
+	tracker *budget.Tracker,
 	processedEventCh chan *event.Event,
 	finalStateDelta map[string][]byte,
 	finalChoices []model.Choice,
@@ -352,6 +442,17 @@ func (r *runner) emitRunnerCompletion(
 		r.propagateGraphCompletion(runnerCompletionEvent, finalStateDelta, finalChoices)
 	}
 
+	// Report the run totals as usage and persist the session totals.
+	if tracker != nil {
+		runnerCompletionEvent.Response.Usage = tracker.Invocation().Usage()
+		if runnerCompletionEvent.StateDelta == nil {
+			runnerCompletionEvent.StateDelta = make(map[string][]byte)
+		}
+		for k, v := range tracker.StateDelta() {
+			runnerCompletionEvent.StateDelta[k] = v
+		}
+	}
+
 	// Append runner completion event to session.
 	if err := r.sessionService.AppendEvent(ctx, sess, runnerCompletionEvent); err != nil {
 		log.Errorf("Failed to append runner completion event to session: %v", err)
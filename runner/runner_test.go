@@ -883,7 +883,7 @@ func TestProcessAgentEvents_EmitEventErrorBranch_Direct(t *testing.T) {
 	sess, _ := rr.sessionService.CreateSession(context.Background(), session.Key{AppName: "app", UserID: "u", SessionID: "s"}, session.StateMap{})
 
 	agentCh := make(chan *event.Event)
-	processed := rr.processAgentEvents(ctx, sess, inv, agentCh)
+	processed := rr.processAgentEvents(ctx, sess, inv, nil, agentCh)
 	// Send one event, then close agentCh
 	go func() {
 		agentCh <- &event.Event{Response: &model.Response{Done: true, Choices: []model.Choice{{Index: 0, Message: model.NewAssistantMessage("x")}}}}
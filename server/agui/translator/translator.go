@@ -57,6 +57,10 @@ func (t *translator) Translate(event *agentevent.Event) ([]aguievents.Event, err
 		}
 		events = append(events, textMessageEvents...)
 	}
+	if rsp.Object == model.ObjectTypeStructuredOutputPartial && event.PartialStructuredOutput != nil {
+		events = append(events, aguievents.NewCustomEvent(model.ObjectTypeStructuredOutputPartial,
+			aguievents.WithValue(event.PartialStructuredOutput.JSON)))
+	}
 	if rsp.IsToolCallResponse() {
 		toolCallEvents, err := t.toolCallEvent(rsp)
 		if err != nil {
@@ -10,6 +10,7 @@
 package translator
 
 import (
+	"encoding/json"
 	"testing"
 
 	aguievents "github.com/ag-ui-protocol/ag-ui/sdks/community/go/pkg/core/events"
@@ -402,3 +403,22 @@ func TestFormatToolCallArguments(t *testing.T) {
 	assert.Equal(t, "", formatToolCallArguments([]byte{}))
 	assert.Equal(t, "{\"foo\":\"bar\"}", formatToolCallArguments([]byte(`{"foo":"bar"}`)))
 }
+
+func TestTranslatePartialStructuredOutput(t *testing.T) {
+	translator := New("thread", "run")
+	evt := agentevent.New("inv", "writer",
+		agentevent.WithObject(model.ObjectTypeStructuredOutputPartial),
+		agentevent.WithPartialStructuredOutput(&agentevent.PartialStructuredOutput{
+			JSON: []byte(`{"title":"Go"}`),
+		}),
+	)
+	evt.IsPartial = true
+
+	events, err := translator.Translate(evt)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	custom, ok := events[0].(*aguievents.CustomEvent)
+	assert.True(t, ok)
+	assert.Equal(t, model.ObjectTypeStructuredOutputPartial, custom.Name)
+	assert.JSONEq(t, `{"title":"Go"}`, string(custom.Value.(json.RawMessage)))
+}
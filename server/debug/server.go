@@ -40,10 +40,10 @@ import (
 // Server exposes HTTP endpoints compatible with the ADK Web UI. Internally it
 // reuses the trpc-agent-go components for sessions, runners and events.
 type Server struct {
-	agents map[string]agent.Agent
 	router *mux.Router
 
 	mu      sync.RWMutex
+	agents  map[string]agent.Agent
 	runners map[string]runner.Runner
 
 	sessionSvc session.Service
@@ -214,6 +214,17 @@ func (e *inMemoryExporter) clear() {
 	e.spans = make([]sdktrace.ReadOnlySpan, 0)
 }
 
+// SetAgents replaces the served agents, for instance after their definitions
+// were reloaded. Sessions are kept, and the runs in progress finish with the
+// previous agents. The definitions replaced by declarative.Watch stay open
+// until those runs are over.
+func (s *Server) SetAgents(agents map[string]agent.Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents = agents
+	s.runners = make(map[string]runner.Runner)
+}
+
 // Handler returns the http.Handler for the server.
 func (s *Server) Handler() http.Handler { return s.router }
 
@@ -317,9 +328,11 @@ func buildTraceAttributes(attributes attribute.Set) map[string]any {
 func (s *Server) handleListApps(w http.ResponseWriter, r *http.Request) {
 	log.Infof("handleListApps called: path=%s", r.URL.Path)
 	var apps []string
+	s.mu.RLock()
 	for name := range s.agents {
 		apps = append(apps, name)
 	}
+	s.mu.RUnlock()
 	s.writeJSON(w, apps)
 }
 
@@ -742,6 +755,17 @@ func convertEventToADKFormat(e *event.Event, isStreaming bool) map[string]any {
 	// Build basic envelope.
 	adkEvent := buildADKEventEnvelope(e)
 
+	// Partial structured output snapshots carry no content parts and only
+	// make sense while streaming.
+	if e.PartialStructuredOutput != nil {
+		if !isStreaming {
+			return nil
+		}
+		adkEvent[keyPartialStructuredOutput] = e.PartialStructuredOutput.JSON
+		addResponseMetadata(adkEvent, e)
+		return adkEvent
+	}
+
 	// Determine role and build content.
 	role := determineEventRole(e)
 	content := map[string]any{
@@ -777,9 +801,8 @@ func (s *Server) getRunner(appName string) (runner.Runner, error) {
 		s.mu.RUnlock()
 		return r, nil
 	}
-	s.mu.RUnlock()
-
 	ag, ok := s.agents[appName]
+	s.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("agent not found")
 	}
@@ -790,7 +813,10 @@ func (s *Server) getRunner(appName string) (runner.Runner, error) {
 
 	r := runner.NewRunner(appName, ag, allOpts...)
 	s.mu.Lock()
-	s.runners[appName] = r
+	// Do not cache the runner of an agent replaced in the meantime.
+	if s.agents[appName] == ag {
+		s.runners[appName] = r
+	}
 	s.mu.Unlock()
 	return r, nil
 }
@@ -810,6 +836,8 @@ const (
 	keyText             = "text"             // Plain textual content part.
 	keyFunctionCall     = "functionCall"     // Function call part key.
 	keyFunctionResponse = "functionResponse" // Function response part key.
+
+	keyPartialStructuredOutput = "partialStructuredOutput" // Partial structured output snapshot key.
 )
 
 // isToolResponse reports whether the supplied event represents a tool
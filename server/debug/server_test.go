@@ -204,6 +204,26 @@ func TestServer_handleListApps(t *testing.T) {
 	assert.True(t, found["agent1"] && found["agent2"], "expected agent names not found in response")
 }
 
+func TestServer_SetAgents(t *testing.T) {
+	old := &mockAgent{name: "old"}
+	server := New(map[string]agent.Agent{"old": old})
+	r, err := server.getRunner("old")
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+
+	server.SetAgents(map[string]agent.Agent{"new": &mockAgent{name: "new"}})
+
+	w := httptest.NewRecorder()
+	server.handleListApps(w, httptest.NewRequest(http.MethodGet, "/list-apps", nil))
+	var apps []string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &apps))
+	assert.Equal(t, []string{"new"}, apps)
+	_, err = server.getRunner("old")
+	assert.Error(t, err, "the runners of replaced agents are dropped")
+	_, err = server.getRunner("new")
+	assert.NoError(t, err)
+}
+
 func TestServer_handleCreateSession(t *testing.T) {
 	agents := map[string]agent.Agent{
 		"test-agent": &mockAgent{name: "test-agent", description: "test description"},
@@ -749,6 +769,28 @@ func TestConvertEventToADKFormat_NonStreamingKeepsToolCall(t *testing.T) {
 	assert.Equal(t, "fn", call["name"])
 }
 
+func TestConvertEventToADKFormat_PartialStructuredOutput(t *testing.T) {
+	evt := &event.Event{
+		InvocationID: "inv",
+		Author:       "writer",
+		ID:           "event-id",
+		Timestamp:    time.Unix(0, 0),
+		Response: &model.Response{
+			Object:    model.ObjectTypeStructuredOutputPartial,
+			IsPartial: true,
+		},
+		PartialStructuredOutput: &event.PartialStructuredOutput{JSON: []byte(`{"title":"Go"}`)},
+	}
+
+	res := convertEventToADKFormat(evt, true)
+	assert.NotNil(t, res)
+	assert.Equal(t, json.RawMessage(`{"title":"Go"}`), res[keyPartialStructuredOutput])
+	assert.Equal(t, true, res["partial"])
+	assert.Equal(t, model.ObjectTypeStructuredOutputPartial, res["object"])
+
+	assert.Nil(t, convertEventToADKFormat(evt, false))
+}
+
 func TestConvertEventToADKFormat_ToolResponseIncludesMetadata(t *testing.T) {
 	evt := &event.Event{
 		InvocationID: "inv",
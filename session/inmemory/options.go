@@ -12,6 +12,7 @@ package inmemory
 import (
 	"time"
 
+	"trpc.group/trpc-go/trpc-agent-go/redact"
 	"trpc.group/trpc-go/trpc-agent-go/session/summary"
 )
 
@@ -36,6 +37,8 @@ type serviceOpts struct {
 	summaryQueueSize int
 	// summaryJobTimeout is the timeout for processing a single summary job.
 	summaryJobTimeout time.Duration
+	// redactor redacts the stored events.
+	redactor *redact.Redactor
 }
 
 // ServiceOpt is the option for the in-memory session service.
@@ -118,3 +121,14 @@ func WithSummaryJobTimeout(timeout time.Duration) ServiceOpt {
 		opts.summaryJobTimeout = timeout
 	}
 }
+
+// WithRedactor stores events whose messages and state delta values are
+// redacted by r. The session passed to AppendEvent keeps the original event.
+// The redacted values are kept in a vault per session, so the state of the
+// sessions read back from the service is restored, while their events stay
+// redacted.
+func WithRedactor(r *redact.Redactor) ServiceOpt {
+	return func(opts *serviceOpts) {
+		opts.redactor = r
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/google/uuid"
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	isession "trpc.group/trpc-go/trpc-agent-go/internal/session"
+	"trpc.group/trpc-go/trpc-agent-go/redact"
 	"trpc.group/trpc-go/trpc-agent-go/session"
 )
 
@@ -41,6 +42,8 @@ type stateWithTTL struct {
 type sessionWithTTL struct {
 	session   *session.Session
 	expiredAt time.Time
+	// vault holds the values redacted from the session, see WithRedactor.
+	vault *redact.Vault
 }
 
 var _ session.Service = (*SessionService)(nil)
@@ -276,6 +279,7 @@ func (s *SessionService) GetSession(
 	sessWithTTL.expiredAt = calculateExpiredAt(s.opts.sessionTTL)
 
 	copiedSess := copySession(sess)
+	redact.RestoreState(copiedSess.State, sessWithTTL.vault)
 
 	// apply filtering options if provided
 	isession.ApplyEventFiltering(copiedSess, opts...)
@@ -322,6 +326,7 @@ func (s *SessionService) ListSessions(
 			continue // Skip expired sessions
 		}
 		copiedSess := copySession(s)
+		redact.RestoreState(copiedSess.State, sWithTTL.vault)
 		isession.ApplyEventFiltering(copiedSess, opts...)
 		// filter events to ensure they start with RoleUser
 		isession.EnsureEventStartWithUser(copiedSess)
@@ -591,6 +596,12 @@ func (s *SessionService) AppendEvent(
 	}
 
 	// update stored session with the given event
+	if s.opts.redactor != nil {
+		if storedSessionWithTTL.vault == nil {
+			storedSessionWithTTL.vault = redact.NewVault()
+		}
+		event = s.opts.redactor.RedactEvent(event, storedSessionWithTTL.vault)
+	}
 	s.updateStoredSession(storedSession, event)
 
 	// Update the session in the wrapper and refresh TTL
@@ -20,6 +20,7 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	isession "trpc.group/trpc-go/trpc-agent-go/internal/session"
 	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/redact"
 	"trpc.group/trpc-go/trpc-agent-go/session"
 )
 
@@ -1157,3 +1158,46 @@ func TestGetSession_AllAssistantEvents_Integration(t *testing.T) {
 	// Should have no events since all are from assistant
 	assert.Equal(t, 0, len(retrievedSess.Events), "Should filter out all assistant events when no user events exist")
 }
+
+func TestAppendEvent_WithRedactor(t *testing.T) {
+	service := NewSessionService(WithRedactor(redact.New()))
+	defer service.Close()
+	key := session.Key{AppName: "app", UserID: "user", SessionID: "sess"}
+	sess, err := service.CreateSession(context.Background(), key, session.StateMap{})
+	require.NoError(t, err)
+
+	evt := event.New("inv", "user")
+	evt.Response = &model.Response{Choices: []model.Choice{{
+		Message: model.NewUserMessage("call me at 13812345678"),
+	}}}
+	evt.StateDelta = map[string][]byte{
+		"phone":                  []byte("13812345678"),
+		"tool_confirmation:main": []byte(`{"calls":[{"arguments":{"to":"13812345678"}}]}`),
+	}
+	require.NoError(t, service.AppendEvent(context.Background(), sess, evt))
+
+	// The caller keeps the original event.
+	assert.Equal(t, "call me at 13812345678", sess.Events[0].Choices[0].Message.Content)
+	assert.Equal(t, "call me at 13812345678", evt.Choices[0].Message.Content)
+
+	got, err := service.GetSession(context.Background(), key)
+	require.NoError(t, err)
+	require.Len(t, got.Events, 1)
+	assert.Equal(t, evt.ID, got.Events[0].ID)
+	assert.Equal(t, "call me at [PHONE_1]", got.Events[0].Choices[0].Message.Content)
+	assert.Equal(t, "[PHONE_1]", string(got.Events[0].StateDelta["phone"]))
+	// The state is restored from the vault of the session.
+	assert.Equal(t, "13812345678", string(got.State["phone"]))
+	assert.Equal(t, `{"calls":[{"arguments":{"to":"13812345678"}}]}`, string(got.State["tool_confirmation:main"]))
+	list, err := service.ListSessions(context.Background(), session.UserKey{AppName: "app", UserID: "user"})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "13812345678", string(list[0].State["phone"]))
+
+	// The stored session only holds redacted values.
+	app, ok := service.getAppSessions("app")
+	require.True(t, ok)
+	stored := app.sessions["user"]["sess"].session
+	assert.Equal(t, "[PHONE_1]", string(stored.State["phone"]))
+	assert.Equal(t, `{"calls":[{"arguments":{"to":"[PHONE_1]"}}]}`, string(stored.State["tool_confirmation:main"]))
+}
@@ -12,6 +12,7 @@ package redis
 import (
 	"time"
 
+	"trpc.group/trpc-go/trpc-agent-go/redact"
 	"trpc.group/trpc-go/trpc-agent-go/session/summary"
 )
 
@@ -34,6 +35,8 @@ type ServiceOpts struct {
 	summaryQueueSize int
 	// summaryJobTimeout is the timeout for processing a single summary job.
 	summaryJobTimeout time.Duration
+	// redactor redacts the stored events.
+	redactor *redact.Redactor
 }
 
 // ServiceOpt is the option for the redis session service.
@@ -149,3 +152,16 @@ func WithSummaryJobTimeout(timeout time.Duration) ServiceOpt {
 		opts.summaryJobTimeout = timeout
 	}
 }
+
+// WithRedactor stores events whose messages and state delta values are
+// redacted by r, so redis never holds the detected values. The session passed
+// to AppendEvent keeps the original event. The redacted values are kept in
+// memory in a vault per session, which expires with the session TTL: the
+// state of the sessions read back by this service is restored, while their
+// events stay redacted. After a restart, or from another process, the state
+// holds the placeholders.
+func WithRedactor(r *redact.Redactor) ServiceOpt {
+	return func(opts *ServiceOpts) {
+		opts.redactor = r
+	}
+}
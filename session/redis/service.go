@@ -24,6 +24,7 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	isession "trpc.group/trpc-go/trpc-agent-go/internal/session"
 	"trpc.group/trpc-go/trpc-agent-go/log"
+	"trpc.group/trpc-go/trpc-agent-go/redact"
 	"trpc.group/trpc-go/trpc-agent-go/session"
 	storage "trpc.group/trpc-go/trpc-agent-go/storage/redis"
 )
@@ -62,6 +63,7 @@ type Service struct {
 	userStateTTL    time.Duration            // TTL for user state
 	eventPairChans  []chan *sessionEventPair // channel for session events to persistence
 	summaryJobChans []chan *summaryJob       // channel for summary jobs to processing
+	vaults          *redact.Vaults           // values redacted from the sessions, see WithRedactor
 	once            sync.Once
 }
 
@@ -115,6 +117,7 @@ func NewService(options ...ServiceOpt) (*Service, error) {
 			sessionTTL:   opts.sessionTTL,
 			appStateTTL:  opts.appStateTTL,
 			userStateTTL: opts.userStateTTL,
+			vaults:       redact.NewVaults(opts.sessionTTL),
 		}
 		if opts.enableAsyncPersist {
 			s.startAsyncPersistWorker()
@@ -138,6 +141,7 @@ func NewService(options ...ServiceOpt) (*Service, error) {
 		sessionTTL:   opts.sessionTTL,
 		appStateTTL:  opts.appStateTTL,
 		userStateTTL: opts.userStateTTL,
+		vaults:       redact.NewVaults(opts.sessionTTL),
 	}
 	if opts.enableAsyncPersist {
 		s.startAsyncPersistWorker()
@@ -267,6 +271,7 @@ func (s *Service) DeleteSession(
 	if err := s.deleteSessionState(ctx, key); err != nil {
 		return fmt.Errorf("redis session service delete session state failed: %w", err)
 	}
+	s.vaults.Delete(getVaultKey(key))
 	return nil
 }
 
@@ -422,6 +427,9 @@ func (s *Service) AppendEvent(
 	}
 	// update user session with the given event
 	isession.UpdateUserSession(sess, event, opts...)
+	if s.opts.redactor != nil {
+		event = s.opts.redactor.RedactEvent(event, s.vaults.Get(getVaultKey(key)))
+	}
 
 	// persist event to redis asynchronously
 	if s.opts.enableAsyncPersist {
@@ -490,6 +498,11 @@ func getSessionStateKey(key session.Key) string {
 	return fmt.Sprintf("sess:{%s}:%s", key.AppName, key.UserID)
 }
 
+// getVaultKey returns the key of the vault of a session, see WithRedactor.
+func getVaultKey(key session.Key) string {
+	return fmt.Sprintf("%s/%s/%s", key.AppName, key.UserID, key.SessionID)
+}
+
 func getSessionSummaryKey(key session.Key) string {
 	return fmt.Sprintf("sesssum:{%s}:%s", key.AppName, key.UserID)
 }
@@ -578,6 +591,7 @@ func (s *Service) getSession(
 		}
 	}
 
+	redact.RestoreState(sess.State, s.vaults.Lookup(getVaultKey(key)))
 	// filter events to ensure they start with RoleUser
 	isession.EnsureEventStartWithUser(sess)
 	return mergeState(appState, userState, sess), nil
@@ -648,6 +662,7 @@ func (s *Service) listSessions(
 			CreatedAt: sessState.CreatedAt,
 		}
 
+		redact.RestoreState(sess.State, s.vaults.Lookup(getVaultKey(sessionKeys[i])))
 		// filter events to ensure they start with RoleUser
 		isession.EnsureEventStartWithUser(sess)
 		sessList = append(sessList, mergeState(appState, userState, sess))
@@ -25,6 +25,7 @@ import (
 	"trpc.group/trpc-go/trpc-agent-go/event"
 	isession "trpc.group/trpc-go/trpc-agent-go/internal/session"
 	"trpc.group/trpc-go/trpc-agent-go/model"
+	"trpc.group/trpc-go/trpc-agent-go/redact"
 	"trpc.group/trpc-go/trpc-agent-go/session"
 )
 
@@ -2575,3 +2576,65 @@ func TestProcessEventCmd_SkipMalformed(t *testing.T) {
 	require.Len(t, events, 1)
 	require.Equal(t, "ok", events[0].ID)
 }
+
+func TestService_WithRedactor(t *testing.T) {
+	redisURL, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	service, err := NewService(
+		WithRedisClientURL(redisURL),
+		WithRedactor(redact.New()),
+	)
+	require.NoError(t, err)
+	defer service.Close()
+
+	sessionKey := session.Key{AppName: "testapp", UserID: "user123", SessionID: "session123"}
+	sess, err := service.CreateSession(context.Background(), sessionKey, session.StateMap{})
+	require.NoError(t, err)
+
+	evt := createTestEvent("e1", "user", "mail me at alice@example.com", time.Now(), false)
+	evt.StateDelta = map[string][]byte{
+		"contact":      []byte(`"alice@example.com"`),
+		"plan:support": []byte(`{"goal":"answer alice@example.com"}`),
+	}
+	require.NoError(t, service.AppendEvent(context.Background(), sess, evt))
+
+	// The caller keeps the original event.
+	assert.Equal(t, "mail me at alice@example.com", sess.Events[0].Choices[0].Message.Content)
+
+	// Redis only holds redacted content.
+	client := buildRedisClient(t, redisURL)
+	defer client.Close()
+	stored, err := client.ZRange(context.Background(), getEventKey(sessionKey), 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.NotContains(t, stored[0], "alice@example.com")
+	state, err := client.HGet(context.Background(), getSessionStateKey(sessionKey), sessionKey.SessionID).Result()
+	require.NoError(t, err)
+	assert.NotContains(t, state, "alice@example.com")
+
+	got, err := service.GetSession(context.Background(), sessionKey)
+	require.NoError(t, err)
+	require.Len(t, got.Events, 1)
+	assert.Equal(t, "e1", got.Events[0].ID)
+	assert.Equal(t, "mail me at [EMAIL_1]", got.Events[0].Choices[0].Message.Content)
+	// The state is restored from the vault of the session.
+	assert.Equal(t, `"alice@example.com"`, string(got.State["contact"]))
+	assert.Equal(t, `{"goal":"answer alice@example.com"}`, string(got.State["plan:support"]))
+	list, err := service.ListSessions(context.Background(), session.UserKey{AppName: "testapp", UserID: "user123"})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, `"alice@example.com"`, string(list[0].State["contact"]))
+
+	// Another service has no vault: the placeholders stay.
+	other, err := NewService(WithRedisClientURL(redisURL), WithRedactor(redact.New()))
+	require.NoError(t, err)
+	defer other.Close()
+	got, err = other.GetSession(context.Background(), sessionKey)
+	require.NoError(t, err)
+	assert.Equal(t, `"[EMAIL_1]"`, string(got.State["contact"]))
+
+	// Deleting the session drops its vault.
+	require.NoError(t, service.DeleteSession(context.Background(), sessionKey))
+	assert.Nil(t, service.vaults.Lookup(getVaultKey(sessionKey)))
+}
@@ -0,0 +1,62 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package tool
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrConfirmationRequired is returned by a BeforeToolCallback to pause the
+// tool call until a human approves it. The agent then ends the run with a
+// confirmation request, and executes the call when a later run carries the
+// approval.
+var ErrConfirmationRequired = errors.New("tool call requires confirmation")
+
+// ConfirmationRequirer is implemented by tools whose calls must be approved
+// by a human before they run.
+type ConfirmationRequirer interface {
+	RequiresConfirmation() bool
+}
+
+// confirmedKey is the context key marking an approved tool call.
+type confirmedKey struct{}
+
+// NewConfirmedContext returns a context marking the tool call executed with
+// it as approved by a human.
+func NewConfirmedContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, confirmedKey{}, true)
+}
+
+// IsConfirmed reports whether the tool call executed with ctx was approved
+// by a human.
+func IsConfirmed(ctx context.Context) bool {
+	confirmed, _ := ctx.Value(confirmedKey{}).(bool)
+	return confirmed
+}
+
+// RequireConfirmation returns a BeforeToolCallback pausing the calls of the
+// named tools, or of every tool when no name is given, until a human
+// approves them.
+func RequireConfirmation(toolNames ...string) BeforeToolCallback {
+	names := make(map[string]bool, len(toolNames))
+	for _, name := range toolNames {
+		names[name] = true
+	}
+	return func(ctx context.Context, toolName string, _ *Declaration, _ *[]byte) (any, error) {
+		if len(names) > 0 && !names[toolName] {
+			return nil, nil
+		}
+		if IsConfirmed(ctx) {
+			return nil, nil
+		}
+		return nil, ErrConfirmationRequired
+	}
+}
@@ -0,0 +1,32 @@
+//
+// Tencent is pleased to support the open source community by making trpc-agent-go available.
+//
+// Copyright (C) 2025 Tencent.  All rights reserved.
+//
+// trpc-agent-go is licensed under the Apache License Version 2.0.
+//
+//
+
+package tool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireConfirmation(t *testing.T) {
+	ctx := context.Background()
+	cb := RequireConfirmation("delete_file")
+
+	_, err := cb(ctx, "delete_file", nil, nil)
+	assert.ErrorIs(t, err, ErrConfirmationRequired)
+	_, err = cb(NewConfirmedContext(ctx), "delete_file", nil, nil)
+	assert.NoError(t, err)
+	_, err = cb(ctx, "read_file", nil, nil)
+	assert.NoError(t, err)
+
+	_, err = RequireConfirmation()(ctx, "read_file", nil, nil)
+	assert.ErrorIs(t, err, ErrConfirmationRequired, "every tool requires confirmation without names")
+}
@@ -30,6 +30,7 @@ type FunctionTool[I, O any] struct {
 	outputSchema *tool.Schema
 	fn           func(context.Context, I) (O, error)
 	longRunning  bool
+	confirm      bool
 	unmarshaler  unmarshaler
 }
 
@@ -42,6 +43,7 @@ type functionToolOptions struct {
 	description string
 	unmarshaler unmarshaler
 	longRunning bool
+	confirm     bool
 }
 
 // WithName sets the name of the function tool.
@@ -74,6 +76,15 @@ func WithLongRunning(longRunning bool) Option {
 	}
 }
 
+// WithRequireConfirmation sets whether calls of the function tool must be
+// approved by a human before they run. The agent ends the run with a
+// confirmation request instead of calling the tool.
+func WithRequireConfirmation(require bool) Option {
+	return func(opts *functionToolOptions) {
+		opts.confirm = require
+	}
+}
+
 // NewFunctionTool creates and returns a new instance of FunctionTool with the specified
 // function implementation and optional configuration.
 // Parameters:
@@ -104,6 +115,7 @@ func NewFunctionTool[I, O any](fn func(context.Context, I) (O, error), opts ...O
 		name:         options.name,
 		description:  options.description,
 		longRunning:  options.longRunning,
+		confirm:      options.confirm,
 		fn:           fn,
 		unmarshaler:  options.unmarshaler,
 		inputSchema:  iSchema,
@@ -134,6 +146,11 @@ func (ft *FunctionTool[I, O]) LongRunning() bool {
 	return ft.longRunning
 }
 
+// RequiresConfirmation indicates whether calls of the function tool must be approved first.
+func (ft *FunctionTool[I, O]) RequiresConfirmation() bool {
+	return ft.confirm
+}
+
 // Declaration returns the tool's declaration information.
 // It provides metadata about the tool including its name, description,
 // and JSON schema for the expected input arguments.
@@ -166,6 +183,7 @@ type StreamableFunctionTool[I, O any] struct {
 	outputSchema *tool.Schema
 	fn           func(context.Context, I) (*tool.StreamReader, error)
 	longRunning  bool
+	confirm      bool
 	unmarshaler  unmarshaler
 }
 
@@ -200,6 +218,7 @@ func NewStreamableFunctionTool[I, O any](fn func(context.Context, I) (*tool.Stre
 		name:         options.name,
 		description:  options.description,
 		longRunning:  options.longRunning,
+		confirm:      options.confirm,
 		fn:           fn,
 		unmarshaler:  options.unmarshaler,
 		inputSchema:  iSchema,
@@ -256,6 +275,11 @@ func (t *StreamableFunctionTool[I, O]) LongRunning() bool {
 	return t.longRunning
 }
 
+// RequiresConfirmation indicates whether calls of the streamable function tool must be approved first.
+func (t *StreamableFunctionTool[I, O]) RequiresConfirmation() bool {
+	return t.confirm
+}
+
 type unmarshaler interface {
 	Unmarshal([]byte, any) error
 }
@@ -327,6 +327,23 @@ func TestFunctionTool_LongRunning(t *testing.T) {
 	}
 }
 
+func TestFunctionTool_RequiresConfirmation(t *testing.T) {
+	fn := func(_ context.Context, in string) (string, error) { return in, nil }
+
+	var requirer tool.ConfirmationRequirer = function.NewFunctionTool(fn)
+	if requirer.RequiresConfirmation() {
+		t.Errorf("expected RequiresConfirmation() = false by default")
+	}
+	requirer = function.NewFunctionTool(fn, function.WithRequireConfirmation(true))
+	if !requirer.RequiresConfirmation() {
+		t.Errorf("expected RequiresConfirmation() = true")
+	}
+	requirer = function.NewStreamableFunctionTool[string, string](nil, function.WithRequireConfirmation(true))
+	if !requirer.RequiresConfirmation() {
+		t.Errorf("expected RequiresConfirmation() = true for streamable tool")
+	}
+}
+
 func TestFunctionTool_Call_UnmarshalError(t *testing.T) {
 	type inputArgs struct {
 		A int `json:"a"`